@@ -2,8 +2,10 @@ package main
 
 import (
     "context"
- 
-    "log"
+    "errors"
+    "flag"
+    "log/slog"
+    "net"
     "net/http"
     "os"
     "os/signal"
@@ -13,45 +15,190 @@ import (
     "github.com/gin-gonic/gin"
     "github.com/Shridhar2104/chat-platform/shared/config"
     "github.com/Shridhar2104/chat-platform/shared/database"
+    "github.com/Shridhar2104/chat-platform/shared/health"
+    "github.com/Shridhar2104/chat-platform/auth-service/internal/clientip"
+    "github.com/Shridhar2104/chat-platform/auth-service/internal/grpcapi"
     "github.com/Shridhar2104/chat-platform/auth-service/internal/handlers"
+    "github.com/Shridhar2104/chat-platform/auth-service/internal/logging"
+    "github.com/Shridhar2104/chat-platform/auth-service/internal/metrics"
     "github.com/Shridhar2104/chat-platform/auth-service/internal/middleware"
     "github.com/Shridhar2104/chat-platform/auth-service/internal/repository"
     "github.com/Shridhar2104/chat-platform/auth-service/internal/services"
+    "github.com/Shridhar2104/chat-platform/auth-service/internal/tracing"
 )
 
 func main() {
-    // Load configuration
-    cfg, err := config.Load()
+    // Load configuration: defaults, YAML file, .env, environment, then flags
+    loader := config.NewLoader(os.Args[1:])
+    cfg, err := loader.Load()
+    if errors.Is(err, flag.ErrHelp) {
+        return
+    }
     if err != nil {
-        log.Fatalf("Failed to load config: %v", err)
+        fatal("Invalid configuration", err)
+    }
+
+    if err := logging.Setup(os.Stderr, logging.Config{Level: cfg.LogLevel, Format: cfg.LogFormat}); err != nil {
+        fatal("Failed to set up logging", err)
+    }
+    if cfg.EnvFile != "" {
+        slog.Debug("Loaded environment file", "path", cfg.EnvFile)
+    } else {
+        slog.Debug("No .env file found, using system environment variables")
+    }
+
+    // Credentials come from the secrets provider and rotate without a restart
+    secretManager := newSecretManager(cfg)
+    if err := resolveSecrets(context.Background(), secretManager, cfg); err != nil {
+        fatal("Failed to load secrets", err)
+    }
+
+    if args := loader.Args(); len(args) > 0 && args[0] == "migrate" {
+        if err := runMigrate(cfg, args[1:]); err != nil {
+            fatal("Migration failed", err)
+        }
+        return
+    }
+
+    // Tracing is installed before anything that opens spans
+    shutdownTracing, err := tracing.Setup(context.Background(), tracing.Config{
+        Exporter:    cfg.TracingExporter,
+        FilePath:    cfg.TracingFilePath,
+        SampleRatio: cfg.TracingSampleRatio,
+        Environment: cfg.Environment,
+    })
+    if err != nil {
+        fatal("Failed to set up tracing", err)
     }
 
     // Setup database connections
     db, err := database.NewPostgresConnection(cfg.DatabaseURL)
     if err != nil {
-        log.Fatalf("Failed to connect to database: %v", err)
+        fatal("Failed to connect to database", err)
     }
     defer db.Close()
 
     redis, err := database.NewRedisConnection(cfg.RedisURL)
     if err != nil {
-        log.Fatalf("Failed to connect to Redis: %v", err)
+        fatal("Failed to connect to Redis", err)
     }
     defer redis.Close()
+    metrics.RegisterPools(db.DB, redis)
+
+    // Dependency checks run in the background, probes read cached results
+    healthRegistry := newHealthRegistry(cfg, db, redis)
+    healthCtx, stopHealth := context.WithCancel(context.Background())
+    defer stopHealth()
+    healthRegistry.Start(healthCtx)
 
     // Initialize repositories
     userRepo := repository.NewUserRepository(db)
+    samlRepo := repository.NewSAMLRepository(db)
+    domainRepo := repository.NewDomainRepository(db)
+    limitStore := repository.NewRedisRateLimitStore(redis)
 
     // Initialize services
-    jwtService := services.NewJWTService(cfg.JWTSecret, cfg.JWTExpiration, cfg.RefreshExpiration)
-    authService := services.NewAuthService(userRepo, jwtService, redis)
+    keySet, err := services.LoadKeySet(cfg.JWTSigningKeyPath, cfg.JWTRetiredKeyPaths)
+    if err != nil {
+        fatal("Failed to load JWT signing keys", err)
+    }
+    jwtService := services.NewJWTService(cfg.JWTSecret, keySet, cfg.JWTExpiration, cfg.RefreshExpiration)
+
+    // High-risk logins wait for a code delivered by the notifier. The log
+    // notifier does not deliver it, so without a webhook they are only notified.
+    var notifier services.Notifier = services.NewLogNotifier()
+    if cfg.NotifyWebhookURL != "" {
+        notifier = services.NewWebhookNotifier(cfg.NotifyWebhookURL, cfg.NotifyWebhookToken)
+    }
+
+    var riskEngine *services.RiskEngine
+    if cfg.RiskEnabled {
+        riskConfig := services.DefaultRiskConfig()
+        riskConfig.MediumThreshold = cfg.RiskMediumThreshold
+        riskConfig.HighThreshold = cfg.RiskHighThreshold
+        riskConfig.MaxTravelSpeedKMH = cfg.RiskMaxTravelSpeedKMH
+        riskConfig.StepUp = cfg.NotifyWebhookURL != ""
+        if !riskConfig.StepUp {
+            slog.Warn("Step-up verification is disabled, NOTIFY_WEBHOOK_URL is not set")
+        }
+
+        // Location rules are skipped when no GeoIP database is configured
+        var geoLocator services.GeoLocator
+        if cfg.GeoIPDatabasePath != "" {
+            maxmind, err := services.NewMaxMindGeoLocator(cfg.GeoIPDatabasePath)
+            if err != nil {
+                fatal("Failed to open GeoIP database", err)
+            }
+            defer maxmind.Close()
+            geoLocator = maxmind
+        }
+
+        riskEngine = services.NewRiskEngine(riskConfig, geoLocator)
+    }
+
+    var txtResolver services.TXTResolver = services.NewDNSResolver()
+    if cfg.DomainVerificationResolver == "static" {
+        txtResolver = services.NewStaticTXTResolver()
+    }
+    domainService := services.NewDomainPolicyService(domainRepo, userRepo, txtResolver, cfg.SignupRequireVerifiedDomain)
+
+    authService := services.NewAuthService(userRepo, userRepo, limitStore, jwtService, riskEngine, notifier, domainService, userRepo)
+    samlService := services.NewSAMLService(samlRepo, authService, redis, cfg.PublicURL)
+    scimService := services.NewSCIMService(userRepo, domainService)
 
     // Initialize handlers
     authHandler := handlers.NewAuthHandler(authService)
-    healthHandler := handlers.NewHealthHandler(db, redis)
+    samlHandler := handlers.NewSAMLHandler(samlService)
+    scimHandler := handlers.NewSCIMHandler(scimService, cfg.PublicURL)
+    domainHandler := handlers.NewDomainHandler(domainService)
+    healthHandler := handlers.NewHealthHandler(healthRegistry)
+    jwksHandler := handlers.NewJWKSHandler(keySet)
+
+    // In-memory limiters clean up in the background until shutdown
+    limiterCtx, stopLimiters := context.WithCancel(context.Background())
+    defer stopLimiters()
 
     // Setup router
-    router := setupRouter(cfg, authHandler, healthHandler, redis)
+    resilience := newResilience(cfg)
+    middleware.SetLegacyHeaders(cfg.RateLimitLegacyHeaders)
+    controls := newRateLimitControls(cfg, redis, resilience)
+    rateLimiter, buckets, setRateLimit := newRateLimiter(limiterCtx, cfg, redis, resilience, controls)
+    policyLimiter, tierLimiter, err := newPolicyLimiters(limiterCtx, cfg, redis, resilience)
+    if err != nil {
+        fatal("Failed to load rate limit policies", err)
+    }
+    var rateLimitHandler *handlers.RateLimitHandler
+    if controls != nil && cfg.RateLimitAdminToken != "" {
+        rateLimitHandler = handlers.NewRateLimitHandler(buckets, controls, userRepo)
+    }
+    resolver, err := clientip.NewResolver(cfg.TrustedProxies)
+    if err != nil {
+        fatal("Invalid trusted proxies", err)
+    }
+    router := setupRouter(cfg, resolver, authHandler, samlHandler, scimHandler, domainHandler, rateLimitHandler, scimService, authService, healthHandler, jwksHandler, redis, controls, rateLimiter, policyLimiter, tierLimiter)
+
+    // Rate limits and the log level follow SIGHUP reloads, other settings need
+    // a restart; rotated secrets are picked up on the refresh interval
+    reloadCtx, stopReload := context.WithCancel(context.Background())
+    defer stopReload()
+    go loader.WatchSIGHUP(reloadCtx, cfg, func(next *config.Config, changed []string) {
+        if err := logging.SetLevel(next.LogLevel); err != nil {
+            slog.Error("Failed to apply log level", "error", err)
+        }
+        setRateLimit(next.RateLimitRPM)
+        middleware.SetLegacyHeaders(next.RateLimitLegacyHeaders)
+    })
+    if controls != nil {
+        go controls.Run(reloadCtx)
+    }
+
+    secretManager.Subscribe(jwtSecretName, jwtService.RotateSecret)
+    secretManager.Subscribe(databaseURLName, func(databaseURL string) {
+        if err := db.SetDatabaseURL(databaseURL); err != nil {
+            slog.Error("Failed to apply rotated database credentials", "error", err)
+        }
+    })
+    go secretManager.Run(reloadCtx)
 
     // Setup server
     srv := &http.Server{
@@ -59,11 +206,33 @@ func main() {
         Handler: router,
     }
 
+    // Load balancers in front may announce the client with the PROXY protocol
+    listener, err := net.Listen("tcp", srv.Addr)
+    if err != nil {
+        fatal("Failed to listen for HTTP", err)
+    }
+    if cfg.ProxyProtocol {
+        listener = clientip.NewProxyListener(listener, resolver)
+    }
+
     // Start server in a goroutine
     go func() {
-        log.Printf("Auth service starting on port %s", cfg.Port)
-        if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-            log.Fatalf("Failed to start server: %v", err)
+        slog.Info("Auth service starting", "port", cfg.Port, "proxy_protocol", cfg.ProxyProtocol)
+        if err := srv.Serve(listener); err != nil && err != http.ErrServerClosed {
+            fatal("Failed to start server", err)
+        }
+    }()
+
+    // Internal gRPC API shares the auth service with the HTTP handlers
+    grpcServer := grpcapi.NewGRPCServer(authService, jwtService, cfg.GRPCServiceToken)
+    grpcListener, err := net.Listen("tcp", ":"+cfg.GRPCPort)
+    if err != nil {
+        fatal("Failed to listen for gRPC", err)
+    }
+    go func() {
+        slog.Info("Auth gRPC server starting", "port", cfg.GRPCPort)
+        if err := grpcServer.Serve(grpcListener); err != nil {
+            fatal("Failed to start gRPC server", err)
         }
     }()
 
@@ -71,64 +240,341 @@ func main() {
     quit := make(chan os.Signal, 1)
     signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
     <-quit
-    log.Println("Shutting down server...")
+    slog.Info("Shutting down server")
 
     // Graceful shutdown with timeout
     ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
     defer cancel()
 
     if err := srv.Shutdown(ctx); err != nil {
-        log.Fatalf("Server forced to shutdown: %v", err)
+        fatal("Server forced to shutdown", err)
+    }
+    grpcServer.GracefulStop()
+    if err := shutdownTracing(ctx); err != nil {
+        slog.Error("Failed to flush traces", "error", err)
     }
 
-    log.Println("Server exited")
+    slog.Info("Server exited")
+}
+
+// fatal logs err and exits, deferred cleanups do not run
+func fatal(msg string, err error) {
+    slog.Error(msg, "error", err)
+    os.Exit(1)
+}
+
+// newRateLimitControls builds the operator allowlist, denylist and
+// overrides, shared through Redis like the limits; nil when disabled
+func newRateLimitControls(cfg *config.Config, redis *database.RedisClient, resilience middleware.Resilience) *middleware.RateLimitControls {
+    if !cfg.RateLimitEnabled {
+        return nil
+    }
+    var store *database.RedisClient
+    if redisRateLimiting(cfg) {
+        store = redis
+    }
+    return middleware.NewRateLimitControls(store, cfg.RateLimitControlsRefresh, resilience)
 }
 
-func setupRouter(cfg *config.Config, authHandler *handlers.AuthHandler, healthHandler *handlers.HealthHandler, redis *database.RedisClient) *gin.Engine {
+// newRateLimiter builds the global token bucket limiter, nil when disabled,
+// with its buckets for the admin API and a function applying a new
+// requests-per-minute limit to it
+func newRateLimiter(ctx context.Context, cfg *config.Config, redis *database.RedisClient, resilience middleware.Resilience, controls *middleware.RateLimitControls) (gin.HandlerFunc, middleware.BucketAdmin, func(int)) {
+    if !cfg.RateLimitEnabled {
+        return nil, nil, func(int) {}
+    }
+
+    // Convert requests per minute to tokens per second
+    capacity, refillRate := cfg.RateLimitRPM, float64(cfg.RateLimitRPM)/60.0
+    if redisRateLimiting(cfg) {
+        loadCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+        defer cancel()
+        if err := middleware.LoadScripts(loadCtx, redis); err != nil {
+            // Scripts are sent again on first use, Redis may just not be up yet
+            slog.Warn("Failed to preload rate limit scripts", "error", err)
+        }
+
+        // Redis-based token bucket for production, with each replica's share
+        // of the limit held locally for Redis outages
+        bucket := middleware.NewRedisTokenBucket(redis, capacity, refillRate, 1)
+        var primary middleware.RateLimiter = bucket
+        if cfg.RateLimitBatchSize > 0 {
+            primary = middleware.NewBatchingTokenBucket(bucket, cfg.RateLimitBatchSize, cfg.RateLimitHotKeyRPS)
+        }
+        local := middleware.PerReplica(capacity, cfg.RateLimitReplicas)
+        fallback := middleware.NewBoundedMemoryTokenBucket(ctx, local, float64(local)/60.0, 1, middleware.DefaultMemoryStoreConfig())
+        limiter := controls.Limiter(middleware.NewResilientLimiter(primary, fallback, resilience))
+        return middleware.RateLimitMiddleware(limiter, "redis"), bucket, func(rpm int) {
+            bucket.SetLimits(rpm, float64(rpm)/60.0)
+            local := middleware.PerReplica(rpm, cfg.RateLimitReplicas)
+            fallback.SetLimits(local, float64(local)/60.0)
+        }
+    }
+    // Memory-based token bucket for development
+    bucket := middleware.NewBoundedMemoryTokenBucket(ctx, capacity, refillRate, 1, middleware.DefaultMemoryStoreConfig())
+    limiter := controls.Limiter(bucket)
+    return middleware.RateLimitMiddleware(limiter, "memory"), bucket, func(rpm int) { bucket.SetLimits(rpm, float64(rpm)/60.0) }
+}
+
+// newPolicyLimiters builds the per-route policy limiter and the
+// authenticated tier limiter from the policy file, or the built-in policies
+// and tiers; both nil when rate limiting is disabled and closed with ctx
+func newPolicyLimiters(ctx context.Context, cfg *config.Config, redis *database.RedisClient, resilience middleware.Resilience) (policy, tier gin.HandlerFunc, err error) {
+    if !cfg.RateLimitEnabled {
+        return nil, nil, nil
+    }
+
+    file := &middleware.PolicyFile{
+        Policies:    middleware.DefaultPolicies(),
+        Tiers:       middleware.DefaultTiers(),
+        DefaultTier: middleware.DefaultTier,
+    }
+    if cfg.RateLimitPoliciesFile != "" {
+        if file, err = middleware.LoadPolicyFile(cfg.RateLimitPoliciesFile); err != nil {
+            return nil, nil, err
+        }
+    }
+
+    var store *database.RedisClient
+    if redisRateLimiting(cfg) {
+        store = redis
+    }
+    policyLimiter, err := middleware.NewPolicyLimiter(ctx, store, file.Policies, resilience)
+    if err != nil {
+        return nil, nil, err
+    }
+    tierLimiter, err := middleware.NewTierLimiter(ctx, store, file.Tiers, file.DefaultTier, resilience)
+    if err != nil {
+        return nil, nil, err
+    }
+    context.AfterFunc(ctx, func() {
+        policyLimiter.Close()
+        tierLimiter.Close()
+    })
+    return policyLimiter.Middleware(), tierLimiter.Middleware(), nil
+}
+
+// newResilience configures how the Redis limiters ride out a Redis outage.
+// They share one breaker, so a dead Redis is skipped by all of them at once.
+func newResilience(cfg *config.Config) middleware.Resilience {
+    breaker := middleware.NewCircuitBreaker(cfg.RateLimitBreakerThreshold, cfg.RateLimitBreakerCooldown)
+    breaker.OnStateChange(func(state string) {
+        slog.Warn("Rate limiter circuit breaker changed state", "state", state, "failure_mode", cfg.RateLimitFailureMode)
+        metrics.RateLimitBreakerChanged(state)
+    })
+    return middleware.Resilience{
+        Mode:     cfg.RateLimitFailureMode,
+        Replicas: cfg.RateLimitReplicas,
+        Breaker:  breaker,
+    }
+}
+
+// redisRateLimiting reports whether the global limiter depends on Redis
+func redisRateLimiting(cfg *config.Config) bool {
+    return cfg.RateLimitEnabled && (cfg.Environment == "production" || cfg.Environment == "staging")
+}
+
+// newHealthRegistry registers a check for every dependency. Postgres is
+// always critical and Redis is critical once rate limiting depends on it.
+func newHealthRegistry(cfg *config.Config, db *database.PostgresDB, redis *database.RedisClient) *health.Registry {
+    registry := health.NewRegistry(cfg.HealthCheckInterval, cfg.HealthCheckTimeout)
+    registry.Register(health.Check{
+        Name:          "database",
+        Criticality:   health.Critical,
+        Run:           health.Postgres(db),
+        SlowThreshold: 2 * time.Second,
+    })
+
+    redisCriticality := health.NonCritical
+    if redisRateLimiting(cfg) {
+        redisCriticality = health.Critical
+    }
+    registry.Register(health.Check{
+        Name:          "redis",
+        Criticality:   redisCriticality,
+        Run:           health.Redis(redis),
+        SlowThreshold: time.Second,
+    })
+
+    registry.Register(health.Check{
+        Name:        "kafka",
+        Criticality: health.NonCritical,
+        Run:         health.TCP(cfg.KafkaBrokers...),
+    })
+    for name, endpoint := range cfg.HealthDependencies {
+        registry.Register(health.Check{
+            Name:        name,
+            Criticality: health.NonCritical,
+            Run:         health.HTTP(nil, endpoint),
+        })
+    }
+    return registry
+}
+
+func setupRouter(cfg *config.Config, resolver *clientip.Resolver, authHandler *handlers.AuthHandler, samlHandler *handlers.SAMLHandler, scimHandler *handlers.SCIMHandler, domainHandler *handlers.DomainHandler, rateLimitHandler *handlers.RateLimitHandler, scimService *services.SCIMService, authService *services.AuthService, healthHandler *handlers.HealthHandler, jwksHandler *handlers.JWKSHandler, redis *database.RedisClient, controls *middleware.RateLimitControls, rateLimiter, policyLimiter, tierLimiter gin.HandlerFunc) *gin.Engine {
     if cfg.Environment == "production" {
         gin.SetMode(gin.ReleaseMode)
     }
 
     router := gin.New()
+    // Client addresses come from the resolver alone, gin must not read
+    // forwarding headers on its own
+    router.SetTrustedProxies(nil)
     
     // Global middleware
-    router.Use(gin.Logger())
+    router.Use(clientip.Middleware(resolver))
+    router.Use(middleware.RequestID())
+    router.Use(middleware.AccessLog())
     router.Use(gin.Recovery())
+    router.Use(tracing.Middleware())
+    router.Use(metrics.Middleware())
+    router.Use(middleware.TimeoutMiddleware(cfg.RequestTimeout, cfg.RouteTimeouts))
+    // Operator allow and deny lists are checked by client IP before the first
+    // limiter, and by user and workspace again after authentication
+    if controls != nil {
+        router.Use(controls.Middleware())
+    }
+    // Per-route policies match on the route pattern, so they cover /api/v1 and /scim alike
+    if policyLimiter != nil {
+        router.Use(policyLimiter)
+    }
     
 
     // Health check endpoints (no rate limiting)
     router.GET("/health", healthHandler.HealthCheck)
     router.GET("/health/live", healthHandler.LivenessProbe)
     router.GET("/health/ready", healthHandler.ReadinessProbe)
-    router.GET("/metrics", healthHandler.MetricsEndpoint)
+    router.GET("/health/startup", healthHandler.StartupProbe)
+    router.GET("/metrics", metrics.Handler())
+    router.GET("/.well-known/jwks.json", jwksHandler.JWKS)
 
     // API v1 routes
     v1 := router.Group("/api/v1")
-    
-    // Simple Token Bucket Rate Limiting (1 token per request for all endpoints)
-    if cfg.RateLimitEnabled {
-        if cfg.Environment == "production" || cfg.Environment == "staging" {
-            // Redis-based token bucket for production
-            v1.Use(middleware.TokenBucketMiddleware(redis, cfg.RateLimitRPM))
-        } else {
-            // Memory-based token bucket for development
-            v1.Use(middleware.MemoryTokenBucketMiddleware(cfg.RateLimitRPM))
+
+    // Public routes share a token bucket per client IP. Authenticated routes
+    // are limited after authentication instead, by user, workspace and plan.
+    public := v1.Group("")
+    if rateLimiter != nil {
+        public.Use(rateLimiter)
+    }
+    authenticated := func(auth gin.HandlerFunc) []gin.HandlerFunc {
+        chain := []gin.HandlerFunc{auth}
+        if controls != nil {
+            chain = append(chain, controls.Middleware())
+        }
+        if tierLimiter != nil {
+            chain = append(chain, tierLimiter)
+        }
+        return chain
+    }
+
+    // Abuse challenges on signup and password login
+    registerChallenge := func(c *gin.Context) { c.Next() }
+    loginChallenge := registerChallenge
+    if cfg.ChallengeEnabled {
+        challengeConfig := services.DefaultChallengeConfig()
+        challengeConfig.Window = cfg.ChallengeWindow
+        challengeConfig.IPThreshold = cfg.ChallengeIPThreshold
+        challengeConfig.SubnetThreshold = cfg.ChallengeSubnetThreshold
+        challengeConfig.GlobalThreshold = cfg.ChallengeGlobalThreshold
+        challengeConfig.FailureThreshold = cfg.ChallengeFailureThreshold
+        challengeConfig.BaseDifficulty = cfg.ChallengeBaseDifficulty
+        challengeConfig.MaxDifficulty = cfg.ChallengeMaxDifficulty
+        challengeConfig.RateLimitCapacity = cfg.RateLimitRPM
+
+        var captcha services.CaptchaVerifier
+        if cfg.CaptchaVerifyURL != "" {
+            captcha = services.NewSiteVerifyCaptcha(cfg.CaptchaVerifyURL, cfg.CaptchaSecret)
         }
+
+        challengeService := services.NewChallengeService(repository.NewRedisRateLimitStore(redis), challengeConfig, captcha)
+        registerChallenge = middleware.ChallengeMiddleware(challengeService, "register")
+        loginChallenge = middleware.ChallengeMiddleware(challengeService, "login")
     }
 
     // Auth routes
-    auth := v1.Group("/auth")
+    auth := public.Group("/auth")
     {
-        auth.POST("/register", authHandler.Register)
-        auth.POST("/login", authHandler.Login)
+        auth.POST("/register", registerChallenge, authHandler.Register)
+        auth.POST("/login", loginChallenge, authHandler.Login)
+        auth.POST("/login/verify", authHandler.VerifyLogin)
         auth.POST("/refresh", authHandler.RefreshToken)
         auth.POST("/forgot-password", authHandler.ForgotPassword)
         auth.POST("/reset-password", authHandler.ResetPassword)
     }
 
+    // SAML SSO routes (per workspace)
+    saml := public.Group("/auth/saml/:workspace_id")
+    {
+        saml.GET("/metadata", samlHandler.Metadata)
+        saml.GET("/login", samlHandler.Login)
+        saml.POST("/acs", samlHandler.AssertionConsumerService)
+    }
+
+    // SCIM 2.0 provisioning routes (per-workspace bearer token)
+    scim := router.Group("/scim/v2")
+    scim.Use(authenticated(middleware.SCIMAuthMiddleware(scimService))...)
+    {
+        scim.GET("/ServiceProviderConfig", scimHandler.ServiceProviderConfig)
+        scim.GET("/Users", scimHandler.ListUsers)
+        scim.POST("/Users", scimHandler.CreateUser)
+        scim.GET("/Users/:id", scimHandler.GetUser)
+        scim.PUT("/Users/:id", scimHandler.ReplaceUser)
+        scim.PATCH("/Users/:id", scimHandler.PatchUser)
+        scim.DELETE("/Users/:id", scimHandler.DeleteUser)
+        scim.GET("/Groups", scimHandler.ListGroups)
+        scim.POST("/Groups", scimHandler.CreateGroup)
+        scim.GET("/Groups/:id", scimHandler.GetGroup)
+        scim.PUT("/Groups/:id", scimHandler.ReplaceGroup)
+        scim.PATCH("/Groups/:id", scimHandler.PatchGroup)
+        scim.DELETE("/Groups/:id", scimHandler.DeleteGroup)
+    }
+
+    // Workspace domain policies, authenticated with the workspace's provisioning token
+    domains := v1.Group("/admin/domains")
+    domains.Use(authenticated(middleware.SCIMAuthMiddleware(scimService))...)
+    {
+        domains.GET("", domainHandler.ListDomains)
+        domains.POST("", domainHandler.ClaimDomain)
+        domains.PUT("/:domain", domainHandler.UpdateDomain)
+        domains.DELETE("/:domain", domainHandler.DeleteDomain)
+        domains.POST("/:domain/verify", domainHandler.VerifyDomain)
+    }
+
+    // Workspace SAML identity provider, authenticated with the workspace's provisioning token
+    samlAdmin := v1.Group("/admin/saml")
+    samlAdmin.Use(authenticated(middleware.SCIMAuthMiddleware(scimService))...)
+    {
+        samlAdmin.GET("", samlHandler.GetIdentityProvider)
+        samlAdmin.PUT("", samlHandler.ConfigureIdentityProvider)
+    }
+
+    // Online token checks for other services, disabled unless a token is
+    // configured. One downstream pod introspects for all of its users, so the
+    // route sits outside the per-IP bucket and only the service token admits.
+    if cfg.IntrospectionToken != "" {
+        v1.POST("/auth/introspect", middleware.ServiceTokenMiddleware(cfg.IntrospectionToken), authHandler.Introspect)
+    }
+
+    // Rate limit administration for operators, disabled unless a token is configured
+    if rateLimitHandler != nil {
+        rateLimits := v1.Group("/admin/rate-limits")
+        rateLimits.Use(middleware.ServiceTokenMiddleware(cfg.RateLimitAdminToken))
+        {
+            rateLimits.GET("/buckets/:identifier", rateLimitHandler.GetBucket)
+            rateLimits.DELETE("/buckets/:identifier", rateLimitHandler.ResetBucket)
+            rateLimits.PUT("/overrides/:identifier", rateLimitHandler.SetOverride)
+            rateLimits.DELETE("/overrides/:identifier", rateLimitHandler.DeleteOverride)
+            rateLimits.GET("/lists", rateLimitHandler.GetLists)
+            rateLimits.PUT("/lists/:list/:identifier", rateLimitHandler.AddToList)
+            rateLimits.DELETE("/lists/:list/:identifier", rateLimitHandler.RemoveFromList)
+            rateLimits.PUT("/plans/:workspace_id", rateLimitHandler.SetPlan)
+        }
+    }
+
     // Protected routes
     protected := v1.Group("/auth")
-    protected.Use(middleware.AuthMiddleware(cfg.JWTSecret))
+    protected.Use(authenticated(middleware.AuthMiddleware(authService))...)
     {
         protected.POST("/logout", authHandler.Logout)
         protected.GET("/me", authHandler.GetCurrentUser)
@@ -0,0 +1,81 @@
+package main
+
+import (
+    "context"
+    "fmt"
+    "log/slog"
+    "os"
+    "os/signal"
+    "strconv"
+    "syscall"
+    "time"
+
+    "github.com/Shridhar2104/chat-platform/auth-service/migrations"
+    "github.com/Shridhar2104/chat-platform/shared/config"
+    "github.com/Shridhar2104/chat-platform/shared/database"
+)
+
+const migrateUsage = `usage: auth-service migrate <command>
+
+Commands:
+  up        apply all pending migrations
+  down [N]  revert the last N applied migrations (default 1)
+  status    list migrations and when they were applied`
+
+// runMigrate implements the migrate subcommand against the configured database
+func runMigrate(cfg *config.Config, args []string) error {
+    if len(args) == 0 {
+        return fmt.Errorf("missing migrate command\n%s", migrateUsage)
+    }
+
+    db, err := database.NewPostgresConnection(cfg.DatabaseURL)
+    if err != nil {
+        return err
+    }
+    defer db.Close()
+
+    migrator, err := database.NewMigrator(db.DB, migrations.FS)
+    if err != nil {
+        return err
+    }
+
+    ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+    defer stop()
+
+    switch args[0] {
+    case "up":
+        applied, err := migrator.Up(ctx)
+        if err != nil {
+            return err
+        }
+        slog.Info("Applied migrations", "count", len(applied))
+    case "down":
+        steps := 1
+        if len(args) > 1 {
+            steps, err = strconv.Atoi(args[1])
+            if err != nil || steps < 1 {
+                return fmt.Errorf("invalid number of migrations to revert: %q", args[1])
+            }
+        }
+        reverted, err := migrator.Down(ctx, steps)
+        if err != nil {
+            return err
+        }
+        slog.Info("Reverted migrations", "count", len(reverted))
+    case "status":
+        statuses, err := migrator.Status(ctx)
+        if err != nil {
+            return err
+        }
+        for _, status := range statuses {
+            appliedAt := "pending"
+            if status.AppliedAt != nil {
+                appliedAt = status.AppliedAt.Format(time.RFC3339)
+            }
+            fmt.Fprintf(os.Stdout, "%03d  %-45s %s\n", status.Version, status.Name, appliedAt)
+        }
+    default:
+        return fmt.Errorf("unknown migrate command %q\n%s", args[0], migrateUsage)
+    }
+    return nil
+}
\ No newline at end of file
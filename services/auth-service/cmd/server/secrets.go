@@ -0,0 +1,57 @@
+package main
+
+import (
+    "context"
+    "errors"
+    "fmt"
+
+    "github.com/Shridhar2104/chat-platform/shared/config"
+    "github.com/Shridhar2104/chat-platform/shared/secrets"
+)
+
+// Secret names as stored in the provider; the env provider maps them to
+// JWT_SECRET and DATABASE_URL
+const (
+    jwtSecretName   = "jwt-secret"
+    databaseURLName = "database-url"
+)
+
+// newSecretManager builds the configured secrets provider behind a cache
+// that is refreshed every SECRETS_REFRESH_INTERVAL
+func newSecretManager(cfg *config.Config) *secrets.Manager {
+    var provider secrets.Provider
+    switch cfg.SecretsProvider {
+    case "file":
+        provider = secrets.NewFileProvider(cfg.SecretsDir)
+    case "keyvault":
+        var tokens secrets.TokenSource = secrets.NewManagedIdentityToken("", nil)
+        if cfg.AzureKeyVaultToken != "" {
+            tokens = secrets.StaticToken(cfg.AzureKeyVaultToken)
+        }
+        provider = secrets.NewKeyVaultProvider(cfg.AzureKeyVaultURL, tokens, nil)
+    default:
+        provider = secrets.NewEnvProvider()
+    }
+    return secrets.NewManager(provider, cfg.SecretsRefreshInterval)
+}
+
+// resolveSecrets replaces the configured credentials with the provider's
+// values. Only the env provider may lack one, the configured value from the
+// file or defaults then stands; either way the result is validated again so
+// production never runs on the built-in credentials.
+func resolveSecrets(ctx context.Context, manager *secrets.Manager, cfg *config.Config) error {
+    for name, target := range map[string]*string{
+        jwtSecretName:   &cfg.JWTSecret,
+        databaseURLName: &cfg.DatabaseURL,
+    } {
+        value, err := manager.Get(ctx, name)
+        if errors.Is(err, secrets.ErrNotFound) && cfg.SecretsProvider == "env" {
+            continue
+        }
+        if err != nil {
+            return fmt.Errorf("failed to read secret %s: %w", name, err)
+        }
+        *target = value
+    }
+    return cfg.ValidateSecrets()
+}
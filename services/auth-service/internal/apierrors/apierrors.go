@@ -0,0 +1,212 @@
+// Package apierrors maps service and repository errors to HTTP statuses and
+// stable error codes, and writes them as RFC 7807 application/problem+json.
+// Clients that explicitly accept only application/json keep receiving the
+// legacy models.ErrorResponse body.
+package apierrors
+
+import (
+    "context"
+    "errors"
+    "net/http"
+    "strings"
+
+    "github.com/gin-gonic/gin"
+    "github.com/google/uuid"
+    "github.com/Shridhar2104/chat-platform/auth-service/internal/logging"
+    "github.com/Shridhar2104/chat-platform/auth-service/internal/models"
+    "github.com/Shridhar2104/chat-platform/auth-service/internal/services"
+)
+
+const (
+    ProblemContentType = "application/problem+json"
+    // TypeURIPrefix namespaces the problem type URIs, one per error code
+    TypeURIPrefix = "urn:chat-platform:problem:"
+
+    CodeValidation = "validation_error"
+    CodeInternal   = "internal_error"
+    CodeTimeout    = "request_timeout"
+
+    codeSSORequired = "sso_required"
+
+    traceIDKey      = "trace_id"
+    requestIDHeader = "X-Request-ID"
+)
+
+type mapping struct {
+    err    error
+    status int
+    code   string
+}
+
+// mappings is the single source of truth for error codes, the first match wins
+var mappings = []mapping{
+    {services.ErrEmailAlreadyRegistered, http.StatusConflict, "email_already_registered"},
+    {services.ErrInvalidCredentials, http.StatusUnauthorized, "invalid_credentials"},
+    {services.ErrAccountDisabled, http.StatusForbidden, "account_disabled"},
+    {services.ErrIncorrectPassword, http.StatusUnauthorized, "incorrect_password"},
+    {services.ErrInvalidToken, http.StatusUnauthorized, "invalid_token"},
+    {services.ErrInvalidRefreshToken, http.StatusUnauthorized, "invalid_refresh_token"},
+    {services.ErrDeviceMismatch, http.StatusUnauthorized, "device_mismatch"},
+    {services.ErrSessionNotFound, http.StatusUnauthorized, "session_expired"},
+    {services.ErrChallengeNotFound, http.StatusUnauthorized, "challenge_expired"},
+    {services.ErrInvalidConfirmationCode, http.StatusUnauthorized, "invalid_confirmation_code"},
+    {services.ErrUserNotFound, http.StatusNotFound, "user_not_found"},
+
+    {services.ErrSignupNotAllowed, http.StatusForbidden, "signup_not_allowed"},
+    {services.ErrInvalidDomain, http.StatusBadRequest, "invalid_domain"},
+    {services.ErrInvalidEmail, http.StatusBadRequest, "invalid_email"},
+    {services.ErrDomainAlreadyClaimed, http.StatusConflict, "domain_already_claimed"},
+    {services.ErrDomainVerifiedElsewhere, http.StatusConflict, "domain_verified_elsewhere"},
+    {services.ErrVerificationRecordNotFound, http.StatusBadRequest, "verification_record_not_found"},
+    {services.ErrVerificationRecordMismatch, http.StatusBadRequest, "verification_record_mismatch"},
+    {services.ErrDomainPolicyNotFound, http.StatusNotFound, "domain_policy_not_found"},
+
+    {services.ErrIdentityProviderNotFound, http.StatusNotFound, "sso_unavailable"},
+    {services.ErrIdentityProviderDisabled, http.StatusNotFound, "sso_unavailable"},
+    {services.ErrSSOAssertionRejected, http.StatusUnauthorized, "sso_failed"},
+}
+
+// Respond writes the problem for err. Errors without a mapping are logged
+// and answered with a generic 500 so internal details never reach clients.
+func Respond(c *gin.Context, err error) {
+    var ssoRequired *services.SSORequiredError
+    if errors.As(err, &ssoRequired) {
+        respondSSORequired(c, ssoRequired)
+        return
+    }
+
+    for _, m := range mappings {
+        if errors.Is(err, m.err) {
+            Write(c, m.status, m.code, m.err.Error())
+            return
+        }
+    }
+
+    if TimedOut(c, err) {
+        Timeout(c)
+        return
+    }
+
+    logging.FromContext(c.Request.Context()).Error("Unhandled error",
+        "method", c.Request.Method, "route", c.FullPath(), "error", err)
+    Write(c, http.StatusInternalServerError, CodeInternal, "An internal error occurred")
+}
+
+// Code returns the error code Respond would use for err, for metrics and logs
+func Code(c *gin.Context, err error) string {
+    var ssoRequired *services.SSORequiredError
+    if errors.As(err, &ssoRequired) {
+        return codeSSORequired
+    }
+    for _, m := range mappings {
+        if errors.Is(err, m.err) {
+            return m.code
+        }
+    }
+    if TimedOut(c, err) {
+        return CodeTimeout
+    }
+    return CodeInternal
+}
+
+// Validation reports a request body or parameter that failed validation
+func Validation(c *gin.Context, detail string) {
+    Write(c, http.StatusBadRequest, CodeValidation, detail)
+}
+
+// Timeout reports that the route's deadline passed before a response was ready
+func Timeout(c *gin.Context) {
+    Write(c, http.StatusGatewayTimeout, CodeTimeout, "The request took too long to complete")
+}
+
+// TimedOut reports whether err, or the failure that led to it, was caused by
+// the request deadline. Drivers do not always wrap context.DeadlineExceeded
+// (lib/pq reports a canceled statement), so the request context is checked too.
+func TimedOut(c *gin.Context, err error) bool {
+    if errors.Is(err, context.DeadlineExceeded) {
+        return true
+    }
+    return errors.Is(c.Request.Context().Err(), context.DeadlineExceeded)
+}
+
+// Write aborts the request with an error in the format the client negotiated
+func Write(c *gin.Context, status int, code, detail string) {
+    if !wantsProblem(c) {
+        c.AbortWithStatusJSON(status, models.ErrorResponse{Error: code, Message: detail})
+        return
+    }
+    c.Header("Content-Type", ProblemContentType)
+    c.AbortWithStatusJSON(status, newProblem(c, status, code, detail))
+}
+
+func respondSSORequired(c *gin.Context, err *services.SSORequiredError) {
+    const (
+        code   = codeSSORequired
+        detail = "Your organization requires signing in with single sign-on"
+    )
+
+    if !wantsProblem(c) {
+        c.AbortWithStatusJSON(http.StatusForbidden, models.SSORequiredResponse{
+            Error:       code,
+            Message:     detail,
+            WorkspaceID: err.WorkspaceID,
+            RedirectURL: err.RedirectPath(),
+        })
+        return
+    }
+    c.Header("Content-Type", ProblemContentType)
+    c.AbortWithStatusJSON(http.StatusForbidden, models.SSORequiredProblem{
+        Problem:     newProblem(c, http.StatusForbidden, code, detail),
+        WorkspaceID: err.WorkspaceID,
+        RedirectURL: err.RedirectPath(),
+    })
+}
+
+func newProblem(c *gin.Context, status int, code, detail string) models.Problem {
+    return models.Problem{
+        Type:     TypeURIPrefix + code,
+        Title:    http.StatusText(status),
+        Status:   status,
+        Detail:   detail,
+        Instance: c.Request.URL.Path,
+        Code:     code,
+        TraceID:  TraceID(c),
+    }
+}
+
+// wantsProblem prefers problem+json unless the client lists application/json without it
+func wantsProblem(c *gin.Context) bool {
+    accept := c.GetHeader("Accept")
+    if strings.Contains(accept, ProblemContentType) {
+        return true
+    }
+    return !strings.Contains(accept, "application/json")
+}
+
+// TraceID returns the ID correlating this request's logs and error bodies,
+// taken from X-Request-ID when the caller supplied one
+func TraceID(c *gin.Context) string {
+    if traceID := c.GetString(traceIDKey); traceID != "" {
+        return traceID
+    }
+    traceID := c.GetHeader(requestIDHeader)
+    if !validRequestID(traceID) {
+        traceID = uuid.New().String()
+    }
+    c.Set(traceIDKey, traceID)
+    return traceID
+}
+
+// validRequestID keeps caller-supplied IDs short and free of characters that
+// could forge log fields or response headers
+func validRequestID(id string) bool {
+    if id == "" || len(id) > 128 {
+        return false
+    }
+    for _, r := range id {
+        if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("-_.:", r)) {
+            return false
+        }
+    }
+    return true
+}
\ No newline at end of file
@@ -0,0 +1,163 @@
+package apierrors
+
+import (
+    "context"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "net/http"
+    "net/http/httptest"
+    "strings"
+    "testing"
+
+    "github.com/gin-gonic/gin"
+    "github.com/google/uuid"
+    "github.com/Shridhar2104/chat-platform/auth-service/internal/models"
+    "github.com/Shridhar2104/chat-platform/auth-service/internal/repository"
+    "github.com/Shridhar2104/chat-platform/auth-service/internal/services"
+)
+
+// respond runs Respond for err behind a test route and returns the recorded response
+func respond(t *testing.T, err error, accept string) *httptest.ResponseRecorder {
+    t.Helper()
+    gin.SetMode(gin.TestMode)
+
+    router := gin.New()
+    router.GET("/api/v1/test", func(c *gin.Context) {
+        Respond(c, err)
+    })
+
+    req := httptest.NewRequest(http.MethodGet, "/api/v1/test", nil)
+    req.Header.Set("X-Request-ID", "req-123")
+    if accept != "" {
+        req.Header.Set("Accept", accept)
+    }
+    rec := httptest.NewRecorder()
+    router.ServeHTTP(rec, req)
+    return rec
+}
+
+func TestRespondMapsErrors(t *testing.T) {
+    tests := []struct {
+        name   string
+        err    error
+        status int
+        code   string
+    }{
+        {name: "sentinel", err: services.ErrEmailAlreadyRegistered, status: http.StatusConflict, code: "email_already_registered"},
+        {name: "wrapped sentinel", err: fmt.Errorf("login: %w", services.ErrInvalidCredentials), status: http.StatusUnauthorized, code: "invalid_credentials"},
+        {name: "repository sentinel", err: repository.ErrDomainPolicyNotFound, status: http.StatusNotFound, code: "domain_policy_not_found"},
+        {name: "sso rejection", err: fmt.Errorf("%w: SAML assertion has expired", services.ErrSSOAssertionRejected), status: http.StatusUnauthorized, code: "sso_failed"},
+        {name: "unmapped", err: errors.New("failed to create user: pq: connection refused"), status: http.StatusInternalServerError, code: CodeInternal},
+        {name: "deadline", err: fmt.Errorf("failed to get user: %w", context.DeadlineExceeded), status: http.StatusGatewayTimeout, code: CodeTimeout},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            rec := respond(t, tt.err, "")
+
+            if rec.Code != tt.status {
+                t.Errorf("status = %d, want %d", rec.Code, tt.status)
+            }
+            if got := rec.Header().Get("Content-Type"); got != ProblemContentType {
+                t.Errorf("Content-Type = %q, want %q", got, ProblemContentType)
+            }
+
+            var problem models.Problem
+            if err := json.Unmarshal(rec.Body.Bytes(), &problem); err != nil {
+                t.Fatalf("invalid body: %v", err)
+            }
+            if problem.Code != tt.code || problem.Status != tt.status || problem.Type != TypeURIPrefix+tt.code {
+                t.Errorf("got problem %+v", problem)
+            }
+            if problem.TraceID != "req-123" || problem.Instance != "/api/v1/test" {
+                t.Errorf("trace_id = %q, instance = %q", problem.TraceID, problem.Instance)
+            }
+            if strings.Contains(problem.Detail, "pq:") || strings.Contains(problem.Detail, "SAML") {
+                t.Errorf("detail leaks internal error text: %q", problem.Detail)
+            }
+        })
+    }
+}
+
+func TestRespondContentNegotiation(t *testing.T) {
+    tests := []struct {
+        accept  string
+        problem bool
+    }{
+        {accept: "", problem: true},
+        {accept: "*/*", problem: true},
+        {accept: "application/problem+json", problem: true},
+        {accept: "application/problem+json, application/json", problem: true},
+        {accept: "application/json", problem: false},
+        {accept: "application/json, text/plain, */*", problem: false},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.accept, func(t *testing.T) {
+            rec := respond(t, services.ErrInvalidCredentials, tt.accept)
+
+            if tt.problem {
+                var problem models.Problem
+                json.Unmarshal(rec.Body.Bytes(), &problem)
+                if problem.Code != "invalid_credentials" {
+                    t.Errorf("expected problem body, got %s", rec.Body.String())
+                }
+                return
+            }
+
+            if strings.HasPrefix(rec.Header().Get("Content-Type"), ProblemContentType) {
+                t.Errorf("legacy response served as %s", ProblemContentType)
+            }
+            var legacy models.ErrorResponse
+            json.Unmarshal(rec.Body.Bytes(), &legacy)
+            if legacy.Error != "invalid_credentials" || legacy.Message != services.ErrInvalidCredentials.Error() {
+                t.Errorf("expected legacy body, got %s", rec.Body.String())
+            }
+        })
+    }
+}
+
+func TestRespondSSORequired(t *testing.T) {
+    workspaceID := uuid.New()
+    err := &services.SSORequiredError{WorkspaceID: workspaceID, Domain: "example.com"}
+
+    rec := respond(t, err, "")
+    var problem models.SSORequiredProblem
+    if err := json.Unmarshal(rec.Body.Bytes(), &problem); err != nil {
+        t.Fatalf("invalid body: %v", err)
+    }
+    if rec.Code != http.StatusForbidden || problem.Code != "sso_required" {
+        t.Errorf("got %d %+v", rec.Code, problem)
+    }
+    if problem.WorkspaceID != workspaceID || problem.RedirectURL != err.RedirectPath() {
+        t.Errorf("workspace_id = %s, redirect_url = %q", problem.WorkspaceID, problem.RedirectURL)
+    }
+
+    rec = respond(t, err, "application/json")
+    var legacy models.SSORequiredResponse
+    json.Unmarshal(rec.Body.Bytes(), &legacy)
+    if legacy.Error != "sso_required" || legacy.RedirectURL != err.RedirectPath() {
+        t.Errorf("expected legacy body, got %s", rec.Body.String())
+    }
+}
+
+func TestRespondExpiredRequestContext(t *testing.T) {
+    gin.SetMode(gin.TestMode)
+
+    router := gin.New()
+    router.GET("/api/v1/test", func(c *gin.Context) {
+        ctx, cancel := context.WithTimeout(c.Request.Context(), 0)
+        defer cancel()
+        c.Request = c.Request.WithContext(ctx)
+        <-ctx.Done()
+        // lib/pq reports a canceled statement without wrapping the context error
+        Respond(c, errors.New("failed to get user: pq: canceling statement due to user request"))
+    })
+
+    rec := httptest.NewRecorder()
+    router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/test", nil))
+    if rec.Code != http.StatusGatewayTimeout {
+        t.Errorf("status = %d, want %d", rec.Code, http.StatusGatewayTimeout)
+    }
+}
\ No newline at end of file
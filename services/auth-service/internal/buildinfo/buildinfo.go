@@ -0,0 +1,57 @@
+// Package buildinfo reports what build of the service is running. Release
+// builds set the variables with -ldflags, for example:
+//
+//	go build -ldflags "-X github.com/Shridhar2104/chat-platform/auth-service/internal/buildinfo.Version=v1.4.0"
+//
+// Anything left unset falls back to the VCS stamp Go embeds in the binary.
+package buildinfo
+
+import (
+    "runtime"
+    "runtime/debug"
+)
+
+var (
+    Version = "dev"
+    Commit  = ""
+    Date    = ""
+)
+
+type Info struct {
+    Version   string `json:"version"`
+    Commit    string `json:"commit"`
+    Date      string `json:"date"`
+    GoVersion string `json:"go_version"`
+}
+
+// Get returns the build's version details, "unknown" where nothing is recorded
+func Get() Info {
+    info := Info{
+        Version:   Version,
+        Commit:    Commit,
+        Date:      Date,
+        GoVersion: runtime.Version(),
+    }
+
+    if build, ok := debug.ReadBuildInfo(); ok {
+        for _, setting := range build.Settings {
+            switch {
+            case setting.Key == "vcs.revision" && info.Commit == "":
+                info.Commit = setting.Value
+            case setting.Key == "vcs.time" && info.Date == "":
+                info.Date = setting.Value
+            }
+        }
+        if info.Version == "dev" && build.Main.Version != "" && build.Main.Version != "(devel)" {
+            info.Version = build.Main.Version
+        }
+    }
+
+    if info.Commit == "" {
+        info.Commit = "unknown"
+    }
+    if info.Date == "" {
+        info.Date = "unknown"
+    }
+    return info
+}
\ No newline at end of file
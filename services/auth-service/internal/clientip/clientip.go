@@ -0,0 +1,148 @@
+package clientip
+
+import (
+    "fmt"
+    "net"
+    "net/http"
+    "net/netip"
+    "strings"
+
+    "github.com/gin-gonic/gin"
+)
+
+// contextKey holds the resolved client IP in the gin context
+const contextKey = "client_ip"
+
+// Resolver finds the client address of a request. Forwarding headers are
+// only believed when the connection comes from a trusted proxy, and the
+// chain they carry is walked back from the nearest hop to the first address
+// that is not a trusted proxy itself, so clients cannot spoof their way past
+// the proxies.
+type Resolver struct {
+    trusted []netip.Prefix
+}
+
+// NewResolver trusts the given CIDRs or bare addresses, none when empty
+func NewResolver(proxies []string) (*Resolver, error) {
+    r := &Resolver{}
+    for _, proxy := range proxies {
+        prefix, err := netip.ParsePrefix(proxy)
+        if err != nil {
+            addr, addrErr := netip.ParseAddr(proxy)
+            if addrErr != nil {
+                return nil, fmt.Errorf("invalid trusted proxy %q: %w", proxy, err)
+            }
+            prefix = netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen())
+        }
+        r.trusted = append(r.trusted, prefix.Masked())
+    }
+    return r, nil
+}
+
+// Trusted reports whether addr belongs to a trusted proxy
+func (r *Resolver) Trusted(addr netip.Addr) bool {
+    addr = addr.Unmap()
+    for _, prefix := range r.trusted {
+        if prefix.Contains(addr) {
+            return true
+        }
+    }
+    return false
+}
+
+// Resolve returns the client IP of req. The Forwarded header (RFC 7239) is
+// preferred over X-Forwarded-For when a trusted proxy sent both.
+func (r *Resolver) Resolve(req *http.Request) string {
+    remote, ok := parseHost(req.RemoteAddr)
+    if !ok {
+        return req.RemoteAddr
+    }
+    if !r.Trusted(remote) {
+        return remote.String()
+    }
+
+    hops, ok := forwardedFor(req.Header.Values("Forwarded"))
+    if !ok {
+        hops = xForwardedFor(req.Header.Values("X-Forwarded-For"))
+    }
+
+    client := remote
+    for i := len(hops) - 1; i >= 0; i-- {
+        addr, ok := parseHost(hops[i])
+        if !ok {
+            // Hidden or malformed hops end the chain we can vouch for
+            break
+        }
+        client = addr
+        if !r.Trusted(addr) {
+            break
+        }
+    }
+    return client.String()
+}
+
+// Middleware resolves the client IP once, before anything reads it
+func Middleware(resolver *Resolver) gin.HandlerFunc {
+    return func(c *gin.Context) {
+        c.Set(contextKey, resolver.Resolve(c.Request))
+        c.Next()
+    }
+}
+
+// FromContext returns the resolved client IP. Without the middleware it
+// falls back to the peer address, never to a forwarding header.
+func FromContext(c *gin.Context) string {
+    if ip := c.GetString(contextKey); ip != "" {
+        return ip
+    }
+    if addr, ok := parseHost(c.Request.RemoteAddr); ok {
+        return addr.String()
+    }
+    return c.Request.RemoteAddr
+}
+
+// forwardedFor collects the for= parameters of Forwarded headers in order,
+// false when there are none
+func forwardedFor(headers []string) ([]string, bool) {
+    var hops []string
+    for _, header := range headers {
+        for _, element := range strings.Split(header, ",") {
+            for _, pair := range strings.Split(element, ";") {
+                name, value, ok := strings.Cut(strings.TrimSpace(pair), "=")
+                if !ok || !strings.EqualFold(name, "for") {
+                    continue
+                }
+                hops = append(hops, strings.Trim(value, `"`))
+            }
+        }
+    }
+    return hops, len(hops) > 0
+}
+
+func xForwardedFor(headers []string) []string {
+    var hops []string
+    for _, header := range headers {
+        for _, hop := range strings.Split(header, ",") {
+            if hop = strings.TrimSpace(hop); hop != "" {
+                hops = append(hops, hop)
+            }
+        }
+    }
+    return hops
+}
+
+// parseHost reads an address with or without a port, IPv6 optionally in
+// brackets as Forwarded requires; obfuscated identifiers like "unknown" or
+// "_hidden" do not parse
+func parseHost(raw string) (netip.Addr, bool) {
+    raw = strings.TrimSpace(raw)
+    if host, _, err := net.SplitHostPort(raw); err == nil {
+        raw = host
+    }
+    raw = strings.TrimSuffix(strings.TrimPrefix(raw, "["), "]")
+    addr, err := netip.ParseAddr(raw)
+    if err != nil {
+        return netip.Addr{}, false
+    }
+    return addr.Unmap().WithZone(""), true
+}
\ No newline at end of file
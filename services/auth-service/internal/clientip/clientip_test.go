@@ -0,0 +1,54 @@
+package clientip
+
+import (
+    "net/http/httptest"
+    "testing"
+)
+
+func TestResolve(t *testing.T) {
+    resolver, err := NewResolver([]string{"10.0.0.0/8", "192.168.1.7", "fd00::/8"})
+    if err != nil {
+        t.Fatalf("NewResolver failed: %v", err)
+    }
+
+    tests := []struct {
+        name      string
+        remote    string
+        forwarded string
+        xff       string
+        want      string
+    }{
+        {"direct client", "203.0.113.9:4000", "", "", "203.0.113.9"},
+        {"untrusted peer cannot spoof", "203.0.113.9:4000", "for=198.51.100.1", "198.51.100.1", "203.0.113.9"},
+        {"one trusted proxy", "10.0.0.2:4000", "", "198.51.100.1", "198.51.100.1"},
+        {"spoofed head of the chain is skipped", "10.0.0.2:4000", "", "1.1.1.1, 198.51.100.1, 10.0.0.3", "198.51.100.1"},
+        {"bare trusted address", "192.168.1.7:4000", "", "198.51.100.1", "198.51.100.1"},
+        {"forwarded wins", "10.0.0.2:4000", `for=198.51.100.1;proto=https, for="[2001:db8::1]:443"`, "192.0.2.1", "2001:db8::1"},
+        {"forwarded through trusted IPv6", "[fd00::2]:4000", `For="198.51.100.1:1234", for=10.1.1.1`, "", "198.51.100.1"},
+        {"obfuscated hop stops the walk", "10.0.0.2:4000", "for=198.51.100.1, for=_hidden", "", "10.0.0.2"},
+        {"all hops trusted", "10.0.0.2:4000", "", "10.0.0.5", "10.0.0.5"},
+        {"no header from a proxy", "10.0.0.2:4000", "", "", "10.0.0.2"},
+        {"mapped IPv4 peer", "[::ffff:203.0.113.9]:4000", "", "", "203.0.113.9"},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            req := httptest.NewRequest("GET", "/", nil)
+            req.RemoteAddr = tt.remote
+            if tt.forwarded != "" {
+                req.Header.Set("Forwarded", tt.forwarded)
+            }
+            if tt.xff != "" {
+                req.Header.Set("X-Forwarded-For", tt.xff)
+            }
+            if got := resolver.Resolve(req); got != tt.want {
+                t.Errorf("Resolve = %q, want %q", got, tt.want)
+            }
+        })
+    }
+}
+
+func TestNewResolverRejectsMalformedProxies(t *testing.T) {
+    if _, err := NewResolver([]string{"10.0.0.0/33"}); err == nil {
+        t.Error("expected an invalid CIDR to be rejected")
+    }
+}
\ No newline at end of file
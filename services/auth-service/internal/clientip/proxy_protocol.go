@@ -0,0 +1,186 @@
+package clientip
+
+import (
+    "bufio"
+    "bytes"
+    "encoding/binary"
+    "errors"
+    "fmt"
+    "io"
+    "net"
+    "net/netip"
+    "strconv"
+    "strings"
+    "sync"
+    "time"
+)
+
+// proxyHeaderTimeout bounds how long a trusted proxy may take to send the header
+const proxyHeaderTimeout = 5 * time.Second
+
+// v1MaxLength is the longest v1 header the specification allows, CRLF included
+const v1MaxLength = 107
+
+var (
+    v1Prefix    = []byte("PROXY ")
+    v2Signature = []byte("\r\n\r\n\x00\r\nQUIT\n")
+
+    ErrMissingProxyHeader = errors.New("proxy protocol: connection from a trusted proxy has no header")
+)
+
+// ProxyListener reads the PROXY protocol header (v1 or v2) that load
+// balancers send ahead of each connection, and reports the client address
+// it carries as the connection's remote address. Only connections from
+// trusted proxies must and may carry the header; others are passed through
+// untouched, so nobody else can claim an address.
+type ProxyListener struct {
+    net.Listener
+    resolver *Resolver
+}
+
+func NewProxyListener(inner net.Listener, resolver *Resolver) *ProxyListener {
+    return &ProxyListener{Listener: inner, resolver: resolver}
+}
+
+// Accept does not wait for the header, it is read on the connection's
+// first use so a slow proxy cannot stall the accept loop
+func (l *ProxyListener) Accept() (net.Conn, error) {
+    conn, err := l.Listener.Accept()
+    if err != nil {
+        return nil, err
+    }
+    addr, ok := parseHost(conn.RemoteAddr().String())
+    if !ok || !l.resolver.Trusted(addr) {
+        return conn, nil
+    }
+    return &proxyConn{Conn: conn, reader: bufio.NewReader(conn)}, nil
+}
+
+type proxyConn struct {
+    net.Conn
+    reader *bufio.Reader
+
+    once   sync.Once
+    remote net.Addr
+    err    error
+}
+
+func (c *proxyConn) readHeader() {
+    c.once.Do(func() {
+        c.Conn.SetReadDeadline(time.Now().Add(proxyHeaderTimeout))
+        c.remote, c.err = readProxyHeader(c.reader)
+        c.Conn.SetReadDeadline(time.Time{})
+    })
+}
+
+func (c *proxyConn) Read(b []byte) (int, error) {
+    c.readHeader()
+    if c.err != nil {
+        return 0, c.err
+    }
+    return c.reader.Read(b)
+}
+
+// RemoteAddr is the client named by the header, or the proxy itself for
+// LOCAL and UNKNOWN headers
+func (c *proxyConn) RemoteAddr() net.Addr {
+    c.readHeader()
+    if c.remote != nil {
+        return c.remote
+    }
+    return c.Conn.RemoteAddr()
+}
+
+// readProxyHeader consumes the header and returns the source address it
+// carries, nil when the proxy sent none
+func readProxyHeader(r *bufio.Reader) (net.Addr, error) {
+    peek, err := r.Peek(len(v2Signature))
+    if err != nil {
+        return nil, fmt.Errorf("proxy protocol: %w", err)
+    }
+    switch {
+    case bytes.Equal(peek, v2Signature):
+        return readV2(r)
+    case bytes.HasPrefix(peek, v1Prefix):
+        return readV1(r)
+    }
+    return nil, ErrMissingProxyHeader
+}
+
+// readV1 parses "PROXY TCP4 <src> <dst> <sport> <dport>\r\n"
+func readV1(r *bufio.Reader) (net.Addr, error) {
+    line := make([]byte, 0, v1MaxLength)
+    for len(line) < v1MaxLength {
+        b, err := r.ReadByte()
+        if err != nil {
+            return nil, fmt.Errorf("proxy protocol: %w", err)
+        }
+        line = append(line, b)
+        if b == '\n' {
+            break
+        }
+    }
+    if !bytes.HasSuffix(line, []byte("\r\n")) {
+        return nil, errors.New("proxy protocol: v1 header is not terminated")
+    }
+
+    fields := strings.Fields(string(line[:len(line)-2]))
+    if len(fields) >= 2 && fields[1] == "UNKNOWN" {
+        return nil, nil
+    }
+    if len(fields) != 6 || (fields[1] != "TCP4" && fields[1] != "TCP6") {
+        return nil, fmt.Errorf("proxy protocol: malformed v1 header %q", line)
+    }
+    ip, err := netip.ParseAddr(fields[2])
+    if err != nil || ip.Is4() != (fields[1] == "TCP4") {
+        return nil, fmt.Errorf("proxy protocol: invalid v1 source address %q", fields[2])
+    }
+    port, err := strconv.ParseUint(fields[4], 10, 16)
+    if err != nil {
+        return nil, fmt.Errorf("proxy protocol: invalid v1 source port %q", fields[4])
+    }
+    return net.TCPAddrFromAddrPort(netip.AddrPortFrom(ip, uint16(port))), nil
+}
+
+// readV2 parses the binary header: signature, version and command,
+// address family, payload length and the addresses followed by TLVs
+func readV2(r *bufio.Reader) (net.Addr, error) {
+    header := make([]byte, 16)
+    if _, err := io.ReadFull(r, header); err != nil {
+        return nil, fmt.Errorf("proxy protocol: %w", err)
+    }
+    if version := header[12] >> 4; version != 2 {
+        return nil, fmt.Errorf("proxy protocol: unsupported version %d", version)
+    }
+    payload := make([]byte, binary.BigEndian.Uint16(header[14:16]))
+    if _, err := io.ReadFull(r, payload); err != nil {
+        return nil, fmt.Errorf("proxy protocol: %w", err)
+    }
+
+    switch command := header[12] & 0x0f; command {
+    case 0x0:
+        // LOCAL: the proxy's own connection, such as a health check
+        return nil, nil
+    case 0x1:
+    default:
+        return nil, fmt.Errorf("proxy protocol: unsupported command %d", command)
+    }
+
+    switch family := header[13] >> 4; family {
+    case 0x1:
+        if len(payload) < 12 {
+            return nil, errors.New("proxy protocol: short IPv4 address block")
+        }
+        ip := netip.AddrFrom4([4]byte(payload[0:4]))
+        return net.TCPAddrFromAddrPort(netip.AddrPortFrom(ip, binary.BigEndian.Uint16(payload[8:10]))), nil
+    case 0x2:
+        if len(payload) < 36 {
+            return nil, errors.New("proxy protocol: short IPv6 address block")
+        }
+        ip := netip.AddrFrom16([16]byte(payload[0:16])).Unmap()
+        return net.TCPAddrFromAddrPort(netip.AddrPortFrom(ip, binary.BigEndian.Uint16(payload[32:34]))), nil
+    default:
+        // UNSPEC and unix sockets carry no IP, the proxy's address stands
+        return nil, nil
+    }
+}
\ No newline at end of file
@@ -0,0 +1,124 @@
+package clientip
+
+import (
+    "bufio"
+    "bytes"
+    "encoding/binary"
+    "errors"
+    "io"
+    "net"
+    "strings"
+    "testing"
+)
+
+// v2Header builds a binary header for a TCP connection from src:sport
+func v2Header(command byte, family byte, src net.IP, sport uint16) []byte {
+    var addresses []byte
+    switch family {
+    case 0x11:
+        addresses = append(append([]byte{}, src.To4()...), 127, 0, 0, 1)
+        addresses = binary.BigEndian.AppendUint16(addresses, sport)
+        addresses = binary.BigEndian.AppendUint16(addresses, 8080)
+    case 0x21:
+        addresses = append(append([]byte{}, src.To16()...), net.IPv6loopback...)
+        addresses = binary.BigEndian.AppendUint16(addresses, sport)
+        addresses = binary.BigEndian.AppendUint16(addresses, 8080)
+    }
+    // A TLV the reader has to skip
+    addresses = append(addresses, 0x04, 0x00, 0x01, 0xff)
+
+    header := append([]byte{}, v2Signature...)
+    header = append(header, 0x20|command, family)
+    header = binary.BigEndian.AppendUint16(header, uint16(len(addresses)))
+    return append(header, addresses...)
+}
+
+func TestReadProxyHeader(t *testing.T) {
+    tests := []struct {
+        name    string
+        header  []byte
+        want    string
+        wantErr bool
+    }{
+        {"v1 TCP4", []byte("PROXY TCP4 198.51.100.1 10.0.0.1 56324 8080\r\n"), "198.51.100.1:56324", false},
+        {"v1 TCP6", []byte("PROXY TCP6 2001:db8::1 ::1 56324 8080\r\n"), "[2001:db8::1]:56324", false},
+        {"v1 unknown", []byte("PROXY UNKNOWN\r\n"), "", false},
+        {"v1 family mismatch", []byte("PROXY TCP4 2001:db8::1 ::1 56324 8080\r\n"), "", true},
+        {"v1 unterminated", []byte("PROXY TCP4 198.51.100.1 10.0.0.1 56324 8080" + strings.Repeat(" ", 80)), "", true},
+        {"v2 IPv4", v2Header(0x1, 0x11, net.ParseIP("198.51.100.1"), 56324), "198.51.100.1:56324", false},
+        {"v2 IPv6", v2Header(0x1, 0x21, net.ParseIP("2001:db8::1"), 443), "[2001:db8::1]:443", false},
+        {"v2 local", v2Header(0x0, 0x11, net.ParseIP("10.0.0.1"), 1), "", false},
+        {"no header", []byte("GET / HTTP/1.1\r\nHost: auth\r\n\r\n"), "", true},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            reader := bufio.NewReader(io.MultiReader(bytes.NewReader(tt.header), strings.NewReader("GET /")))
+            addr, err := readProxyHeader(reader)
+            if (err != nil) != tt.wantErr {
+                t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+            }
+            if err != nil {
+                return
+            }
+            got := ""
+            if addr != nil {
+                got = addr.String()
+            }
+            if got != tt.want {
+                t.Errorf("addr = %q, want %q", got, tt.want)
+            }
+            // The request that follows is left for the server
+            if rest, _ := io.ReadAll(reader); string(rest) != "GET /" {
+                t.Errorf("left %q after the header", rest)
+            }
+        })
+    }
+}
+
+func TestProxyListener(t *testing.T) {
+    inner, err := net.Listen("tcp", "127.0.0.1:0")
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer inner.Close()
+
+    accept := func(t *testing.T, resolver *Resolver, payload string) (net.Conn, string) {
+        t.Helper()
+        client, err := net.Dial("tcp", inner.Addr().String())
+        if err != nil {
+            t.Fatal(err)
+        }
+        defer client.Close()
+        if _, err := client.Write([]byte(payload)); err != nil {
+            t.Fatal(err)
+        }
+        conn, err := NewProxyListener(inner, resolver).Accept()
+        if err != nil {
+            t.Fatal(err)
+        }
+        buf := make([]byte, 5)
+        n, _ := io.ReadFull(conn, buf)
+        return conn, string(buf[:n])
+    }
+
+    trusted, _ := NewResolver([]string{"127.0.0.1"})
+    conn, body := accept(t, trusted, "PROXY TCP4 198.51.100.1 10.0.0.1 56324 8080\r\nGET /")
+    if got := conn.RemoteAddr().String(); got != "198.51.100.1:56324" || body != "GET /" {
+        t.Errorf("trusted proxy = %s %q", got, body)
+    }
+    conn.Close()
+
+    conn, _ = accept(t, trusted, "GET / HTTP/1.1\r\n")
+    if _, err := conn.Read(make([]byte, 1)); !errors.Is(err, ErrMissingProxyHeader) {
+        t.Errorf("trusted proxy without a header = %v", err)
+    }
+    conn.Close()
+
+    // Other peers cannot announce an address
+    untrusted, _ := NewResolver(nil)
+    conn, body = accept(t, untrusted, "PROXY TCP4 198.51.100.1 10.0.0.1 56324 8080\r\n")
+    if strings.HasPrefix(conn.RemoteAddr().String(), "198.51.100.1") || body != "PROXY" {
+        t.Errorf("untrusted peer = %s %q", conn.RemoteAddr(), body)
+    }
+    conn.Close()
+}
\ No newline at end of file
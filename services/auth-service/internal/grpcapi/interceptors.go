@@ -0,0 +1,70 @@
+package grpcapi
+
+import (
+    "context"
+    "crypto/subtle"
+
+    "github.com/google/uuid"
+    "google.golang.org/grpc"
+    "google.golang.org/grpc/codes"
+    "google.golang.org/grpc/metadata"
+    "google.golang.org/grpc/status"
+    "github.com/Shridhar2104/chat-platform/auth-service/internal/services"
+    "github.com/Shridhar2104/chat-platform/shared/authclient"
+)
+
+type callerKey struct{}
+
+// Caller identifies who made a gRPC call: an internal service holding the
+// service token, or a user presenting an access token
+type Caller struct {
+    Service  bool
+    UserID   uuid.UUID
+    Email    string
+    DeviceID string
+}
+
+func CallerFromContext(ctx context.Context) (*Caller, bool) {
+    caller, ok := ctx.Value(callerKey{}).(*Caller)
+    return caller, ok
+}
+
+// AuthInterceptor mirrors AuthMiddleware for gRPC: every call needs a Bearer
+// token in the authorization metadata
+func AuthInterceptor(jwtService *services.JWTService, serviceToken string) grpc.UnaryServerInterceptor {
+    return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+        caller, err := authenticate(ctx, jwtService, serviceToken)
+        if err != nil {
+            return nil, err
+        }
+        return handler(context.WithValue(ctx, callerKey{}, caller), req)
+    }
+}
+
+func authenticate(ctx context.Context, jwtService *services.JWTService, serviceToken string) (*Caller, error) {
+    md, _ := metadata.FromIncomingContext(ctx)
+    values := md.Get("authorization")
+    if len(values) == 0 || values[0] == "" {
+        return nil, status.Error(codes.Unauthenticated, "authorization metadata is required")
+    }
+
+    token, ok := authclient.BearerToken(values[0])
+    if !ok {
+        return nil, status.Error(codes.Unauthenticated, "authorization must be in format: Bearer <token>")
+    }
+
+    if serviceToken != "" && subtle.ConstantTimeCompare([]byte(token), []byte(serviceToken)) == 1 {
+        return &Caller{Service: true}, nil
+    }
+
+    claims, err := jwtService.ValidateAccessToken(token)
+    if err != nil {
+        return nil, status.Error(codes.Unauthenticated, "invalid or expired token")
+    }
+
+    return &Caller{
+        UserID:   claims.UserID,
+        Email:    claims.Email,
+        DeviceID: claims.DeviceID,
+    }, nil
+}
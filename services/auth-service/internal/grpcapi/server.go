@@ -0,0 +1,162 @@
+package grpcapi
+
+import (
+    "context"
+    "errors"
+
+    "github.com/google/uuid"
+    "google.golang.org/grpc"
+    "google.golang.org/grpc/codes"
+    "google.golang.org/grpc/status"
+    "google.golang.org/protobuf/types/known/timestamppb"
+    authv1 "github.com/Shridhar2104/chat-platform/auth-service/proto/auth/v1"
+    "github.com/Shridhar2104/chat-platform/auth-service/internal/services"
+    "github.com/Shridhar2104/chat-platform/shared/models"
+)
+
+const maxBatchGetUsers = 500
+
+// AuthBackend is the part of services.AuthService the gRPC API is built on
+type AuthBackend interface {
+    ValidateAccessToken(ctx context.Context, accessToken string) (*services.Claims, *models.User, error)
+    GetUserByID(ctx context.Context, userID uuid.UUID) (*models.User, error)
+    GetUsersByIDs(ctx context.Context, userIDs []uuid.UUID) ([]models.User, error)
+    RevokeSessions(ctx context.Context, userID uuid.UUID, deviceID string) error
+}
+
+type Server struct {
+    authv1.UnimplementedAuthServiceServer
+    backend AuthBackend
+}
+
+func NewServer(backend AuthBackend) *Server {
+    return &Server{backend: backend}
+}
+
+// NewGRPCServer creates a gRPC server with the auth interceptor and the AuthService registered
+func NewGRPCServer(backend AuthBackend, jwtService *services.JWTService, serviceToken string, opts ...grpc.ServerOption) *grpc.Server {
+    opts = append(opts, grpc.ChainUnaryInterceptor(AuthInterceptor(jwtService, serviceToken)))
+    server := grpc.NewServer(opts...)
+    authv1.RegisterAuthServiceServer(server, NewServer(backend))
+    return server
+}
+
+func (s *Server) ValidateToken(ctx context.Context, req *authv1.ValidateTokenRequest) (*authv1.ValidateTokenResponse, error) {
+    if req.GetAccessToken() == "" {
+        return nil, status.Error(codes.InvalidArgument, "access_token is required")
+    }
+
+    claims, _, err := s.backend.ValidateAccessToken(ctx, req.GetAccessToken())
+    if err != nil {
+        if errors.Is(err, services.ErrInvalidToken) || errors.Is(err, services.ErrAccountDisabled) {
+            return nil, status.Error(codes.Unauthenticated, err.Error())
+        }
+        return nil, status.Error(codes.Internal, "failed to validate token")
+    }
+
+    response := &authv1.ValidateTokenResponse{
+        UserId:   claims.UserID.String(),
+        Email:    claims.Email,
+        DeviceId: claims.DeviceID,
+    }
+    if claims.ExpiresAt != nil {
+        response.ExpiresAt = timestamppb.New(claims.ExpiresAt.Time)
+    }
+    return response, nil
+}
+
+// GetUser is open to internal services and to users reading their own profile
+func (s *Server) GetUser(ctx context.Context, req *authv1.GetUserRequest) (*authv1.GetUserResponse, error) {
+    userID, err := uuid.Parse(req.GetUserId())
+    if err != nil {
+        return nil, status.Error(codes.InvalidArgument, "invalid user_id")
+    }
+    if !mayAccessUser(ctx, userID) {
+        return nil, status.Error(codes.PermissionDenied, "cannot read another user's profile")
+    }
+
+    user, err := s.backend.GetUserByID(ctx, userID)
+    if err != nil {
+        if errors.Is(err, services.ErrUserNotFound) {
+            return nil, status.Error(codes.NotFound, "user not found")
+        }
+        return nil, status.Error(codes.Internal, "failed to get user")
+    }
+
+    return &authv1.GetUserResponse{User: toProtoUser(user)}, nil
+}
+
+// BatchGetUsers is open to internal services, and to users only for themselves
+func (s *Server) BatchGetUsers(ctx context.Context, req *authv1.BatchGetUsersRequest) (*authv1.BatchGetUsersResponse, error) {
+    if len(req.GetUserIds()) > maxBatchGetUsers {
+        return nil, status.Errorf(codes.InvalidArgument, "at most %d user_ids per request", maxBatchGetUsers)
+    }
+
+    userIDs := make([]uuid.UUID, 0, len(req.GetUserIds()))
+    for _, id := range req.GetUserIds() {
+        userID, err := uuid.Parse(id)
+        if err != nil {
+            return nil, status.Errorf(codes.InvalidArgument, "invalid user_id %q", id)
+        }
+        if !mayAccessUser(ctx, userID) {
+            return nil, status.Error(codes.PermissionDenied, "cannot read another user's profile")
+        }
+        userIDs = append(userIDs, userID)
+    }
+
+    users, err := s.backend.GetUsersByIDs(ctx, userIDs)
+    if err != nil {
+        return nil, status.Error(codes.Internal, "failed to get users")
+    }
+
+    found := make(map[uuid.UUID]bool, len(users))
+    response := &authv1.BatchGetUsersResponse{}
+    for i := range users {
+        found[users[i].ID] = true
+        response.Users = append(response.Users, toProtoUser(&users[i]))
+    }
+    for _, userID := range userIDs {
+        if !found[userID] {
+            response.MissingUserIds = append(response.MissingUserIds, userID.String())
+        }
+    }
+    return response, nil
+}
+
+// RevokeSession is open to internal services and to users revoking their own sessions
+func (s *Server) RevokeSession(ctx context.Context, req *authv1.RevokeSessionRequest) (*authv1.RevokeSessionResponse, error) {
+    userID, err := uuid.Parse(req.GetUserId())
+    if err != nil {
+        return nil, status.Error(codes.InvalidArgument, "invalid user_id")
+    }
+
+    if !mayAccessUser(ctx, userID) {
+        return nil, status.Error(codes.PermissionDenied, "cannot revoke another user's sessions")
+    }
+
+    if err := s.backend.RevokeSessions(ctx, userID, req.GetDeviceId()); err != nil {
+        return nil, status.Error(codes.Internal, "failed to revoke sessions")
+    }
+    return &authv1.RevokeSessionResponse{}, nil
+}
+
+// mayAccessUser reports whether the caller is an internal service or the user itself
+func mayAccessUser(ctx context.Context, userID uuid.UUID) bool {
+    caller, ok := CallerFromContext(ctx)
+    return ok && (caller.Service || caller.UserID == userID)
+}
+
+func toProtoUser(user *models.User) *authv1.User {
+    protoUser := &authv1.User{
+        Id:            user.ID.String(),
+        Email:         user.Email,
+        DisplayName:   user.DisplayName,
+        EmailVerified: user.EmailVerified,
+        Active:        user.Active,
+        CreatedAt:     timestamppb.New(user.CreatedAt),
+    }
+    if user.AvatarURL != nil {
+        protoUser.AvatarUrl = *user.AvatarURL
+    }
+    return protoUser
+}
@@ -0,0 +1,284 @@
+package grpcapi
+
+import (
+    "context"
+    "crypto/rand"
+    "crypto/rsa"
+    "net"
+    "testing"
+    "time"
+
+    "github.com/google/uuid"
+    "google.golang.org/grpc"
+    "google.golang.org/grpc/codes"
+    "google.golang.org/grpc/credentials/insecure"
+    "google.golang.org/grpc/metadata"
+    "google.golang.org/grpc/status"
+    "google.golang.org/grpc/test/bufconn"
+    authv1 "github.com/Shridhar2104/chat-platform/auth-service/proto/auth/v1"
+    "github.com/Shridhar2104/chat-platform/auth-service/internal/services"
+    "github.com/Shridhar2104/chat-platform/shared/models"
+)
+
+const (
+    testJWTSecret    = "grpc-test-secret"
+    testServiceToken = "internal-service-token"
+)
+
+type fakeBackend struct {
+    jwtService *services.JWTService
+    users      map[uuid.UUID]*models.User
+    revoked    []string
+}
+
+func (b *fakeBackend) ValidateAccessToken(ctx context.Context, accessToken string) (*services.Claims, *models.User, error) {
+    claims, err := b.jwtService.ValidateAccessToken(accessToken)
+    if err != nil {
+        return nil, nil, services.ErrInvalidToken
+    }
+    user, ok := b.users[claims.UserID]
+    if !ok {
+        return nil, nil, services.ErrInvalidToken
+    }
+    if !user.Active {
+        return nil, nil, services.ErrAccountDisabled
+    }
+    return claims, user, nil
+}
+
+func (b *fakeBackend) GetUserByID(ctx context.Context, userID uuid.UUID) (*models.User, error) {
+    user, ok := b.users[userID]
+    if !ok {
+        return nil, services.ErrUserNotFound
+    }
+    return user, nil
+}
+
+func (b *fakeBackend) GetUsersByIDs(ctx context.Context, userIDs []uuid.UUID) ([]models.User, error) {
+    users := []models.User{}
+    for _, userID := range userIDs {
+        if user, ok := b.users[userID]; ok {
+            users = append(users, *user)
+        }
+    }
+    return users, nil
+}
+
+func (b *fakeBackend) RevokeSessions(ctx context.Context, userID uuid.UUID, deviceID string) error {
+    b.revoked = append(b.revoked, userID.String()+"/"+deviceID)
+    return nil
+}
+
+type testEnv struct {
+    client     authv1.AuthServiceClient
+    backend    *fakeBackend
+    jwtService *services.JWTService
+    alice      *models.User
+    bob        *models.User
+}
+
+func newTestKeySet(t *testing.T) *services.KeySet {
+    t.Helper()
+    key, err := rsa.GenerateKey(rand.Reader, 2048)
+    if err != nil {
+        t.Fatalf("failed to generate signing key: %v", err)
+    }
+    return services.NewKeySet(key)
+}
+
+// newTestEnv serves the gRPC API over an in-memory bufconn listener
+func newTestEnv(t *testing.T) *testEnv {
+    t.Helper()
+
+    jwtService := services.NewJWTService(testJWTSecret, newTestKeySet(t), 15*time.Minute, time.Hour)
+    alice := &models.User{ID: uuid.New(), Email: "alice@example.com", DisplayName: "Alice", Active: true, CreatedAt: time.Now()}
+    bob := &models.User{ID: uuid.New(), Email: "bob@example.com", DisplayName: "Bob", Active: false, CreatedAt: time.Now()}
+    backend := &fakeBackend{
+        jwtService: jwtService,
+        users:      map[uuid.UUID]*models.User{alice.ID: alice, bob.ID: bob},
+    }
+
+    listener := bufconn.Listen(1024 * 1024)
+    server := NewGRPCServer(backend, jwtService, testServiceToken)
+    go server.Serve(listener)
+    t.Cleanup(server.Stop)
+
+    conn, err := grpc.NewClient("passthrough:///bufnet",
+        grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+            return listener.DialContext(ctx)
+        }),
+        grpc.WithTransportCredentials(insecure.NewCredentials()),
+    )
+    if err != nil {
+        t.Fatalf("failed to dial bufconn: %v", err)
+    }
+    t.Cleanup(func() { conn.Close() })
+
+    return &testEnv{
+        client:     authv1.NewAuthServiceClient(conn),
+        backend:    backend,
+        jwtService: jwtService,
+        alice:      alice,
+        bob:        bob,
+    }
+}
+
+func (e *testEnv) accessToken(t *testing.T, user *models.User, deviceID string) string {
+    t.Helper()
+    token, _, _, err := e.jwtService.GenerateTokenPair(user.ID, user.Email, deviceID, nil)
+    if err != nil {
+        t.Fatalf("failed to generate token: %v", err)
+    }
+    return token
+}
+
+func withBearer(token string) context.Context {
+    return metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+token)
+}
+
+func TestAuthInterceptorRejectsMissingAndInvalidCredentials(t *testing.T) {
+    env := newTestEnv(t)
+
+    tests := []struct {
+        name string
+        ctx  context.Context
+    }{
+        {name: "no metadata", ctx: context.Background()},
+        {name: "wrong scheme", ctx: metadata.AppendToOutgoingContext(context.Background(), "authorization", "Basic abc")},
+        {name: "invalid token", ctx: withBearer("not-a-jwt")},
+        {name: "token signed with another key", ctx: withBearer(func() string {
+            token, _, _, _ := services.NewJWTService("other-secret", newTestKeySet(t), time.Minute, time.Minute).GenerateTokenPair(env.alice.ID, env.alice.Email, "d1", nil)
+            return token
+        }())},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            _, err := env.client.GetUser(tt.ctx, &authv1.GetUserRequest{UserId: env.alice.ID.String()})
+            if status.Code(err) != codes.Unauthenticated {
+                t.Errorf("got %v, want Unauthenticated", err)
+            }
+        })
+    }
+}
+
+func TestValidateToken(t *testing.T) {
+    env := newTestEnv(t)
+    ctx := withBearer(testServiceToken)
+
+    response, err := env.client.ValidateToken(ctx, &authv1.ValidateTokenRequest{AccessToken: env.accessToken(t, env.alice, "phone")})
+    if err != nil {
+        t.Fatalf("ValidateToken returned error: %v", err)
+    }
+    if response.UserId != env.alice.ID.String() || response.Email != env.alice.Email || response.DeviceId != "phone" {
+        t.Errorf("unexpected claims %+v", response)
+    }
+    if response.ExpiresAt == nil || !response.ExpiresAt.AsTime().After(time.Now()) {
+        t.Errorf("expires_at = %v, want a future time", response.ExpiresAt)
+    }
+
+    _, err = env.client.ValidateToken(ctx, &authv1.ValidateTokenRequest{AccessToken: env.accessToken(t, env.bob, "phone")})
+    if status.Code(err) != codes.Unauthenticated {
+        t.Errorf("disabled account: got %v, want Unauthenticated", err)
+    }
+
+    _, err = env.client.ValidateToken(ctx, &authv1.ValidateTokenRequest{})
+    if status.Code(err) != codes.InvalidArgument {
+        t.Errorf("empty token: got %v, want InvalidArgument", err)
+    }
+}
+
+func TestGetUserAndBatchGetUsers(t *testing.T) {
+    env := newTestEnv(t)
+    ctx := withBearer(testServiceToken)
+
+    response, err := env.client.GetUser(ctx, &authv1.GetUserRequest{UserId: env.bob.ID.String()})
+    if err != nil {
+        t.Fatalf("GetUser returned error: %v", err)
+    }
+    if response.User.Email != env.bob.Email || response.User.Active {
+        t.Errorf("unexpected user %+v", response.User)
+    }
+
+    _, err = env.client.GetUser(ctx, &authv1.GetUserRequest{UserId: uuid.NewString()})
+    if status.Code(err) != codes.NotFound {
+        t.Errorf("unknown user: got %v, want NotFound", err)
+    }
+    _, err = env.client.GetUser(ctx, &authv1.GetUserRequest{UserId: "nope"})
+    if status.Code(err) != codes.InvalidArgument {
+        t.Errorf("bad id: got %v, want InvalidArgument", err)
+    }
+
+    missing := uuid.NewString()
+    batch, err := env.client.BatchGetUsers(ctx, &authv1.BatchGetUsersRequest{
+        UserIds: []string{env.alice.ID.String(), missing, env.bob.ID.String()},
+    })
+    if err != nil {
+        t.Fatalf("BatchGetUsers returned error: %v", err)
+    }
+    if len(batch.Users) != 2 {
+        t.Errorf("got %d users, want 2", len(batch.Users))
+    }
+    if len(batch.MissingUserIds) != 1 || batch.MissingUserIds[0] != missing {
+        t.Errorf("missing_user_ids = %v, want [%s]", batch.MissingUserIds, missing)
+    }
+
+    tooMany := make([]string, maxBatchGetUsers+1)
+    for i := range tooMany {
+        tooMany[i] = uuid.NewString()
+    }
+    _, err = env.client.BatchGetUsers(ctx, &authv1.BatchGetUsersRequest{UserIds: tooMany})
+    if status.Code(err) != codes.InvalidArgument {
+        t.Errorf("oversized batch: got %v, want InvalidArgument", err)
+    }
+}
+
+func TestUserTokensOnlyReadOwnProfile(t *testing.T) {
+    env := newTestEnv(t)
+    aliceCtx := withBearer(env.accessToken(t, env.alice, "laptop"))
+
+    response, err := env.client.GetUser(aliceCtx, &authv1.GetUserRequest{UserId: env.alice.ID.String()})
+    if err != nil || response.User.Email != env.alice.Email {
+        t.Fatalf("user reading own profile: %v, %v", response, err)
+    }
+    if _, err := env.client.BatchGetUsers(aliceCtx, &authv1.BatchGetUsersRequest{UserIds: []string{env.alice.ID.String()}}); err != nil {
+        t.Fatalf("user batch-reading own profile: %v", err)
+    }
+
+    _, err = env.client.GetUser(aliceCtx, &authv1.GetUserRequest{UserId: env.bob.ID.String()})
+    if status.Code(err) != codes.PermissionDenied {
+        t.Errorf("user reading another profile: got %v, want PermissionDenied", err)
+    }
+    // Unknown IDs are refused too, so a user cannot probe which accounts exist
+    _, err = env.client.GetUser(aliceCtx, &authv1.GetUserRequest{UserId: uuid.NewString()})
+    if status.Code(err) != codes.PermissionDenied {
+        t.Errorf("user reading an unknown profile: got %v, want PermissionDenied", err)
+    }
+    _, err = env.client.BatchGetUsers(aliceCtx, &authv1.BatchGetUsersRequest{UserIds: []string{env.alice.ID.String(), env.bob.ID.String()}})
+    if status.Code(err) != codes.PermissionDenied {
+        t.Errorf("user batch-reading other profiles: got %v, want PermissionDenied", err)
+    }
+}
+
+func TestRevokeSessionAuthorization(t *testing.T) {
+    env := newTestEnv(t)
+    aliceCtx := withBearer(env.accessToken(t, env.alice, "laptop"))
+
+    if _, err := env.client.RevokeSession(aliceCtx, &authv1.RevokeSessionRequest{UserId: env.alice.ID.String(), DeviceId: "phone"}); err != nil {
+        t.Fatalf("user revoking own session: %v", err)
+    }
+
+    _, err := env.client.RevokeSession(aliceCtx, &authv1.RevokeSessionRequest{UserId: env.bob.ID.String()})
+    if status.Code(err) != codes.PermissionDenied {
+        t.Errorf("user revoking another user's sessions: got %v, want PermissionDenied", err)
+    }
+
+    if _, err := env.client.RevokeSession(withBearer(testServiceToken), &authv1.RevokeSessionRequest{UserId: env.bob.ID.String()}); err != nil {
+        t.Fatalf("service revoking sessions: %v", err)
+    }
+
+    want := []string{env.alice.ID.String() + "/phone", env.bob.ID.String() + "/"}
+    if len(env.backend.revoked) != len(want) || env.backend.revoked[0] != want[0] || env.backend.revoked[1] != want[1] {
+        t.Errorf("revoked = %v, want %v", env.backend.revoked, want)
+    }
+}
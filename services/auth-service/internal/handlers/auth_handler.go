@@ -1,11 +1,15 @@
 package handlers
 
 import (
+    "errors"
     "net/http"
     "time"
 
     "github.com/gin-gonic/gin"
     "github.com/google/uuid"
+    "github.com/Shridhar2104/chat-platform/auth-service/internal/clientip"
+    "github.com/Shridhar2104/chat-platform/auth-service/internal/apierrors"
+    "github.com/Shridhar2104/chat-platform/auth-service/internal/metrics"
     "github.com/Shridhar2104/chat-platform/auth-service/internal/models"
     "github.com/Shridhar2104/chat-platform/auth-service/internal/services"
 )
@@ -21,25 +25,17 @@ func NewAuthHandler(authService *services.AuthService) *AuthHandler {
 func (h *AuthHandler) Register(c *gin.Context) {
     var req models.RegisterRequest
     if err := c.ShouldBindJSON(&req); err != nil {
-        c.JSON(http.StatusBadRequest, models.ErrorResponse{
-            Error:   "validation_error",
-            Message: err.Error(),
-        })
+        apierrors.Validation(c, err.Error())
         return
     }
 
-    user, accessToken, refreshToken, expiresAt, err := h.authService.Register(req.Email, req.Password, req.DisplayName)
+    user, accessToken, refreshToken, expiresAt, err := h.authService.Register(c.Request.Context(), req.Email, req.Password, req.DisplayName)
     if err != nil {
-        status := http.StatusInternalServerError
-        if err.Error() == "email already registered" {
-            status = http.StatusConflict
-        }
-        c.JSON(status, models.ErrorResponse{
-            Error:   "registration_failed",
-            Message: err.Error(),
-        })
+        apierrors.Respond(c, err)
         return
     }
+    c.Set("user_id", user.ID.String())
+    metrics.TokensIssued("register")
 
     response := models.AuthResponse{
         User: models.UserResponse{
@@ -61,21 +57,66 @@ func (h *AuthHandler) Register(c *gin.Context) {
 func (h *AuthHandler) Login(c *gin.Context) {
     var req models.LoginRequest
     if err := c.ShouldBindJSON(&req); err != nil {
-        c.JSON(http.StatusBadRequest, models.ErrorResponse{
-            Error:   "validation_error",
-            Message: err.Error(),
-        })
+        apierrors.Validation(c, err.Error())
+        return
+    }
+
+    // Recorded on the access log line whatever the outcome
+    c.Set("device_id", req.DeviceID)
+
+    user, accessToken, refreshToken, expiresAt, err := h.authService.Login(c.Request.Context(), req.Email, req.Password, req.DeviceID, clientip.FromContext(c))
+    if err != nil {
+        var stepUp *services.StepUpRequiredError
+        if errors.As(err, &stepUp) {
+            metrics.LoginSteppedUp()
+            c.JSON(http.StatusAccepted, models.StepUpResponse{
+                Error:       "step_up_required",
+                Message:     "Confirm this sign-in with the code sent to your email",
+                ChallengeID: stepUp.ChallengeID,
+                Method:      stepUp.Method,
+                ExpiresAt:   stepUp.ExpiresAt.Unix(),
+            })
+            return
+        }
+        metrics.LoginFailed(apierrors.Code(c, err))
+        apierrors.Respond(c, err)
+        return
+    }
+    metrics.LoginSucceeded()
+    metrics.TokensIssued("password")
+    c.Set("user_id", user.ID.String())
+
+    response := models.AuthResponse{
+        User: models.UserResponse{
+            ID:            user.ID,
+            Email:         user.Email,
+            DisplayName:   user.DisplayName,
+            AvatarURL:     user.AvatarURL,
+            EmailVerified: user.EmailVerified,
+            CreatedAt:     user.CreatedAt.Format(time.RFC3339),
+        },
+        AccessToken:  accessToken,
+        RefreshToken: refreshToken,
+        ExpiresAt:    expiresAt.Unix(),
+    }
+
+    c.JSON(http.StatusOK, response)
+}
+
+func (h *AuthHandler) VerifyLogin(c *gin.Context) {
+    var req models.VerifyLoginRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        apierrors.Validation(c, err.Error())
         return
     }
 
-    user, accessToken, refreshToken, expiresAt, err := h.authService.Login(req.Email, req.Password, req.DeviceID)
+    user, accessToken, refreshToken, expiresAt, err := h.authService.VerifyLoginChallenge(c.Request.Context(), req.ChallengeID, req.Code)
     if err != nil {
-        c.JSON(http.StatusUnauthorized, models.ErrorResponse{
-            Error:   "login_failed",
-            Message: "Invalid credentials",
-        })
+        apierrors.Respond(c, err)
         return
     }
+    c.Set("user_id", user.ID.String())
+    metrics.TokensIssued("step_up")
 
     response := models.AuthResponse{
         User: models.UserResponse{
@@ -97,21 +138,18 @@ func (h *AuthHandler) Login(c *gin.Context) {
 func (h *AuthHandler) RefreshToken(c *gin.Context) {
     var req models.RefreshTokenRequest
     if err := c.ShouldBindJSON(&req); err != nil {
-        c.JSON(http.StatusBadRequest, models.ErrorResponse{
-            Error:   "validation_error",
-            Message: err.Error(),
-        })
+        apierrors.Validation(c, err.Error())
         return
     }
 
-    accessToken, refreshToken, expiresAt, err := h.authService.RefreshToken(req.RefreshToken, req.DeviceID)
+    c.Set("device_id", req.DeviceID)
+
+    accessToken, refreshToken, expiresAt, err := h.authService.RefreshToken(c.Request.Context(), req.RefreshToken, req.DeviceID)
     if err != nil {
-        c.JSON(http.StatusUnauthorized, models.ErrorResponse{
-            Error:   "refresh_failed",
-            Message: "Invalid or expired refresh token",
-        })
+        apierrors.Respond(c, err)
         return
     }
+    metrics.TokensIssued("refresh")
 
     response := gin.H{
         "access_token":  accessToken,
@@ -127,28 +165,19 @@ func (h *AuthHandler) Logout(c *gin.Context) {
     deviceID := c.Query("device_id")
 
     if deviceID == "" {
-        c.JSON(http.StatusBadRequest, models.ErrorResponse{
-            Error:   "validation_error",
-            Message: "device_id is required",
-        })
+        apierrors.Validation(c, "device_id is required")
         return
     }
 
     userUUID, err := uuid.Parse(userID)
     if err != nil {
-        c.JSON(http.StatusBadRequest, models.ErrorResponse{
-            Error:   "invalid_user_id",
-            Message: "Invalid user ID format",
-        })
+        apierrors.Write(c, http.StatusBadRequest, "invalid_user_id", "Invalid user ID format")
         return
     }
 
-    err = h.authService.Logout(userUUID, deviceID)
+    err = h.authService.Logout(c.Request.Context(), userUUID, deviceID)
     if err != nil {
-        c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-            Error:   "logout_failed",
-            Message: err.Error(),
-        })
+        apierrors.Respond(c, err)
         return
     }
 
@@ -161,19 +190,13 @@ func (h *AuthHandler) GetCurrentUser(c *gin.Context) {
     userID := c.GetString("user_id")
     userUUID, err := uuid.Parse(userID)
     if err != nil {
-        c.JSON(http.StatusBadRequest, models.ErrorResponse{
-            Error:   "invalid_user_id",
-            Message: "Invalid user ID format",
-        })
+        apierrors.Write(c, http.StatusBadRequest, "invalid_user_id", "Invalid user ID format")
         return
     }
 
-    user, err := h.authService.GetUserByID(userUUID)
+    user, err := h.authService.GetUserByID(c.Request.Context(), userUUID)
     if err != nil {
-        c.JSON(http.StatusNotFound, models.ErrorResponse{
-            Error:   "user_not_found",
-            Message: "User not found",
-        })
+        apierrors.Respond(c, err)
         return
     }
 
@@ -192,33 +215,20 @@ func (h *AuthHandler) GetCurrentUser(c *gin.Context) {
 func (h *AuthHandler) ChangePassword(c *gin.Context) {
     var req models.ChangePasswordRequest
     if err := c.ShouldBindJSON(&req); err != nil {
-        c.JSON(http.StatusBadRequest, models.ErrorResponse{
-            Error:   "validation_error",
-            Message: err.Error(),
-        })
+        apierrors.Validation(c, err.Error())
         return
     }
 
     userID := c.GetString("user_id")
     userUUID, err := uuid.Parse(userID)
     if err != nil {
-        c.JSON(http.StatusBadRequest, models.ErrorResponse{
-            Error:   "invalid_user_id",
-            Message: "Invalid user ID format",
-        })
+        apierrors.Write(c, http.StatusBadRequest, "invalid_user_id", "Invalid user ID format")
         return
     }
 
-    err = h.authService.ChangePassword(userUUID, req.CurrentPassword, req.NewPassword)
+    err = h.authService.ChangePassword(c.Request.Context(), userUUID, req.CurrentPassword, req.NewPassword)
     if err != nil {
-        status := http.StatusInternalServerError
-        if err.Error() == "current password is incorrect" {
-            status = http.StatusUnauthorized
-        }
-        c.JSON(status, models.ErrorResponse{
-            Error:   "password_change_failed",
-            Message: err.Error(),
-        })
+        apierrors.Respond(c, err)
         return
     }
 
@@ -230,10 +240,7 @@ func (h *AuthHandler) ChangePassword(c *gin.Context) {
 func (h *AuthHandler) ForgotPassword(c *gin.Context) {
     var req models.ForgotPasswordRequest
     if err := c.ShouldBindJSON(&req); err != nil {
-        c.JSON(http.StatusBadRequest, models.ErrorResponse{
-            Error:   "validation_error",
-            Message: err.Error(),
-        })
+        apierrors.Validation(c, err.Error())
         return
     }
 
@@ -247,10 +254,7 @@ func (h *AuthHandler) ForgotPassword(c *gin.Context) {
 func (h *AuthHandler) ResetPassword(c *gin.Context) {
     var req models.ResetPasswordRequest
     if err := c.ShouldBindJSON(&req); err != nil {
-        c.JSON(http.StatusBadRequest, models.ErrorResponse{
-            Error:   "validation_error",
-            Message: err.Error(),
-        })
+        apierrors.Validation(c, err.Error())
         return
     }
 
@@ -258,4 +262,33 @@ func (h *AuthHandler) ResetPassword(c *gin.Context) {
     c.JSON(http.StatusOK, models.SuccessResponse{
         Message: "Password reset successfully",
     })
+}
+
+// Introspect reports whether an access token is active (RFC 7662) for services
+// that need to catch revoked accounts before the token expires
+func (h *AuthHandler) Introspect(c *gin.Context) {
+    token := c.PostForm("token")
+    if token == "" {
+        apierrors.Write(c, http.StatusBadRequest, "invalid_request", "token is required")
+        return
+    }
+
+    claims, user, err := h.authService.ValidateAccessToken(c.Request.Context(), token)
+    if err != nil {
+        c.JSON(http.StatusOK, models.IntrospectionResponse{Active: false})
+        return
+    }
+
+    c.JSON(http.StatusOK, models.IntrospectionResponse{
+        Active:    true,
+        Subject:   user.ID.String(),
+        Email:     user.Email,
+        DeviceID:  claims.DeviceID,
+        Scope:     claims.Scope,
+        Roles:     claims.Roles,
+        TokenType: "Bearer",
+        ExpiresAt: claims.ExpiresAt.Unix(),
+        IssuedAt:  claims.IssuedAt.Unix(),
+        Issuer:    claims.Issuer,
+    })
 }
\ No newline at end of file
@@ -0,0 +1,108 @@
+package handlers
+
+import (
+    "net/http"
+    "time"
+
+    "github.com/gin-gonic/gin"
+    "github.com/Shridhar2104/chat-platform/auth-service/internal/apierrors"
+    "github.com/Shridhar2104/chat-platform/auth-service/internal/models"
+    "github.com/Shridhar2104/chat-platform/auth-service/internal/services"
+    sharedmodels "github.com/Shridhar2104/chat-platform/shared/models"
+)
+
+type DomainHandler struct {
+    domainService *services.DomainPolicyService
+}
+
+func NewDomainHandler(domainService *services.DomainPolicyService) *DomainHandler {
+    return &DomainHandler{domainService: domainService}
+}
+
+func (h *DomainHandler) ListDomains(c *gin.Context) {
+    policies, err := h.domainService.ListPolicies(c.Request.Context(), tokenWorkspaceID(c))
+    if err != nil {
+        apierrors.Respond(c, err)
+        return
+    }
+
+    response := make([]models.DomainPolicyResponse, 0, len(policies))
+    for i := range policies {
+        response = append(response, h.toResponse(&policies[i]))
+    }
+    c.JSON(http.StatusOK, response)
+}
+
+// ClaimDomain starts a domain claim and returns the TXT record to publish
+func (h *DomainHandler) ClaimDomain(c *gin.Context) {
+    var req models.ClaimDomainRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        apierrors.Validation(c, err.Error())
+        return
+    }
+
+    allowSignup := req.AllowSignup == nil || *req.AllowSignup
+    policy, err := h.domainService.ClaimDomain(c.Request.Context(), tokenWorkspaceID(c), req.Domain, allowSignup, req.EnforceSSO, req.AutoJoin)
+    if err != nil {
+        apierrors.Respond(c, err)
+        return
+    }
+
+    c.JSON(http.StatusCreated, h.toResponse(policy))
+}
+
+// VerifyDomain checks the domain's TXT record and activates its policy
+func (h *DomainHandler) VerifyDomain(c *gin.Context) {
+    policy, err := h.domainService.VerifyDomain(c.Request.Context(), tokenWorkspaceID(c), c.Param("domain"))
+    if err != nil {
+        apierrors.Respond(c, err)
+        return
+    }
+
+    c.JSON(http.StatusOK, h.toResponse(policy))
+}
+
+func (h *DomainHandler) UpdateDomain(c *gin.Context) {
+    var req models.UpdateDomainPolicyRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        apierrors.Validation(c, err.Error())
+        return
+    }
+
+    policy, err := h.domainService.UpdatePolicy(c.Request.Context(), tokenWorkspaceID(c), c.Param("domain"), req.AllowSignup, req.EnforceSSO, req.AutoJoin)
+    if err != nil {
+        apierrors.Respond(c, err)
+        return
+    }
+
+    c.JSON(http.StatusOK, h.toResponse(policy))
+}
+
+func (h *DomainHandler) DeleteDomain(c *gin.Context) {
+    if err := h.domainService.DeletePolicy(c.Request.Context(), tokenWorkspaceID(c), c.Param("domain")); err != nil {
+        apierrors.Respond(c, err)
+        return
+    }
+
+    c.JSON(http.StatusOK, models.SuccessResponse{
+        Message: "Domain policy deleted",
+    })
+}
+
+func (h *DomainHandler) toResponse(policy *sharedmodels.DomainPolicy) models.DomainPolicyResponse {
+    verification := h.domainService.Verification(policy)
+    response := models.DomainPolicyResponse{
+        Domain:             policy.Domain,
+        Verified:           policy.Verified,
+        AllowSignup:        policy.AllowSignup,
+        EnforceSSO:         policy.EnforceSSO,
+        AutoJoin:           policy.AutoJoin,
+        VerificationRecord: verification.RecordName,
+        VerificationValue:  verification.RecordValue,
+    }
+    if policy.VerifiedAt != nil {
+        verifiedAt := policy.VerifiedAt.Format(time.RFC3339)
+        response.VerifiedAt = &verifiedAt
+    }
+    return response
+}
\ No newline at end of file
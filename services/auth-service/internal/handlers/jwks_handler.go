@@ -0,0 +1,23 @@
+package handlers
+
+import (
+    "net/http"
+
+    "github.com/gin-gonic/gin"
+    "github.com/Shridhar2104/chat-platform/auth-service/internal/services"
+)
+
+// JWKSHandler publishes the public keys access tokens are verified with
+type JWKSHandler struct {
+    keys *services.KeySet
+}
+
+func NewJWKSHandler(keys *services.KeySet) *JWKSHandler {
+    return &JWKSHandler{keys: keys}
+}
+
+func (h *JWKSHandler) JWKS(c *gin.Context) {
+    // Verifiers refetch on unknown key IDs, so a short cache is enough to follow rotations
+    c.Header("Cache-Control", "public, max-age=300")
+    c.JSON(http.StatusOK, h.keys.JWKS())
+}
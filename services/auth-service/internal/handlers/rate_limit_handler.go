@@ -0,0 +1,264 @@
+package handlers
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "net/http"
+    "sort"
+    "time"
+
+    "github.com/gin-gonic/gin"
+    "github.com/google/uuid"
+    "github.com/Shridhar2104/chat-platform/auth-service/internal/apierrors"
+    "github.com/Shridhar2104/chat-platform/auth-service/internal/middleware"
+    "github.com/Shridhar2104/chat-platform/auth-service/internal/models"
+)
+
+// maxOverrideTTL keeps overrides temporary, a lasting change belongs in config
+const maxOverrideTTL = 7 * 24 * time.Hour
+
+// PlanStore sets the subscription plan of a workspace
+type PlanStore interface {
+    SetWorkspacePlan(ctx context.Context, workspaceID uuid.UUID, plan string) error
+}
+
+// RateLimitHandler lets operators inspect and reset the global limiter's
+// buckets, override its limit per client IP, manage the allow and deny lists
+// and set the plans that pick each workspace's tier
+type RateLimitHandler struct {
+    buckets  middleware.BucketAdmin
+    controls *middleware.RateLimitControls
+    plans    PlanStore
+}
+
+func NewRateLimitHandler(buckets middleware.BucketAdmin, controls *middleware.RateLimitControls, plans PlanStore) *RateLimitHandler {
+    return &RateLimitHandler{buckets: buckets, controls: controls, plans: plans}
+}
+
+// GetBucket returns the identifier's bucket and the controls applying to it
+func (h *RateLimitHandler) GetBucket(c *gin.Context) {
+    identifier, ok := h.bucketIdentifier(c)
+    if !ok {
+        return
+    }
+
+    state, err := h.buckets.GetBucketState(c.Request.Context(), identifier)
+    if err != nil {
+        apierrors.Respond(c, err)
+        return
+    }
+
+    lists := h.controls.Lists()
+    _, allowlisted := lists[middleware.ListAllow][identifier]
+    _, denylisted := lists[middleware.ListDeny][identifier]
+    response := models.RateLimitStateResponse{
+        Identifier:  identifier,
+        Tokens:      state.Tokens,
+        Capacity:    state.Capacity,
+        RefillRate:  state.RefillRate,
+        LastRefill:  state.LastRefill.UTC().Format(time.RFC3339),
+        Allowlisted: allowlisted,
+        Denylisted:  denylisted,
+    }
+    if override, ok := h.controls.GetOverride(identifier); ok {
+        response.Override = toOverrideResponse(override)
+    }
+    c.JSON(http.StatusOK, response)
+}
+
+// ResetBucket refills the identifier's bucket
+func (h *RateLimitHandler) ResetBucket(c *gin.Context) {
+    identifier, ok := h.bucketIdentifier(c)
+    if !ok {
+        return
+    }
+
+    if err := h.buckets.Reset(c.Request.Context(), identifier); err != nil {
+        apierrors.Respond(c, err)
+        return
+    }
+
+    c.JSON(http.StatusOK, models.SuccessResponse{
+        Message: "Rate limit bucket reset",
+    })
+}
+
+// SetOverride replaces the identifier's global limit for a while
+func (h *RateLimitHandler) SetOverride(c *gin.Context) {
+    identifier, ok := h.bucketIdentifier(c)
+    if !ok {
+        return
+    }
+
+    var req models.RateLimitOverrideRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        apierrors.Validation(c, err.Error())
+        return
+    }
+    ttl, err := time.ParseDuration(req.TTL)
+    if err != nil || ttl <= 0 || ttl > maxOverrideTTL {
+        apierrors.Validation(c, fmt.Sprintf("ttl must be a positive duration of at most %s", maxOverrideTTL))
+        return
+    }
+
+    override := middleware.Override{
+        RequestsPerMinute: req.RequestsPerMinute,
+        Burst:             req.Burst,
+        ExpiresAt:         time.Now().Add(ttl),
+    }
+    if err := h.controls.SetOverride(c.Request.Context(), identifier, override); err != nil {
+        h.respond(c, err)
+        return
+    }
+
+    c.JSON(http.StatusOK, toOverrideResponse(override))
+}
+
+// DeleteOverride restores the identifier's global limit
+func (h *RateLimitHandler) DeleteOverride(c *gin.Context) {
+    identifier, ok := h.identifier(c)
+    if !ok {
+        return
+    }
+
+    if err := h.controls.RemoveOverride(c.Request.Context(), identifier); err != nil {
+        apierrors.Respond(c, err)
+        return
+    }
+
+    c.JSON(http.StatusOK, models.SuccessResponse{
+        Message: "Rate limit override removed",
+    })
+}
+
+func (h *RateLimitHandler) GetLists(c *gin.Context) {
+    lists := h.controls.Lists()
+    c.JSON(http.StatusOK, models.RateLimitListsResponse{
+        Allow: toListResponse(lists[middleware.ListAllow]),
+        Deny:  toListResponse(lists[middleware.ListDeny]),
+    })
+}
+
+// AddToList puts the identifier on the allow or deny list, for ttl if given
+func (h *RateLimitHandler) AddToList(c *gin.Context) {
+    identifier, ok := h.identifier(c)
+    if !ok {
+        return
+    }
+
+    var req models.RateLimitListRequest
+    if c.Request.ContentLength != 0 {
+        if err := c.ShouldBindJSON(&req); err != nil {
+            apierrors.Validation(c, err.Error())
+            return
+        }
+    }
+    var ttl time.Duration
+    if req.TTL != "" {
+        var err error
+        if ttl, err = time.ParseDuration(req.TTL); err != nil || ttl <= 0 {
+            apierrors.Validation(c, "ttl must be a positive duration")
+            return
+        }
+    }
+
+    if err := h.controls.SetList(c.Request.Context(), c.Param("list"), identifier, ttl); err != nil {
+        h.respond(c, err)
+        return
+    }
+
+    c.JSON(http.StatusOK, models.SuccessResponse{
+        Message: fmt.Sprintf("Added to the %s list", c.Param("list")),
+    })
+}
+
+func (h *RateLimitHandler) RemoveFromList(c *gin.Context) {
+    identifier, ok := h.identifier(c)
+    if !ok {
+        return
+    }
+
+    if err := h.controls.RemoveList(c.Request.Context(), c.Param("list"), identifier); err != nil {
+        h.respond(c, err)
+        return
+    }
+
+    c.JSON(http.StatusOK, models.SuccessResponse{
+        Message: fmt.Sprintf("Removed from the %s list", c.Param("list")),
+    })
+}
+
+// SetPlan sets the workspace's plan, tokens pick it up when next issued or refreshed
+func (h *RateLimitHandler) SetPlan(c *gin.Context) {
+    workspaceID, err := uuid.Parse(c.Param("workspace_id"))
+    if err != nil {
+        apierrors.Validation(c, "workspace_id must be a UUID")
+        return
+    }
+
+    var req models.RateLimitPlanRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        apierrors.Validation(c, err.Error())
+        return
+    }
+
+    if err := h.plans.SetWorkspacePlan(c.Request.Context(), workspaceID, req.Plan); err != nil {
+        apierrors.Respond(c, err)
+        return
+    }
+
+    c.JSON(http.StatusOK, models.SuccessResponse{
+        Message: fmt.Sprintf("Workspace plan set to %s", req.Plan),
+    })
+}
+
+// identifier validates the identifier path parameter, answering when it is malformed
+func (h *RateLimitHandler) identifier(c *gin.Context) (string, bool) {
+    identifier := c.Param("identifier")
+    if !middleware.ValidIdentifier(identifier) {
+        apierrors.Validation(c, middleware.ErrInvalidIdentifier.Error())
+        return "", false
+    }
+    return identifier, true
+}
+
+// bucketIdentifier validates an identifier the global limiter keys on
+func (h *RateLimitHandler) bucketIdentifier(c *gin.Context) (string, bool) {
+    identifier := c.Param("identifier")
+    if !middleware.ValidBucketIdentifier(identifier) {
+        apierrors.Validation(c, middleware.ErrInvalidBucketIdentifier.Error())
+        return "", false
+    }
+    return identifier, true
+}
+
+func (h *RateLimitHandler) respond(c *gin.Context, err error) {
+    if errors.Is(err, middleware.ErrUnknownList) || errors.Is(err, middleware.ErrInvalidIdentifier) || errors.Is(err, middleware.ErrInvalidBucketIdentifier) {
+        apierrors.Validation(c, err.Error())
+        return
+    }
+    apierrors.Respond(c, err)
+}
+
+func toOverrideResponse(override middleware.Override) *models.RateLimitOverrideResponse {
+    return &models.RateLimitOverrideResponse{
+        RequestsPerMinute: override.RequestsPerMinute,
+        Burst:             override.Burst,
+        ExpiresAt:         override.ExpiresAt.UTC().Format(time.RFC3339),
+    }
+}
+
+func toListResponse(entries map[string]middleware.ListEntry) []models.RateLimitListEntryResponse {
+    response := make([]models.RateLimitListEntryResponse, 0, len(entries))
+    for identifier, entry := range entries {
+        item := models.RateLimitListEntryResponse{Identifier: identifier}
+        if !entry.ExpiresAt.IsZero() {
+            expiresAt := entry.ExpiresAt.UTC().Format(time.RFC3339)
+            item.ExpiresAt = &expiresAt
+        }
+        response = append(response, item)
+    }
+    sort.Slice(response, func(i, j int) bool { return response[i].Identifier < response[j].Identifier })
+    return response
+}
\ No newline at end of file
@@ -0,0 +1,162 @@
+package handlers
+
+import (
+    "errors"
+    "net/http"
+    "time"
+
+    "github.com/gin-gonic/gin"
+    "github.com/google/uuid"
+    "github.com/Shridhar2104/chat-platform/auth-service/internal/apierrors"
+    "github.com/Shridhar2104/chat-platform/auth-service/internal/metrics"
+    "github.com/Shridhar2104/chat-platform/auth-service/internal/models"
+    "github.com/Shridhar2104/chat-platform/auth-service/internal/services"
+    sharedmodels "github.com/Shridhar2104/chat-platform/shared/models"
+)
+
+type SAMLHandler struct {
+    samlService *services.SAMLService
+}
+
+func NewSAMLHandler(samlService *services.SAMLService) *SAMLHandler {
+    return &SAMLHandler{samlService: samlService}
+}
+
+// Metadata serves the SP metadata document for a workspace
+func (h *SAMLHandler) Metadata(c *gin.Context) {
+    workspaceID, ok := parseWorkspaceID(c)
+    if !ok {
+        return
+    }
+
+    metadata, err := h.samlService.Metadata(workspaceID)
+    if err != nil {
+        apierrors.Respond(c, err)
+        return
+    }
+
+    c.Data(http.StatusOK, "application/samlmetadata+xml", metadata)
+}
+
+// Login redirects the browser to the workspace IdP with a new AuthnRequest
+func (h *SAMLHandler) Login(c *gin.Context) {
+    workspaceID, ok := parseWorkspaceID(c)
+    if !ok {
+        return
+    }
+
+    deviceID := c.Query("device_id")
+    if deviceID == "" {
+        apierrors.Validation(c, "device_id is required")
+        return
+    }
+
+    redirectURL, err := h.samlService.CreateAuthnRequest(c.Request.Context(), workspaceID, deviceID)
+    if err != nil {
+        apierrors.Respond(c, err)
+        return
+    }
+
+    c.Redirect(http.StatusFound, redirectURL)
+}
+
+// AssertionConsumerService receives the IdP response via the HTTP-POST binding
+func (h *SAMLHandler) AssertionConsumerService(c *gin.Context) {
+    workspaceID, ok := parseWorkspaceID(c)
+    if !ok {
+        return
+    }
+
+    samlResponse := c.PostForm("SAMLResponse")
+    if samlResponse == "" {
+        apierrors.Validation(c, "SAMLResponse is required")
+        return
+    }
+
+    user, accessToken, refreshToken, expiresAt, err := h.samlService.HandleResponse(c.Request.Context(), workspaceID, samlResponse)
+    if err != nil {
+        apierrors.Respond(c, err)
+        return
+    }
+    metrics.TokensIssued("sso")
+
+    response := models.AuthResponse{
+        User: models.UserResponse{
+            ID:            user.ID,
+            Email:         user.Email,
+            DisplayName:   user.DisplayName,
+            AvatarURL:     user.AvatarURL,
+            EmailVerified: user.EmailVerified,
+            CreatedAt:     user.CreatedAt.Format(time.RFC3339),
+        },
+        AccessToken:  accessToken,
+        RefreshToken: refreshToken,
+        ExpiresAt:    expiresAt.Unix(),
+    }
+
+    c.JSON(http.StatusOK, response)
+}
+
+// GetIdentityProvider returns the IdP of the provisioning token's workspace
+func (h *SAMLHandler) GetIdentityProvider(c *gin.Context) {
+    idp, err := h.samlService.IdentityProvider(c.Request.Context(), tokenWorkspaceID(c))
+    if err != nil {
+        apierrors.Respond(c, err)
+        return
+    }
+
+    c.JSON(http.StatusOK, h.toResponse(idp))
+}
+
+// ConfigureIdentityProvider registers or replaces the workspace's IdP
+func (h *SAMLHandler) ConfigureIdentityProvider(c *gin.Context) {
+    var req models.SAMLIdentityProviderRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        apierrors.Validation(c, err.Error())
+        return
+    }
+
+    idp, err := h.samlService.ConfigureIdentityProvider(c.Request.Context(), tokenWorkspaceID(c), services.IdentityProviderSettings{
+        Metadata:             req.Metadata,
+        EntityID:             req.EntityID,
+        SSOURL:               req.SSOURL,
+        Certificate:          req.Certificate,
+        EmailAttribute:       req.EmailAttribute,
+        DisplayNameAttribute: req.DisplayNameAttribute,
+        Enabled:              req.Enabled == nil || *req.Enabled,
+    })
+    if errors.Is(err, services.ErrInvalidIdentityProvider) {
+        // The reason is about the caller's own configuration, so show it
+        apierrors.Validation(c, err.Error())
+        return
+    }
+    if err != nil {
+        apierrors.Respond(c, err)
+        return
+    }
+
+    c.JSON(http.StatusOK, h.toResponse(idp))
+}
+
+func (h *SAMLHandler) toResponse(idp *sharedmodels.SAMLIdentityProvider) models.SAMLIdentityProviderResponse {
+    return models.SAMLIdentityProviderResponse{
+        EntityID:             idp.EntityID,
+        SSOURL:               idp.SSOURL,
+        Certificate:          idp.Certificate,
+        EmailAttribute:       idp.EmailAttribute,
+        DisplayNameAttribute: idp.DisplayNameAttribute,
+        Enabled:              idp.Enabled,
+        SPEntityID:           h.samlService.EntityID(idp.WorkspaceID),
+        ACSURL:               h.samlService.ACSURL(idp.WorkspaceID),
+        UpdatedAt:            idp.UpdatedAt.Format(time.RFC3339),
+    }
+}
+
+func parseWorkspaceID(c *gin.Context) (uuid.UUID, bool) {
+    workspaceID, err := uuid.Parse(c.Param("workspace_id"))
+    if err != nil {
+        apierrors.Write(c, http.StatusBadRequest, "invalid_workspace_id", "Invalid workspace ID format")
+        return uuid.Nil, false
+    }
+    return workspaceID, true
+}
@@ -0,0 +1,369 @@
+package handlers
+
+import (
+    "errors"
+    "net/http"
+    "strconv"
+    "strings"
+    "time"
+
+    "github.com/gin-gonic/gin"
+    "github.com/google/uuid"
+    "github.com/Shridhar2104/chat-platform/auth-service/internal/apierrors"
+    "github.com/Shridhar2104/chat-platform/auth-service/internal/models"
+    "github.com/Shridhar2104/chat-platform/auth-service/internal/services"
+    sharedmodels "github.com/Shridhar2104/chat-platform/shared/models"
+)
+
+const scimContentType = "application/scim+json"
+
+type SCIMHandler struct {
+    scimService *services.SCIMService
+    baseURL     string
+}
+
+func NewSCIMHandler(scimService *services.SCIMService, baseURL string) *SCIMHandler {
+    return &SCIMHandler{
+        scimService: scimService,
+        baseURL:     strings.TrimRight(baseURL, "/") + "/scim/v2",
+    }
+}
+
+// ServiceProviderConfig advertises the SCIM features this service supports
+func (h *SCIMHandler) ServiceProviderConfig(c *gin.Context) {
+    h.respond(c, http.StatusOK, gin.H{
+        "schemas":               []string{models.SCIMConfigSchema},
+        "patch":                 gin.H{"supported": true},
+        "bulk":                  gin.H{"supported": false, "maxOperations": 0, "maxPayloadSize": 0},
+        "filter":                gin.H{"supported": true, "maxResults": services.SCIMMaxCount},
+        "changePassword":        gin.H{"supported": false},
+        "sort":                  gin.H{"supported": false},
+        "etag":                  gin.H{"supported": false},
+        "authenticationSchemes": []gin.H{{
+            "type":        "oauthbearertoken",
+            "name":        "Bearer Token",
+            "description": "Per-workspace SCIM provisioning token",
+        }},
+    })
+}
+
+func (h *SCIMHandler) ListUsers(c *gin.Context) {
+    startIndex, count := scimPagination(c)
+    users, total, err := h.scimService.ListUsers(c.Request.Context(), tokenWorkspaceID(c), c.Query("filter"), startIndex, count)
+    if err != nil {
+        h.respondError(c, err)
+        return
+    }
+
+    resources := make([]models.SCIMUser, 0, len(users))
+    for i := range users {
+        resources = append(resources, h.toSCIMUser(&users[i]))
+    }
+    h.respond(c, http.StatusOK, models.SCIMListResponse{
+        Schemas:      []string{models.SCIMListResponseSchema},
+        TotalResults: total,
+        StartIndex:   startIndex,
+        ItemsPerPage: len(resources),
+        Resources:    resources,
+    })
+}
+
+func (h *SCIMHandler) GetUser(c *gin.Context) {
+    userID, ok := h.parseResourceID(c)
+    if !ok {
+        return
+    }
+
+    user, err := h.scimService.GetUser(c.Request.Context(), tokenWorkspaceID(c), userID)
+    if err != nil {
+        h.respondError(c, err)
+        return
+    }
+    h.respond(c, http.StatusOK, h.toSCIMUser(user))
+}
+
+func (h *SCIMHandler) CreateUser(c *gin.Context) {
+    var req models.SCIMUser
+    if !h.bindJSON(c, &req) {
+        return
+    }
+
+    user, err := h.scimService.CreateUser(c.Request.Context(), tokenWorkspaceID(c), &req)
+    if err != nil {
+        h.respondError(c, err)
+        return
+    }
+
+    resource := h.toSCIMUser(user)
+    c.Header("Location", resource.Meta.Location)
+    h.respond(c, http.StatusCreated, resource)
+}
+
+func (h *SCIMHandler) ReplaceUser(c *gin.Context) {
+    userID, ok := h.parseResourceID(c)
+    if !ok {
+        return
+    }
+
+    var req models.SCIMUser
+    if !h.bindJSON(c, &req) {
+        return
+    }
+
+    user, err := h.scimService.ReplaceUser(c.Request.Context(), tokenWorkspaceID(c), userID, &req)
+    if err != nil {
+        h.respondError(c, err)
+        return
+    }
+    h.respond(c, http.StatusOK, h.toSCIMUser(user))
+}
+
+func (h *SCIMHandler) PatchUser(c *gin.Context) {
+    userID, ok := h.parseResourceID(c)
+    if !ok {
+        return
+    }
+
+    var req models.SCIMPatchRequest
+    if !h.bindJSON(c, &req) {
+        return
+    }
+
+    user, err := h.scimService.PatchUser(c.Request.Context(), tokenWorkspaceID(c), userID, req.Operations)
+    if err != nil {
+        h.respondError(c, err)
+        return
+    }
+    h.respond(c, http.StatusOK, h.toSCIMUser(user))
+}
+
+// DeleteUser deprovisions the user from the workspace
+func (h *SCIMHandler) DeleteUser(c *gin.Context) {
+    userID, ok := h.parseResourceID(c)
+    if !ok {
+        return
+    }
+
+    if err := h.scimService.DeleteUser(c.Request.Context(), tokenWorkspaceID(c), userID); err != nil {
+        h.respondError(c, err)
+        return
+    }
+    c.Status(http.StatusNoContent)
+}
+
+func (h *SCIMHandler) ListGroups(c *gin.Context) {
+    startIndex, count := scimPagination(c)
+    groups, total, err := h.scimService.ListGroups(c.Request.Context(), tokenWorkspaceID(c), c.Query("filter"), startIndex, count)
+    if err != nil {
+        h.respondError(c, err)
+        return
+    }
+
+    resources := make([]models.SCIMGroup, 0, len(groups))
+    for i := range groups {
+        resources = append(resources, h.toSCIMGroup(&groups[i]))
+    }
+    h.respond(c, http.StatusOK, models.SCIMListResponse{
+        Schemas:      []string{models.SCIMListResponseSchema},
+        TotalResults: total,
+        StartIndex:   startIndex,
+        ItemsPerPage: len(resources),
+        Resources:    resources,
+    })
+}
+
+func (h *SCIMHandler) GetGroup(c *gin.Context) {
+    groupID, ok := h.parseResourceID(c)
+    if !ok {
+        return
+    }
+
+    group, err := h.scimService.GetGroup(c.Request.Context(), tokenWorkspaceID(c), groupID)
+    if err != nil {
+        h.respondError(c, err)
+        return
+    }
+    h.respond(c, http.StatusOK, h.toSCIMGroup(group))
+}
+
+func (h *SCIMHandler) CreateGroup(c *gin.Context) {
+    var req models.SCIMGroup
+    if !h.bindJSON(c, &req) {
+        return
+    }
+
+    group, err := h.scimService.CreateGroup(c.Request.Context(), tokenWorkspaceID(c), &req)
+    if err != nil {
+        h.respondError(c, err)
+        return
+    }
+
+    resource := h.toSCIMGroup(group)
+    c.Header("Location", resource.Meta.Location)
+    h.respond(c, http.StatusCreated, resource)
+}
+
+func (h *SCIMHandler) ReplaceGroup(c *gin.Context) {
+    groupID, ok := h.parseResourceID(c)
+    if !ok {
+        return
+    }
+
+    var req models.SCIMGroup
+    if !h.bindJSON(c, &req) {
+        return
+    }
+
+    group, err := h.scimService.ReplaceGroup(c.Request.Context(), tokenWorkspaceID(c), groupID, &req)
+    if err != nil {
+        h.respondError(c, err)
+        return
+    }
+    h.respond(c, http.StatusOK, h.toSCIMGroup(group))
+}
+
+func (h *SCIMHandler) PatchGroup(c *gin.Context) {
+    groupID, ok := h.parseResourceID(c)
+    if !ok {
+        return
+    }
+
+    var req models.SCIMPatchRequest
+    if !h.bindJSON(c, &req) {
+        return
+    }
+
+    group, err := h.scimService.PatchGroup(c.Request.Context(), tokenWorkspaceID(c), groupID, req.Operations)
+    if err != nil {
+        h.respondError(c, err)
+        return
+    }
+    h.respond(c, http.StatusOK, h.toSCIMGroup(group))
+}
+
+func (h *SCIMHandler) DeleteGroup(c *gin.Context) {
+    groupID, ok := h.parseResourceID(c)
+    if !ok {
+        return
+    }
+
+    if err := h.scimService.DeleteGroup(c.Request.Context(), tokenWorkspaceID(c), groupID); err != nil {
+        h.respondError(c, err)
+        return
+    }
+    c.Status(http.StatusNoContent)
+}
+
+func (h *SCIMHandler) toSCIMUser(user *sharedmodels.User) models.SCIMUser {
+    active := user.Active
+    resource := models.SCIMUser{
+        Schemas:     []string{models.SCIMUserSchema},
+        ID:          user.ID.String(),
+        UserName:    user.Email,
+        DisplayName: user.DisplayName,
+        Name:        &models.SCIMName{Formatted: user.DisplayName},
+        Emails:      []models.SCIMEmail{{Value: user.Email, Type: "work", Primary: true}},
+        Active:      &active,
+        Meta: &models.SCIMMeta{
+            ResourceType: "User",
+            Created:      user.CreatedAt.UTC().Format(time.RFC3339),
+            LastModified: user.UpdatedAt.UTC().Format(time.RFC3339),
+            Location:     h.baseURL + "/Users/" + user.ID.String(),
+        },
+    }
+    if user.ExternalID != nil {
+        resource.ExternalID = *user.ExternalID
+    }
+    return resource
+}
+
+func (h *SCIMHandler) toSCIMGroup(group *sharedmodels.Group) models.SCIMGroup {
+    members := make([]models.SCIMMember, 0, len(group.MemberIDs))
+    for _, memberID := range group.MemberIDs {
+        members = append(members, models.SCIMMember{
+            Value: memberID.String(),
+            Ref:   h.baseURL + "/Users/" + memberID.String(),
+        })
+    }
+
+    resource := models.SCIMGroup{
+        Schemas:     []string{models.SCIMGroupSchema},
+        ID:          group.ID.String(),
+        DisplayName: group.DisplayName,
+        Members:     members,
+        Meta: &models.SCIMMeta{
+            ResourceType: "Group",
+            Created:      group.CreatedAt.UTC().Format(time.RFC3339),
+            LastModified: group.UpdatedAt.UTC().Format(time.RFC3339),
+            Location:     h.baseURL + "/Groups/" + group.ID.String(),
+        },
+    }
+    if group.ExternalID != nil {
+        resource.ExternalID = *group.ExternalID
+    }
+    return resource
+}
+
+func (h *SCIMHandler) bindJSON(c *gin.Context, obj interface{}) bool {
+    if err := c.ShouldBindJSON(obj); err != nil {
+        h.respondError(c, &services.SCIMError{Status: http.StatusBadRequest, ScimType: "invalidSyntax", Detail: err.Error()})
+        return false
+    }
+    return true
+}
+
+func (h *SCIMHandler) parseResourceID(c *gin.Context) (uuid.UUID, bool) {
+    id, err := uuid.Parse(c.Param("id"))
+    if err != nil {
+        h.respondError(c, &services.SCIMError{Status: http.StatusNotFound, Detail: "resource not found"})
+        return uuid.Nil, false
+    }
+    return id, true
+}
+
+func (h *SCIMHandler) respond(c *gin.Context, status int, body interface{}) {
+    c.Header("Content-Type", scimContentType)
+    c.JSON(status, body)
+}
+
+func (h *SCIMHandler) respondError(c *gin.Context, err error) {
+    var scimErr *services.SCIMError
+    switch {
+    case errors.As(err, &scimErr):
+    case apierrors.TimedOut(c, err):
+        scimErr = &services.SCIMError{Status: http.StatusGatewayTimeout, Detail: "request timed out"}
+    default:
+        scimErr = &services.SCIMError{Status: http.StatusInternalServerError, Detail: "internal server error"}
+    }
+
+    h.respond(c, scimErr.Status, models.SCIMError{
+        Schemas:  []string{models.SCIMErrorSchema},
+        Status:   strconv.Itoa(scimErr.Status),
+        ScimType: scimErr.ScimType,
+        Detail:   scimErr.Detail,
+    })
+}
+
+// scimPagination reads the 1-based startIndex and count query parameters
+func scimPagination(c *gin.Context) (int, int) {
+    startIndex, err := strconv.Atoi(c.Query("startIndex"))
+    if err != nil || startIndex < 1 {
+        startIndex = 1
+    }
+
+    count, err := strconv.Atoi(c.DefaultQuery("count", strconv.Itoa(services.SCIMDefaultCount)))
+    if err != nil || count < 0 {
+        count = services.SCIMDefaultCount
+    }
+    if count > services.SCIMMaxCount {
+        count = services.SCIMMaxCount
+    }
+    return startIndex, count
+}
+
+// tokenWorkspaceID returns the workspace resolved from the workspace bearer token
+func tokenWorkspaceID(c *gin.Context) uuid.UUID {
+    workspaceID, _ := c.Get("workspace_id")
+    id, _ := workspaceID.(uuid.UUID)
+    return id
+}
@@ -0,0 +1,101 @@
+// Package logging configures the service's log/slog logger and carries a
+// request-scoped logger through contexts. Every record passes through
+// Redact, so emails and tokens never reach the log sink in clear text.
+package logging
+
+import (
+    "context"
+    "fmt"
+    "io"
+    "log/slog"
+    "strings"
+)
+
+const (
+    FormatJSON = "json"
+    FormatText = "text"
+)
+
+// Config selects the minimum level (debug, info, warn, error) and the output format
+type Config struct {
+    Level  string
+    Format string
+}
+
+// level is the default logger's level, changed by SetLevel on config reload
+var level slog.LevelVar
+
+// New builds a logger writing to w
+func New(w io.Writer, config Config) (*slog.Logger, error) {
+    return newLogger(w, config, new(slog.LevelVar))
+}
+
+func newLogger(w io.Writer, config Config, levelVar *slog.LevelVar) (*slog.Logger, error) {
+    parsed, err := ParseLevel(config.Level)
+    if err != nil {
+        return nil, err
+    }
+    levelVar.Set(parsed)
+    options := &slog.HandlerOptions{Level: levelVar, ReplaceAttr: Redact}
+
+    switch strings.ToLower(config.Format) {
+    case "", FormatJSON:
+        return slog.New(slog.NewJSONHandler(w, options)), nil
+    case FormatText:
+        return slog.New(slog.NewTextHandler(w, options)), nil
+    default:
+        return nil, fmt.Errorf("unknown log format %q", config.Format)
+    }
+}
+
+// Setup installs the logger as the slog default, which also routes the
+// standard log package through it
+func Setup(w io.Writer, config Config) error {
+    logger, err := newLogger(w, config, &level)
+    if err != nil {
+        return err
+    }
+    slog.SetDefault(logger)
+    return nil
+}
+
+// SetLevel changes the default logger's minimum level while running
+func SetLevel(name string) error {
+    parsed, err := ParseLevel(name)
+    if err != nil {
+        return err
+    }
+    level.Set(parsed)
+    return nil
+}
+
+// ParseLevel accepts the level names used by LOG_LEVEL, empty means info
+func ParseLevel(name string) (slog.Level, error) {
+    switch strings.ToLower(strings.TrimSpace(name)) {
+    case "debug":
+        return slog.LevelDebug, nil
+    case "", "info":
+        return slog.LevelInfo, nil
+    case "warn", "warning":
+        return slog.LevelWarn, nil
+    case "error":
+        return slog.LevelError, nil
+    default:
+        return slog.LevelInfo, fmt.Errorf("unknown log level %q", name)
+    }
+}
+
+type contextKey struct{}
+
+// WithContext returns a copy of ctx carrying logger
+func WithContext(ctx context.Context, logger *slog.Logger) context.Context {
+    return context.WithValue(ctx, contextKey{}, logger)
+}
+
+// FromContext returns the request-scoped logger, or the default logger
+func FromContext(ctx context.Context) *slog.Logger {
+    if logger, ok := ctx.Value(contextKey{}).(*slog.Logger); ok {
+        return logger
+    }
+    return slog.Default()
+}
\ No newline at end of file
@@ -0,0 +1,64 @@
+package logging
+
+import (
+    "fmt"
+    "log/slog"
+    "regexp"
+    "strings"
+)
+
+const redacted = "[REDACTED]"
+
+// sensitiveKeys are attribute names whose values are always dropped
+var sensitiveKeys = map[string]bool{
+    "password":         true,
+    "current_password": true,
+    "new_password":     true,
+    "token":            true,
+    "access_token":     true,
+    "refresh_token":    true,
+    "id_token":         true,
+    "authorization":    true,
+    "cookie":           true,
+    "secret":           true,
+    "code":             true,
+    "challenge_id":     true,
+    "saml_response":    true,
+}
+
+var (
+    emailPattern  = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@([A-Za-z0-9\-]+\.)+[A-Za-z]{2,}`)
+    jwtPattern    = regexp.MustCompile(`eyJ[A-Za-z0-9_\-]+\.[A-Za-z0-9_\-]+\.[A-Za-z0-9_\-]*`)
+    bearerPattern = regexp.MustCompile(`(?i)\b(bearer|basic)\s+[A-Za-z0-9._~+/\-]+=*`)
+)
+
+// Redact is a slog ReplaceAttr hook. Sensitive keys lose their value, and
+// emails, JWTs and bearer credentials are masked inside any string, including
+// the message and error values.
+func Redact(groups []string, a slog.Attr) slog.Attr {
+    if sensitiveKeys[strings.ToLower(a.Key)] {
+        return slog.String(a.Key, redacted)
+    }
+
+    switch a.Value.Kind() {
+    case slog.KindString:
+        a.Value = slog.StringValue(RedactString(a.Value.String()))
+    case slog.KindAny:
+        switch v := a.Value.Any().(type) {
+        case error:
+            a.Value = slog.StringValue(RedactString(v.Error()))
+        case fmt.Stringer:
+            a.Value = slog.StringValue(RedactString(v.String()))
+        }
+    }
+    return a
+}
+
+// RedactString masks credentials and keeps only the domain of email addresses
+func RedactString(s string) string {
+    s = jwtPattern.ReplaceAllString(s, redacted)
+    s = bearerPattern.ReplaceAllString(s, "$1 "+redacted)
+    return emailPattern.ReplaceAllStringFunc(s, func(email string) string {
+        return "***" + email[strings.LastIndex(email, "@"):]
+    })
+}
\ No newline at end of file
@@ -0,0 +1,171 @@
+// Package metrics holds the service's Prometheus collectors and serves them
+// from a dedicated registry at /metrics.
+package metrics
+
+import (
+    "strconv"
+    "time"
+
+    "github.com/gin-gonic/gin"
+    "github.com/jmoiron/sqlx"
+    "github.com/prometheus/client_golang/prometheus"
+    "github.com/prometheus/client_golang/prometheus/collectors"
+    "github.com/prometheus/client_golang/prometheus/promhttp"
+    "github.com/Shridhar2104/chat-platform/auth-service/internal/buildinfo"
+    "github.com/Shridhar2104/chat-platform/shared/database"
+)
+
+const namespace = "auth"
+
+// Registry holds every collector the service exports
+var Registry = prometheus.NewRegistry()
+
+var (
+    httpRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+        Namespace: namespace,
+        Name:      "http_request_duration_seconds",
+        Help:      "HTTP request latency by route and status code.",
+        Buckets:   []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10},
+    }, []string{"method", "route", "status"})
+
+    loginAttempts = prometheus.NewCounterVec(prometheus.CounterOpts{
+        Namespace: namespace,
+        Name:      "login_attempts_total",
+        Help:      "Password login attempts by outcome and failure reason.",
+    }, []string{"outcome", "reason"})
+
+    tokensIssued = prometheus.NewCounterVec(prometheus.CounterOpts{
+        Namespace: namespace,
+        Name:      "tokens_issued_total",
+        Help:      "Token pairs issued by grant (register, password, step_up, refresh, sso).",
+    }, []string{"grant"})
+
+    rateLimitDecisions = prometheus.NewCounterVec(prometheus.CounterOpts{
+        Namespace: namespace,
+        Name:      "rate_limit_decisions_total",
+        Help:      "Rate limiter decisions by limiter and result (allowed, denied, error).",
+    }, []string{"limiter", "result"})
+
+    rateLimitDegraded = prometheus.NewCounterVec(prometheus.CounterOpts{
+        Namespace: namespace,
+        Name:      "rate_limit_degraded_decisions_total",
+        Help:      "Rate limit decisions made while Redis was unavailable, by failure mode (open, closed, local) and result.",
+    }, []string{"mode", "result"})
+
+    rateLimitBreakerTransitions = prometheus.NewCounterVec(prometheus.CounterOpts{
+        Namespace: namespace,
+        Name:      "rate_limit_breaker_transitions_total",
+        Help:      "Rate limiter circuit breaker transitions by the state entered.",
+    }, []string{"state"})
+
+    rateLimitBreakerState = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+        Namespace: namespace,
+        Name:      "rate_limit_breaker_state",
+        Help:      "Current rate limiter circuit breaker state, 1 for the state it is in.",
+    }, []string{"state"})
+
+    rateLimitEvictions = prometheus.NewCounter(prometheus.CounterOpts{
+        Namespace: namespace,
+        Name:      "rate_limit_memory_evictions_total",
+        Help:      "In-memory rate limit keys evicted to stay within the key limit.",
+    })
+
+    buildInfo = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+        Namespace: namespace,
+        Name:      "build_info",
+        Help:      "Build of the running service, always 1.",
+    }, []string{"version", "commit", "date", "go_version"})
+)
+
+func init() {
+    Registry.MustRegister(
+        collectors.NewGoCollector(),
+        collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+        httpRequestDuration,
+        loginAttempts,
+        tokensIssued,
+        rateLimitDecisions,
+        rateLimitDegraded,
+        rateLimitBreakerTransitions,
+        rateLimitBreakerState,
+        rateLimitEvictions,
+        buildInfo,
+    )
+
+    info := buildinfo.Get()
+    buildInfo.WithLabelValues(info.Version, info.Commit, info.Date, info.GoVersion).Set(1)
+}
+
+// Handler serves the registry in the Prometheus exposition format
+func Handler() gin.HandlerFunc {
+    return gin.WrapH(promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry}))
+}
+
+// Middleware records request latency labelled with the route pattern, so
+// path parameters never create new series
+func Middleware() gin.HandlerFunc {
+    return func(c *gin.Context) {
+        start := time.Now()
+        c.Next()
+
+        route := c.FullPath()
+        if route == "" {
+            route = "unmatched"
+        }
+        httpRequestDuration.WithLabelValues(c.Request.Method, route, strconv.Itoa(c.Writer.Status())).
+            Observe(time.Since(start).Seconds())
+    }
+}
+
+// RegisterPools exports connection pool statistics for Postgres and Redis
+func RegisterPools(db *sqlx.DB, redis *database.RedisClient) {
+    Registry.MustRegister(
+        collectors.NewDBStatsCollector(db.DB, "postgres"),
+        newRedisPoolCollector(redis),
+    )
+}
+
+// LoginSucceeded counts a password login that issued tokens
+func LoginSucceeded() {
+    loginAttempts.WithLabelValues("success", "").Inc()
+}
+
+// LoginFailed counts a rejected password login, reason is a stable error code
+func LoginFailed(reason string) {
+    loginAttempts.WithLabelValues("failure", reason).Inc()
+}
+
+// LoginSteppedUp counts a login held back for step-up verification
+func LoginSteppedUp() {
+    loginAttempts.WithLabelValues("step_up", "").Inc()
+}
+
+func TokensIssued(grant string) {
+    tokensIssued.WithLabelValues(grant).Inc()
+}
+
+// RateLimitDecision counts one rate limiter outcome
+func RateLimitDecision(limiter, result string) {
+    rateLimitDecisions.WithLabelValues(limiter, result).Inc()
+}
+
+// RateLimitDegraded counts a decision made by a Redis failure mode
+func RateLimitDegraded(mode string, allowed bool) {
+    result := "denied"
+    if allowed {
+        result = "allowed"
+    }
+    rateLimitDegraded.WithLabelValues(mode, result).Inc()
+}
+
+// RateLimitBreakerChanged records the rate limiter circuit breaker entering state
+func RateLimitBreakerChanged(state string) {
+    rateLimitBreakerTransitions.WithLabelValues(state).Inc()
+    rateLimitBreakerState.Reset()
+    rateLimitBreakerState.WithLabelValues(state).Set(1)
+}
+
+// RateLimitEvicted counts in-memory limiter keys dropped while still in use
+func RateLimitEvicted() {
+    rateLimitEvictions.Inc()
+}
\ No newline at end of file
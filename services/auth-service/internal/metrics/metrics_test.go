@@ -0,0 +1,57 @@
+package metrics
+
+import (
+    "io"
+    "net/http"
+    "net/http/httptest"
+    "strings"
+    "testing"
+
+    "github.com/gin-gonic/gin"
+    "github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestMiddlewareLabelsByRoutePattern(t *testing.T) {
+    gin.SetMode(gin.TestMode)
+    router := gin.New()
+    router.Use(Middleware())
+    router.GET("/api/v1/users/:id", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+
+    for _, path := range []string{"/api/v1/users/1", "/api/v1/users/2", "/missing"} {
+        router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+    }
+
+    if got := testutil.CollectAndCount(httpRequestDuration, "auth_http_request_duration_seconds"); got != 2 {
+        t.Errorf("series = %d, want one per route and status", got)
+    }
+}
+
+func TestHandlerExposesServiceMetrics(t *testing.T) {
+    LoginFailed("invalid_credentials")
+    LoginSucceeded()
+    TokensIssued("password")
+    RateLimitDecision("memory", "denied")
+
+    if got := testutil.ToFloat64(loginAttempts.WithLabelValues("failure", "invalid_credentials")); got < 1 {
+        t.Errorf("failed logins = %v", got)
+    }
+
+    gin.SetMode(gin.TestMode)
+    router := gin.New()
+    router.GET("/metrics", Handler())
+    rec := httptest.NewRecorder()
+    router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+    body, _ := io.ReadAll(rec.Body)
+    for _, want := range []string{
+        `auth_login_attempts_total{outcome="failure",reason="invalid_credentials"}`,
+        `auth_tokens_issued_total{grant="password"}`,
+        `auth_rate_limit_decisions_total{limiter="memory",result="denied"}`,
+        `auth_build_info{`,
+        `go_goroutines`,
+    } {
+        if !strings.Contains(string(body), want) {
+            t.Errorf("exposition is missing %s", want)
+        }
+    }
+}
\ No newline at end of file
@@ -0,0 +1,52 @@
+package metrics
+
+import (
+    "github.com/prometheus/client_golang/prometheus"
+    "github.com/Shridhar2104/chat-platform/shared/database"
+)
+
+// redisPoolCollector reads go-redis pool statistics on every scrape
+type redisPoolCollector struct {
+    redis *database.RedisClient
+
+    hits       *prometheus.Desc
+    misses     *prometheus.Desc
+    timeouts   *prometheus.Desc
+    totalConns *prometheus.Desc
+    idleConns  *prometheus.Desc
+    staleConns *prometheus.Desc
+}
+
+func newRedisPoolCollector(redis *database.RedisClient) *redisPoolCollector {
+    desc := func(name, help string) *prometheus.Desc {
+        return prometheus.NewDesc(prometheus.BuildFQName("redis", "pool", name), help, nil, nil)
+    }
+    return &redisPoolCollector{
+        redis:      redis,
+        hits:       desc("hits_total", "Times a free connection was found in the pool."),
+        misses:     desc("misses_total", "Times a free connection was not found in the pool."),
+        timeouts:   desc("timeouts_total", "Times a wait for a connection timed out."),
+        totalConns: desc("connections", "Connections in the pool."),
+        idleConns:  desc("idle_connections", "Idle connections in the pool."),
+        staleConns: desc("stale_connections_removed_total", "Stale connections removed from the pool."),
+    }
+}
+
+func (c *redisPoolCollector) Describe(ch chan<- *prometheus.Desc) {
+    ch <- c.hits
+    ch <- c.misses
+    ch <- c.timeouts
+    ch <- c.totalConns
+    ch <- c.idleConns
+    ch <- c.staleConns
+}
+
+func (c *redisPoolCollector) Collect(ch chan<- prometheus.Metric) {
+    stats := c.redis.Client.PoolStats()
+    ch <- prometheus.MustNewConstMetric(c.hits, prometheus.CounterValue, float64(stats.Hits))
+    ch <- prometheus.MustNewConstMetric(c.misses, prometheus.CounterValue, float64(stats.Misses))
+    ch <- prometheus.MustNewConstMetric(c.timeouts, prometheus.CounterValue, float64(stats.Timeouts))
+    ch <- prometheus.MustNewConstMetric(c.totalConns, prometheus.GaugeValue, float64(stats.TotalConns))
+    ch <- prometheus.MustNewConstMetric(c.idleConns, prometheus.GaugeValue, float64(stats.IdleConns))
+    ch <- prometheus.MustNewConstMetric(c.staleConns, prometheus.CounterValue, float64(stats.StaleConns))
+}
\ No newline at end of file
@@ -1,47 +1,46 @@
 package middleware
 
 import (
+    "context"
+    "errors"
     "net/http"
-    "strings"
 
     "github.com/gin-gonic/gin"
-    "github.com/Shridhar2104/chat-platform/auth-service/internal/models"
+    "github.com/Shridhar2104/chat-platform/auth-service/internal/apierrors"
     "github.com/Shridhar2104/chat-platform/auth-service/internal/services"
+    "github.com/Shridhar2104/chat-platform/shared/authclient"
+    "github.com/Shridhar2104/chat-platform/shared/models"
 )
 
-func AuthMiddleware(jwtSecret string) gin.HandlerFunc {
-    jwtService := services.NewJWTService(jwtSecret, 0, 0) // Only need validation
+// AccessTokenValidator checks an access token and the account behind it,
+// AuthService implements it
+type AccessTokenValidator interface {
+    ValidateAccessToken(ctx context.Context, accessToken string) (*services.Claims, *models.User, error)
+}
 
+// AuthMiddleware admits requests with a valid access token of an active
+// account, so deprovisioned users are cut off before their tokens expire
+func AuthMiddleware(validator AccessTokenValidator) gin.HandlerFunc {
     return func(c *gin.Context) {
         authHeader := c.GetHeader("Authorization")
         if authHeader == "" {
-            c.JSON(http.StatusUnauthorized, models.ErrorResponse{
-                Error:   "missing_authorization",
-                Message: "Authorization header is required",
-            })
-            c.Abort()
+            apierrors.Write(c, http.StatusUnauthorized, "missing_authorization", "Authorization header is required")
             return
         }
 
-        // Check for Bearer token
-        tokenParts := strings.Split(authHeader, " ")
-        if len(tokenParts) != 2 || tokenParts[0] != "Bearer" {
-            c.JSON(http.StatusUnauthorized, models.ErrorResponse{
-                Error:   "invalid_authorization",
-                Message: "Authorization header must be in format: Bearer <token>",
-            })
-            c.Abort()
+        token, ok := authclient.BearerToken(authHeader)
+        if !ok {
+            apierrors.Write(c, http.StatusUnauthorized, "invalid_authorization", "Authorization header must be in format: Bearer <token>")
             return
         }
 
-        token := tokenParts[1]
-        claims, err := jwtService.ValidateAccessToken(token)
+        claims, _, err := validator.ValidateAccessToken(c.Request.Context(), token)
+        if errors.Is(err, services.ErrInvalidToken) {
+            apierrors.Write(c, http.StatusUnauthorized, "invalid_token", "Invalid or expired token")
+            return
+        }
         if err != nil {
-            c.JSON(http.StatusUnauthorized, models.ErrorResponse{
-                Error:   "invalid_token",
-                Message: "Invalid or expired token",
-            })
-            c.Abort()
+            apierrors.Respond(c, err)
             return
         }
 
@@ -49,6 +48,12 @@ func AuthMiddleware(jwtSecret string) gin.HandlerFunc {
         c.Set("user_id", claims.UserID.String())
         c.Set("email", claims.Email)
         c.Set("device_id", claims.DeviceID)
+        if claims.WorkspaceID != "" {
+            c.Set("workspace_id", claims.WorkspaceID)
+        }
+        if claims.Plan != "" {
+            c.Set("plan", claims.Plan)
+        }
 
         c.Next()
     }
@@ -0,0 +1,92 @@
+package middleware
+
+import (
+    "context"
+    "crypto/rand"
+    "crypto/rsa"
+    "net/http"
+    "net/http/httptest"
+    "testing"
+    "time"
+
+    "github.com/gin-gonic/gin"
+    "github.com/google/uuid"
+
+    "github.com/Shridhar2104/chat-platform/auth-service/internal/services"
+    "github.com/Shridhar2104/chat-platform/shared/models"
+)
+
+// tokenValidator checks signatures with a real JWTService and treats the
+// accounts in disabled as deprovisioned
+type tokenValidator struct {
+    jwtService *services.JWTService
+    disabled   map[uuid.UUID]bool
+}
+
+func newTokenValidator(t *testing.T) *tokenValidator {
+    t.Helper()
+    key, err := rsa.GenerateKey(rand.Reader, 2048)
+    if err != nil {
+        t.Fatalf("failed to generate signing key: %v", err)
+    }
+    return &tokenValidator{
+        jwtService: services.NewJWTService("test-secret", services.NewKeySet(key), 15*time.Minute, time.Hour),
+        disabled:   make(map[uuid.UUID]bool),
+    }
+}
+
+func (v *tokenValidator) ValidateAccessToken(ctx context.Context, accessToken string) (*services.Claims, *models.User, error) {
+    claims, err := v.jwtService.ValidateAccessToken(accessToken)
+    if err != nil {
+        return nil, nil, services.ErrInvalidToken
+    }
+    if v.disabled[claims.UserID] {
+        return nil, nil, services.ErrAccountDisabled
+    }
+    return claims, &models.User{ID: claims.UserID, Email: claims.Email, Active: true}, nil
+}
+
+func (v *tokenValidator) token(t *testing.T, userID uuid.UUID, workspace *services.TokenWorkspace) string {
+    t.Helper()
+    accessToken, _, _, err := v.jwtService.GenerateTokenPair(userID, "user@example.com", "laptop", workspace)
+    if err != nil {
+        t.Fatalf("GenerateTokenPair failed: %v", err)
+    }
+    return accessToken
+}
+
+func TestAuthMiddleware(t *testing.T) {
+    validator := newTokenValidator(t)
+    gin.SetMode(gin.TestMode)
+    router := gin.New()
+    router.GET("/me", AuthMiddleware(validator), func(c *gin.Context) { c.String(http.StatusOK, c.GetString("user_id")) })
+
+    request := func(authorization string) *httptest.ResponseRecorder {
+        req := httptest.NewRequest(http.MethodGet, "/me", nil)
+        req.Header.Set("Authorization", authorization)
+        rec := httptest.NewRecorder()
+        router.ServeHTTP(rec, req)
+        return rec
+    }
+
+    userID := uuid.New()
+    token := validator.token(t, userID, nil)
+    if rec := request("bearer " + token); rec.Code != http.StatusOK || rec.Body.String() != userID.String() {
+        t.Errorf("valid token = %d %s", rec.Code, rec.Body)
+    }
+    if rec := request(""); rec.Code != http.StatusUnauthorized {
+        t.Errorf("missing header = %d, want 401", rec.Code)
+    }
+    if rec := request("Basic " + token); rec.Code != http.StatusUnauthorized {
+        t.Errorf("basic scheme = %d, want 401", rec.Code)
+    }
+    if rec := request("Bearer not-a-token"); rec.Code != http.StatusUnauthorized {
+        t.Errorf("invalid token = %d, want 401", rec.Code)
+    }
+
+    // Deprovisioning cuts off the token before it expires
+    validator.disabled[userID] = true
+    if rec := request("Bearer " + token); rec.Code != http.StatusForbidden {
+        t.Errorf("token of a disabled account = %d, want 403", rec.Code)
+    }
+}
\ No newline at end of file
@@ -0,0 +1,78 @@
+package middleware
+
+import (
+    "net/http"
+
+    "github.com/gin-gonic/gin"
+    "github.com/Shridhar2104/chat-platform/auth-service/internal/clientip"
+    "github.com/Shridhar2104/chat-platform/auth-service/internal/logging"
+    "github.com/Shridhar2104/chat-platform/auth-service/internal/models"
+    "github.com/Shridhar2104/chat-platform/auth-service/internal/services"
+)
+
+const (
+    powChallengeHeader = "X-PoW-Challenge"
+    powNonceHeader     = "X-PoW-Nonce"
+    captchaTokenHeader = "X-Captcha-Token"
+)
+
+// ChallengeMiddleware makes clients solve a proof-of-work (or CAPTCHA)
+// challenge once abuse signals for the route cross the configured thresholds
+func ChallengeMiddleware(challengeService *services.ChallengeService, route string) gin.HandlerFunc {
+    return func(c *gin.Context) {
+        ipAddress := clientip.FromContext(c)
+
+        signals, err := challengeService.RecordAttempt(c.Request.Context(), route, ipAddress)
+        if err != nil {
+            // If Redis is down, allow request but log error
+            logging.FromContext(c.Request.Context()).Error("Failed to record challenge signals", "route", route, "error", err)
+            c.Next()
+            return
+        }
+
+        difficulty := challengeService.RequiredDifficulty(signals)
+        if difficulty > 0 && !solvedChallenge(c, challengeService, route, ipAddress, difficulty) {
+            challenge, err := challengeService.IssueChallenge(c.Request.Context(), route, difficulty)
+            if err != nil {
+                logging.FromContext(c.Request.Context()).Error("Failed to issue challenge", "route", route, "error", err)
+                c.Next()
+                return
+            }
+
+            c.JSON(http.StatusPreconditionRequired, models.ChallengeResponse{
+                Error:       "challenge_required",
+                Message:     "Solve the challenge and retry with the X-PoW-Challenge and X-PoW-Nonce headers",
+                ChallengeID: challenge.ID,
+                Algorithm:   "sha256",
+                Prefix:      challenge.Prefix,
+                Difficulty:  challenge.Difficulty,
+                ExpiresAt:   challenge.ExpiresAt.Unix(),
+                Captcha:     challengeService.CaptchaEnabled(),
+            })
+            c.Abort()
+            return
+        }
+
+        c.Next()
+
+        // Rejected requests feed back into the client's abuse signals
+        if status := c.Writer.Status(); status >= http.StatusBadRequest && status != http.StatusPreconditionRequired {
+            challengeService.RecordFailure(c.Request.Context(), route, ipAddress)
+        }
+    }
+}
+
+func solvedChallenge(c *gin.Context, challengeService *services.ChallengeService, route, ipAddress string, difficulty int) bool {
+    if token := c.GetHeader(captchaTokenHeader); token != "" && challengeService.CaptchaEnabled() {
+        if err := challengeService.VerifyCaptcha(c.Request.Context(), token, ipAddress); err == nil {
+            return true
+        }
+    }
+
+    challengeID := c.GetHeader(powChallengeHeader)
+    nonce := c.GetHeader(powNonceHeader)
+    if challengeID == "" || nonce == "" {
+        return false
+    }
+    return challengeService.VerifySolution(c.Request.Context(), route, challengeID, nonce, difficulty) == nil
+}
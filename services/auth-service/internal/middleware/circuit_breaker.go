@@ -0,0 +1,137 @@
+package middleware
+
+import (
+    "context"
+    "errors"
+    "sync"
+    "time"
+)
+
+// Circuit breaker states
+const (
+    BreakerClosed   = "closed"
+    BreakerOpen     = "open"
+    BreakerHalfOpen = "half_open"
+)
+
+// CircuitBreaker stops calls to a failing dependency. After threshold
+// consecutive failures it opens for cooldown, then lets a single probe
+// through; the probe's outcome closes or reopens it.
+type CircuitBreaker struct {
+    threshold int
+    cooldown  time.Duration
+    now       func() time.Time
+
+    mu       sync.Mutex
+    state    string
+    failures int
+    openedAt time.Time
+    probing  bool
+    onChange []func(state string)
+}
+
+func NewCircuitBreaker(threshold int, cooldown time.Duration) *CircuitBreaker {
+    return &CircuitBreaker{
+        threshold: max(1, threshold),
+        cooldown:  cooldown,
+        now:       time.Now,
+        state:     BreakerClosed,
+    }
+}
+
+// OnStateChange registers fn to be called with the new state after every transition
+func (b *CircuitBreaker) OnStateChange(fn func(state string)) {
+    b.mu.Lock()
+    defer b.mu.Unlock()
+    b.onChange = append(b.onChange, fn)
+}
+
+// State returns the current state
+func (b *CircuitBreaker) State() string {
+    b.mu.Lock()
+    defer b.mu.Unlock()
+    return b.state
+}
+
+// Allow reports whether a call may go to the dependency. Every allowed
+// call must be followed by Record.
+func (b *CircuitBreaker) Allow() bool {
+    b.mu.Lock()
+    var changed []func(string)
+    defer func() {
+        b.mu.Unlock()
+        notify(changed, BreakerHalfOpen)
+    }()
+
+    switch b.state {
+    case BreakerClosed:
+        return true
+    case BreakerOpen:
+        if b.now().Sub(b.openedAt) < b.cooldown {
+            return false
+        }
+        changed = b.transition(BreakerHalfOpen)
+        b.probing = true
+        return true
+    default:
+        if b.probing {
+            return false
+        }
+        b.probing = true
+        return true
+    }
+}
+
+// Record reports the outcome of an allowed call. Cancelled calls say
+// nothing about the dependency and only free the probe.
+func (b *CircuitBreaker) Record(err error) {
+    b.mu.Lock()
+    var changed []func(string)
+    state := b.state
+    defer func() {
+        b.mu.Unlock()
+        notify(changed, state)
+    }()
+
+    b.probing = false
+    if errors.Is(err, context.Canceled) {
+        return
+    }
+    if err == nil {
+        b.failures = 0
+        if b.state != BreakerClosed {
+            state = BreakerClosed
+            changed = b.transition(state)
+        }
+        return
+    }
+
+    b.failures++
+    if b.state == BreakerHalfOpen || (b.state == BreakerClosed && b.failures >= b.threshold) {
+        b.openedAt = b.now()
+        state = BreakerOpen
+        changed = b.transition(state)
+    }
+}
+
+// RetryAfter is how long until the next probe may reach the dependency
+func (b *CircuitBreaker) RetryAfter() time.Duration {
+    b.mu.Lock()
+    defer b.mu.Unlock()
+    if b.state != BreakerOpen {
+        return b.cooldown
+    }
+    return max(b.openedAt.Add(b.cooldown).Sub(b.now()), 0)
+}
+
+// transition sets the state and returns the callbacks to run once unlocked
+func (b *CircuitBreaker) transition(state string) []func(string) {
+    b.state = state
+    return b.onChange
+}
+
+func notify(callbacks []func(string), state string) {
+    for _, fn := range callbacks {
+        fn(state)
+    }
+}
\ No newline at end of file
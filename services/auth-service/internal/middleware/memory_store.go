@@ -0,0 +1,135 @@
+package middleware
+
+import (
+    "container/list"
+    "hash/maphash"
+    "sync"
+
+    "github.com/Shridhar2104/chat-platform/auth-service/internal/metrics"
+)
+
+// MemoryStoreConfig bounds the keys an in-memory limiter keeps. Keys are
+// spread over Shards, each with its own lock, and a shard that is full
+// evicts its least recently used key, so eviction can start a little before
+// MaxKeys when keys hash unevenly.
+type MemoryStoreConfig struct {
+    MaxKeys int
+    Shards  int
+}
+
+// DefaultMemoryStoreConfig keeps up to 100k keys, a few tens of megabytes
+func DefaultMemoryStoreConfig() MemoryStoreConfig {
+    return MemoryStoreConfig{MaxKeys: 100_000, Shards: 64}
+}
+
+// memoryStore is a size-bounded map of limiter state. Unrelated keys rarely
+// share a shard lock, and a flood of made-up identifiers only pushes out
+// the keys used least recently instead of growing memory without bound.
+type memoryStore[V any] struct {
+    seed   maphash.Seed
+    shards []memoryShard[V]
+}
+
+type memoryShard[V any] struct {
+    mu       sync.Mutex
+    capacity int
+    entries  map[string]*list.Element
+    // order holds *memoryEntry values, the most recently used at the front
+    order list.List
+}
+
+type memoryEntry[V any] struct {
+    key   string
+    value V
+}
+
+func newMemoryStore[V any](config MemoryStoreConfig) *memoryStore[V] {
+    shards := max(1, config.Shards)
+    perShard := max(1, (config.MaxKeys+shards-1)/shards)
+    store := &memoryStore[V]{seed: maphash.MakeSeed(), shards: make([]memoryShard[V], shards)}
+    for i := range store.shards {
+        store.shards[i].capacity = perShard
+        store.shards[i].entries = make(map[string]*list.Element)
+    }
+    return store
+}
+
+func (s *memoryStore[V]) shard(key string) *memoryShard[V] {
+    return &s.shards[maphash.String(s.seed, key)%uint64(len(s.shards))]
+}
+
+// update runs fn on the value of key under its shard lock, starting from
+// init() when the key is new
+func (s *memoryStore[V]) update(key string, init func() V, fn func(value *V)) {
+    shard := s.shard(key)
+    shard.mu.Lock()
+    defer shard.mu.Unlock()
+
+    element, ok := shard.entries[key]
+    if ok {
+        shard.order.MoveToFront(element)
+    } else {
+        if len(shard.entries) >= shard.capacity {
+            oldest := shard.order.Back()
+            shard.order.Remove(oldest)
+            delete(shard.entries, oldest.Value.(*memoryEntry[V]).key)
+            metrics.RateLimitEvicted()
+        }
+        element = shard.order.PushFront(&memoryEntry[V]{key: key, value: init()})
+        shard.entries[key] = element
+    }
+    fn(&element.Value.(*memoryEntry[V]).value)
+}
+
+// view runs fn on the value of key under its shard lock without creating
+// it or counting as a use, false when the key is absent
+func (s *memoryStore[V]) view(key string, fn func(value *V)) bool {
+    shard := s.shard(key)
+    shard.mu.Lock()
+    defer shard.mu.Unlock()
+
+    element, ok := shard.entries[key]
+    if ok {
+        fn(&element.Value.(*memoryEntry[V]).value)
+    }
+    return ok
+}
+
+func (s *memoryStore[V]) remove(key string) {
+    shard := s.shard(key)
+    shard.mu.Lock()
+    defer shard.mu.Unlock()
+
+    if element, ok := shard.entries[key]; ok {
+        shard.order.Remove(element)
+        delete(shard.entries, key)
+    }
+}
+
+// sweep drops the keys expired reports on, one shard lock at a time
+func (s *memoryStore[V]) sweep(expired func(value *V) bool) {
+    for i := range s.shards {
+        shard := &s.shards[i]
+        shard.mu.Lock()
+        for element := shard.order.Front(); element != nil; {
+            next := element.Next()
+            entry := element.Value.(*memoryEntry[V])
+            if expired(&entry.value) {
+                shard.order.Remove(element)
+                delete(shard.entries, entry.key)
+            }
+            element = next
+        }
+        shard.mu.Unlock()
+    }
+}
+
+func (s *memoryStore[V]) len() int {
+    n := 0
+    for i := range s.shards {
+        s.shards[i].mu.Lock()
+        n += len(s.shards[i].entries)
+        s.shards[i].mu.Unlock()
+    }
+    return n
+}
\ No newline at end of file
@@ -0,0 +1,112 @@
+package middleware
+
+import (
+    "context"
+    "fmt"
+    "strconv"
+    "sync/atomic"
+    "testing"
+    "time"
+)
+
+func TestMemoryStoreEvictsLeastRecentlyUsed(t *testing.T) {
+    store := newMemoryStore[int](MemoryStoreConfig{MaxKeys: 3, Shards: 1})
+    for _, key := range []string{"a", "b", "c"} {
+        store.update(key, func() int { return 0 }, func(*int) {})
+    }
+    // Touching a keeps it, b is now the least recently used
+    store.update("a", func() int { return 0 }, func(v *int) { *v++ })
+    store.update("d", func() int { return 0 }, func(*int) {})
+
+    if got := store.len(); got != 3 {
+        t.Errorf("len = %d, want the store bounded at 3", got)
+    }
+    if store.view("b", func(*int) {}) {
+        t.Error("expected b to be evicted")
+    }
+    var a int
+    if !store.view("a", func(v *int) { a = *v }) || a != 1 {
+        t.Errorf("a = %d, want it kept with its state", a)
+    }
+}
+
+func TestMemoryStoreBoundsEveryShard(t *testing.T) {
+    store := newMemoryStore[int](MemoryStoreConfig{MaxKeys: 1000, Shards: 16})
+    for i := 0; i < 10_000; i++ {
+        store.update(strconv.Itoa(i), func() int { return 0 }, func(*int) {})
+    }
+    // Shards round their share up, so the bound holds to within one per shard
+    if got := store.len(); got > 1000+16 {
+        t.Errorf("len = %d after a flood of keys, want at most about 1000", got)
+    }
+}
+
+func TestMemoryTokenBucketSweepsIdleBuckets(t *testing.T) {
+    limiter := NewBoundedMemoryTokenBucket(t.Context(), 10, 1, 1, DefaultMemoryStoreConfig())
+
+    limiter.AllowRequest("idle")
+    limiter.AllowRequest("busy")
+    limiter.buckets.update("idle", nil, func(bucket *tokenBucket) {
+        bucket.lastRefill = bucket.lastRefill.Add(-time.Hour)
+    })
+
+    limiter.sweep(time.Now())
+    if limiter.buckets.view("idle", func(*tokenBucket) {}) {
+        t.Error("expected the idle bucket to be swept")
+    }
+    if !limiter.buckets.view("busy", func(*tokenBucket) {}) {
+        t.Error("expected the busy bucket to be kept")
+    }
+}
+
+func TestMemoryTokenBucketClose(t *testing.T) {
+    limiter := NewBoundedMemoryTokenBucket(t.Context(), 10, 1, 1, DefaultMemoryStoreConfig())
+    if err := limiter.Close(); err != nil {
+        t.Fatalf("Close failed: %v", err)
+    }
+    select {
+    case <-limiter.done:
+    default:
+        t.Fatal("expected cleanup to have stopped")
+    }
+    if err := limiter.Close(); err != nil {
+        t.Errorf("second Close failed: %v", err)
+    }
+
+    // Cancelling the context it was built with stops it as well
+    ctx, cancel := context.WithCancel(context.Background())
+    limiter = NewBoundedMemoryTokenBucket(ctx, 10, 1, 1, DefaultMemoryStoreConfig())
+    cancel()
+    select {
+    case <-limiter.done:
+    case <-time.After(time.Second):
+        t.Fatal("expected cleanup to stop with its context")
+    }
+}
+
+// BenchmarkMemoryTokenBucketParallel spreads requests over many clients
+// from all cores; a single shard stands in for one global lock
+func BenchmarkMemoryTokenBucketParallel(b *testing.B) {
+    const clients = 10_000
+    keys := make([]string, clients)
+    for i := range keys {
+        keys[i] = "ip:" + strconv.Itoa(i)
+    }
+
+    for _, shards := range []int{1, DefaultMemoryStoreConfig().Shards} {
+        b.Run(fmt.Sprintf("shards=%d", shards), func(b *testing.B) {
+            limiter := NewBoundedMemoryTokenBucket(b.Context(), 1_000_000, 1_000_000, 1,
+                MemoryStoreConfig{MaxKeys: DefaultMemoryStoreConfig().MaxKeys, Shards: shards})
+
+            var next atomic.Uint64
+            b.ResetTimer()
+            b.RunParallel(func(pb *testing.PB) {
+                i := next.Add(7919)
+                for pb.Next() {
+                    i++
+                    limiter.AllowRequest(keys[i%clients])
+                }
+            })
+        })
+    }
+}
\ No newline at end of file
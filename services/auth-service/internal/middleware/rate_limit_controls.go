@@ -0,0 +1,405 @@
+package middleware
+
+import (
+    "context"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "log/slog"
+    "net/http"
+    "strings"
+    "sync"
+    "time"
+
+    "github.com/gin-gonic/gin"
+
+    "github.com/Shridhar2104/chat-platform/auth-service/internal/clientip"
+    "github.com/Shridhar2104/chat-platform/auth-service/internal/apierrors"
+    "github.com/Shridhar2104/chat-platform/shared/database"
+)
+
+// Lists an identifier can be put on by operators
+const (
+    ListAllow = "allow"
+    ListDeny  = "deny"
+)
+
+// rateLimitExemptKey marks allowlisted requests in the gin context
+const rateLimitExemptKey = "rate_limit_exempt"
+
+// controlsKeyPrefix prefixes the Redis hashes shared by every replica
+const controlsKeyPrefix = "rate_limit:admin"
+
+var (
+    ErrInvalidIdentifier = errors.New("identifier must be ip:<address>, user:<id> or workspace:<id>")
+    ErrUnknownList       = errors.New("list must be allow or deny")
+
+    // The global limiter runs before authentication and only ever sees the
+    // client IP, so its buckets and overrides are keyed on ip: alone
+    ErrInvalidBucketIdentifier = errors.New("identifier must be ip:<address>, the global limiter only keys on the client IP")
+)
+
+// Override temporarily replaces the global limit of one identifier
+type Override struct {
+    RequestsPerMinute int       `json:"requests_per_minute"`
+    Burst             int       `json:"burst,omitempty"`
+    ExpiresAt         time.Time `json:"expires_at"`
+}
+
+func (o Override) expires() time.Time { return o.ExpiresAt }
+
+// ListEntry is an identifier on a list, forever when ExpiresAt is zero
+type ListEntry struct {
+    ExpiresAt time.Time `json:"expires_at,omitempty"`
+}
+
+func (e ListEntry) expires() time.Time { return e.ExpiresAt }
+
+// BucketAdmin inspects and resets the global limiter's buckets
+type BucketAdmin interface {
+    GetBucketState(ctx context.Context, identifier string) (*BucketState, error)
+    Reset(ctx context.Context, identifier string) error
+}
+
+// ValidIdentifier reports whether identifier names a client the limiters key on
+func ValidIdentifier(identifier string) bool {
+    kind, value, ok := strings.Cut(identifier, ":")
+    if !ok || value == "" {
+        return false
+    }
+    return kind == "ip" || kind == "user" || kind == "workspace"
+}
+
+// ValidBucketIdentifier reports whether identifier names a global limiter bucket
+func ValidBucketIdentifier(identifier string) bool {
+    kind, value, ok := strings.Cut(identifier, ":")
+    return ok && value != "" && kind == "ip"
+}
+
+// RateLimitControls holds the operator allowlist, denylist and overrides.
+// With Redis they are stored in hashes and every replica reloads them on
+// the refresh interval; without Redis they only live in this process.
+type RateLimitControls struct {
+    redis      *database.RedisClient
+    refresh    time.Duration
+    resilience Resilience
+    now        func() time.Time
+
+    mu        sync.RWMutex
+    lists     map[string]map[string]ListEntry
+    overrides map[string]Override
+    limiters  map[string]RateLimiter
+}
+
+func NewRateLimitControls(redis *database.RedisClient, refresh time.Duration, resilience Resilience) *RateLimitControls {
+    return &RateLimitControls{
+        redis:      redis,
+        refresh:    refresh,
+        resilience: resilience,
+        now:        time.Now,
+        lists: map[string]map[string]ListEntry{
+            ListAllow: {},
+            ListDeny:  {},
+        },
+        overrides: make(map[string]Override),
+        limiters:  make(map[string]RateLimiter),
+    }
+}
+
+// Run reloads the controls from Redis until ctx is cancelled
+func (rc *RateLimitControls) Run(ctx context.Context) {
+    if rc.redis == nil {
+        return
+    }
+    ticker := time.NewTicker(rc.refresh)
+    defer ticker.Stop()
+    for {
+        if err := rc.Reload(ctx); err != nil && ctx.Err() == nil {
+            slog.Warn("Failed to reload rate limit controls", "error", err)
+        }
+        select {
+        case <-ctx.Done():
+            return
+        case <-ticker.C:
+        }
+    }
+}
+
+// Reload replaces the local controls with the ones stored in Redis
+func (rc *RateLimitControls) Reload(ctx context.Context) error {
+    if rc.redis == nil {
+        return nil
+    }
+    lists := make(map[string]map[string]ListEntry)
+    for _, list := range []string{ListAllow, ListDeny} {
+        entries := make(map[string]ListEntry)
+        if err := loadHash(ctx, rc, list, entries); err != nil {
+            return err
+        }
+        lists[list] = entries
+    }
+    overrides := make(map[string]Override)
+    if err := loadHash(ctx, rc, "overrides", overrides); err != nil {
+        return err
+    }
+
+    rc.mu.Lock()
+    defer rc.mu.Unlock()
+    rc.lists = lists
+    for identifier, override := range overrides {
+        if current, ok := rc.overrides[identifier]; !ok || current != override {
+            delete(rc.limiters, identifier)
+        }
+    }
+    for identifier := range rc.limiters {
+        if _, ok := overrides[identifier]; !ok {
+            delete(rc.limiters, identifier)
+        }
+    }
+    rc.overrides = overrides
+    return nil
+}
+
+// loadHash decodes the live fields of one hash into into and removes the
+// expired ones
+func loadHash[T interface{ expires() time.Time }](ctx context.Context, rc *RateLimitControls, name string, into map[string]T) error {
+    key := controlsKeyPrefix + ":" + name
+    fields, err := rc.redis.Client.HGetAll(ctx, key).Result()
+    if err != nil {
+        return fmt.Errorf("failed to load %s: %w", key, err)
+    }
+    var expired []string
+    for identifier, raw := range fields {
+        var value T
+        if err := json.Unmarshal([]byte(raw), &value); err != nil {
+            return fmt.Errorf("failed to decode %s %s: %w", key, identifier, err)
+        }
+        if expiresAt := value.expires(); !expiresAt.IsZero() && !rc.now().Before(expiresAt) {
+            expired = append(expired, identifier)
+            continue
+        }
+        into[identifier] = value
+    }
+    if len(expired) > 0 {
+        if err := rc.redis.Client.HDel(ctx, key, expired...).Err(); err != nil {
+            return fmt.Errorf("failed to remove expired %s: %w", key, err)
+        }
+    }
+    return nil
+}
+
+// SetList puts identifier on list, for ttl or forever when ttl is zero
+func (rc *RateLimitControls) SetList(ctx context.Context, list, identifier string, ttl time.Duration) error {
+    if list != ListAllow && list != ListDeny {
+        return ErrUnknownList
+    }
+    if !ValidIdentifier(identifier) {
+        return ErrInvalidIdentifier
+    }
+    var entry ListEntry
+    if ttl > 0 {
+        entry.ExpiresAt = rc.now().Add(ttl).UTC()
+    }
+    if err := rc.store(ctx, list, identifier, entry); err != nil {
+        return err
+    }
+
+    rc.mu.Lock()
+    defer rc.mu.Unlock()
+    rc.lists[list][identifier] = entry
+    return nil
+}
+
+// RemoveList takes identifier off list
+func (rc *RateLimitControls) RemoveList(ctx context.Context, list, identifier string) error {
+    if list != ListAllow && list != ListDeny {
+        return ErrUnknownList
+    }
+    if err := rc.remove(ctx, list, identifier); err != nil {
+        return err
+    }
+
+    rc.mu.Lock()
+    defer rc.mu.Unlock()
+    delete(rc.lists[list], identifier)
+    return nil
+}
+
+// SetOverride replaces the global limit of identifier until the override expires
+func (rc *RateLimitControls) SetOverride(ctx context.Context, identifier string, override Override) error {
+    if !ValidBucketIdentifier(identifier) {
+        return ErrInvalidBucketIdentifier
+    }
+    override.ExpiresAt = override.ExpiresAt.UTC()
+    if err := rc.store(ctx, "overrides", identifier, override); err != nil {
+        return err
+    }
+
+    rc.mu.Lock()
+    defer rc.mu.Unlock()
+    rc.overrides[identifier] = override
+    delete(rc.limiters, identifier)
+    return nil
+}
+
+// RemoveOverride restores the global limit of identifier
+func (rc *RateLimitControls) RemoveOverride(ctx context.Context, identifier string) error {
+    if err := rc.remove(ctx, "overrides", identifier); err != nil {
+        return err
+    }
+
+    rc.mu.Lock()
+    defer rc.mu.Unlock()
+    delete(rc.overrides, identifier)
+    delete(rc.limiters, identifier)
+    return nil
+}
+
+func (rc *RateLimitControls) store(ctx context.Context, name, identifier string, value any) error {
+    if rc.redis == nil {
+        return nil
+    }
+    raw, err := json.Marshal(value)
+    if err != nil {
+        return err
+    }
+    if err := rc.redis.Client.HSet(ctx, controlsKeyPrefix+":"+name, identifier, raw).Err(); err != nil {
+        return fmt.Errorf("failed to store rate limit control: %w", err)
+    }
+    return nil
+}
+
+func (rc *RateLimitControls) remove(ctx context.Context, name, identifier string) error {
+    if rc.redis == nil {
+        return nil
+    }
+    if err := rc.redis.Client.HDel(ctx, controlsKeyPrefix+":"+name, identifier).Err(); err != nil {
+        return fmt.Errorf("failed to remove rate limit control: %w", err)
+    }
+    return nil
+}
+
+// Lists returns the live entries of both lists
+func (rc *RateLimitControls) Lists() map[string]map[string]ListEntry {
+    rc.mu.RLock()
+    defer rc.mu.RUnlock()
+    now := rc.now()
+    lists := make(map[string]map[string]ListEntry, len(rc.lists))
+    for list, entries := range rc.lists {
+        lists[list] = make(map[string]ListEntry, len(entries))
+        for identifier, entry := range entries {
+            if entry.ExpiresAt.IsZero() || now.Before(entry.ExpiresAt) {
+                lists[list][identifier] = entry
+            }
+        }
+    }
+    return lists
+}
+
+// GetOverride returns the live override of identifier
+func (rc *RateLimitControls) GetOverride(identifier string) (Override, bool) {
+    rc.mu.RLock()
+    defer rc.mu.RUnlock()
+    override, ok := rc.overrides[identifier]
+    if !ok || !rc.now().Before(override.ExpiresAt) {
+        return Override{}, false
+    }
+    return override, true
+}
+
+// listed reports whether identifier is live on list
+func (rc *RateLimitControls) listed(list, identifier string) bool {
+    rc.mu.RLock()
+    entry, ok := rc.lists[list][identifier]
+    rc.mu.RUnlock()
+    return ok && (entry.ExpiresAt.IsZero() || rc.now().Before(entry.ExpiresAt))
+}
+
+// Middleware rejects denylisted clients and exempts allowlisted ones from
+// every limiter after it. It checks the client IP and, once authentication
+// has run, the user and workspace, so it is mounted before the first
+// limiter and again after authentication.
+func (rc *RateLimitControls) Middleware() gin.HandlerFunc {
+    return func(c *gin.Context) {
+        identifiers := []string{"ip:" + clientip.FromContext(c)}
+        if userID := contextString(c, "user_id"); userID != "" {
+            identifiers = append(identifiers, "user:"+userID)
+        }
+        if workspaceID := contextString(c, "workspace_id"); workspaceID != "" {
+            identifiers = append(identifiers, "workspace:"+workspaceID)
+        }
+
+        for _, identifier := range identifiers {
+            if rc.listed(ListDeny, identifier) {
+                apierrors.Write(c, http.StatusForbidden, "access_denied", "This client has been blocked")
+                return
+            }
+        }
+        for _, identifier := range identifiers {
+            if rc.listed(ListAllow, identifier) {
+                c.Set(rateLimitExemptKey, true)
+                break
+            }
+        }
+        c.Next()
+    }
+}
+
+// Limiter applies live overrides on top of the global limiter
+func (rc *RateLimitControls) Limiter(base RateLimiter) RateLimiter {
+    return &overridableLimiter{base: base, controls: rc}
+}
+
+type overridableLimiter struct {
+    base     RateLimiter
+    controls *RateLimitControls
+}
+
+func (l *overridableLimiter) Close() error {
+    return closeLimiter(l.base)
+}
+
+func (l *overridableLimiter) Allow(ctx context.Context, key string, cost int) (Decision, error) {
+    limiter, err := l.controls.overrideLimiter(key)
+    if err != nil {
+        return Decision{}, err
+    }
+    if limiter == nil {
+        return l.base.Allow(ctx, key, cost)
+    }
+    return limiter.Allow(ctx, key, cost)
+}
+
+// overrideLimiter returns the limiter of identifier's live override, nil
+// without one. Overrides use GCRA, which keeps no goroutine per limiter.
+func (rc *RateLimitControls) overrideLimiter(identifier string) (RateLimiter, error) {
+    override, ok := rc.GetOverride(identifier)
+    if !ok {
+        return nil, nil
+    }
+
+    rc.mu.Lock()
+    defer rc.mu.Unlock()
+    if limiter, ok := rc.limiters[identifier]; ok {
+        return limiter, nil
+    }
+    limit := Limit{
+        Name:      "override",
+        Requests:  override.RequestsPerMinute,
+        Burst:     override.Burst,
+        Period:    time.Minute,
+        Algorithm: AlgorithmGCRA,
+    }
+    // GCRA starts no cleanup, so the limiter needs no context to stop it
+    limits, err := compileLimits(context.Background(), rc.redis, "override", "rate_limit:override", []Limit{limit}, 1, rc.resilience)
+    if err != nil {
+        return nil, err
+    }
+    rc.limiters[identifier] = limits[0].limiter
+    return limits[0].limiter, nil
+}
+
+// exempt reports whether the controls allowlisted the request
+func exempt(c *gin.Context) bool {
+    return c.GetBool(rateLimitExemptKey)
+}
\ No newline at end of file
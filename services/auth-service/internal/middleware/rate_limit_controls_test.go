@@ -0,0 +1,189 @@
+package middleware
+
+import (
+    "context"
+    "net/http"
+    "net/http/httptest"
+    "testing"
+    "time"
+
+    "github.com/alicebob/miniredis/v2"
+    "github.com/gin-gonic/gin"
+    "github.com/redis/go-redis/v9"
+
+    "github.com/Shridhar2104/chat-platform/shared/database"
+)
+
+// newControlsRouter limits every client IP to one request per long while,
+// unless the controls say otherwise
+func newControlsRouter(t *testing.T, controls *RateLimitControls) *gin.Engine {
+    bucket := NewBoundedMemoryTokenBucket(t.Context(), 1, 0.001, 1, DefaultMemoryStoreConfig())
+    gin.SetMode(gin.TestMode)
+    router := gin.New()
+    router.Use(controls.Middleware(), RateLimitMiddleware(controls.Limiter(bucket), "memory"))
+    router.GET("/", func(c *gin.Context) { c.Status(http.StatusOK) })
+    return router
+}
+
+func getFrom(router http.Handler, ip string) *httptest.ResponseRecorder {
+    req := httptest.NewRequest(http.MethodGet, "/", nil)
+    req.RemoteAddr = ip + ":1234"
+    rec := httptest.NewRecorder()
+    router.ServeHTTP(rec, req)
+    return rec
+}
+
+func TestRateLimitControlsAllowAndDenyLists(t *testing.T) {
+    controls := NewRateLimitControls(nil, time.Second, Resilience{})
+    router := newControlsRouter(t, controls)
+    ctx := context.Background()
+
+    if err := controls.SetList(ctx, ListAllow, "ip:10.0.0.2", 0); err != nil {
+        t.Fatal(err)
+    }
+    if err := controls.SetList(ctx, ListDeny, "ip:10.0.0.3", time.Hour); err != nil {
+        t.Fatal(err)
+    }
+    if err := controls.SetList(ctx, "maybe", "ip:10.0.0.4", 0); err != ErrUnknownList {
+        t.Errorf("unknown list = %v", err)
+    }
+    if err := controls.SetList(ctx, ListDeny, "10.0.0.4", 0); err != ErrInvalidIdentifier {
+        t.Errorf("bare address = %v", err)
+    }
+
+    for i, want := range []int{http.StatusOK, http.StatusTooManyRequests} {
+        if got := getFrom(router, "10.0.0.1").Code; got != want {
+            t.Errorf("unlisted request %d = %d, want %d", i+1, got, want)
+        }
+    }
+    for i := 0; i < 3; i++ {
+        rec := getFrom(router, "10.0.0.2")
+        if rec.Code != http.StatusOK || rec.Header().Get("RateLimit") != "" {
+            t.Errorf("allowlisted request %d = %d, want no limit at all", i+1, rec.Code)
+        }
+    }
+    if got := getFrom(router, "10.0.0.3").Code; got != http.StatusForbidden {
+        t.Errorf("denylisted request = %d, want 403", got)
+    }
+
+    if err := controls.RemoveList(ctx, ListDeny, "ip:10.0.0.3"); err != nil {
+        t.Fatal(err)
+    }
+    if got := getFrom(router, "10.0.0.3").Code; got != http.StatusOK {
+        t.Errorf("request after removal = %d", got)
+    }
+}
+
+func TestRateLimitControlsOverride(t *testing.T) {
+    controls := NewRateLimitControls(nil, time.Second, Resilience{})
+    clock := &fakeClock{now: time.Now()}
+    controls.now = clock.Now
+    router := newControlsRouter(t, controls)
+
+    override := Override{RequestsPerMinute: 60, Burst: 3, ExpiresAt: clock.Now().Add(time.Minute)}
+    if err := controls.SetOverride(context.Background(), "ip:10.0.0.1", override); err != nil {
+        t.Fatal(err)
+    }
+    for i, want := range []int{http.StatusOK, http.StatusOK, http.StatusOK, http.StatusTooManyRequests} {
+        if got := getFrom(router, "10.0.0.1").Code; got != want {
+            t.Errorf("overridden request %d = %d, want %d", i+1, got, want)
+        }
+    }
+
+    // An expired override falls back to the global bucket, still full
+    clock.Advance(time.Minute)
+    for i, want := range []int{http.StatusOK, http.StatusTooManyRequests} {
+        if got := getFrom(router, "10.0.0.1").Code; got != want {
+            t.Errorf("request %d after expiry = %d, want %d", i+1, got, want)
+        }
+    }
+}
+
+func TestRateLimitControlsOverrideOnlyKeysOnIP(t *testing.T) {
+    controls := NewRateLimitControls(nil, time.Second, Resilience{})
+    override := Override{RequestsPerMinute: 60, ExpiresAt: time.Now().Add(time.Minute)}
+
+    // The global limiter runs before authentication, so user and workspace
+    // overrides would be accepted and never applied
+    for _, identifier := range []string{"user:alice", "workspace:acme", "ip:", "10.0.0.1"} {
+        if err := controls.SetOverride(context.Background(), identifier, override); err != ErrInvalidBucketIdentifier {
+            t.Errorf("SetOverride(%s) = %v, want ErrInvalidBucketIdentifier", identifier, err)
+        }
+        if _, ok := controls.GetOverride(identifier); ok {
+            t.Errorf("override for %s was stored", identifier)
+        }
+    }
+}
+
+func TestRateLimitControlsSharedThroughRedis(t *testing.T) {
+    server := miniredis.RunT(t)
+    client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+    t.Cleanup(func() { client.Close() })
+    store := &database.RedisClient{Client: client}
+    ctx := context.Background()
+
+    writer := NewRateLimitControls(store, time.Second, Resilience{})
+    reader := NewRateLimitControls(store, time.Second, Resilience{})
+    clock := &fakeClock{now: time.Now()}
+    writer.now, reader.now = clock.Now, clock.Now
+
+    if err := writer.SetList(ctx, ListDeny, "user:alice", time.Minute); err != nil {
+        t.Fatal(err)
+    }
+    if err := writer.SetOverride(ctx, "ip:10.0.0.9", Override{RequestsPerMinute: 10, ExpiresAt: clock.Now().Add(time.Hour)}); err != nil {
+        t.Fatal(err)
+    }
+
+    // Another replica picks both up on its next reload
+    if err := reader.Reload(ctx); err != nil {
+        t.Fatalf("Reload failed: %v", err)
+    }
+    if !reader.listed(ListDeny, "user:alice") {
+        t.Error("denylist entry not shared")
+    }
+    if override, ok := reader.GetOverride("ip:10.0.0.9"); !ok || override.RequestsPerMinute != 10 {
+        t.Errorf("override = %+v, %v", override, ok)
+    }
+
+    // Expired entries are dropped and removed from Redis
+    clock.Advance(time.Minute)
+    if err := reader.Reload(ctx); err != nil {
+        t.Fatalf("Reload failed: %v", err)
+    }
+    if reader.listed(ListDeny, "user:alice") {
+        t.Error("expired denylist entry still applied")
+    }
+    if fields, _ := client.HKeys(ctx, controlsKeyPrefix+":"+ListDeny).Result(); len(fields) != 0 {
+        t.Errorf("expired entries left in Redis: %v", fields)
+    }
+}
+
+func TestRateLimitHeaders(t *testing.T) {
+    limiter := NewBoundedMemoryTokenBucket(t.Context(), 2, 2.0/60, 1, DefaultMemoryStoreConfig())
+    gin.SetMode(gin.TestMode)
+    router := gin.New()
+    router.Use(RateLimitMiddleware(limiter, "memory"))
+    router.GET("/", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+    rec := getFrom(router, "10.0.0.1")
+    if got := rec.Header().Get("RateLimit-Policy"); got != `"global";q=2;w=60` {
+        t.Errorf("RateLimit-Policy = %q", got)
+    }
+    if got := rec.Header().Get("RateLimit"); got != `"global";r=1;t=30` {
+        t.Errorf("RateLimit = %q", got)
+    }
+    if rec.Header().Get("X-RateLimit-Limit") != "2" || rec.Header().Get("Retry-After") != "" {
+        t.Errorf("allowed request headers = %v", rec.Header())
+    }
+
+    SetLegacyHeaders(false)
+    t.Cleanup(func() { SetLegacyHeaders(true) })
+    getFrom(router, "10.0.0.1")
+    rec = getFrom(router, "10.0.0.1")
+    if rec.Code != http.StatusTooManyRequests || rec.Header().Get("Retry-After") != "30" {
+        t.Errorf("denied = %d Retry-After=%q, want 429 after 30s", rec.Code, rec.Header().Get("Retry-After"))
+    }
+    if rec.Header().Get("X-RateLimit-Limit") != "" {
+        t.Error("legacy headers sent while disabled")
+    }
+}
\ No newline at end of file
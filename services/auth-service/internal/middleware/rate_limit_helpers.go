@@ -2,10 +2,16 @@ package middleware
 
 import (
     "fmt"
+    "math"
+    "net/http"
     "strconv"
+    "sync/atomic"
     "time"
 
     "github.com/gin-gonic/gin"
+
+    "github.com/Shridhar2104/chat-platform/auth-service/internal/clientip"
+    "github.com/Shridhar2104/chat-platform/auth-service/internal/apierrors"
 )
 
 // getIdentifier determines the rate limiting identifier
@@ -14,19 +20,87 @@ func getIdentifier(c *gin.Context) string {
     if userID := c.GetString("user_id"); userID != "" {
         return fmt.Sprintf("user:%s", userID)
     }
-    return fmt.Sprintf("ip:%s", c.ClientIP())
+    return fmt.Sprintf("ip:%s", clientip.FromContext(c))
+}
+
+// legacyHeaders keeps the X-RateLimit-* headers next to the IETF ones
+var legacyHeaders atomic.Bool
+
+func init() {
+    legacyHeaders.Store(true)
+}
+
+// SetLegacyHeaders turns the X-RateLimit-* headers on or off, the IETF
+// RateLimit and RateLimit-Policy headers are always sent
+func SetLegacyHeaders(enabled bool) {
+    legacyHeaders.Store(enabled)
+}
+
+// setRateLimitHeaders describes a decision with the IETF RateLimit-Policy
+// (quota and window) and RateLimit (remaining and reset) fields
+func setRateLimitHeaders(c *gin.Context, policy string, decision Decision) {
+    reset := decision.ResetAfter
+    if !decision.Allowed {
+        reset = decision.RetryAfter
+    }
+
+    quota := fmt.Sprintf("%q;q=%d", policy, decision.Limit)
+    if decision.Window > 0 {
+        quota += fmt.Sprintf(";w=%d", ceilSeconds(decision.Window))
+    }
+    c.Header("RateLimit-Policy", quota)
+    c.Header("RateLimit", fmt.Sprintf("%q;r=%d;t=%d", policy, decision.Remaining, ceilSeconds(reset)))
+
+    if legacyHeaders.Load() {
+        c.Header("X-RateLimit-Limit", strconv.Itoa(decision.Limit))
+        c.Header("X-RateLimit-Remaining", strconv.Itoa(decision.Remaining))
+        c.Header("X-RateLimit-Reset", strconv.FormatInt(time.Now().Add(reset).Unix(), 10))
+        c.Header("X-RateLimit-Type", "token-bucket")
+    }
 }
 
-// setRateLimitHeaders sets standard rate limiting headers
-func setRateLimitHeaders(c *gin.Context, limit int, remaining int, resetTime time.Time) {
-    c.Header("X-RateLimit-Limit", strconv.Itoa(limit))
-    c.Header("X-RateLimit-Remaining", strconv.Itoa(remaining))
-    c.Header("X-RateLimit-Reset", strconv.FormatInt(resetTime.Unix(), 10))
-    c.Header("X-RateLimit-Type", "token-bucket")
+// writeRateLimited rejects the request with 429 and when to retry
+func writeRateLimited(c *gin.Context, retryAfter time.Duration) {
+    c.Header("Retry-After", strconv.FormatInt(max(1, ceilSeconds(retryAfter)), 10))
+    apierrors.Write(c, http.StatusTooManyRequests, "rate_limit_exceeded", fmt.Sprintf("Rate limit exceeded. Try again in %.2f seconds.", retryAfter.Seconds()))
+}
+
+func ceilSeconds(d time.Duration) int64 {
+    return int64(math.Ceil(d.Seconds()))
 }
 
 // getEndpointIdentifier creates endpoint-specific identifiers
 func getEndpointIdentifier(c *gin.Context, baseIdentifier string) string {
     endpoint := c.FullPath()
     return fmt.Sprintf("%s:%s", baseIdentifier, endpoint)
+}
+
+// bucketLimits is swapped atomically so limits can change while serving
+type bucketLimits struct {
+    capacity   int
+    refillRate float64
+}
+
+// window is how long an empty bucket takes to refill
+func (l *bucketLimits) window() time.Duration {
+    return time.Duration(float64(l.capacity) / l.refillRate * float64(time.Second))
+}
+
+type limitsHolder struct {
+    limits atomic.Pointer[bucketLimits]
+}
+
+func (h *limitsHolder) load() *bucketLimits {
+    return h.limits.Load()
+}
+
+// SetLimits applies a new capacity and refill rate (tokens per second) to
+// every bucket from the next request on
+func (h *limitsHolder) SetLimits(capacity int, refillRate float64) {
+    h.limits.Store(&bucketLimits{capacity: capacity, refillRate: refillRate})
+}
+
+// Capacity returns the current bucket capacity
+func (h *limitsHolder) Capacity() int {
+    return h.load().capacity
 }
\ No newline at end of file
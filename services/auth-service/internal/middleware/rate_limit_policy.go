@@ -0,0 +1,561 @@
+package middleware
+
+import (
+    "bytes"
+    "context"
+    "crypto/sha256"
+    "encoding/hex"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "io"
+    "os"
+    "strings"
+    "time"
+
+    "github.com/gin-gonic/gin"
+    "gopkg.in/yaml.v3"
+
+    "github.com/Shridhar2104/chat-platform/auth-service/internal/clientip"
+    "github.com/Shridhar2104/chat-platform/auth-service/internal/metrics"
+    "github.com/Shridhar2104/chat-platform/shared/database"
+)
+
+// Key parts a limit can be keyed on. Email and device are read from the
+// JSON request body, user and workspace from the authenticated context and
+// so only by tiers.
+const (
+    KeyIP        = "ip"
+    KeyEmail     = "email"
+    KeyDevice    = "device"
+    KeyUser      = "user"
+    KeyWorkspace = "workspace"
+    KeyRoute     = "route"
+)
+
+// maxPeekBytes bounds how much of a request body is read to find key fields
+const maxPeekBytes = 64 << 10
+
+// Limit allows Requests per Period for each distinct key. Burst is the
+// bucket capacity and defaults to Requests. Algorithm defaults to
+// token_bucket; for concurrency Requests is the number in flight and Period
+// the optional lease lifetime.
+type Limit struct {
+    Name      string        `yaml:"name"`
+    Algorithm string        `yaml:"algorithm"`
+    Requests  int           `yaml:"requests"`
+    Period    time.Duration `yaml:"period"`
+    Burst     int           `yaml:"burst"`
+    Key       []string      `yaml:"key"`
+}
+
+func (l Limit) algorithm() string {
+    if l.Algorithm == "" {
+        return AlgorithmTokenBucket
+    }
+    return l.Algorithm
+}
+
+// capacity is the largest cost a single request can have
+func (l Limit) capacity() int {
+    switch {
+    case l.algorithm() == AlgorithmSlidingWindow || l.algorithm() == AlgorithmConcurrency:
+        return l.Requests
+    case l.Burst > 0:
+        return l.Burst
+    default:
+        return l.Requests
+    }
+}
+
+// perReplica is one replica's share of the limit for the local fallback.
+// The capacity still fits minCapacity so weighted requests stay possible.
+func (l Limit) perReplica(replicas, minCapacity int) Limit {
+    l.Requests = PerReplica(l.Requests, replicas)
+    if l.Burst > 0 {
+        l.Burst = PerReplica(l.Burst, replicas)
+    }
+    if l.capacity() < minCapacity {
+        if l.algorithm() == AlgorithmSlidingWindow || l.algorithm() == AlgorithmConcurrency {
+            l.Requests = minCapacity
+        } else {
+            l.Burst = minCapacity
+        }
+    }
+    return l
+}
+
+// Policy applies its limits to matching routes. Routes are "METHOD /path"
+// or "/path" using the Gin route pattern, and a trailing /* matches the
+// whole group. Each request costs Cost tokens unless Costs names the route.
+type Policy struct {
+    Name   string         `yaml:"name"`
+    Routes []string       `yaml:"routes"`
+    Cost   int            `yaml:"cost"`
+    Costs  map[string]int `yaml:"costs"`
+    Limits []Limit        `yaml:"limits"`
+}
+
+// DefaultPolicies protect the credential endpoints when no policy file is configured
+func DefaultPolicies() []Policy {
+    return []Policy{
+        {
+            Name:   "login",
+            Routes: []string{"POST /api/v1/auth/login"},
+            Limits: []Limit{{Name: "ip-email", Requests: 5, Period: time.Minute, Key: []string{KeyIP, KeyEmail}}},
+        },
+        {
+            Name:   "register",
+            Routes: []string{"POST /api/v1/auth/register"},
+            Limits: []Limit{{Name: "ip", Requests: 3, Period: time.Hour, Key: []string{KeyIP}}},
+        },
+        {
+            Name:   "refresh",
+            Routes: []string{"POST /api/v1/auth/refresh"},
+            Limits: []Limit{{Name: "device", Requests: 30, Period: time.Minute, Key: []string{KeyDevice}}},
+        },
+        {
+            Name:   "password-reset",
+            Routes: []string{"POST /api/v1/auth/forgot-password", "POST /api/v1/auth/reset-password"},
+            Limits: []Limit{
+                {Name: "ip", Requests: 10, Period: time.Hour, Key: []string{KeyIP}},
+                {Name: "email", Requests: 3, Period: time.Hour, Key: []string{KeyEmail}},
+            },
+        },
+    }
+}
+
+// PolicyFile is the YAML rate limit configuration: per-route policies and
+// the quotas of each subscription tier
+type PolicyFile struct {
+    Policies    []Policy `yaml:"policies"`
+    Tiers       []Tier   `yaml:"tiers"`
+    DefaultTier string   `yaml:"default_tier"`
+}
+
+// LoadPolicyFile reads and validates a policy file. Tiers default to
+// DefaultTiers when the file has none.
+func LoadPolicyFile(path string) (*PolicyFile, error) {
+    data, err := os.ReadFile(path)
+    if err != nil {
+        return nil, fmt.Errorf("failed to read rate limit policies: %w", err)
+    }
+
+    var file PolicyFile
+    decoder := yaml.NewDecoder(bytes.NewReader(data))
+    decoder.KnownFields(true)
+    if err := decoder.Decode(&file); err != nil {
+        return nil, fmt.Errorf("failed to parse rate limit policies %s: %w", path, err)
+    }
+    if len(file.Tiers) == 0 {
+        file.Tiers = DefaultTiers()
+        if file.DefaultTier == "" {
+            file.DefaultTier = DefaultTier
+        }
+    }
+    if err := errors.Join(ValidatePolicies(file.Policies), ValidateTiers(file.Tiers, file.DefaultTier)); err != nil {
+        return nil, fmt.Errorf("invalid rate limit policies %s: %w", path, err)
+    }
+    return &file, nil
+}
+
+// ValidatePolicies reports every malformed policy together
+func ValidatePolicies(policies []Policy) error {
+    var errs []error
+    names := make(map[string]bool)
+    for i, policy := range policies {
+        if policy.Name == "" {
+            errs = append(errs, fmt.Errorf("policy %d: name is required", i))
+        } else if names[policy.Name] {
+            errs = append(errs, fmt.Errorf("policy %s: duplicate name", policy.Name))
+        }
+        names[policy.Name] = true
+
+        if len(policy.Routes) == 0 {
+            errs = append(errs, fmt.Errorf("policy %s: at least one route is required", policy.Name))
+        }
+        if policy.Cost < 0 {
+            errs = append(errs, fmt.Errorf("policy %s: cost must not be negative", policy.Name))
+        }
+        for route, cost := range policy.Costs {
+            if cost <= 0 {
+                errs = append(errs, fmt.Errorf("policy %s: cost for %s must be positive", policy.Name, route))
+            }
+        }
+        if len(policy.Limits) == 0 {
+            errs = append(errs, fmt.Errorf("policy %s: at least one limit is required", policy.Name))
+        }
+
+        errs = append(errs, validateLimits("policy "+policy.Name, policy.Limits, policy.maxCost())...)
+        // Policies run before authentication, where every caller would
+        // share the bucket of an empty user or workspace
+        for _, limit := range policy.Limits {
+            for _, part := range limit.Key {
+                if part == KeyUser || part == KeyWorkspace {
+                    errs = append(errs, fmt.Errorf("policy %s limit %s: key part %q is only known after authentication, use a tier", policy.Name, limit.Name, part))
+                }
+            }
+        }
+    }
+    return errors.Join(errs...)
+}
+
+// validateLimits checks the limits of one policy or tier, owner names it in errors
+func validateLimits(owner string, limits []Limit, maxCost int) []error {
+    var errs []error
+    limitNames := make(map[string]bool)
+    for j, limit := range limits {
+        label := fmt.Sprintf("%s limit %d", owner, j)
+        if limit.Name != "" {
+            label = fmt.Sprintf("%s limit %s", owner, limit.Name)
+        }
+        if limit.Name == "" || limitNames[limit.Name] {
+            errs = append(errs, fmt.Errorf("%s: a unique name is required", label))
+        }
+        limitNames[limit.Name] = true
+        switch limit.algorithm() {
+        case AlgorithmTokenBucket, AlgorithmGCRA, AlgorithmSlidingWindow:
+            if limit.Requests <= 0 || limit.Period <= 0 {
+                errs = append(errs, fmt.Errorf("%s: requests and period must be positive", label))
+            }
+        case AlgorithmConcurrency:
+            if limit.Requests <= 0 || limit.Period < 0 {
+                errs = append(errs, fmt.Errorf("%s: requests must be positive and the lease period not negative", label))
+            }
+        default:
+            errs = append(errs, fmt.Errorf("%s: unknown algorithm %q", label, limit.Algorithm))
+        }
+        if limit.Burst < 0 {
+            errs = append(errs, fmt.Errorf("%s: burst must not be negative", label))
+        }
+        if maxCost > limit.capacity() {
+            errs = append(errs, fmt.Errorf("%s: cost %d exceeds the bucket capacity", label, maxCost))
+        }
+        if len(limit.Key) == 0 {
+            errs = append(errs, fmt.Errorf("%s: at least one key part is required", label))
+        }
+        for _, part := range limit.Key {
+            switch part {
+            case KeyIP, KeyEmail, KeyDevice, KeyUser, KeyWorkspace, KeyRoute:
+            default:
+                errs = append(errs, fmt.Errorf("%s: unknown key part %q", label, part))
+            }
+        }
+    }
+    return errs
+}
+
+type policyLimit struct {
+    Limit
+    limiter RateLimiter
+    // headerName names the limit in the RateLimit headers, owner.limit
+    headerName string
+}
+
+type compiledPolicy struct {
+    Policy
+    limits []policyLimit
+    // label names the policy in metrics
+    label string
+    // keyed limits are skipped when a key part is missing rather than
+    // sharing one bucket between everyone lacking it
+    keyed bool
+}
+
+// PolicyLimiter applies every policy matching a request; the request is
+// rejected when any of their limits is exhausted
+type PolicyLimiter struct {
+    policies []compiledPolicy
+}
+
+// NewPolicyLimiter builds a limiter per policy limit, in memory when redis
+// is nil. Redis limiters follow resilience while Redis is unavailable, and
+// in-memory ones clean up until ctx is done.
+func NewPolicyLimiter(ctx context.Context, redis *database.RedisClient, policies []Policy, resilience Resilience) (*PolicyLimiter, error) {
+    limiter := &PolicyLimiter{}
+    for _, policy := range policies {
+        limits, err := compileLimits(ctx, redis, policy.Name, "rate_limit:policy:"+policy.Name, policy.Limits, policy.maxCost(), resilience)
+        if err != nil {
+            return nil, fmt.Errorf("policy %s %w", policy.Name, err)
+        }
+        limiter.policies = append(limiter.policies, compiledPolicy{Policy: policy, limits: limits, label: "policy:" + policy.Name})
+    }
+    return limiter, nil
+}
+
+// compileLimits builds the limiters of one policy or tier under keyPrefix
+func compileLimits(ctx context.Context, redis *database.RedisClient, owner, keyPrefix string, limits []Limit, maxCost int, resilience Resilience) ([]policyLimit, error) {
+    var compiled []policyLimit
+    for _, limit := range limits {
+        prefix := keyPrefix + ":" + limit.Name
+        rateLimiter, err := NewLimiter(ctx, redis, prefix, limit)
+        if err != nil {
+            return nil, fmt.Errorf("limit %s: %w", limit.Name, err)
+        }
+        if redis != nil {
+            fallback, err := NewLimiter(ctx, nil, prefix, limit.perReplica(resilience.Replicas, maxCost))
+            if err != nil {
+                return nil, fmt.Errorf("limit %s: %w", limit.Name, err)
+            }
+            rateLimiter = NewResilientLimiter(rateLimiter, fallback, resilience)
+        }
+        compiled = append(compiled, policyLimit{Limit: limit, limiter: rateLimiter, headerName: owner + "." + limit.Name})
+    }
+    return compiled, nil
+}
+
+// Close stops the background work of every policy limit
+func (l *PolicyLimiter) Close() error {
+    var errs []error
+    for i := range l.policies {
+        errs = append(errs, l.policies[i].close())
+    }
+    return errors.Join(errs...)
+}
+
+func (p *compiledPolicy) close() error {
+    var errs []error
+    for _, limit := range p.limits {
+        errs = append(errs, closeLimiter(limit.limiter))
+    }
+    return errors.Join(errs...)
+}
+
+// Middleware enforces the policies matching the route
+func (l *PolicyLimiter) Middleware() gin.HandlerFunc {
+    return func(c *gin.Context) {
+        route := c.FullPath()
+        if route == "" {
+            c.Next()
+            return
+        }
+
+        var matched []matchedPolicy
+        for i := range l.policies {
+            if cost, ok := l.policies[i].match(c.Request.Method, route); ok {
+                matched = append(matched, matchedPolicy{policy: &l.policies[i], cost: cost})
+            }
+        }
+        enforce(c, matched)
+    }
+}
+
+type matchedPolicy struct {
+    policy *compiledPolicy
+    cost   int
+}
+
+// enforce checks every limit of the matched policies in order and rejects
+// the request at the first exhausted one. Limiter errors fail open,
+// concurrency slots are released once the request finishes and
+// allowlisted requests are not limited at all.
+func enforce(c *gin.Context, matched []matchedPolicy) {
+    if exempt(c) {
+        c.Next()
+        return
+    }
+    type held struct {
+        releaser   Releaser
+        identifier string
+        decision   Decision
+    }
+    var holds []held
+    defer func() {
+        // Released with a fresh context, the request's may be cancelled
+        for _, h := range holds {
+            if err := h.releaser.Release(context.WithoutCancel(c.Request.Context()), h.identifier, h.decision); err != nil {
+                metrics.RateLimitDecision("concurrency", "error")
+            }
+        }
+    }()
+
+    var body map[string]any
+    bodyRead := false
+    var strictest *Decision
+    var strictestName string
+
+    for _, match := range matched {
+        policy := match.policy
+        for _, limit := range policy.limits {
+            if policy.keyed && !hasKeyValues(c, limit.Key) {
+                continue
+            }
+            if !bodyRead && needsBody(limit.Key) {
+                body = peekJSONBody(c)
+                bodyRead = true
+            }
+
+            identifier := limitIdentifier(c, limit.Key, body)
+            decision, err := limit.limiter.Allow(c.Request.Context(), identifier, match.cost)
+            if err != nil {
+                metrics.RateLimitDecision(policy.label, "error")
+                continue
+            }
+
+            if !decision.Allowed {
+                metrics.RateLimitDecision(policy.label, "denied")
+                if decision.Limit > 0 {
+                    setRateLimitHeaders(c, limit.headerName, decision)
+                }
+                writeRateLimited(c, decision.RetryAfter)
+                return
+            }
+            metrics.RateLimitDecision(policy.label, "allowed")
+
+            if releaser, ok := limit.limiter.(Releaser); ok {
+                holds = append(holds, held{releaser: releaser, identifier: identifier, decision: decision})
+            }
+            if decision.Limit > 0 && (strictest == nil || decision.Remaining < strictest.Remaining) {
+                strictest, strictestName = &decision, limit.headerName
+            }
+        }
+    }
+
+    // The headers describe the limit closest to being exhausted
+    if strictest != nil {
+        setRateLimitHeaders(c, strictestName, *strictest)
+    }
+    c.Next()
+}
+
+// maxCost is the most a single request can cost under the policy
+func (p Policy) maxCost() int {
+    cost := max(1, p.Cost)
+    for _, c := range p.Costs {
+        cost = max(cost, c)
+    }
+    return cost
+}
+
+// match reports whether the policy covers the route and what the request costs
+func (p compiledPolicy) match(method, route string) (int, bool) {
+    matched := false
+    for _, pattern := range p.Routes {
+        if routeMatches(pattern, method, route) {
+            matched = true
+            break
+        }
+    }
+    if !matched {
+        return 0, false
+    }
+
+    if cost, ok := p.Costs[method+" "+route]; ok {
+        return cost, true
+    }
+    if cost, ok := p.Costs[route]; ok {
+        return cost, true
+    }
+    if p.Cost > 0 {
+        return p.Cost, true
+    }
+    return 1, true
+}
+
+func routeMatches(pattern, method, route string) bool {
+    if patternMethod, path, ok := strings.Cut(pattern, " "); ok {
+        if !strings.EqualFold(patternMethod, method) {
+            return false
+        }
+        pattern = path
+    }
+    if prefix, ok := strings.CutSuffix(pattern, "/*"); ok {
+        return route == prefix || strings.HasPrefix(route, prefix+"/")
+    }
+    return pattern == route
+}
+
+func needsBody(key []string) bool {
+    for _, part := range key {
+        if part == KeyEmail || part == KeyDevice {
+            return true
+        }
+    }
+    return false
+}
+
+// peekJSONBody decodes the start of a JSON body and puts it back for the handler
+func peekJSONBody(c *gin.Context) map[string]any {
+    if c.Request.Body == nil {
+        return nil
+    }
+    peeked, err := io.ReadAll(io.LimitReader(c.Request.Body, maxPeekBytes))
+    c.Request.Body = readCloser{io.MultiReader(bytes.NewReader(peeked), c.Request.Body), c.Request.Body}
+    if err != nil {
+        return nil
+    }
+
+    var body map[string]any
+    if json.Unmarshal(peeked, &body) != nil {
+        return nil
+    }
+    return body
+}
+
+type readCloser struct {
+    io.Reader
+    io.Closer
+}
+
+// limitIdentifier joins the key parts. Without a route part a limit is
+// shared by every route of its policy. Body values are hashed so emails do
+// not end up in Redis keys; missing values share one bucket per key part.
+func limitIdentifier(c *gin.Context, key []string, body map[string]any) string {
+    parts := make([]string, 0, len(key))
+    for _, part := range key {
+        var value string
+        switch part {
+        case KeyIP:
+            value = clientip.FromContext(c)
+        case KeyUser:
+            value = contextString(c, "user_id")
+        case KeyWorkspace:
+            value = contextString(c, "workspace_id")
+        case KeyRoute:
+            value = getEndpointIdentifier(c, c.Request.Method)
+        case KeyEmail:
+            email, _ := body["email"].(string)
+            value = hashKeyValue(strings.ToLower(strings.TrimSpace(email)))
+        case KeyDevice:
+            device, _ := body["device_id"].(string)
+            value = hashKeyValue(device)
+        }
+        parts = append(parts, part+"="+value)
+    }
+    return strings.Join(parts, "|")
+}
+
+// hasKeyValues reports whether the caller is known for every context key part
+func hasKeyValues(c *gin.Context, key []string) bool {
+    for _, part := range key {
+        switch {
+        case part == KeyUser && contextString(c, "user_id") == "":
+            return false
+        case part == KeyWorkspace && contextString(c, "workspace_id") == "":
+            return false
+        }
+    }
+    return true
+}
+
+// contextString reads a value set by an authentication middleware, which
+// may store IDs as strings or as UUIDs
+func contextString(c *gin.Context, key string) string {
+    value, _ := c.Get(key)
+    switch v := value.(type) {
+    case string:
+        return v
+    case fmt.Stringer:
+        return v.String()
+    default:
+        return ""
+    }
+}
+
+func hashKeyValue(value string) string {
+    if value == "" {
+        return ""
+    }
+    sum := sha256.Sum256([]byte(value))
+    return hex.EncodeToString(sum[:12])
+}
\ No newline at end of file
@@ -0,0 +1,239 @@
+package middleware
+
+import (
+    "io"
+    "net/http"
+    "net/http/httptest"
+    "os"
+    "path/filepath"
+    "strings"
+    "testing"
+    "time"
+
+    "github.com/alicebob/miniredis/v2"
+    "github.com/gin-gonic/gin"
+    "github.com/redis/go-redis/v9"
+
+    "github.com/Shridhar2104/chat-platform/shared/database"
+)
+
+func newPolicyRouter(t *testing.T, redis *database.RedisClient, policies []Policy) *gin.Engine {
+    t.Helper()
+    limiter, err := NewPolicyLimiter(t.Context(), redis, policies, Resilience{})
+    if err != nil {
+        t.Fatalf("failed to build policy limiter: %v", err)
+    }
+    gin.SetMode(gin.TestMode)
+    router := gin.New()
+    router.Use(limiter.Middleware())
+    echo := func(c *gin.Context) {
+        // Handlers still see the body the limiter peeked at
+        body, _ := io.ReadAll(c.Request.Body)
+        c.String(http.StatusOK, string(body))
+    }
+    router.POST("/api/v1/auth/login", echo)
+    router.POST("/scim/v2/Users", echo)
+    router.GET("/scim/v2/Users", echo)
+    return router
+}
+
+func postJSON(router http.Handler, path, body, ip string) *httptest.ResponseRecorder {
+    req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+    req.Header.Set("Content-Type", "application/json")
+    req.RemoteAddr = ip + ":1234"
+    rec := httptest.NewRecorder()
+    router.ServeHTTP(rec, req)
+    return rec
+}
+
+func TestLoginPolicyKeysOnIPAndEmail(t *testing.T) {
+    router := newPolicyRouter(t, nil, DefaultPolicies())
+    alice := `{"email":"Alice@example.com","password":"x","device_id":"d"}`
+
+    for i := 0; i < 5; i++ {
+        rec := postJSON(router, "/api/v1/auth/login", alice, "10.0.0.1")
+        if rec.Code != http.StatusOK || rec.Body.String() != alice {
+            t.Fatalf("attempt %d = %d %q", i+1, rec.Code, rec.Body.String())
+        }
+    }
+
+    // Email case does not open a new bucket
+    if rec := postJSON(router, "/api/v1/auth/login", strings.ToLower(alice), "10.0.0.1"); rec.Code != http.StatusTooManyRequests {
+        t.Errorf("sixth attempt = %d, want 429", rec.Code)
+    }
+    if rec := postJSON(router, "/api/v1/auth/login", `{"email":"bob@example.com"}`, "10.0.0.1"); rec.Code != http.StatusOK {
+        t.Errorf("another email from the same IP = %d", rec.Code)
+    }
+    if rec := postJSON(router, "/api/v1/auth/login", alice, "10.0.0.2"); rec.Code != http.StatusOK {
+        t.Errorf("the same email from another IP = %d", rec.Code)
+    }
+}
+
+func TestWeightedCostsAndStackedPolicies(t *testing.T) {
+    policies := []Policy{
+        {
+            Name:   "scim",
+            Routes: []string{"/scim/v2/*"},
+            Costs:  map[string]int{"POST /scim/v2/Users": 4},
+            Limits: []Limit{{Name: "ip", Requests: 10, Period: time.Hour, Key: []string{KeyIP}}},
+        },
+        {
+            Name:   "scim-writes",
+            Routes: []string{"POST /scim/v2/Users"},
+            Limits: []Limit{{Name: "ip", Requests: 2, Period: time.Hour, Key: []string{KeyIP}}},
+        },
+    }
+    if err := ValidatePolicies(policies); err != nil {
+        t.Fatalf("policies rejected: %v", err)
+    }
+    router := newPolicyRouter(t, nil, policies)
+
+    // Two writes use 8 of the 10 shared tokens and exhaust the write limit
+    for i := 0; i < 2; i++ {
+        if rec := postJSON(router, "/scim/v2/Users", "{}", "10.0.0.1"); rec.Code != http.StatusOK {
+            t.Fatalf("write %d = %d", i+1, rec.Code)
+        }
+    }
+    if rec := postJSON(router, "/scim/v2/Users", "{}", "10.0.0.1"); rec.Code != http.StatusTooManyRequests {
+        t.Errorf("third write = %d, want 429", rec.Code)
+    }
+
+    read := func() int {
+        req := httptest.NewRequest(http.MethodGet, "/scim/v2/Users", nil)
+        req.RemoteAddr = "10.0.0.1:1234"
+        rec := httptest.NewRecorder()
+        router.ServeHTTP(rec, req)
+        return rec.Code
+    }
+    // Reads cost one token of the two left in the group bucket
+    for i, want := range []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests} {
+        if got := read(); got != want {
+            t.Errorf("read %d = %d, want %d", i+1, got, want)
+        }
+    }
+}
+
+func TestRedisPolicyLimiter(t *testing.T) {
+    server := miniredis.RunT(t)
+    client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+    t.Cleanup(func() { client.Close() })
+
+    router := newPolicyRouter(t, &database.RedisClient{Client: client}, DefaultPolicies())
+    body := `{"email":"alice@example.com"}`
+    for i := 0; i < 5; i++ {
+        if rec := postJSON(router, "/api/v1/auth/login", body, "10.0.0.1"); rec.Code != http.StatusOK {
+            t.Fatalf("attempt %d = %d", i+1, rec.Code)
+        }
+    }
+    rec := postJSON(router, "/api/v1/auth/login", body, "10.0.0.1")
+    if rec.Code != http.StatusTooManyRequests || rec.Header().Get("X-RateLimit-Limit") != "5" {
+        t.Errorf("sixth attempt = %d limit=%s", rec.Code, rec.Header().Get("X-RateLimit-Limit"))
+    }
+
+    for _, key := range server.Keys() {
+        if strings.Contains(key, "alice") {
+            t.Errorf("redis key %q contains the email", key)
+        }
+    }
+}
+
+func TestLoadPolicies(t *testing.T) {
+    dir := t.TempDir()
+    path := filepath.Join(dir, "policies.yaml")
+    write := func(content string) {
+        if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+            t.Fatal(err)
+        }
+    }
+
+    write(`
+policies:
+  - name: refresh
+    routes: [POST /api/v1/auth/refresh]
+    limits:
+      - name: device
+        requests: 30
+        period: 1m
+        key: [device]
+`)
+    file, err := LoadPolicyFile(path)
+    if err != nil {
+        t.Fatalf("LoadPolicyFile failed: %v", err)
+    }
+    if len(file.Policies) != 1 || file.Policies[0].Limits[0].Period != time.Minute {
+        t.Errorf("policies = %+v", file.Policies)
+    }
+    if len(file.Tiers) != len(DefaultTiers()) || file.DefaultTier != DefaultTier {
+        t.Errorf("tiers = %+v default %q, want the built-in tiers", file.Tiers, file.DefaultTier)
+    }
+
+    write(`
+policies:
+  - name: login
+    routes: [POST /api/v1/auth/login]
+    cost: 10
+    limits:
+      - name: ip
+        requests: 5
+        period: 1m
+        key: [ip, password]
+`)
+    _, err = LoadPolicyFile(path)
+    if err == nil || !strings.Contains(err.Error(), "password") || !strings.Contains(err.Error(), "capacity") {
+        t.Errorf("expected an unknown key part and an oversized cost, got %v", err)
+    }
+
+    // Policies run before authentication, so a user key would be one shared bucket
+    write(`
+policies:
+  - name: sessions
+    routes: [GET /api/v1/auth/sessions]
+    limits:
+      - name: user
+        requests: 5
+        period: 1m
+        key: [user]
+`)
+    _, err = LoadPolicyFile(path)
+    if err == nil || !strings.Contains(err.Error(), `key part "user"`) {
+        t.Errorf("expected a policy keyed on user to be rejected, got %v", err)
+    }
+
+    write("policies:\n  - name: x\n    limit: []\n")
+    if _, err := LoadPolicyFile(path); err == nil {
+        t.Error("expected an unknown field to be rejected")
+    }
+
+    write(`
+tiers:
+  - name: team
+    limits:
+      - name: workspace
+        requests: 100
+        period: 1m
+        key: [workspace]
+`)
+    if _, err := LoadPolicyFile(path); err == nil || !strings.Contains(err.Error(), "default_tier") {
+        t.Errorf("expected custom tiers without a default to be rejected, got %v", err)
+    }
+}
+
+func TestRouteMatches(t *testing.T) {
+    tests := []struct {
+        pattern, method, route string
+        want                   bool
+    }{
+        {"POST /api/v1/auth/login", "POST", "/api/v1/auth/login", true},
+        {"POST /api/v1/auth/login", "GET", "/api/v1/auth/login", false},
+        {"/api/v1/auth/login", "GET", "/api/v1/auth/login", true},
+        {"/scim/v2/*", "PATCH", "/scim/v2/Users/:id", true},
+        {"/scim/v2/*", "GET", "/scim/v2", true},
+        {"/scim/v2/*", "GET", "/scim/v2x", false},
+        {"GET /scim/*", "POST", "/scim/v2/Users", false},
+    }
+    for _, tt := range tests {
+        if got := routeMatches(tt.pattern, tt.method, tt.route); got != tt.want {
+            t.Errorf("routeMatches(%q, %s %s) = %v, want %v", tt.pattern, tt.method, tt.route, got, tt.want)
+        }
+    }
+}
\ No newline at end of file
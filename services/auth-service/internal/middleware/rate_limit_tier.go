@@ -0,0 +1,108 @@
+package middleware
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "time"
+
+    "github.com/gin-gonic/gin"
+
+    "github.com/Shridhar2104/chat-platform/shared/database"
+)
+
+// DefaultTier is the plan of callers whose token names none
+const DefaultTier = "free"
+
+// Tier holds the quotas of one subscription plan. Its limits apply to every
+// authenticated route and are keyed on user or workspace; a limit is
+// skipped for callers without the key, like SCIM tokens without a user.
+type Tier struct {
+    Name   string  `yaml:"name"`
+    Limits []Limit `yaml:"limits"`
+}
+
+// DefaultTiers are used when the policy file defines no tiers
+func DefaultTiers() []Tier {
+    tier := func(name string, user, workspace int) Tier {
+        return Tier{
+            Name: name,
+            Limits: []Limit{
+                {Name: "user", Requests: user, Period: time.Minute, Key: []string{KeyUser}},
+                {Name: "workspace", Requests: workspace, Period: time.Minute, Key: []string{KeyWorkspace}},
+            },
+        }
+    }
+    return []Tier{
+        tier("free", 60, 600),
+        tier("pro", 300, 3000),
+        tier("enterprise", 1000, 10000),
+    }
+}
+
+// ValidateTiers reports every malformed tier together
+func ValidateTiers(tiers []Tier, defaultTier string) error {
+    var errs []error
+    names := make(map[string]bool)
+    for i, tier := range tiers {
+        if tier.Name == "" {
+            errs = append(errs, fmt.Errorf("tier %d: name is required", i))
+        } else if names[tier.Name] {
+            errs = append(errs, fmt.Errorf("tier %s: duplicate name", tier.Name))
+        }
+        names[tier.Name] = true
+        if len(tier.Limits) == 0 {
+            errs = append(errs, fmt.Errorf("tier %s: at least one limit is required", tier.Name))
+        }
+        errs = append(errs, validateLimits("tier "+tier.Name, tier.Limits, 1)...)
+    }
+    if !names[defaultTier] {
+        errs = append(errs, fmt.Errorf("default_tier: %q is not a tier", defaultTier))
+    }
+    return errors.Join(errs...)
+}
+
+// TierLimiter enforces the quotas of the caller's plan. It runs after
+// authentication, which sets the user, workspace and plan.
+type TierLimiter struct {
+    tiers       map[string]*compiledPolicy
+    defaultTier string
+}
+
+// NewTierLimiter builds the tier limiters, in memory when redis is nil and
+// then cleaning up until ctx is done
+func NewTierLimiter(ctx context.Context, redis *database.RedisClient, tiers []Tier, defaultTier string, resilience Resilience) (*TierLimiter, error) {
+    limiter := &TierLimiter{tiers: make(map[string]*compiledPolicy), defaultTier: defaultTier}
+    for _, tier := range tiers {
+        limits, err := compileLimits(ctx, redis, tier.Name, "rate_limit:tier:"+tier.Name, tier.Limits, 1, resilience)
+        if err != nil {
+            return nil, fmt.Errorf("tier %s %w", tier.Name, err)
+        }
+        limiter.tiers[tier.Name] = &compiledPolicy{limits: limits, label: "tier:" + tier.Name, keyed: true}
+    }
+    if limiter.tiers[defaultTier] == nil {
+        return nil, fmt.Errorf("default tier %q is not defined", defaultTier)
+    }
+    return limiter, nil
+}
+
+// Close stops the background work of every tier limit
+func (l *TierLimiter) Close() error {
+    var errs []error
+    for _, tier := range l.tiers {
+        errs = append(errs, tier.close())
+    }
+    return errors.Join(errs...)
+}
+
+// Middleware applies the tier named by the plan in the context, or the
+// default tier when the plan is missing or unknown
+func (l *TierLimiter) Middleware() gin.HandlerFunc {
+    return func(c *gin.Context) {
+        tier, ok := l.tiers[c.GetString("plan")]
+        if !ok {
+            tier = l.tiers[l.defaultTier]
+        }
+        enforce(c, []matchedPolicy{{policy: tier, cost: 1}})
+    }
+}
\ No newline at end of file
@@ -0,0 +1,144 @@
+package middleware
+
+import (
+    "net/http"
+    "net/http/httptest"
+    "testing"
+    "time"
+
+    "github.com/gin-gonic/gin"
+    "github.com/google/uuid"
+
+    "github.com/Shridhar2104/chat-platform/auth-service/internal/services"
+)
+
+func newTierRouter(t *testing.T) *gin.Engine {
+    t.Helper()
+    tiers := []Tier{
+        {Name: "free", Limits: []Limit{
+            {Name: "user", Requests: 2, Period: time.Hour, Key: []string{KeyUser}},
+            {Name: "workspace", Requests: 3, Period: time.Hour, Key: []string{KeyWorkspace}},
+        }},
+        {Name: "pro", Limits: []Limit{
+            {Name: "user", Requests: 4, Period: time.Hour, Key: []string{KeyUser}},
+        }},
+    }
+    if err := ValidateTiers(tiers, "free"); err != nil {
+        t.Fatalf("tiers rejected: %v", err)
+    }
+    limiter, err := NewTierLimiter(t.Context(), nil, tiers, "free", Resilience{})
+    if err != nil {
+        t.Fatalf("NewTierLimiter failed: %v", err)
+    }
+
+    // Stands in for authentication, SCIM tokens set a UUID workspace and no user
+    authenticate := func(c *gin.Context) {
+        if user := c.GetHeader("X-User"); user != "" {
+            c.Set("user_id", user)
+        }
+        if workspace := c.GetHeader("X-Workspace"); workspace != "" {
+            c.Set("workspace_id", uuid.MustParse(workspace))
+        }
+        if plan := c.GetHeader("X-Plan"); plan != "" {
+            c.Set("plan", plan)
+        }
+        c.Next()
+    }
+
+    gin.SetMode(gin.TestMode)
+    router := gin.New()
+    router.GET("/me", authenticate, limiter.Middleware(), func(c *gin.Context) { c.Status(http.StatusOK) })
+    return router
+}
+
+func tierRequest(router http.Handler, user, workspace, plan string) int {
+    req := httptest.NewRequest(http.MethodGet, "/me", nil)
+    req.Header.Set("X-User", user)
+    req.Header.Set("X-Workspace", workspace)
+    req.Header.Set("X-Plan", plan)
+    rec := httptest.NewRecorder()
+    router.ServeHTTP(rec, req)
+    return rec.Code
+}
+
+func TestTierLimiterQuotasByPlan(t *testing.T) {
+    router := newTierRouter(t)
+
+    // Free users get two requests; an unknown plan is the default tier
+    for i, want := range []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests} {
+        if got := tierRequest(router, "alice", "", "platinum"); got != want {
+            t.Errorf("free request %d = %d, want %d", i+1, got, want)
+        }
+    }
+    for i := 0; i < 4; i++ {
+        if got := tierRequest(router, "bob", "", "pro"); got != http.StatusOK {
+            t.Errorf("pro request %d = %d", i+1, got)
+        }
+    }
+    if got := tierRequest(router, "bob", "", "pro"); got != http.StatusTooManyRequests {
+        t.Errorf("fifth pro request = %d, want 429", got)
+    }
+}
+
+func TestTierLimiterSharesWorkspaceQuota(t *testing.T) {
+    router := newTierRouter(t)
+    workspace := uuid.NewString()
+
+    // Users of one workspace draw on its quota of three together
+    for i, user := range []string{"alice", "bob", "carol"} {
+        if got := tierRequest(router, user, workspace, ""); got != http.StatusOK {
+            t.Errorf("request %d = %d", i+1, got)
+        }
+    }
+    if got := tierRequest(router, "dave", workspace, ""); got != http.StatusTooManyRequests {
+        t.Errorf("fourth user in the workspace = %d, want 429", got)
+    }
+
+    // Callers without a user only meet the workspace limit and never share
+    // a user bucket with each other
+    other := uuid.NewString()
+    for i := 0; i < 3; i++ {
+        if got := tierRequest(router, "", other, ""); got != http.StatusOK {
+            t.Errorf("workspace token request %d = %d", i+1, got)
+        }
+    }
+}
+
+func TestTierLimiterUsesPlanFromToken(t *testing.T) {
+    validator := newTokenValidator(t)
+    limiter, err := NewTierLimiter(t.Context(), nil, []Tier{
+        {Name: "free", Limits: []Limit{{Name: "user", Requests: 1, Period: time.Hour, Key: []string{KeyUser}}}},
+        {Name: "pro", Limits: []Limit{{Name: "user", Requests: 3, Period: time.Hour, Key: []string{KeyUser}}}},
+    }, "free", Resilience{})
+    if err != nil {
+        t.Fatalf("NewTierLimiter failed: %v", err)
+    }
+
+    gin.SetMode(gin.TestMode)
+    router := gin.New()
+    router.GET("/me", AuthMiddleware(validator), limiter.Middleware(), func(c *gin.Context) { c.Status(http.StatusOK) })
+
+    request := func(token string) int {
+        req := httptest.NewRequest(http.MethodGet, "/me", nil)
+        req.Header.Set("Authorization", "Bearer "+token)
+        rec := httptest.NewRecorder()
+        router.ServeHTTP(rec, req)
+        return rec.Code
+    }
+
+    // A member of a pro workspace gets the pro quota
+    pro := validator.token(t, uuid.New(), &services.TokenWorkspace{ID: uuid.New(), Plan: "pro"})
+    for i, want := range []int{http.StatusOK, http.StatusOK, http.StatusOK, http.StatusTooManyRequests} {
+        if got := request(pro); got != want {
+            t.Errorf("pro request %d = %d, want %d", i+1, got, want)
+        }
+    }
+
+    // A token without a workspace falls back to the default tier
+    free := validator.token(t, uuid.New(), nil)
+    for i, want := range []int{http.StatusOK, http.StatusTooManyRequests} {
+        if got := request(free); got != want {
+            t.Errorf("free request %d = %d, want %d", i+1, got, want)
+        }
+    }
+}
\ No newline at end of file
@@ -0,0 +1,172 @@
+package middleware
+
+import (
+    "context"
+    "fmt"
+    "io"
+    "time"
+
+    "github.com/gin-gonic/gin"
+    "github.com/redis/go-redis/v9"
+
+    "github.com/Shridhar2104/chat-platform/auth-service/internal/metrics"
+    "github.com/Shridhar2104/chat-platform/shared/database"
+)
+
+// Algorithms a policy limit can use
+const (
+    AlgorithmTokenBucket   = "token_bucket"
+    AlgorithmGCRA          = "gcra"
+    AlgorithmSlidingWindow = "sliding_window"
+    AlgorithmConcurrency   = "concurrency"
+)
+
+// defaultLeaseTTL bounds how long a crashed request can hold a concurrency slot
+const defaultLeaseTTL = time.Minute
+
+// defaultBreakerCooldown is how long an unconfigured breaker stays open
+const defaultBreakerCooldown = 30 * time.Second
+
+// Decision is the outcome of one rate limit check
+type Decision struct {
+    Allowed   bool
+    Limit     int
+    Remaining int
+    // RetryAfter is how long a rejected caller should wait
+    RetryAfter time.Duration
+    // ResetAfter is how long until the limit is fully available again
+    ResetAfter time.Duration
+    // Window is the period the limit is measured over, zero for concurrency
+    Window time.Duration
+    // Degraded names the failure mode that decided while Redis was unavailable
+    Degraded string
+
+    // lease identifies the slots a concurrency limiter handed out
+    lease string
+    cost  int
+}
+
+// RateLimiter decides whether key may spend cost units now. Every algorithm
+// has an in-memory and a Redis backend with the same behavior.
+type RateLimiter interface {
+    Allow(ctx context.Context, key string, cost int) (Decision, error)
+}
+
+// Releaser is implemented by limiters that hold capacity until the request
+// finishes, the allowed decision is handed back once it has
+type Releaser interface {
+    Release(ctx context.Context, key string, decision Decision) error
+}
+
+// closeLimiter stops the background work of limiters that have any, like
+// the cleanup of in-memory token buckets
+func closeLimiter(limiter RateLimiter) error {
+    if closer, ok := limiter.(io.Closer); ok {
+        return closer.Close()
+    }
+    return nil
+}
+
+// NewLimiter builds the limiter for one policy limit. A nil redis client
+// selects the in-memory backend, whose background cleanup runs until ctx is
+// done or the limiter is closed.
+func NewLimiter(ctx context.Context, redis *database.RedisClient, keyPrefix string, limit Limit) (RateLimiter, error) {
+    capacity := limit.capacity()
+    switch limit.algorithm() {
+    case AlgorithmTokenBucket:
+        refillRate := float64(limit.Requests) / limit.Period.Seconds()
+        if redis == nil {
+            return NewBoundedMemoryTokenBucket(ctx, capacity, refillRate, 1, DefaultMemoryStoreConfig()), nil
+        }
+        bucket := NewRedisTokenBucket(redis, capacity, refillRate, 1)
+        bucket.keyPrefix = keyPrefix
+        return bucket, nil
+    case AlgorithmGCRA:
+        if redis == nil {
+            return NewMemoryGCRA(limit.Requests, limit.Period, capacity), nil
+        }
+        return NewRedisGCRA(redis, keyPrefix, limit.Requests, limit.Period, capacity), nil
+    case AlgorithmSlidingWindow:
+        if redis == nil {
+            return NewMemorySlidingWindow(limit.Requests, limit.Period), nil
+        }
+        return NewRedisSlidingWindow(redis, keyPrefix, limit.Requests, limit.Period), nil
+    case AlgorithmConcurrency:
+        if redis == nil {
+            return NewMemoryConcurrencyLimiter(limit.Requests), nil
+        }
+        return NewRedisConcurrencyLimiter(redis, keyPrefix, limit.Requests, limit.Period), nil
+    default:
+        return nil, fmt.Errorf("unknown rate limit algorithm %q", limit.Algorithm)
+    }
+}
+
+// LoadScripts preloads every limiter script with SCRIPT LOAD. Limiters call
+// scripts by EVALSHA and resend the source on NOSCRIPT, so preloading only
+// spares the first requests after a Redis restart or SCRIPT FLUSH.
+func LoadScripts(ctx context.Context, client *database.RedisClient) error {
+    scripts := []*redis.Script{tokenBucketScript, gcraScript, slidingWindowScript, concurrencyScript}
+    for _, script := range scripts {
+        if err := script.Load(ctx, client.Client).Err(); err != nil {
+            return fmt.Errorf("failed to load rate limit script: %w", err)
+        }
+    }
+    return nil
+}
+
+// RateLimitMiddleware spends one unit of limiter per request, keyed on the
+// user or client IP. Decisions made without a known limit, like failing
+// open, carry no rate limit headers, and allowlisted clients skip it.
+func RateLimitMiddleware(limiter RateLimiter, name string) gin.HandlerFunc {
+    return func(c *gin.Context) {
+        if exempt(c) {
+            c.Next()
+            return
+        }
+        decision, err := limiter.Allow(c.Request.Context(), getIdentifier(c), 1)
+        if err != nil {
+            metrics.RateLimitDecision(name, "error")
+            c.Next()
+            return
+        }
+        if decision.Limit > 0 {
+            setRateLimitHeaders(c, "global", decision)
+        }
+
+        if !decision.Allowed {
+            metrics.RateLimitDecision(name, "denied")
+            writeRateLimited(c, decision.RetryAfter)
+            return
+        }
+        metrics.RateLimitDecision(name, "allowed")
+        c.Next()
+    }
+}
+
+// Allow adapts the token bucket to RateLimiter
+func (mtb *MemoryTokenBucket) Allow(ctx context.Context, key string, cost int) (Decision, error) {
+    allowed, remaining, wait := mtb.AllowRequestWithTokens(key, cost)
+    return tokenBucketDecision(allowed, remaining, wait, mtb.load()), nil
+}
+
+// Allow adapts the token bucket to RateLimiter
+func (tb *RedisTokenBucket) Allow(ctx context.Context, key string, cost int) (Decision, error) {
+    limits := tb.load()
+    allowed, remaining, wait, err := tb.AllowRequestWithTokens(ctx, key, cost)
+    if err != nil {
+        return Decision{}, err
+    }
+    return tokenBucketDecision(allowed, remaining, wait, limits), nil
+}
+
+func tokenBucketDecision(allowed bool, remaining float64, wait time.Duration, limits *bucketLimits) Decision {
+    refill := (float64(limits.capacity) - remaining) / limits.refillRate
+    return Decision{
+        Allowed:    allowed,
+        Limit:      limits.capacity,
+        Remaining:  int(remaining),
+        RetryAfter: wait,
+        ResetAfter: time.Duration(refill * float64(time.Second)),
+        Window:     limits.window(),
+    }
+}
\ No newline at end of file
@@ -0,0 +1,190 @@
+package middleware
+
+import (
+    "context"
+    "testing"
+    "time"
+
+    "github.com/alicebob/miniredis/v2"
+    "github.com/redis/go-redis/v9"
+
+    "github.com/Shridhar2104/chat-platform/shared/database"
+)
+
+type fakeClock struct {
+    now time.Time
+}
+
+func (c *fakeClock) Now() time.Time          { return c.now }
+func (c *fakeClock) Advance(d time.Duration) { c.now = c.now.Add(d) }
+
+// newTestLimiter builds the limiter on the backend with its clock replaced
+func newTestLimiter(t *testing.T, backend string, clock *fakeClock, limit Limit) RateLimiter {
+    t.Helper()
+    var store *database.RedisClient
+    if backend == "redis" {
+        server := miniredis.RunT(t)
+        client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+        t.Cleanup(func() { client.Close() })
+        store = &database.RedisClient{Client: client}
+    }
+
+    limiter, err := NewLimiter(t.Context(), store, "rate_limit:test", limit)
+    if err != nil {
+        t.Fatalf("NewLimiter failed: %v", err)
+    }
+    switch l := limiter.(type) {
+    case *MemoryGCRA:
+        l.now = clock.Now
+    case *RedisGCRA:
+        l.now = clock.Now
+    case *MemorySlidingWindow:
+        l.now = clock.Now
+    case *RedisSlidingWindow:
+        l.now = clock.Now
+    case *RedisConcurrencyLimiter:
+        l.now = clock.Now
+    }
+    return limiter
+}
+
+type limiterStep struct {
+    advance time.Duration
+    // release hands back the oldest allowed decision before this step
+    release   bool
+    cost      int
+    allowed   bool
+    remaining int
+    // retryAfter is checked on rejected steps
+    retryAfter time.Duration
+}
+
+func TestRateLimiterAlgorithms(t *testing.T) {
+    tests := []struct {
+        name  string
+        limit Limit
+        steps []limiterStep
+    }{
+        {
+            name:  "gcra spaces requests by the emission interval",
+            limit: Limit{Algorithm: AlgorithmGCRA, Requests: 3, Period: 3 * time.Second},
+            steps: []limiterStep{
+                {cost: 1, allowed: true, remaining: 2},
+                {cost: 1, allowed: true, remaining: 1},
+                {cost: 1, allowed: true, remaining: 0},
+                {cost: 1, allowed: false, retryAfter: time.Second},
+                {advance: time.Second, cost: 1, allowed: true, remaining: 0},
+                {advance: 500 * time.Millisecond, cost: 1, allowed: false, retryAfter: 500 * time.Millisecond},
+                {advance: 10 * time.Second, cost: 3, allowed: true, remaining: 0},
+            },
+        },
+        {
+            name:  "gcra burst above the sustained rate",
+            limit: Limit{Algorithm: AlgorithmGCRA, Requests: 1, Period: time.Second, Burst: 3},
+            steps: []limiterStep{
+                {cost: 2, allowed: true, remaining: 1},
+                {cost: 2, allowed: false, retryAfter: time.Second},
+                {cost: 1, allowed: true, remaining: 0},
+                {advance: 2 * time.Second, cost: 2, allowed: true, remaining: 0},
+            },
+        },
+        {
+            name:  "sliding window weights the previous window",
+            limit: Limit{Algorithm: AlgorithmSlidingWindow, Requests: 4, Period: 10 * time.Second},
+            steps: []limiterStep{
+                {cost: 1, allowed: true, remaining: 3},
+                {cost: 1, allowed: true, remaining: 2},
+                {cost: 2, allowed: true, remaining: 0},
+                {cost: 1, allowed: false, retryAfter: 12500 * time.Millisecond},
+                // 2.5s into the next window, 75% of the previous 4 still counts
+                {advance: 12500 * time.Millisecond, cost: 1, allowed: true, remaining: 0},
+                {cost: 1, allowed: false, retryAfter: 2500 * time.Millisecond},
+                {advance: 2500 * time.Millisecond, cost: 1, allowed: true, remaining: 0},
+                {advance: 20 * time.Second, cost: 4, allowed: true, remaining: 0},
+            },
+        },
+        {
+            name:  "concurrency holds slots until release",
+            limit: Limit{Algorithm: AlgorithmConcurrency, Requests: 2},
+            steps: []limiterStep{
+                {cost: 1, allowed: true, remaining: 1},
+                {cost: 1, allowed: true, remaining: 0},
+                {cost: 1, allowed: false},
+                {release: true, cost: 1, allowed: true, remaining: 0},
+                {release: true, cost: 2, allowed: false},
+                {release: true, cost: 2, allowed: true, remaining: 0},
+            },
+        },
+    }
+
+    for _, backend := range []string{"memory", "redis"} {
+        for _, tt := range tests {
+            t.Run(backend+"/"+tt.name, func(t *testing.T) {
+                clock := &fakeClock{now: time.Unix(1_700_000_000, 0)}
+                limiter := newTestLimiter(t, backend, clock, tt.limit)
+                ctx := context.Background()
+                var held []Decision
+
+                for i, step := range tt.steps {
+                    clock.Advance(step.advance)
+                    if step.release {
+                        if err := limiter.(Releaser).Release(ctx, "client", held[0]); err != nil {
+                            t.Fatalf("step %d: Release failed: %v", i, err)
+                        }
+                        held = held[1:]
+                    }
+
+                    decision, err := limiter.Allow(ctx, "client", step.cost)
+                    if err != nil {
+                        t.Fatalf("step %d: Allow failed: %v", i, err)
+                    }
+                    if decision.Allowed != step.allowed {
+                        t.Fatalf("step %d: allowed = %v, want %v (%+v)", i, decision.Allowed, step.allowed, decision)
+                    }
+                    if step.allowed {
+                        held = append(held, decision)
+                        if decision.Remaining != step.remaining {
+                            t.Errorf("step %d: remaining = %d, want %d", i, decision.Remaining, step.remaining)
+                        }
+                    } else if step.retryAfter != 0 && decision.RetryAfter != step.retryAfter {
+                        t.Errorf("step %d: retry after = %s, want %s", i, decision.RetryAfter, step.retryAfter)
+                    }
+                }
+            })
+        }
+    }
+}
+
+func TestRedisConcurrencyLeasesExpire(t *testing.T) {
+    clock := &fakeClock{now: time.Unix(1_700_000_000, 0)}
+    limiter := newTestLimiter(t, "redis", clock, Limit{Algorithm: AlgorithmConcurrency, Requests: 1, Period: 30 * time.Second})
+    ctx := context.Background()
+
+    if decision, _ := limiter.Allow(ctx, "client", 1); !decision.Allowed {
+        t.Fatal("first request rejected")
+    }
+    if decision, _ := limiter.Allow(ctx, "client", 1); decision.Allowed {
+        t.Fatal("second request allowed while the slot is held")
+    }
+
+    // A replica that crashed without releasing loses the slot after its lease
+    clock.Advance(31 * time.Second)
+    if decision, _ := limiter.Allow(ctx, "client", 1); !decision.Allowed {
+        t.Error("expired lease still holds the slot")
+    }
+}
+
+func TestPolicyLimiterReleasesConcurrencySlots(t *testing.T) {
+    router := newPolicyRouter(t, nil, []Policy{{
+        Name:   "scim",
+        Routes: []string{"/scim/v2/*"},
+        Limits: []Limit{{Name: "in-flight", Algorithm: AlgorithmConcurrency, Requests: 1, Key: []string{KeyIP}}},
+    }})
+
+    // Sequential requests never overlap, so each finds the slot free
+    for i := 0; i < 3; i++ {
+        if rec := postJSON(router, "/scim/v2/Users", "{}", "10.0.0.1"); rec.Code != 200 {
+            t.Fatalf("request %d = %d", i+1, rec.Code)
+        }
+    }
+}
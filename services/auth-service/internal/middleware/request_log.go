@@ -0,0 +1,72 @@
+package middleware
+
+import (
+    "log/slog"
+    "net/http"
+    "time"
+
+    "github.com/gin-gonic/gin"
+    "go.opentelemetry.io/otel/trace"
+    "github.com/Shridhar2104/chat-platform/auth-service/internal/clientip"
+    "github.com/Shridhar2104/chat-platform/auth-service/internal/apierrors"
+    "github.com/Shridhar2104/chat-platform/auth-service/internal/logging"
+)
+
+const requestIDHeader = "X-Request-ID"
+
+// RequestID accepts the caller's X-Request-ID or generates one, echoes it on
+// the response and attaches a logger carrying it to the request context. The
+// same ID is reported as trace_id in problem responses.
+func RequestID() gin.HandlerFunc {
+    return func(c *gin.Context) {
+        requestID := apierrors.TraceID(c)
+        c.Header(requestIDHeader, requestID)
+
+        logger := slog.Default().With(slog.String("request_id", requestID))
+        c.Request = c.Request.WithContext(logging.WithContext(c.Request.Context(), logger))
+
+        c.Next()
+    }
+}
+
+// AccessLog writes one structured record per request once it completes.
+// Server errors log at error level and client errors at warn.
+func AccessLog() gin.HandlerFunc {
+    return func(c *gin.Context) {
+        start := time.Now()
+        c.Next()
+
+        status := c.Writer.Status()
+        route := c.FullPath()
+        if route == "" {
+            route = "unmatched"
+        }
+        attrs := []slog.Attr{
+            slog.String("method", c.Request.Method),
+            slog.String("route", route),
+            slog.String("path", c.Request.URL.Path),
+            slog.Int("status", status),
+            slog.Float64("latency_ms", float64(time.Since(start).Microseconds())/1000),
+            slog.Int("bytes", c.Writer.Size()),
+            slog.String("client_ip", clientip.FromContext(c)),
+        }
+        if userID := c.GetString("user_id"); userID != "" {
+            attrs = append(attrs, slog.String("user_id", userID))
+        }
+        if deviceID := c.GetString("device_id"); deviceID != "" {
+            attrs = append(attrs, slog.String("device_id", deviceID))
+        }
+        if span := trace.SpanContextFromContext(c.Request.Context()); span.IsValid() {
+            attrs = append(attrs, slog.String("otel_trace_id", span.TraceID().String()))
+        }
+
+        level := slog.LevelInfo
+        switch {
+        case status >= http.StatusInternalServerError:
+            level = slog.LevelError
+        case status >= http.StatusBadRequest:
+            level = slog.LevelWarn
+        }
+        logging.FromContext(c.Request.Context()).LogAttrs(c.Request.Context(), level, "Request completed", attrs...)
+    }
+}
\ No newline at end of file
@@ -0,0 +1,99 @@
+package middleware
+
+import (
+    "bytes"
+    "encoding/json"
+    "log/slog"
+    "net/http"
+    "net/http/httptest"
+    "strings"
+    "testing"
+
+    "github.com/gin-gonic/gin"
+    "github.com/Shridhar2104/chat-platform/auth-service/internal/logging"
+)
+
+func newLoggedRouter(t *testing.T, buf *bytes.Buffer) *gin.Engine {
+    t.Helper()
+    previous := slog.Default()
+    if err := logging.Setup(buf, logging.Config{Level: "debug", Format: logging.FormatJSON}); err != nil {
+        t.Fatalf("Setup failed: %v", err)
+    }
+    t.Cleanup(func() { slog.SetDefault(previous) })
+
+    gin.SetMode(gin.TestMode)
+    router := gin.New()
+    router.Use(RequestID(), AccessLog())
+    router.GET("/api/v1/users/:id", func(c *gin.Context) {
+        c.Set("user_id", "user-1")
+        c.Set("device_id", "device-1")
+        logging.FromContext(c.Request.Context()).Info("Handling request")
+        c.Status(http.StatusNotFound)
+    })
+    return router
+}
+
+func logLines(t *testing.T, buf *bytes.Buffer) []map[string]any {
+    t.Helper()
+    var records []map[string]any
+    for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+        var record map[string]any
+        if err := json.Unmarshal([]byte(line), &record); err != nil {
+            t.Fatalf("log line is not JSON: %v: %s", err, line)
+        }
+        records = append(records, record)
+    }
+    return records
+}
+
+func TestRequestIDIsEchoedAndLogged(t *testing.T) {
+    var buf bytes.Buffer
+    router := newLoggedRouter(t, &buf)
+
+    req := httptest.NewRequest(http.MethodGet, "/api/v1/users/7", nil)
+    req.Header.Set(requestIDHeader, "req-123")
+    rec := httptest.NewRecorder()
+    router.ServeHTTP(rec, req)
+
+    if got := rec.Header().Get(requestIDHeader); got != "req-123" {
+        t.Errorf("response request ID = %q, want the caller's", got)
+    }
+
+    records := logLines(t, &buf)
+    if len(records) != 2 {
+        t.Fatalf("log lines = %d, want handler and access log", len(records))
+    }
+    for _, record := range records {
+        if record["request_id"] != "req-123" {
+            t.Errorf("record %v is missing the request ID", record["msg"])
+        }
+    }
+
+    access := records[1]
+    if access["level"] != "WARN" || access["route"] != "/api/v1/users/:id" || access["status"] != float64(http.StatusNotFound) {
+        t.Errorf("unexpected access log: %v", access)
+    }
+    if access["user_id"] != "user-1" || access["device_id"] != "device-1" {
+        t.Errorf("access log is missing per-request identity: %v", access)
+    }
+    if _, ok := access["latency_ms"]; !ok {
+        t.Error("access log is missing latency_ms")
+    }
+}
+
+func TestRequestIDRejectsUnsafeValues(t *testing.T) {
+    var buf bytes.Buffer
+    router := newLoggedRouter(t, &buf)
+
+    for _, supplied := range []string{"", "bad id\" injected=1", strings.Repeat("a", 200)} {
+        req := httptest.NewRequest(http.MethodGet, "/api/v1/users/7", nil)
+        req.Header.Set(requestIDHeader, supplied)
+        rec := httptest.NewRecorder()
+        router.ServeHTTP(rec, req)
+
+        got := rec.Header().Get(requestIDHeader)
+        if got == "" || got == supplied {
+            t.Errorf("supplied %q, got request ID %q, want a generated one", supplied, got)
+        }
+    }
+}
\ No newline at end of file
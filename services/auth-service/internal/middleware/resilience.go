@@ -0,0 +1,103 @@
+package middleware
+
+import (
+    "context"
+    "errors"
+
+    "github.com/Shridhar2104/chat-platform/auth-service/internal/metrics"
+)
+
+// What Redis-backed limiters do while Redis is unavailable
+const (
+    FailOpen   = "open"
+    FailClosed = "closed"
+    FailLocal  = "local"
+)
+
+// Resilience configures the behavior of Redis-backed limiters during a
+// Redis outage. The zero value fails open, and limiters sharing one Redis
+// should share one Breaker.
+type Resilience struct {
+    Mode string
+    // Replicas divides the local fallback limits so the fleet together
+    // stays close to the shared limit
+    Replicas int
+    Breaker  *CircuitBreaker
+}
+
+// PerReplica is one replica's share of n, never below one
+func PerReplica(n, replicas int) int {
+    if replicas <= 1 {
+        return n
+    }
+    return max(1, (n+replicas-1)/replicas)
+}
+
+// ResilientLimiter guards a Redis limiter with a circuit breaker and
+// applies the failure mode while Redis errors or the breaker is open
+type ResilientLimiter struct {
+    primary  RateLimiter
+    fallback RateLimiter
+    mode     string
+    breaker  *CircuitBreaker
+}
+
+// NewResilientLimiter wraps primary; fallback is only used by the local mode
+func NewResilientLimiter(primary, fallback RateLimiter, resilience Resilience) *ResilientLimiter {
+    breaker := resilience.Breaker
+    if breaker == nil {
+        breaker = NewCircuitBreaker(5, defaultBreakerCooldown)
+    }
+    mode := resilience.Mode
+    if mode == "" || (mode == FailLocal && fallback == nil) {
+        mode = FailOpen
+    }
+    return &ResilientLimiter{primary: primary, fallback: fallback, mode: mode, breaker: breaker}
+}
+
+func (l *ResilientLimiter) Allow(ctx context.Context, key string, cost int) (Decision, error) {
+    if l.breaker.Allow() {
+        decision, err := l.primary.Allow(ctx, key, cost)
+        l.breaker.Record(err)
+        if err == nil {
+            return decision, nil
+        }
+    }
+
+    var decision Decision
+    switch l.mode {
+    case FailClosed:
+        decision = Decision{Allowed: false, RetryAfter: l.breaker.RetryAfter()}
+    case FailLocal:
+        var err error
+        if decision, err = l.fallback.Allow(ctx, key, cost); err != nil {
+            return Decision{}, err
+        }
+    default:
+        decision = Decision{Allowed: true}
+    }
+    decision.Degraded = l.mode
+    metrics.RateLimitDegraded(l.mode, decision.Allowed)
+    return decision, nil
+}
+
+// Close closes both the primary and the fallback limiter
+func (l *ResilientLimiter) Close() error {
+    return errors.Join(closeLimiter(l.primary), closeLimiter(l.fallback))
+}
+
+// Release hands the decision back to whichever limiter made it
+func (l *ResilientLimiter) Release(ctx context.Context, key string, decision Decision) error {
+    limiter := l.primary
+    switch decision.Degraded {
+    case "":
+    case FailLocal:
+        limiter = l.fallback
+    default:
+        return nil
+    }
+    if releaser, ok := limiter.(Releaser); ok {
+        return releaser.Release(ctx, key, decision)
+    }
+    return nil
+}
\ No newline at end of file
@@ -0,0 +1,177 @@
+package middleware
+
+import (
+    "context"
+    "errors"
+    "net/http"
+    "net/http/httptest"
+    "reflect"
+    "testing"
+    "time"
+
+    "github.com/alicebob/miniredis/v2"
+    "github.com/gin-gonic/gin"
+    "github.com/redis/go-redis/v9"
+
+    "github.com/Shridhar2104/chat-platform/shared/database"
+)
+
+func TestCircuitBreakerTransitions(t *testing.T) {
+    clock := &fakeClock{now: time.Unix(1_700_000_000, 0)}
+    breaker := NewCircuitBreaker(2, 30*time.Second)
+    breaker.now = clock.Now
+    var states []string
+    breaker.OnStateChange(func(state string) { states = append(states, state) })
+
+    failure := errors.New("connection refused")
+    breaker.Allow()
+    breaker.Record(failure)
+    breaker.Allow()
+    breaker.Record(context.Canceled)
+    if breaker.State() != BreakerClosed {
+        t.Fatalf("a cancelled call opened the breaker")
+    }
+    breaker.Allow()
+    breaker.Record(failure)
+    if breaker.State() != BreakerOpen || breaker.Allow() {
+        t.Fatalf("state = %s, want open and skipping calls", breaker.State())
+    }
+    if got := breaker.RetryAfter(); got != 30*time.Second {
+        t.Errorf("RetryAfter = %s", got)
+    }
+
+    // One probe after the cooldown, a failed probe reopens at once
+    clock.Advance(30 * time.Second)
+    if !breaker.Allow() || breaker.Allow() {
+        t.Fatal("expected exactly one probe once the cooldown passed")
+    }
+    breaker.Record(failure)
+    clock.Advance(30 * time.Second)
+    breaker.Allow()
+    breaker.Record(nil)
+
+    want := []string{BreakerOpen, BreakerHalfOpen, BreakerOpen, BreakerHalfOpen, BreakerClosed}
+    if !reflect.DeepEqual(states, want) {
+        t.Errorf("transitions = %v, want %v", states, want)
+    }
+}
+
+// newFailingBucket returns a Redis token bucket of capacity 4 and its
+// miniredis server, which fails every command once broken
+func newFailingBucket(t *testing.T) (*RedisTokenBucket, *miniredis.Miniredis) {
+    t.Helper()
+    server := miniredis.RunT(t)
+    client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+    t.Cleanup(func() { client.Close() })
+    return NewRedisTokenBucket(&database.RedisClient{Client: client}, 4, 0.001, 1), server
+}
+
+func TestResilientLimiterFailureModes(t *testing.T) {
+    tests := []struct {
+        mode    string
+        allowed []bool
+    }{
+        {FailOpen, []bool{true, true, true}},
+        {FailClosed, []bool{false, false, false}},
+        // Two replicas share the capacity of 4
+        {FailLocal, []bool{true, true, false}},
+    }
+    for _, tt := range tests {
+        t.Run(tt.mode, func(t *testing.T) {
+            bucket, server := newFailingBucket(t)
+            breaker := NewCircuitBreaker(2, time.Minute)
+            fallback := NewBoundedMemoryTokenBucket(t.Context(), PerReplica(4, 2), 0.001, 1, DefaultMemoryStoreConfig())
+            limiter := NewResilientLimiter(bucket, fallback, Resilience{Mode: tt.mode, Replicas: 2, Breaker: breaker})
+
+            if d, err := limiter.Allow(context.Background(), "ip:10.0.0.1", 1); err != nil || !d.Allowed || d.Degraded != "" {
+                t.Fatalf("healthy Redis = %+v, %v", d, err)
+            }
+
+            server.SetError("LOADING Redis is loading the dataset in memory")
+            for i, want := range tt.allowed {
+                d, err := limiter.Allow(context.Background(), "ip:10.0.0.1", 1)
+                if err != nil {
+                    t.Fatalf("request %d failed: %v", i+1, err)
+                }
+                if d.Allowed != want || d.Degraded != tt.mode {
+                    t.Errorf("request %d = %+v, want allowed=%v in mode %s", i+1, d, want, tt.mode)
+                }
+                if !d.Allowed && d.RetryAfter <= 0 {
+                    t.Errorf("request %d has no Retry-After", i+1)
+                }
+            }
+
+            // The breaker opened after two errors and stopped calling Redis
+            if breaker.State() != BreakerOpen {
+                t.Errorf("breaker = %s, want open", breaker.State())
+            }
+            calls := server.CommandCount()
+            limiter.Allow(context.Background(), "ip:10.0.0.1", 1)
+            if server.CommandCount() != calls {
+                t.Error("an open breaker still sent commands to Redis")
+            }
+        })
+    }
+}
+
+func TestFailOpenSetsNoRateLimitHeaders(t *testing.T) {
+    bucket, server := newFailingBucket(t)
+    server.SetError("ERR connection lost")
+    limiter := NewResilientLimiter(bucket, nil, Resilience{Mode: FailOpen})
+
+    gin.SetMode(gin.TestMode)
+    router := gin.New()
+    router.Use(RateLimitMiddleware(limiter, "redis"))
+    router.GET("/", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+
+    rec := httptest.NewRecorder()
+    router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+    if rec.Code != http.StatusNoContent || rec.Header().Get("X-RateLimit-Remaining") != "" {
+        t.Errorf("fail open = %d remaining=%q, want no made-up headers", rec.Code, rec.Header().Get("X-RateLimit-Remaining"))
+    }
+}
+
+func TestPolicyLimiterFallsBackToReplicaShare(t *testing.T) {
+    server := miniredis.RunT(t)
+    client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+    t.Cleanup(func() { client.Close() })
+    server.SetError("ERR connection lost")
+
+    policies := []Policy{{
+        Name:   "login",
+        Routes: []string{"POST /api/v1/auth/login"},
+        Limits: []Limit{{Name: "ip", Requests: 10, Period: time.Minute, Key: []string{KeyIP}}},
+    }}
+    limiter, err := NewPolicyLimiter(t.Context(), &database.RedisClient{Client: client}, policies, Resilience{Mode: FailLocal, Replicas: 5})
+    if err != nil {
+        t.Fatalf("NewPolicyLimiter failed: %v", err)
+    }
+    gin.SetMode(gin.TestMode)
+    router := gin.New()
+    router.Use(limiter.Middleware())
+    router.POST("/api/v1/auth/login", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+    // Each of five replicas enforces two of the ten requests
+    for i, want := range []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests} {
+        rec := postJSON(router, "/api/v1/auth/login", "{}", "10.0.0.1")
+        if rec.Code != want {
+            t.Errorf("attempt %d = %d, want %d", i+1, rec.Code, want)
+        }
+        if i == 0 && rec.Header().Get("X-RateLimit-Limit") != "2" {
+            t.Errorf("X-RateLimit-Limit = %q, want the local share", rec.Header().Get("X-RateLimit-Limit"))
+        }
+    }
+}
+
+func TestLimitPerReplicaKeepsRoomForCost(t *testing.T) {
+    limit := Limit{Requests: 10, Burst: 20, Period: time.Minute}
+    if got := limit.perReplica(4, 1); got.Requests != 3 || got.Burst != 5 {
+        t.Errorf("perReplica = %+v", got)
+    }
+    if got := limit.perReplica(4, 8); got.capacity() != 8 {
+        t.Errorf("capacity = %d, want room for a cost of 8", got.capacity())
+    }
+    if got := (Limit{Requests: 3}).perReplica(1, 1); got.Requests != 3 || got.Burst != 0 {
+        t.Errorf("a single replica changed the limit: %+v", got)
+    }
+}
\ No newline at end of file
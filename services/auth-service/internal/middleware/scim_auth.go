@@ -0,0 +1,40 @@
+package middleware
+
+import (
+    "net/http"
+
+    "github.com/gin-gonic/gin"
+    "github.com/Shridhar2104/chat-platform/auth-service/internal/models"
+    "github.com/Shridhar2104/chat-platform/auth-service/internal/services"
+    "github.com/Shridhar2104/chat-platform/shared/authclient"
+)
+
+// SCIMAuthMiddleware authenticates directory provisioning requests with a
+// per-workspace bearer token and scopes the request to that workspace
+func SCIMAuthMiddleware(scimService *services.SCIMService) gin.HandlerFunc {
+    return func(c *gin.Context) {
+        bearer, ok := authclient.BearerToken(c.GetHeader("Authorization"))
+        if !ok {
+            abortSCIMUnauthorized(c, "Authorization header must be in format: Bearer <token>")
+            return
+        }
+
+        token, err := scimService.Authenticate(c.Request.Context(), bearer)
+        if err != nil {
+            abortSCIMUnauthorized(c, "Invalid or revoked SCIM token")
+            return
+        }
+
+        c.Set("workspace_id", token.WorkspaceID)
+        c.Next()
+    }
+}
+
+func abortSCIMUnauthorized(c *gin.Context, detail string) {
+    c.Header("Content-Type", "application/scim+json")
+    c.AbortWithStatusJSON(http.StatusUnauthorized, models.SCIMError{
+        Schemas: []string{models.SCIMErrorSchema},
+        Status:  "401",
+        Detail:  detail,
+    })
+}
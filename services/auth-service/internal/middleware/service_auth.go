@@ -0,0 +1,22 @@
+package middleware
+
+import (
+    "crypto/subtle"
+    "net/http"
+
+    "github.com/gin-gonic/gin"
+    "github.com/Shridhar2104/chat-platform/auth-service/internal/apierrors"
+    "github.com/Shridhar2104/chat-platform/shared/authclient"
+)
+
+// ServiceTokenMiddleware admits internal services presenting the shared bearer token
+func ServiceTokenMiddleware(serviceToken string) gin.HandlerFunc {
+    return func(c *gin.Context) {
+        token, ok := authclient.BearerToken(c.GetHeader("Authorization"))
+        if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(serviceToken)) != 1 {
+            apierrors.Write(c, http.StatusUnauthorized, "invalid_client", "A valid service token is required")
+            return
+        }
+        c.Next()
+    }
+}
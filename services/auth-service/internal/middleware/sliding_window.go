@@ -0,0 +1,195 @@
+package middleware
+
+import (
+    "context"
+    "fmt"
+    "math"
+    "sync"
+    "time"
+
+    "github.com/Shridhar2104/chat-platform/auth-service/internal/tracing"
+    "github.com/Shridhar2104/chat-platform/shared/database"
+    "github.com/redis/go-redis/v9"
+    "go.opentelemetry.io/otel/attribute"
+    semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+)
+
+// slidingWindow approximates a sliding log from two fixed windows: the
+// previous window's count is weighted by how much of it still overlaps
+type slidingWindow struct {
+    limit  int
+    window time.Duration
+}
+
+// position returns the index of the window holding now and how far into it now is
+func (s slidingWindow) position(now time.Time) (int64, time.Duration) {
+    index := now.UnixNano() / int64(s.window)
+    return index, time.Duration(now.UnixNano() - index*int64(s.window))
+}
+
+func (s slidingWindow) estimate(elapsed time.Duration, previous, current int) float64 {
+    weight := float64(s.window-elapsed) / float64(s.window)
+    return float64(previous)*weight + float64(current)
+}
+
+// decide judges a request of cost given the counts before it
+func (s slidingWindow) decide(elapsed time.Duration, previous, current, cost int) Decision {
+    estimate := s.estimate(elapsed, previous, current)
+    if estimate+float64(cost) <= float64(s.limit) {
+        return Decision{
+            Allowed:    true,
+            Limit:      s.limit,
+            Remaining:  int(math.Floor(float64(s.limit) - estimate - float64(cost))),
+            ResetAfter: 2*s.window - elapsed,
+            Window:     s.window,
+        }
+    }
+    return Decision{
+        Allowed:    false,
+        Limit:      s.limit,
+        Remaining:  max(0, int(math.Floor(float64(s.limit)-estimate))),
+        RetryAfter: s.retryAfter(elapsed, previous, current, cost),
+        ResetAfter: 2*s.window - elapsed,
+        Window:     s.window,
+    }
+}
+
+// retryAfter solves for when enough of the previous window has slid out
+func (s slidingWindow) retryAfter(elapsed time.Duration, previous, current, cost int) time.Duration {
+    free := float64(s.limit - current - cost)
+    if free >= 0 && previous > 0 {
+        at := time.Duration(float64(s.window) * (1 - free/float64(previous)))
+        return max(at-elapsed, 0)
+    }
+
+    // Wait for the next window, where the current count becomes the previous one
+    untilNext := s.window - elapsed
+    if current == 0 {
+        return untilNext
+    }
+    free = float64(s.limit - cost)
+    at := time.Duration(float64(s.window) * (1 - free/float64(current)))
+    return untilNext + max(at, 0)
+}
+
+type windowCounts struct {
+    index    int64
+    previous int
+    current  int
+}
+
+// MemorySlidingWindow is a sliding window counter held in process memory
+type MemorySlidingWindow struct {
+    slidingWindow
+    now func() time.Time
+
+    mu        sync.Mutex
+    counts    map[string]*windowCounts
+    lastSweep int64
+}
+
+func NewMemorySlidingWindow(limit int, window time.Duration) *MemorySlidingWindow {
+    return &MemorySlidingWindow{
+        slidingWindow: slidingWindow{limit: limit, window: window},
+        now:           time.Now,
+        counts:        make(map[string]*windowCounts),
+    }
+}
+
+func (s *MemorySlidingWindow) Allow(ctx context.Context, key string, cost int) (Decision, error) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    index, elapsed := s.position(s.now())
+    counts, ok := s.counts[key]
+    if !ok {
+        counts = &windowCounts{index: index}
+        s.counts[key] = counts
+    }
+    switch {
+    case counts.index == index-1:
+        counts.previous, counts.current = counts.current, 0
+    case counts.index < index-1:
+        counts.previous, counts.current = 0, 0
+    }
+    counts.index = index
+
+    decision := s.decide(elapsed, counts.previous, counts.current, cost)
+    if decision.Allowed {
+        counts.current += cost
+    }
+
+    // Counts two windows old no longer affect any estimate
+    if index > s.lastSweep {
+        for k, c := range s.counts {
+            if c.index < index-1 {
+                delete(s.counts, k)
+            }
+        }
+        s.lastSweep = index
+    }
+    return decision, nil
+}
+
+// slidingWindowScript counts into one key per window and returns the counts
+// seen before the request, so the decision is made with the Go arithmetic
+var slidingWindowScript = redis.NewScript(`
+local limit = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local elapsed = tonumber(ARGV[3])
+local cost = tonumber(ARGV[4])
+
+local current = tonumber(redis.call('GET', KEYS[1])) or 0
+local previous = tonumber(redis.call('GET', KEYS[2])) or 0
+local estimate = previous * (window - elapsed) / window + current
+
+if estimate + cost > limit then
+    return {0, previous, current}
+end
+redis.call('INCRBY', KEYS[1], cost)
+redis.call('PEXPIRE', KEYS[1], window * 2)
+return {1, previous, current}
+`)
+
+// RedisSlidingWindow shares sliding window counters between replicas
+type RedisSlidingWindow struct {
+    slidingWindow
+    redis     *database.RedisClient
+    keyPrefix string
+    now       func() time.Time
+}
+
+func NewRedisSlidingWindow(redis *database.RedisClient, keyPrefix string, limit int, window time.Duration) *RedisSlidingWindow {
+    return &RedisSlidingWindow{
+        slidingWindow: slidingWindow{limit: limit, window: window},
+        redis:         redis,
+        keyPrefix:     keyPrefix,
+        now:           time.Now,
+    }
+}
+
+func (s *RedisSlidingWindow) Allow(ctx context.Context, key string, cost int) (Decision, error) {
+    ctx, span := tracing.StartDB(ctx, semconv.DBSystemRedis, "EVALSHA", "")
+    span.SetAttributes(attribute.String("ratelimit.algorithm", AlgorithmSlidingWindow), attribute.String("ratelimit.key_prefix", s.keyPrefix))
+    defer span.End()
+
+    index, elapsed := s.position(s.now())
+    keys := []string{
+        fmt.Sprintf("%s:%s:%d", s.keyPrefix, key, index),
+        fmt.Sprintf("%s:%s:%d", s.keyPrefix, key, index-1),
+    }
+    result, err := slidingWindowScript.Run(ctx, s.redis.Client, keys,
+        s.limit, s.window.Milliseconds(), elapsed.Milliseconds(), cost).Int64Slice()
+    if err != nil {
+        tracing.RecordError(span, err)
+        return Decision{}, fmt.Errorf("redis sliding window error: %w", err)
+    }
+    if len(result) != 3 {
+        return Decision{}, fmt.Errorf("unexpected redis sliding window result %v", result)
+    }
+
+    decision := s.decide(elapsed, int(result[1]), int(result[2]), cost)
+    decision.Allowed = result[0] == 1
+    span.SetAttributes(attribute.Bool("ratelimit.allowed", decision.Allowed))
+    return decision, nil
+}
\ No newline at end of file
@@ -0,0 +1,47 @@
+package middleware
+
+import (
+    "context"
+    "errors"
+    "time"
+
+    "github.com/gin-gonic/gin"
+    "github.com/Shridhar2104/chat-platform/auth-service/internal/apierrors"
+)
+
+// TimeoutMiddleware bounds each request with a deadline that services and
+// repositories see through the request context. Routes are looked up in
+// routeTimeouts as "METHOD /path" first, then "/path", using the Gin route
+// pattern; anything else gets defaultTimeout. A zero timeout disables the
+// deadline. Handlers that return without writing once the deadline has
+// passed are answered with a 504.
+func TimeoutMiddleware(defaultTimeout time.Duration, routeTimeouts map[string]time.Duration) gin.HandlerFunc {
+    return func(c *gin.Context) {
+        timeout := RouteTimeout(c.Request.Method, c.FullPath(), defaultTimeout, routeTimeouts)
+        if timeout <= 0 {
+            c.Next()
+            return
+        }
+
+        ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+        defer cancel()
+        c.Request = c.Request.WithContext(ctx)
+
+        c.Next()
+
+        if !c.Writer.Written() && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+            apierrors.Timeout(c)
+        }
+    }
+}
+
+// RouteTimeout resolves the deadline for a route
+func RouteTimeout(method, path string, defaultTimeout time.Duration, routeTimeouts map[string]time.Duration) time.Duration {
+    if timeout, ok := routeTimeouts[method+" "+path]; ok {
+        return timeout
+    }
+    if timeout, ok := routeTimeouts[path]; ok {
+        return timeout
+    }
+    return defaultTimeout
+}
\ No newline at end of file
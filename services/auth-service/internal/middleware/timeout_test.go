@@ -0,0 +1,89 @@
+package middleware
+
+import (
+    "encoding/json"
+    "fmt"
+    "net/http"
+    "net/http/httptest"
+    "testing"
+    "time"
+
+    "github.com/gin-gonic/gin"
+    "github.com/Shridhar2104/chat-platform/auth-service/internal/apierrors"
+    "github.com/Shridhar2104/chat-platform/auth-service/internal/models"
+)
+
+func TestRouteTimeout(t *testing.T) {
+    routeTimeouts := map[string]time.Duration{
+        "POST /api/v1/auth/login": time.Second,
+        "/scim/v2/Users":          30 * time.Second,
+    }
+
+    tests := []struct {
+        method string
+        path   string
+        want   time.Duration
+    }{
+        {method: http.MethodPost, path: "/api/v1/auth/login", want: time.Second},
+        {method: http.MethodGet, path: "/api/v1/auth/login", want: 10 * time.Second},
+        {method: http.MethodPatch, path: "/scim/v2/Users", want: 30 * time.Second},
+        {method: http.MethodGet, path: "/health", want: 10 * time.Second},
+    }
+
+    for _, tt := range tests {
+        if got := RouteTimeout(tt.method, tt.path, 10*time.Second, routeTimeouts); got != tt.want {
+            t.Errorf("RouteTimeout(%s %s) = %s, want %s", tt.method, tt.path, got, tt.want)
+        }
+    }
+}
+
+func newTimeoutRouter(handler gin.HandlerFunc) *gin.Engine {
+    gin.SetMode(gin.TestMode)
+    router := gin.New()
+    router.Use(TimeoutMiddleware(time.Second, map[string]time.Duration{"/slow": 20 * time.Millisecond}))
+    router.GET("/slow", handler)
+    router.GET("/fast", handler)
+    return router
+}
+
+// waitForDeadline blocks like a context-aware repository call and surfaces the error
+func waitForDeadline(c *gin.Context) {
+    select {
+    case <-c.Request.Context().Done():
+        apierrors.Respond(c, fmt.Errorf("failed to get user: %w", c.Request.Context().Err()))
+    case <-time.After(200 * time.Millisecond):
+        c.Status(http.StatusOK)
+    }
+}
+
+func TestTimeoutMiddlewareReturns504(t *testing.T) {
+    router := newTimeoutRouter(waitForDeadline)
+
+    rec := httptest.NewRecorder()
+    router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/slow", nil))
+    if rec.Code != http.StatusGatewayTimeout {
+        t.Fatalf("status = %d, want %d", rec.Code, http.StatusGatewayTimeout)
+    }
+    var problem models.Problem
+    if err := json.Unmarshal(rec.Body.Bytes(), &problem); err != nil || problem.Code != apierrors.CodeTimeout {
+        t.Errorf("got body %s", rec.Body.String())
+    }
+
+    rec = httptest.NewRecorder()
+    router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/fast", nil))
+    if rec.Code != http.StatusOK {
+        t.Errorf("status = %d, want %d", rec.Code, http.StatusOK)
+    }
+}
+
+func TestTimeoutMiddlewareAnswersSilentHandlers(t *testing.T) {
+    router := newTimeoutRouter(func(c *gin.Context) {
+        <-c.Request.Context().Done()
+    })
+
+    rec := httptest.NewRecorder()
+    router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/slow", nil))
+    if rec.Code != http.StatusGatewayTimeout {
+        t.Errorf("status = %d, want %d", rec.Code, http.StatusGatewayTimeout)
+    }
+}
\ No newline at end of file
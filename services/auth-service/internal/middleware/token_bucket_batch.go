@@ -0,0 +1,112 @@
+package middleware
+
+import (
+    "context"
+    "sync"
+    "time"
+)
+
+// batchLifetime bounds how long reserved tokens may be spent locally, and
+// so how far a replica's view of a hot key can lag behind Redis
+const batchLifetime = time.Second
+
+// BatchingTokenBucket spends tokens of a Redis token bucket locally for hot
+// keys. Once a key makes hotRate requests in a second on this replica, its
+// tokens are reserved batchSize at a time, so a burst costs one round trip
+// per batch instead of per request. Reserved tokens unused after
+// batchLifetime are lost, which errs on the side of the limit.
+type BatchingTokenBucket struct {
+    bucket    *RedisTokenBucket
+    batchSize int
+    hotRate   int
+    now       func() time.Time
+
+    mu        sync.Mutex
+    keys      map[string]*localBatch
+    lastSweep time.Time
+}
+
+type localBatch struct {
+    tokens  int
+    expires time.Time
+    // hits counts the requests of the second starting at window
+    window time.Time
+    hits   int
+}
+
+func NewBatchingTokenBucket(bucket *RedisTokenBucket, batchSize, hotRate int) *BatchingTokenBucket {
+    return &BatchingTokenBucket{
+        bucket:    bucket,
+        batchSize: batchSize,
+        hotRate:   max(1, hotRate),
+        now:       time.Now,
+        keys:      make(map[string]*localBatch),
+    }
+}
+
+func (b *BatchingTokenBucket) Allow(ctx context.Context, key string, cost int) (Decision, error) {
+    now := b.now()
+    limits := b.bucket.load()
+
+    b.mu.Lock()
+    b.sweep(now)
+    batch, ok := b.keys[key]
+    if !ok {
+        batch = &localBatch{window: now}
+        b.keys[key] = batch
+    }
+    if now.Sub(batch.window) >= time.Second {
+        batch.window, batch.hits = now, 0
+    }
+    batch.hits++
+    if batch.tokens >= cost && now.Before(batch.expires) {
+        batch.tokens -= cost
+        remaining := batch.tokens
+        b.mu.Unlock()
+        return Decision{Allowed: true, Limit: limits.capacity, Remaining: remaining, Window: limits.window()}, nil
+    }
+    hot := batch.hits >= b.hotRate
+    b.mu.Unlock()
+
+    reserve := min(max(cost, b.batchSize), limits.capacity)
+    if !hot || reserve == cost {
+        return b.bucket.Allow(ctx, key, cost)
+    }
+
+    decision, err := b.bucket.Allow(ctx, key, reserve)
+    if err != nil {
+        return Decision{}, err
+    }
+    if !decision.Allowed {
+        // Less than a batch is left, spend only what the request needs
+        return b.bucket.Allow(ctx, key, cost)
+    }
+
+    b.mu.Lock()
+    defer b.mu.Unlock()
+    if batch, ok = b.keys[key]; !ok {
+        batch = &localBatch{window: now}
+        b.keys[key] = batch
+    }
+    if !now.Before(batch.expires) {
+        batch.tokens = 0
+    }
+    batch.tokens += reserve - cost
+    batch.expires = now.Add(batchLifetime)
+    decision.Remaining += batch.tokens
+    return decision, nil
+}
+
+// sweep drops keys with neither live tokens nor recent hits, at most once a
+// lifetime; the caller holds mu
+func (b *BatchingTokenBucket) sweep(now time.Time) {
+    if now.Sub(b.lastSweep) < batchLifetime {
+        return
+    }
+    for key, batch := range b.keys {
+        if !now.Before(batch.expires) && now.Sub(batch.window) >= time.Second {
+            delete(b.keys, key)
+        }
+    }
+    b.lastSweep = now
+}
\ No newline at end of file
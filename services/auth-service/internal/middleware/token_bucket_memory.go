@@ -1,69 +1,64 @@
 package middleware
 
 import (
-    "fmt"
+    "context"
     "math"
-    "net/http"
-    "sync"
     "time"
 
     "github.com/gin-gonic/gin"
-    "github.com/Shridhar2104/chat-platform/auth-service/internal/models"
 )
 
+// MemoryTokenBucket keeps token buckets in process memory, bounded by a
+// sharded LRU store. Idle buckets are cleaned up in the background until
+// the context it was built with is done or it is closed.
 type MemoryTokenBucket struct {
-    buckets      map[string]*tokenBucket
-    mutex        sync.RWMutex
-    capacity     int
-    refillRate   float64
+    buckets *memoryStore[tokenBucket]
+    limitsHolder
     tokensPerReq int
+
+    stop context.CancelFunc
+    done chan struct{}
 }
 
 type tokenBucket struct {
     tokens     float64
     lastRefill time.Time
-    mutex      sync.Mutex
 }
 
-func NewMemoryTokenBucket(capacity int, refillRate float64, tokensPerReq int) *MemoryTokenBucket {
+// refill adds the tokens earned since the last refill, up to capacity
+func (b *tokenBucket) refill(now time.Time, limits *bucketLimits) {
+    elapsed := now.Sub(b.lastRefill).Seconds()
+    b.tokens = math.Min(float64(limits.capacity), b.tokens+elapsed*limits.refillRate)
+    b.lastRefill = now
+}
+
+// NewBoundedMemoryTokenBucket keeps the buckets in a store bounded by
+// store, cleaning up idle ones until ctx is done
+func NewBoundedMemoryTokenBucket(ctx context.Context, capacity int, refillRate float64, tokensPerReq int, store MemoryStoreConfig) *MemoryTokenBucket {
+    ctx, stop := context.WithCancel(ctx)
     tb := &MemoryTokenBucket{
-        buckets:      make(map[string]*tokenBucket),
-        capacity:     capacity,
-        refillRate:   refillRate,
+        buckets:      newMemoryStore[tokenBucket](store),
         tokensPerReq: tokensPerReq,
+        stop:         stop,
+        done:         make(chan struct{}),
     }
+    tb.SetLimits(capacity, refillRate)
     
-    // Start cleanup routine
-    go tb.cleanup()
+    go tb.cleanup(ctx)
     
     return tb
 }
 
-// MemoryTokenBucketMiddleware creates an in-memory token bucket rate limiter
-func MemoryTokenBucketMiddleware(requestsPerMinute int) gin.HandlerFunc {
-    refillRate := float64(requestsPerMinute) / 60.0
-    capacity := requestsPerMinute
-    
-    limiter := NewMemoryTokenBucket(capacity, refillRate, 1)
-
-    return func(c *gin.Context) {
-        identifier := getIdentifier(c)
-        
-        allowed, tokens, waitTime := limiter.AllowRequest(identifier)
-        
-        setRateLimitHeaders(c, capacity, int(tokens), time.Now().Add(waitTime))
-
-        if !allowed {
-            c.JSON(http.StatusTooManyRequests, models.ErrorResponse{
-                Error:   "rate_limit_exceeded",
-                Message: fmt.Sprintf("Rate limit exceeded. Try again in %.2f seconds.", waitTime.Seconds()),
-            })
-            c.Abort()
-            return
-        }
+// Close stops the cleanup routine and waits for it to exit
+func (mtb *MemoryTokenBucket) Close() error {
+    mtb.stop()
+    <-mtb.done
+    return nil
+}
 
-        c.Next()
-    }
+// MemoryTokenBucketMiddleware rate limits requests with an in-memory token bucket
+func MemoryTokenBucketMiddleware(limiter *MemoryTokenBucket) gin.HandlerFunc {
+    return RateLimitMiddleware(limiter, "memory")
 }
 
 // AllowRequest checks if request is allowed
@@ -73,97 +68,76 @@ func (mtb *MemoryTokenBucket) AllowRequest(identifier string) (allowed bool, rem
 
 // AllowRequestWithTokens allows custom token cost
 func (mtb *MemoryTokenBucket) AllowRequestWithTokens(identifier string, tokensNeeded int) (allowed bool, remainingTokens float64, waitTime time.Duration) {
-    bucket := mtb.getOrCreateBucket(identifier)
-    
-    bucket.mutex.Lock()
-    defer bucket.mutex.Unlock()
-    
     now := time.Now()
+    limits := mtb.load()
     
-    // Calculate tokens to add
-    timeElapsed := now.Sub(bucket.lastRefill).Seconds()
-    tokensToAdd := timeElapsed * mtb.refillRate
-    
-    // Update tokens (cap at capacity)
-    bucket.tokens = math.Min(float64(mtb.capacity), bucket.tokens+tokensToAdd)
-    bucket.lastRefill = now
-    
-    // Check if enough tokens
-    if bucket.tokens >= float64(tokensNeeded) {
-        bucket.tokens -= float64(tokensNeeded)
-        return true, bucket.tokens, 0
+    newBucket := func() tokenBucket {
+        return tokenBucket{tokens: float64(limits.capacity), lastRefill: now}
     }
+    mtb.buckets.update(identifier, newBucket, func(bucket *tokenBucket) {
+        bucket.refill(now, limits)
+        
+        // Check if enough tokens
+        if bucket.tokens >= float64(tokensNeeded) {
+            bucket.tokens -= float64(tokensNeeded)
+            allowed = true
+        } else {
+            // Calculate wait time
+            deficit := float64(tokensNeeded) - bucket.tokens
+            waitTime = time.Duration(deficit / limits.refillRate * float64(time.Second))
+        }
+        remainingTokens = bucket.tokens
+    })
     
-    // Calculate wait time
-    deficit := float64(tokensNeeded) - bucket.tokens
-    waitTime = time.Duration(deficit/mtb.refillRate) * time.Second
-    
-    return false, bucket.tokens, waitTime
+    return allowed, remainingTokens, waitTime
 }
 
-// getOrCreateBucket safely gets or creates a bucket
-func (mtb *MemoryTokenBucket) getOrCreateBucket(identifier string) *tokenBucket {
-    mtb.mutex.RLock()
-    bucket, exists := mtb.buckets[identifier]
-    mtb.mutex.RUnlock()
-    
-    if exists {
-        return bucket
-    }
-    
-    mtb.mutex.Lock()
-    defer mtb.mutex.Unlock()
-    
-    // Double check after acquiring write lock
-    if bucket, exists := mtb.buckets[identifier]; exists {
-        return bucket
-    }
-    
-    // Create new bucket
-    bucket = &tokenBucket{
-        tokens:     float64(mtb.capacity),
-        lastRefill: time.Now(),
+// GetBucketState returns current bucket state, a full bucket for unknown identifiers
+func (mtb *MemoryTokenBucket) GetBucketState(ctx context.Context, identifier string) (*BucketState, error) {
+    now := time.Now()
+    limits := mtb.load()
+    state := &BucketState{
+        Tokens:     float64(limits.capacity),
+        LastRefill: now,
+        Capacity:   limits.capacity,
+        RefillRate: limits.refillRate,
     }
-    mtb.buckets[identifier] = bucket
     
-    return bucket
+    // Update tokens before returning state
+    mtb.buckets.view(identifier, func(bucket *tokenBucket) {
+        bucket.refill(now, limits)
+        state.Tokens, state.LastRefill = bucket.tokens, bucket.lastRefill
+    })
+    return state, nil
 }
 
-// GetBucketState returns current bucket state
-func (mtb *MemoryTokenBucket) GetBucketState(identifier string) (tokens float64, lastRefill time.Time) {
-    bucket := mtb.getOrCreateBucket(identifier)
-    
-    bucket.mutex.Lock()
-    defer bucket.mutex.Unlock()
-    
-    // Update tokens before returning state
-    now := time.Now()
-    timeElapsed := now.Sub(bucket.lastRefill).Seconds()
-    tokensToAdd := timeElapsed * mtb.refillRate
-    bucket.tokens = math.Min(float64(mtb.capacity), bucket.tokens+tokensToAdd)
-    bucket.lastRefill = now
-    
-    return bucket.tokens, bucket.lastRefill
+// Reset clears the bucket for an identifier
+func (mtb *MemoryTokenBucket) Reset(ctx context.Context, identifier string) error {
+    mtb.buckets.remove(identifier)
+    return nil
 }
 
-// cleanup removes old buckets
-func (mtb *MemoryTokenBucket) cleanup() {
+// cleanup removes idle buckets until ctx is done
+func (mtb *MemoryTokenBucket) cleanup(ctx context.Context) {
+    defer close(mtb.done)
     ticker := time.NewTicker(5 * time.Minute)
     defer ticker.Stop()
     
-    for range ticker.C {
-        mtb.mutex.Lock()
-        now := time.Now()
-        
-        for identifier, bucket := range mtb.buckets {
-            bucket.mutex.Lock()
-            // Remove buckets that haven't been used in 10 minutes
-            if now.Sub(bucket.lastRefill) > 10*time.Minute {
-                delete(mtb.buckets, identifier)
-            }
-            bucket.mutex.Unlock()
+    for {
+        select {
+        case <-ctx.Done():
+            return
+        case now := <-ticker.C:
+            mtb.sweep(now)
         }
-        
-        mtb.mutex.Unlock()
     }
+}
+
+// sweep drops idle buckets. An idle bucket is only dropped once it would
+// have refilled, so limits with long periods are not reset early.
+func (mtb *MemoryTokenBucket) sweep(now time.Time) {
+    idle := max(10*time.Minute, mtb.load().window())
+    mtb.buckets.sweep(func(bucket *tokenBucket) bool {
+        return now.Sub(bucket.lastRefill) > idle
+    })
 }
\ No newline at end of file
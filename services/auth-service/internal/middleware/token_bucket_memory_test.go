@@ -0,0 +1,42 @@
+package middleware
+
+import (
+    "net/http"
+    "net/http/httptest"
+    "testing"
+    "time"
+
+    "github.com/gin-gonic/gin"
+)
+
+func TestMemoryTokenBucketAppliesNewLimits(t *testing.T) {
+    gin.SetMode(gin.TestMode)
+    limiter := NewBoundedMemoryTokenBucket(t.Context(), 1, 0.001, 1, DefaultMemoryStoreConfig())
+    router := gin.New()
+    router.Use(MemoryTokenBucketMiddleware(limiter))
+    router.GET("/", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+
+    request := func() *httptest.ResponseRecorder {
+        rec := httptest.NewRecorder()
+        router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+        return rec
+    }
+
+    if rec := request(); rec.Code != http.StatusNoContent {
+        t.Fatalf("first request = %d", rec.Code)
+    }
+    if rec := request(); rec.Code != http.StatusTooManyRequests {
+        t.Fatalf("second request = %d, want the bucket to be empty", rec.Code)
+    }
+
+    // A reload raising the limit refills buckets at the new rate
+    limiter.SetLimits(100, 1000)
+    time.Sleep(5 * time.Millisecond)
+    rec := request()
+    if rec.Code != http.StatusNoContent {
+        t.Errorf("request after raising the limit = %d", rec.Code)
+    }
+    if got := rec.Header().Get("X-RateLimit-Limit"); got != "100" {
+        t.Errorf("X-RateLimit-Limit = %s, want the new capacity", got)
+    }
+}
\ No newline at end of file
@@ -3,19 +3,20 @@ package middleware
 import (
 	"context"
 	"fmt"
-	"net/http"
 	"strconv"
 	"time"
 
-	"github.com/Shridhar2104/chat-platform/auth-service/internal/models"
+	"github.com/Shridhar2104/chat-platform/auth-service/internal/tracing"
 	"github.com/Shridhar2104/chat-platform/shared/database"
 	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
 )
 
 type RedisTokenBucket struct{
 	redis *database.RedisClient
-	capacity int
-	refillRate	float64
+	limitsHolder
 	tokensPerReq	int
 	keyPrefix 	string
 }
@@ -29,123 +30,128 @@ type BucketState struct{
 }
 
 func NewRedisTokenBucket(redis *database.RedisClient, capacity int, refillRate float64, tokensPerReq int) *RedisTokenBucket {
-    return &RedisTokenBucket{
+    tb := &RedisTokenBucket{
         redis:        redis,
-        capacity:     capacity,
-        refillRate:   refillRate,
         tokensPerReq: tokensPerReq,
         keyPrefix:    "rate_limit:bucket",
     }
+    tb.SetLimits(capacity, refillRate)
+    return tb
 }
 
-func TokenBucketMiddleware(redis *database.RedisClient, requestsPerMinute int)	gin.HandlerFunc{
-	//Convert requests per minute to tokens per second
-	refillRate:= float64(requestsPerMinute)/60.0
-	capacity := requestsPerMinute
-	bucket := NewRedisTokenBucket(redis, capacity, refillRate, 1)
-	return func(c *gin.Context){
-		identifier := getIdentifier(c)
-		allowed, tokens, waitTime, err:= bucket.AllowRequest(identifier)
-		if err != nil {
-            // If Redis is down, allow request but log error
-            setRateLimitHeaders(c, capacity, capacity, time.Now())
-            c.Next()
-            return
-        }
-		setRateLimitHeaders(c, capacity, int(tokens), time.Now().Add(waitTime))
-
-		if !allowed {
-            c.JSON(http.StatusTooManyRequests, models.ErrorResponse{
-                Error:   "rate_limit_exceeded",
-                Message: fmt.Sprintf("Rate limit exceeded. Try again in %.2f seconds.", waitTime.Seconds()),
-            })
-            c.Abort()
-            return
-        }
-
-        c.Next()
-	}
-
+// TokenBucketMiddleware rate limits requests with a token bucket shared
+// through Redis. Redis errors fail open; wrap the bucket in a
+// ResilientLimiter to choose another failure mode.
+func TokenBucketMiddleware(bucket *RedisTokenBucket)	gin.HandlerFunc{
+	return RateLimitMiddleware(bucket, "redis")
 }
+
 // AllowRequest checks if a request is allowed and consumes tokens
-func (tb *RedisTokenBucket) AllowRequest(identifier string) (allowed bool, remainingTokens float64, waitTime time.Duration, err error) {
-    return tb.AllowRequestWithTokens(identifier, tb.tokensPerReq)
+func (tb *RedisTokenBucket) AllowRequest(ctx context.Context, identifier string) (allowed bool, remainingTokens float64, waitTime time.Duration, err error) {
+    return tb.AllowRequestWithTokens(ctx, identifier, tb.tokensPerReq)
 }
 
 
-func (tb *RedisTokenBucket) AllowRequestWithTokens(identifier string, tokensNeeded int)(allowed bool,remainingTokens float64, waitTime time.Duration, err error){
-	ctx := context.Background()
+// tokenBucketScript refills and spends in one step on Redis time in
+// milliseconds, so replicas with skewed clocks agree and refill is not
+// rounded to whole seconds
+var tokenBucketScript = redis.NewScript(`
+-- Older Redis refuses writes after TIME unless effects are replicated
+if redis.replicate_commands then
+    redis.replicate_commands()
+end
+
+local key = KEYS[1]
+local capacity = tonumber(ARGV[1])
+local refill_rate = tonumber(ARGV[2])
+local tokens_needed = tonumber(ARGV[3])
+
+local time = redis.call('TIME')
+local now = tonumber(time[1]) * 1000 + math.floor(tonumber(time[2]) / 1000)
+-- keep the bucket until it would be full again, so long periods are not reset early
+local ttl = math.max(300, math.ceil(capacity / refill_rate))
+
+local bucket_data = redis.call('HMGET', key, 'tokens', 'last_refill')
+local current_tokens = tonumber(bucket_data[1]) or capacity
+local last_refill = tonumber(bucket_data[2]) or now
+
+-- refill_rate is per second, time is in milliseconds
+local elapsed = math.max(0, now - last_refill)
+current_tokens = math.min(capacity, current_tokens + elapsed * refill_rate / 1000)
+
+local allowed = 0
+local wait_ms = 0
+if current_tokens >= tokens_needed then
+    current_tokens = current_tokens - tokens_needed
+    allowed = 1
+else
+    wait_ms = math.ceil((tokens_needed - current_tokens) / refill_rate * 1000)
+end
+
+redis.call('HSET', key, 'tokens', tostring(current_tokens), 'last_refill', now)
+redis.call('EXPIRE', key, ttl)
+
+-- Lua numbers are truncated to integers in replies, the token count is
+-- returned as a string to keep its fraction
+return {allowed, tostring(current_tokens), wait_ms}
+`)
+
+func (tb *RedisTokenBucket) AllowRequestWithTokens(ctx context.Context, identifier string, tokensNeeded int)(allowed bool,remainingTokens float64, waitTime time.Duration, err error){
     key := fmt.Sprintf("%s:%s", tb.keyPrefix, identifier)
-    now := time.Now()
 
-	//lua script for atomic token bucket ops
-
-	luaScript:= `
-		local key = KEYS[1]
-		local capacity = tonumber(ARGV[1])
-		local refill_rate = tonumber(ARGV[2])
-		local tokens_needed = tonumber(ARGV[3])
-		local now = tonumber(ARGV[4])
-
-		-- Get current bucket state
-		local bucket_data = redis.call('HMGET', key, 'tokens', 'last_refill')
-		local current_tokens = tonumber(bucket_data[1]) or capacity
-		local last_refill = tonumber(bucket_data[2]) or now
-
-		-- Calculate tokens to add based on time elapsed
-		local time_elapsed = now - last_refill
-		local tokens_to_add = time_elapsed * refill_rate
-
-		-- Update tokens (cap at capacity)
-		current_tokens = math.min(capacity, current_tokens + tokens_to_add)
-
-		-- Check if enough tokens available
-		if current_tokens >= tokens_needed then
-			-- Consume tokens
-			current_tokens = current_tokens - tokens_needed
-			
-			-- Update bucket state
-			redis.call('HMSET', key, 'tokens', current_tokens, 'last_refill', now)
-			redis.call('EXPIRE', key, 300) -- 5 minute expiry for cleanup
-			
-			return {1, current_tokens, 0} -- allowed, remaining_tokens, wait_time
-		else
-			-- Not enough tokens - calculate wait time
-			local tokens_deficit = tokens_needed - current_tokens
-			local wait_time = tokens_deficit / refill_rate
-			
-			-- Update last_refill time but don't consume tokens
-			redis.call('HMSET', key, 'tokens', current_tokens, 'last_refill', now)
-			redis.call('EXPIRE', key, 300)
-			
-			return {0, current_tokens, wait_time} -- not allowed, remaining_tokens, wait_time
-		end
-	`
-
-	result, err := tb.redis.Client.Eval(ctx, luaScript, []string{key}, 
-        tb.capacity, tb.refillRate, tokensNeeded, now.Unix()).Result()
+    ctx, span := tracing.StartDB(ctx, semconv.DBSystemRedis, "EVALSHA", "")
+    span.SetAttributes(attribute.String("ratelimit.key_prefix", tb.keyPrefix), attribute.Int("ratelimit.tokens_needed", tokensNeeded))
+    defer span.End()
+
+    limits := tb.load()
+    result, err := tokenBucketScript.Run(ctx, tb.redis.Client, []string{key},
+        limits.capacity, limits.refillRate, tokensNeeded).Slice()
     if err != nil {
-        return false, 0, 0, fmt.Errorf("redis eval error: %w", err)
+        tracing.RecordError(span, err)
+        return false, 0, 0, fmt.Errorf("redis token bucket error: %w", err)
     }
-
-    // Parse result
-    resultSlice, ok := result.([]interface{})
-    if !ok || len(resultSlice) != 3 {
-        return false, 0, 0, fmt.Errorf("unexpected redis result format")
+    if len(result) != 3 {
+        return false, 0, 0, fmt.Errorf("unexpected redis token bucket result %v", result)
     }
 
-    allowedInt := resultSlice[0].(int64)
-    remainingTokens = resultSlice[1].(float64)
-    waitTimeSeconds := resultSlice[2].(float64)
-    waitTime = time.Duration(waitTimeSeconds * float64(time.Second))
+    allowedFlag, err := luaNumber(result[0])
+    if err != nil {
+        return false, 0, 0, fmt.Errorf("unexpected redis token bucket result: %w", err)
+    }
+    if remainingTokens, err = luaNumber(result[1]); err != nil {
+        return false, 0, 0, fmt.Errorf("unexpected redis token bucket result: %w", err)
+    }
+    waitMillis, err := luaNumber(result[2])
+    if err != nil {
+        return false, 0, 0, fmt.Errorf("unexpected redis token bucket result: %w", err)
+    }
 
-    return allowedInt == 1, remainingTokens, waitTime, nil
+    allowed = allowedFlag == 1
+    span.SetAttributes(attribute.Bool("ratelimit.allowed", allowed))
+    return allowed, remainingTokens, time.Duration(waitMillis * float64(time.Millisecond)), nil
+}
 
+// luaNumber reads a number from a script reply, which arrives as an
+// integer or, when the script kept a fraction, as a string
+func luaNumber(value interface{}) (float64, error) {
+    switch v := value.(type) {
+    case int64:
+        return float64(v), nil
+    case float64:
+        return v, nil
+    case string:
+        n, err := strconv.ParseFloat(v, 64)
+        if err != nil {
+            return 0, fmt.Errorf("%q is not a number", v)
+        }
+        return n, nil
+    default:
+        return 0, fmt.Errorf("%v (%T) is not a number", value, value)
+    }
 }
 
 // GetBucketState returns current state of the bucket
-func (tb *RedisTokenBucket) GetBucketState(identifier string) (*BucketState, error) {
-    ctx := context.Background()
+func (tb *RedisTokenBucket) GetBucketState(ctx context.Context, identifier string) (*BucketState, error) {
     key := fmt.Sprintf("%s:%s", tb.keyPrefix, identifier)
     
     data, err := tb.redis.Client.HMGet(ctx, key, "tokens", "last_refill").Result()
@@ -155,7 +161,8 @@ func (tb *RedisTokenBucket) GetBucketState(identifier string) (*BucketState, err
     
     now := time.Now()
     
-    var tokens float64 = float64(tb.capacity) // default
+    limits := tb.load()
+    var tokens float64 = float64(limits.capacity) // default
     var lastRefill time.Time = now           // default
     
     if data[0] != nil {
@@ -166,8 +173,8 @@ func (tb *RedisTokenBucket) GetBucketState(identifier string) (*BucketState, err
     
     if data[1] != nil {
         if refillStr, ok := data[1].(string); ok {
-            if timestamp, err := strconv.ParseInt(refillStr, 10, 64); err == nil {
-                lastRefill = time.Unix(timestamp, 0)
+            if millis, err := strconv.ParseInt(refillStr, 10, 64); err == nil {
+                lastRefill = time.UnixMilli(millis)
             }
         }
     }
@@ -175,14 +182,13 @@ func (tb *RedisTokenBucket) GetBucketState(identifier string) (*BucketState, err
     return &BucketState{
         Tokens:     tokens,
         LastRefill: lastRefill,
-        Capacity:   tb.capacity,
-        RefillRate: tb.refillRate,
+        Capacity:   limits.capacity,
+        RefillRate: limits.refillRate,
     }, nil
 }
 
 // Reset clears the bucket for an identifier
-func (tb *RedisTokenBucket) Reset(identifier string) error {
-    ctx := context.Background()
+func (tb *RedisTokenBucket) Reset(ctx context.Context, identifier string) error {
     key := fmt.Sprintf("%s:%s", tb.keyPrefix, identifier)
     return tb.redis.Client.Del(ctx, key).Err()
 }
\ No newline at end of file
@@ -0,0 +1,130 @@
+package middleware
+
+import (
+    "context"
+    "testing"
+    "time"
+
+    "github.com/alicebob/miniredis/v2"
+    "github.com/redis/go-redis/v9"
+
+    "github.com/Shridhar2104/chat-platform/shared/database"
+)
+
+func newRedisBucket(t *testing.T, capacity int, refillRate float64) (*RedisTokenBucket, *miniredis.Miniredis) {
+    t.Helper()
+    server := miniredis.RunT(t)
+    client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+    t.Cleanup(func() { client.Close() })
+    return NewRedisTokenBucket(&database.RedisClient{Client: client}, capacity, refillRate, 1), server
+}
+
+func TestRedisTokenBucketRefillsOnRedisMilliseconds(t *testing.T) {
+    bucket, server := newRedisBucket(t, 2, 10)
+    start := time.Unix(1_700_000_000, 0)
+    server.SetTime(start)
+    ctx := context.Background()
+
+    for i := 0; i < 2; i++ {
+        if allowed, _, _, err := bucket.AllowRequest(ctx, "ip:10.0.0.1"); err != nil || !allowed {
+            t.Fatalf("request %d = %v, %v", i+1, allowed, err)
+        }
+    }
+
+    // Half a token after 50ms, kept as a fraction rather than truncated
+    server.SetTime(start.Add(50 * time.Millisecond))
+    allowed, remaining, wait, err := bucket.AllowRequest(ctx, "ip:10.0.0.1")
+    if err != nil || allowed || remaining != 0.5 || wait != 50*time.Millisecond {
+        t.Fatalf("after 50ms = %v remaining=%v wait=%s err=%v", allowed, remaining, wait, err)
+    }
+
+    server.SetTime(start.Add(100 * time.Millisecond))
+    if allowed, _, _, err := bucket.AllowRequest(ctx, "ip:10.0.0.1"); err != nil || !allowed {
+        t.Errorf("after 100ms = %v, %v, want the refilled token", allowed, err)
+    }
+}
+
+func TestRedisTokenBucketReloadsFlushedScript(t *testing.T) {
+    bucket, _ := newRedisBucket(t, 5, 1)
+    ctx := context.Background()
+    if err := LoadScripts(ctx, bucket.redis); err != nil {
+        t.Fatalf("LoadScripts failed: %v", err)
+    }
+    if err := bucket.redis.Client.ScriptFlush(ctx).Err(); err != nil {
+        t.Fatal(err)
+    }
+
+    // EVALSHA reports NOSCRIPT and the source is sent again
+    for i := 0; i < 2; i++ {
+        if allowed, _, _, err := bucket.AllowRequest(ctx, "ip:10.0.0.1"); err != nil || !allowed {
+            t.Fatalf("request %d = %v, %v", i+1, allowed, err)
+        }
+    }
+    if exists, err := tokenBucketScript.Exists(ctx, bucket.redis.Client).Result(); err != nil || !exists[0] {
+        t.Errorf("script not cached again: %v %v", exists, err)
+    }
+}
+
+func TestLuaNumber(t *testing.T) {
+    tests := []struct {
+        value   interface{}
+        want    float64
+        wantErr bool
+    }{
+        {int64(3), 3, false},
+        {"2.75", 2.75, false},
+        {1.5, 1.5, false},
+        {"tokens", 0, true},
+        {nil, 0, true},
+    }
+    for _, tt := range tests {
+        got, err := luaNumber(tt.value)
+        if got != tt.want || (err != nil) != tt.wantErr {
+            t.Errorf("luaNumber(%#v) = %v, %v", tt.value, got, err)
+        }
+    }
+}
+
+func TestBatchingTokenBucketSpendsHotKeysLocally(t *testing.T) {
+    bucket, server := newRedisBucket(t, 10, 0.001)
+    clock := &fakeClock{now: time.Unix(1_700_000_000, 0)}
+    server.SetTime(clock.now)
+    batching := NewBatchingTokenBucket(bucket, 4, 2)
+    batching.now = clock.Now
+    ctx := context.Background()
+
+    var allowed, redisCalls int
+    for i := 0; i < 12; i++ {
+        calls := server.CommandCount()
+        decision, err := batching.Allow(ctx, "ip:10.0.0.1", 1)
+        if err != nil {
+            t.Fatalf("request %d failed: %v", i+1, err)
+        }
+        if decision.Allowed {
+            allowed++
+        }
+        if server.CommandCount() != calls {
+            redisCalls++
+        }
+    }
+
+    // The batches never let through more than the bucket holds
+    if allowed != 10 {
+        t.Errorf("allowed = %d, want the capacity of 10", allowed)
+    }
+    // The first request, two batches and the three requests once less than
+    // a batch was left; the other six were served locally
+    if redisCalls > 6 {
+        t.Errorf("redis calls = %d, want at most 6", redisCalls)
+    }
+
+    // Leftover tokens lapse with the batch and idle keys are dropped
+    clock.Advance(batchLifetime)
+    batching.mu.Lock()
+    batching.sweep(clock.Now())
+    left := len(batching.keys)
+    batching.mu.Unlock()
+    if left != 0 {
+        t.Errorf("keys after the lifetime = %d, want none", left)
+    }
+}
\ No newline at end of file
@@ -15,6 +15,11 @@ type LoginRequest struct {
     DeviceID string `json:"device_id" binding:"required"`
 }
 
+type VerifyLoginRequest struct {
+    ChallengeID string `json:"challenge_id" binding:"required"`
+    Code        string `json:"code" binding:"required,len=6,numeric"`
+}
+
 type RefreshTokenRequest struct {
     RefreshToken string `json:"refresh_token" binding:"required"`
     DeviceID     string `json:"device_id" binding:"required"`
@@ -35,6 +40,38 @@ type ChangePasswordRequest struct {
     NewPassword     string `json:"new_password" binding:"required,min=8"`
 }
 
+type ClaimDomainRequest struct {
+    Domain      string `json:"domain" binding:"required"`
+    AllowSignup *bool  `json:"allow_signup"`
+    EnforceSSO  bool   `json:"enforce_sso"`
+    AutoJoin    bool   `json:"auto_join"`
+}
+
+type UpdateDomainPolicyRequest struct {
+    AllowSignup bool `json:"allow_signup"`
+    EnforceSSO  bool `json:"enforce_sso"`
+    AutoJoin    bool `json:"auto_join"`
+}
+
+
+// RateLimitOverrideRequest replaces an identifier's global limit for TTL,
+// a Go duration such as "30m"
+type RateLimitOverrideRequest struct {
+    RequestsPerMinute int    `json:"requests_per_minute" binding:"required,min=1"`
+    Burst             int    `json:"burst" binding:"min=0"`
+    TTL               string `json:"ttl" binding:"required"`
+}
+
+// RateLimitPlanRequest sets a workspace's subscription plan, which picks
+// the rate limit tier of its tokens; unknown plans get the default tier
+type RateLimitPlanRequest struct {
+    Plan string `json:"plan" binding:"required,max=50"`
+}
+
+// RateLimitListRequest optionally limits how long an identifier stays listed
+type RateLimitListRequest struct {
+    TTL string `json:"ttl"`
+}
 
 type AuthResponse struct {
     User         UserResponse `json:"user"`
@@ -52,6 +89,128 @@ type UserResponse struct {
     CreatedAt     string    `json:"created_at"`
 }
 
+type StepUpResponse struct {
+    Error       string `json:"error"`
+    Message     string `json:"message"`
+    ChallengeID string `json:"challenge_id"`
+    Method      string `json:"method"`
+    ExpiresAt   int64  `json:"expires_at"`
+}
+
+type ChallengeResponse struct {
+    Error       string `json:"error"`
+    Message     string `json:"message"`
+    ChallengeID string `json:"challenge_id"`
+    Algorithm   string `json:"algorithm"`
+    Prefix      string `json:"prefix"`
+    Difficulty  int    `json:"difficulty"`
+    ExpiresAt   int64  `json:"expires_at"`
+    Captcha     bool   `json:"captcha_accepted"`
+}
+
+type SSORequiredResponse struct {
+    Error       string    `json:"error"`
+    Message     string    `json:"message"`
+    WorkspaceID uuid.UUID `json:"workspace_id"`
+    RedirectURL string    `json:"redirect_url"`
+}
+
+type DomainPolicyResponse struct {
+    Domain             string  `json:"domain"`
+    Verified           bool    `json:"verified"`
+    VerifiedAt         *string `json:"verified_at,omitempty"`
+    AllowSignup        bool    `json:"allow_signup"`
+    EnforceSSO         bool    `json:"enforce_sso"`
+    AutoJoin           bool    `json:"auto_join"`
+    VerificationRecord string  `json:"verification_record"`
+    VerificationValue  string  `json:"verification_value"`
+}
+
+// SAMLIdentityProviderRequest configures the workspace IdP from its metadata
+// document, or from its entity ID, SSO URL and certificate
+type SAMLIdentityProviderRequest struct {
+    Metadata             string `json:"metadata" binding:"max=1048576"`
+    EntityID             string `json:"entity_id"`
+    SSOURL               string `json:"sso_url"`
+    Certificate          string `json:"certificate"`
+    EmailAttribute       string `json:"email_attribute" binding:"max=255"`
+    DisplayNameAttribute string `json:"display_name_attribute" binding:"max=255"`
+    Enabled              *bool  `json:"enabled"`
+}
+
+// SAMLIdentityProviderResponse also returns the values the IdP needs about us
+type SAMLIdentityProviderResponse struct {
+    EntityID             string `json:"entity_id"`
+    SSOURL               string `json:"sso_url"`
+    Certificate          string `json:"certificate"`
+    EmailAttribute       string `json:"email_attribute"`
+    DisplayNameAttribute string `json:"display_name_attribute"`
+    Enabled              bool   `json:"enabled"`
+    SPEntityID           string `json:"sp_entity_id"`
+    ACSURL               string `json:"acs_url"`
+    UpdatedAt            string `json:"updated_at"`
+}
+
+type RateLimitOverrideResponse struct {
+    RequestsPerMinute int    `json:"requests_per_minute"`
+    Burst             int    `json:"burst,omitempty"`
+    ExpiresAt         string `json:"expires_at"`
+}
+
+// RateLimitStateResponse describes one identifier's global bucket and controls
+type RateLimitStateResponse struct {
+    Identifier  string                     `json:"identifier"`
+    Tokens      float64                    `json:"tokens"`
+    Capacity    int                        `json:"capacity"`
+    RefillRate  float64                    `json:"refill_rate"`
+    LastRefill  string                     `json:"last_refill"`
+    Override    *RateLimitOverrideResponse `json:"override,omitempty"`
+    Allowlisted bool                       `json:"allowlisted"`
+    Denylisted  bool                       `json:"denylisted"`
+}
+
+type RateLimitListEntryResponse struct {
+    Identifier string  `json:"identifier"`
+    ExpiresAt  *string `json:"expires_at,omitempty"`
+}
+
+type RateLimitListsResponse struct {
+    Allow []RateLimitListEntryResponse `json:"allow"`
+    Deny  []RateLimitListEntryResponse `json:"deny"`
+}
+
+// IntrospectionResponse follows RFC 7662, inactive tokens carry only Active
+type IntrospectionResponse struct {
+    Active    bool     `json:"active"`
+    Subject   string   `json:"sub,omitempty"`
+    Email     string   `json:"email,omitempty"`
+    DeviceID  string   `json:"device_id,omitempty"`
+    Scope     string   `json:"scope,omitempty"`
+    Roles     []string `json:"roles,omitempty"`
+    TokenType string   `json:"token_type,omitempty"`
+    ExpiresAt int64    `json:"exp,omitempty"`
+    IssuedAt  int64    `json:"iat,omitempty"`
+    Issuer    string   `json:"iss,omitempty"`
+}
+
+// Problem is an RFC 7807 problem details body, Code and TraceID are extension members
+type Problem struct {
+    Type     string `json:"type"`
+    Title    string `json:"title"`
+    Status   int    `json:"status"`
+    Detail   string `json:"detail,omitempty"`
+    Instance string `json:"instance,omitempty"`
+    Code     string `json:"code"`
+    TraceID  string `json:"trace_id"`
+}
+
+type SSORequiredProblem struct {
+    Problem
+    WorkspaceID uuid.UUID `json:"workspace_id"`
+    RedirectURL string    `json:"redirect_url"`
+}
+
+// ErrorResponse is the legacy error body, still served to clients that ask for application/json
 type ErrorResponse struct {
     Error   string `json:"error"`
     Message string `json:"message,omitempty"`
@@ -0,0 +1,84 @@
+package models
+
+import "encoding/json"
+
+const (
+    SCIMUserSchema         = "urn:ietf:params:scim:schemas:core:2.0:User"
+    SCIMGroupSchema        = "urn:ietf:params:scim:schemas:core:2.0:Group"
+    SCIMListResponseSchema = "urn:ietf:params:scim:api:messages:2.0:ListResponse"
+    SCIMPatchOpSchema      = "urn:ietf:params:scim:api:messages:2.0:PatchOp"
+    SCIMErrorSchema        = "urn:ietf:params:scim:api:messages:2.0:Error"
+    SCIMConfigSchema       = "urn:ietf:params:scim:schemas:core:2.0:ServiceProviderConfig"
+)
+
+type SCIMMeta struct {
+    ResourceType string `json:"resourceType"`
+    Created      string `json:"created,omitempty"`
+    LastModified string `json:"lastModified,omitempty"`
+    Location     string `json:"location,omitempty"`
+}
+
+type SCIMName struct {
+    Formatted  string `json:"formatted,omitempty"`
+    GivenName  string `json:"givenName,omitempty"`
+    FamilyName string `json:"familyName,omitempty"`
+}
+
+type SCIMEmail struct {
+    Value   string `json:"value"`
+    Type    string `json:"type,omitempty"`
+    Primary bool   `json:"primary,omitempty"`
+}
+
+type SCIMUser struct {
+    Schemas     []string    `json:"schemas"`
+    ID          string      `json:"id,omitempty"`
+    ExternalID  string      `json:"externalId,omitempty"`
+    UserName    string      `json:"userName"`
+    Name        *SCIMName   `json:"name,omitempty"`
+    DisplayName string      `json:"displayName,omitempty"`
+    Emails      []SCIMEmail `json:"emails,omitempty"`
+    Active      *bool       `json:"active,omitempty"`
+    Meta        *SCIMMeta   `json:"meta,omitempty"`
+}
+
+type SCIMMember struct {
+    Value   string `json:"value"`
+    Display string `json:"display,omitempty"`
+    Ref     string `json:"$ref,omitempty"`
+}
+
+type SCIMGroup struct {
+    Schemas     []string     `json:"schemas"`
+    ID          string       `json:"id,omitempty"`
+    ExternalID  string       `json:"externalId,omitempty"`
+    DisplayName string       `json:"displayName"`
+    Members     []SCIMMember `json:"members"`
+    Meta        *SCIMMeta    `json:"meta,omitempty"`
+}
+
+type SCIMListResponse struct {
+    Schemas      []string    `json:"schemas"`
+    TotalResults int         `json:"totalResults"`
+    StartIndex   int         `json:"startIndex"`
+    ItemsPerPage int         `json:"itemsPerPage"`
+    Resources    interface{} `json:"Resources"`
+}
+
+type SCIMPatchOperation struct {
+    Op    string          `json:"op"`
+    Path  string          `json:"path,omitempty"`
+    Value json.RawMessage `json:"value,omitempty"`
+}
+
+type SCIMPatchRequest struct {
+    Schemas    []string             `json:"schemas"`
+    Operations []SCIMPatchOperation `json:"Operations" binding:"required,min=1"`
+}
+
+type SCIMError struct {
+    Schemas  []string `json:"schemas"`
+    Status   string   `json:"status"`
+    ScimType string   `json:"scimType,omitempty"`
+    Detail   string   `json:"detail,omitempty"`
+}
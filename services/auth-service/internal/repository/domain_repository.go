@@ -0,0 +1,153 @@
+package repository
+
+import (
+    "context"
+    "database/sql"
+    "fmt"
+    "time"
+
+    "github.com/google/uuid"
+    "github.com/jmoiron/sqlx"
+    "github.com/Shridhar2104/chat-platform/auth-service/internal/tracing"
+    "github.com/Shridhar2104/chat-platform/shared/database"
+    "github.com/Shridhar2104/chat-platform/shared/models"
+)
+
+type DomainRepository struct {
+    db *database.PostgresDB
+}
+
+func NewDomainRepository(db *database.PostgresDB) *DomainRepository {
+    return &DomainRepository{db: db}
+}
+
+func (r *DomainRepository) CreateDomainPolicy(ctx context.Context, policy *models.DomainPolicy) error {
+    ctx, span := startQuery(ctx, "INSERT", "domain_policies")
+    defer span.End()
+
+    query := `
+        INSERT INTO domain_policies (id, workspace_id, domain, verification_token, verified, allow_signup, enforce_sso, auto_join, created_at, updated_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+    `
+    _, err := r.db.DB.ExecContext(ctx, query,
+        policy.ID,
+        policy.WorkspaceID,
+        policy.Domain,
+        policy.VerificationToken,
+        policy.Verified,
+        policy.AllowSignup,
+        policy.EnforceSSO,
+        policy.AutoJoin,
+        policy.CreatedAt,
+        policy.UpdatedAt,
+    )
+    if err != nil {
+        tracing.RecordError(span, err)
+        return writeError("create domain policy", err)
+    }
+    return nil
+}
+
+func (r *DomainRepository) GetDomainPolicy(ctx context.Context, workspaceID uuid.UUID, domain string) (*models.DomainPolicy, error) {
+    ctx, span := startQuery(ctx, "SELECT", "domain_policies")
+    defer span.End()
+
+    var policy models.DomainPolicy
+    query := `
+        SELECT id, workspace_id, domain, verification_token, verified, verified_at, allow_signup, enforce_sso, auto_join, created_at, updated_at
+        FROM domain_policies WHERE workspace_id = $1 AND domain = $2
+    `
+    err := r.db.DB.GetContext(ctx, &policy, query, workspaceID, domain)
+    if err != nil {
+        if err == sql.ErrNoRows {
+            return nil, ErrDomainPolicyNotFound
+        }
+        tracing.RecordError(span, err)
+        return nil, fmt.Errorf("failed to get domain policy: %w", err)
+    }
+    return &policy, nil
+}
+
+func (r *DomainRepository) ListDomainPolicies(ctx context.Context, workspaceID uuid.UUID) ([]models.DomainPolicy, error) {
+    ctx, span := startQuery(ctx, "SELECT", "domain_policies")
+    defer span.End()
+
+    policies := []models.DomainPolicy{}
+    query := `
+        SELECT id, workspace_id, domain, verification_token, verified, verified_at, allow_signup, enforce_sso, auto_join, created_at, updated_at
+        FROM domain_policies WHERE workspace_id = $1 ORDER BY domain
+    `
+    if err := r.db.DB.SelectContext(ctx, &policies, query, workspaceID); err != nil {
+        tracing.RecordError(span, err)
+        return nil, fmt.Errorf("failed to list domain policies: %w", err)
+    }
+    return policies, nil
+}
+
+// GetVerifiedDomainPolicies returns the verified policies matching any of the given domains
+func (r *DomainRepository) GetVerifiedDomainPolicies(ctx context.Context, domains []string) ([]models.DomainPolicy, error) {
+    policies := []models.DomainPolicy{}
+    if len(domains) == 0 {
+        return policies, nil
+    }
+
+    ctx, span := startQuery(ctx, "SELECT", "domain_policies")
+    defer span.End()
+
+    query, args, err := sqlx.In(`
+        SELECT id, workspace_id, domain, verification_token, verified, verified_at, allow_signup, enforce_sso, auto_join, created_at, updated_at
+        FROM domain_policies WHERE verified = true AND domain IN (?)
+    `, domains)
+    if err != nil {
+        tracing.RecordError(span, err)
+        return nil, fmt.Errorf("failed to build domain policy query: %w", err)
+    }
+    if err := r.db.DB.SelectContext(ctx, &policies, r.db.DB.Rebind(query), args...); err != nil {
+        tracing.RecordError(span, err)
+        return nil, fmt.Errorf("failed to get domain policies: %w", err)
+    }
+    return policies, nil
+}
+
+func (r *DomainRepository) UpdateDomainPolicy(ctx context.Context, policy *models.DomainPolicy) error {
+    ctx, span := startQuery(ctx, "UPDATE", "domain_policies")
+    defer span.End()
+
+    query := `
+        UPDATE domain_policies
+        SET allow_signup = $1, enforce_sso = $2, auto_join = $3, updated_at = $4
+        WHERE id = $5
+    `
+    _, err := r.db.DB.ExecContext(ctx, query, policy.AllowSignup, policy.EnforceSSO, policy.AutoJoin, time.Now(), policy.ID)
+    if err != nil {
+        tracing.RecordError(span, err)
+        return fmt.Errorf("failed to update domain policy: %w", err)
+    }
+    return nil
+}
+
+func (r *DomainRepository) MarkDomainVerified(ctx context.Context, policyID uuid.UUID) error {
+    ctx, span := startQuery(ctx, "UPDATE", "domain_policies")
+    defer span.End()
+
+    query := `UPDATE domain_policies SET verified = true, verified_at = $1, updated_at = $1 WHERE id = $2`
+    _, err := r.db.DB.ExecContext(ctx, query, time.Now(), policyID)
+    if err != nil {
+        tracing.RecordError(span, err)
+        return writeError("mark domain verified", err)
+    }
+    return nil
+}
+
+func (r *DomainRepository) DeleteDomainPolicy(ctx context.Context, workspaceID uuid.UUID, domain string) error {
+    ctx, span := startQuery(ctx, "DELETE", "domain_policies")
+    defer span.End()
+
+    query := `DELETE FROM domain_policies WHERE workspace_id = $1 AND domain = $2`
+    _, err := r.db.DB.ExecContext(ctx, query, workspaceID, domain)
+    if err != nil {
+        tracing.RecordError(span, err)
+        return fmt.Errorf("failed to delete domain policy: %w", err)
+    }
+    return nil
+}
@@ -0,0 +1,34 @@
+package repository
+
+import (
+    "errors"
+    "fmt"
+
+    "github.com/lib/pq"
+)
+
+var (
+    ErrUserNotFound             = errors.New("user not found")
+    ErrSessionNotFound          = errors.New("session not found or expired")
+    ErrGroupNotFound            = errors.New("group not found")
+    ErrSCIMTokenNotFound        = errors.New("scim token not found")
+    ErrDomainPolicyNotFound     = errors.New("domain policy not found")
+    ErrIdentityProviderNotFound = errors.New("identity provider not found")
+    ErrWorkspaceNotFound        = errors.New("workspace not found")
+    ErrKeyNotFound              = errors.New("key not found or expired")
+
+    // ErrDuplicate marks writes rejected by a unique constraint
+    ErrDuplicate = errors.New("duplicate key")
+)
+
+// uniqueViolation is the Postgres SQLSTATE for unique constraint violations
+const uniqueViolation = "23505"
+
+// writeError wraps a failed write, tagging unique violations with ErrDuplicate
+func writeError(action string, err error) error {
+    var pqErr *pq.Error
+    if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation {
+        return fmt.Errorf("failed to %s: %w: %w", action, ErrDuplicate, err)
+    }
+    return fmt.Errorf("failed to %s: %w", action, err)
+}
@@ -0,0 +1,129 @@
+package repository
+
+import (
+    "context"
+    "fmt"
+    "sort"
+    "sync"
+    "time"
+
+    "github.com/google/uuid"
+    "github.com/Shridhar2104/chat-platform/shared/models"
+)
+
+// MemoryDomainStore is an in-memory DomainStore for tests and local development.
+// It enforces the same unique constraints as the domain_policies table.
+type MemoryDomainStore struct {
+    mu       sync.RWMutex
+    policies map[uuid.UUID]models.DomainPolicy
+}
+
+func NewMemoryDomainStore() *MemoryDomainStore {
+    return &MemoryDomainStore{policies: make(map[uuid.UUID]models.DomainPolicy)}
+}
+
+func (s *MemoryDomainStore) CreateDomainPolicy(ctx context.Context, policy *models.DomainPolicy) error {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    for _, existing := range s.policies {
+        if existing.ID == policy.ID || existing.WorkspaceID == policy.WorkspaceID && existing.Domain == policy.Domain ||
+            policy.Verified && existing.Verified && existing.Domain == policy.Domain {
+            return fmt.Errorf("failed to create domain policy: %w", ErrDuplicate)
+        }
+    }
+    s.policies[policy.ID] = *policy
+    return nil
+}
+
+func (s *MemoryDomainStore) GetDomainPolicy(ctx context.Context, workspaceID uuid.UUID, domain string) (*models.DomainPolicy, error) {
+    s.mu.RLock()
+    defer s.mu.RUnlock()
+
+    for _, policy := range s.policies {
+        if policy.WorkspaceID == workspaceID && policy.Domain == domain {
+            return &policy, nil
+        }
+    }
+    return nil, ErrDomainPolicyNotFound
+}
+
+func (s *MemoryDomainStore) ListDomainPolicies(ctx context.Context, workspaceID uuid.UUID) ([]models.DomainPolicy, error) {
+    s.mu.RLock()
+    defer s.mu.RUnlock()
+
+    policies := []models.DomainPolicy{}
+    for _, policy := range s.policies {
+        if policy.WorkspaceID == workspaceID {
+            policies = append(policies, policy)
+        }
+    }
+    sort.Slice(policies, func(i, j int) bool { return policies[i].Domain < policies[j].Domain })
+    return policies, nil
+}
+
+func (s *MemoryDomainStore) GetVerifiedDomainPolicies(ctx context.Context, domains []string) ([]models.DomainPolicy, error) {
+    s.mu.RLock()
+    defer s.mu.RUnlock()
+
+    wanted := make(map[string]bool, len(domains))
+    for _, domain := range domains {
+        wanted[domain] = true
+    }
+    policies := []models.DomainPolicy{}
+    for _, policy := range s.policies {
+        if policy.Verified && wanted[policy.Domain] {
+            policies = append(policies, policy)
+        }
+    }
+    return policies, nil
+}
+
+func (s *MemoryDomainStore) UpdateDomainPolicy(ctx context.Context, policy *models.DomainPolicy) error {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    stored, ok := s.policies[policy.ID]
+    if !ok {
+        return nil
+    }
+    stored.AllowSignup = policy.AllowSignup
+    stored.EnforceSSO = policy.EnforceSSO
+    stored.AutoJoin = policy.AutoJoin
+    stored.UpdatedAt = time.Now()
+    s.policies[policy.ID] = stored
+    return nil
+}
+
+func (s *MemoryDomainStore) MarkDomainVerified(ctx context.Context, policyID uuid.UUID) error {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    stored, ok := s.policies[policyID]
+    if !ok {
+        return nil
+    }
+    for _, existing := range s.policies {
+        if existing.ID != policyID && existing.Verified && existing.Domain == stored.Domain {
+            return fmt.Errorf("failed to mark domain verified: %w", ErrDuplicate)
+        }
+    }
+    now := time.Now()
+    stored.Verified = true
+    stored.VerifiedAt = &now
+    stored.UpdatedAt = now
+    s.policies[policyID] = stored
+    return nil
+}
+
+func (s *MemoryDomainStore) DeleteDomainPolicy(ctx context.Context, workspaceID uuid.UUID, domain string) error {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    for id, policy := range s.policies {
+        if policy.WorkspaceID == workspaceID && policy.Domain == domain {
+            delete(s.policies, id)
+        }
+    }
+    return nil
+}
\ No newline at end of file
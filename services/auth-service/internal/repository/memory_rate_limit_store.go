@@ -0,0 +1,152 @@
+package repository
+
+import (
+    "context"
+    "strconv"
+    "sync"
+    "time"
+)
+
+// sweepInterval is how many writes pass between scans for expired keys
+const sweepInterval = 1024
+
+type memoryEntry struct {
+    value     []byte
+    expiresAt time.Time
+}
+
+// MemoryRateLimitStore is a single-process RateLimitStore for tests and local development
+type MemoryRateLimitStore struct {
+    mu      sync.Mutex
+    entries map[string]memoryEntry
+    writes  int
+    now     func() time.Time
+}
+
+func NewMemoryRateLimitStore() *MemoryRateLimitStore {
+    return &MemoryRateLimitStore{
+        entries: make(map[string]memoryEntry),
+        now:     time.Now,
+    }
+}
+
+func (s *MemoryRateLimitStore) Increment(ctx context.Context, window time.Duration, keys ...string) ([]int64, error) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    counts := make([]int64, len(keys))
+    for i, key := range keys {
+        entry, ok := s.lookup(key)
+        if !ok {
+            entry = memoryEntry{expiresAt: s.now().Add(window)}
+        }
+        count, _ := strconv.ParseInt(string(entry.value), 10, 64)
+        count++
+        entry.value = []byte(strconv.FormatInt(count, 10))
+        s.store(key, entry)
+        counts[i] = count
+    }
+    return counts, nil
+}
+
+func (s *MemoryRateLimitStore) Counter(ctx context.Context, key string) (int64, error) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    entry, ok := s.lookup(key)
+    if !ok {
+        return 0, nil
+    }
+    count, _ := strconv.ParseInt(string(entry.value), 10, 64)
+    return count, nil
+}
+
+// BucketTokens always reports no bucket, in-process token buckets live in the rate limit middleware
+func (s *MemoryRateLimitStore) BucketTokens(ctx context.Context, key string) (float64, bool, error) {
+    return 0, false, nil
+}
+
+func (s *MemoryRateLimitStore) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    entry := memoryEntry{value: append([]byte(nil), value...)}
+    if ttl > 0 {
+        entry.expiresAt = s.now().Add(ttl)
+    }
+    s.store(key, entry)
+    return nil
+}
+
+func (s *MemoryRateLimitStore) Get(ctx context.Context, key string) ([]byte, error) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    entry, ok := s.lookup(key)
+    if !ok {
+        return nil, ErrKeyNotFound
+    }
+    return append([]byte(nil), entry.value...), nil
+}
+
+func (s *MemoryRateLimitStore) Take(ctx context.Context, key string) ([]byte, error) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    entry, ok := s.lookup(key)
+    if !ok {
+        return nil, ErrKeyNotFound
+    }
+    delete(s.entries, key)
+    return entry.value, nil
+}
+
+func (s *MemoryRateLimitStore) Replace(ctx context.Context, key string, value []byte) error {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    entry, ok := s.lookup(key)
+    if !ok {
+        return ErrKeyNotFound
+    }
+    entry.value = append([]byte(nil), value...)
+    s.store(key, entry)
+    return nil
+}
+
+func (s *MemoryRateLimitStore) Delete(ctx context.Context, key string) error {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    delete(s.entries, key)
+    return nil
+}
+
+// lookup returns a live entry, dropping it if it has expired
+func (s *MemoryRateLimitStore) lookup(key string) (memoryEntry, bool) {
+    entry, ok := s.entries[key]
+    if !ok {
+        return memoryEntry{}, false
+    }
+    if s.expired(entry) {
+        delete(s.entries, key)
+        return memoryEntry{}, false
+    }
+    return entry, true
+}
+
+func (s *MemoryRateLimitStore) store(key string, entry memoryEntry) {
+    s.entries[key] = entry
+    s.writes++
+    if s.writes%sweepInterval == 0 {
+        for key, entry := range s.entries {
+            if s.expired(entry) {
+                delete(s.entries, key)
+            }
+        }
+    }
+}
+
+func (s *MemoryRateLimitStore) expired(entry memoryEntry) bool {
+    return !entry.expiresAt.IsZero() && !s.now().Before(entry.expiresAt)
+}
\ No newline at end of file
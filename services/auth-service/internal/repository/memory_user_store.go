@@ -0,0 +1,199 @@
+package repository
+
+import (
+    "context"
+    "fmt"
+    "sort"
+    "sync"
+    "time"
+
+    "github.com/google/uuid"
+    "github.com/Shridhar2104/chat-platform/shared/models"
+)
+
+// MemoryUserStore is an in-memory UserStore for tests and local development.
+// It enforces the same unique email constraint as the users table.
+type MemoryUserStore struct {
+    mu     sync.RWMutex
+    users  map[uuid.UUID]models.User
+    events []models.LoginEvent
+}
+
+func NewMemoryUserStore() *MemoryUserStore {
+    return &MemoryUserStore{users: make(map[uuid.UUID]models.User)}
+}
+
+func (s *MemoryUserStore) CreateUser(ctx context.Context, user *models.User) error {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    if _, ok := s.users[user.ID]; ok {
+        return fmt.Errorf("failed to create user: %w", ErrDuplicate)
+    }
+    if _, ok := s.findByEmail(user.Email); ok {
+        return fmt.Errorf("failed to create user: %w", ErrDuplicate)
+    }
+    s.users[user.ID] = *user
+    return nil
+}
+
+func (s *MemoryUserStore) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
+    s.mu.RLock()
+    defer s.mu.RUnlock()
+
+    user, ok := s.findByEmail(email)
+    if !ok {
+        return nil, ErrUserNotFound
+    }
+    return &user, nil
+}
+
+func (s *MemoryUserStore) GetUserByID(ctx context.Context, userID uuid.UUID) (*models.User, error) {
+    s.mu.RLock()
+    defer s.mu.RUnlock()
+
+    user, ok := s.users[userID]
+    if !ok {
+        return nil, ErrUserNotFound
+    }
+    return &user, nil
+}
+
+func (s *MemoryUserStore) GetUsersByIDs(ctx context.Context, userIDs []uuid.UUID) ([]models.User, error) {
+    s.mu.RLock()
+    defer s.mu.RUnlock()
+
+    users := []models.User{}
+    seen := make(map[uuid.UUID]bool, len(userIDs))
+    for _, userID := range userIDs {
+        if user, ok := s.users[userID]; ok && !seen[userID] {
+            seen[userID] = true
+            users = append(users, user)
+        }
+    }
+    return users, nil
+}
+
+func (s *MemoryUserStore) EmailExists(ctx context.Context, email string) (bool, error) {
+    s.mu.RLock()
+    defer s.mu.RUnlock()
+
+    _, ok := s.findByEmail(email)
+    return ok, nil
+}
+
+func (s *MemoryUserStore) UpdateUserPassword(ctx context.Context, userID uuid.UUID, passwordHash string) error {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    // Like the UPDATE it mirrors, an unknown user is not an error
+    if user, ok := s.users[userID]; ok {
+        user.PasswordHash = passwordHash
+        user.UpdatedAt = time.Now()
+        s.users[userID] = user
+    }
+    return nil
+}
+
+func (s *MemoryUserStore) CreateLoginEvent(ctx context.Context, event *models.LoginEvent) error {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    if _, ok := s.users[event.UserID]; !ok {
+        return fmt.Errorf("failed to create login event: %w", ErrUserNotFound)
+    }
+    s.events = append(s.events, *event)
+    return nil
+}
+
+func (s *MemoryUserStore) GetRecentLoginEvents(ctx context.Context, userID uuid.UUID, limit int) ([]models.LoginEvent, error) {
+    s.mu.RLock()
+    defer s.mu.RUnlock()
+
+    var events []models.LoginEvent
+    for _, event := range s.events {
+        if event.UserID == userID {
+            events = append(events, event)
+        }
+    }
+    sort.SliceStable(events, func(i, j int) bool { return events[i].CreatedAt.After(events[j].CreatedAt) })
+    if len(events) > limit {
+        events = events[:limit]
+    }
+    return events, nil
+}
+
+func (s *MemoryUserStore) findByEmail(email string) (models.User, bool) {
+    for _, user := range s.users {
+        if user.Email == email {
+            return user, true
+        }
+    }
+    return models.User{}, false
+}
+
+// MemorySessionStore is an in-memory SessionStore for tests and local development
+type MemorySessionStore struct {
+    mu       sync.RWMutex
+    sessions map[uuid.UUID]models.UserSession
+}
+
+func NewMemorySessionStore() *MemorySessionStore {
+    return &MemorySessionStore{sessions: make(map[uuid.UUID]models.UserSession)}
+}
+
+func (s *MemorySessionStore) CreateSession(ctx context.Context, session *models.UserSession) error {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    if _, ok := s.sessions[session.ID]; ok {
+        return fmt.Errorf("failed to create session: %w", ErrDuplicate)
+    }
+    s.sessions[session.ID] = *session
+    return nil
+}
+
+func (s *MemorySessionStore) GetSessionByRefreshToken(ctx context.Context, refreshTokenHash string) (*models.UserSession, error) {
+    s.mu.RLock()
+    defer s.mu.RUnlock()
+
+    now := time.Now()
+    for _, session := range s.sessions {
+        if session.RefreshTokenHash == refreshTokenHash && session.ExpiresAt.After(now) {
+            return &session, nil
+        }
+    }
+    return nil, ErrSessionNotFound
+}
+
+func (s *MemorySessionStore) DeleteSession(ctx context.Context, sessionID uuid.UUID) error {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    delete(s.sessions, sessionID)
+    return nil
+}
+
+func (s *MemorySessionStore) DeleteUserSessions(ctx context.Context, userID uuid.UUID, deviceID string) error {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    for id, session := range s.sessions {
+        if session.UserID == userID && session.DeviceID == deviceID {
+            delete(s.sessions, id)
+        }
+    }
+    return nil
+}
+
+func (s *MemorySessionStore) DeleteAllUserSessions(ctx context.Context, userID uuid.UUID) error {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    for id, session := range s.sessions {
+        if session.UserID == userID {
+            delete(s.sessions, id)
+        }
+    }
+    return nil
+}
\ No newline at end of file
@@ -0,0 +1,100 @@
+package repository
+
+import (
+    "context"
+    "sync"
+    "time"
+
+    "github.com/google/uuid"
+)
+
+type memoryMember struct {
+    source   string
+    joinedAt time.Time
+}
+
+type memoryMemberKey struct {
+    workspaceID uuid.UUID
+    userID      uuid.UUID
+}
+
+// MemoryWorkspaceStore is an in-memory WorkspaceStore for tests and local development
+type MemoryWorkspaceStore struct {
+    mu      sync.RWMutex
+    members map[memoryMemberKey]memoryMember
+    plans   map[uuid.UUID]string
+    now     func() time.Time
+}
+
+func NewMemoryWorkspaceStore() *MemoryWorkspaceStore {
+    return &MemoryWorkspaceStore{
+        members: make(map[memoryMemberKey]memoryMember),
+        plans:   make(map[uuid.UUID]string),
+        now:     time.Now,
+    }
+}
+
+func (s *MemoryWorkspaceStore) AddWorkspaceMember(ctx context.Context, workspaceID, userID uuid.UUID, source string) error {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    key := memoryMemberKey{workspaceID, userID}
+    if _, ok := s.members[key]; !ok {
+        s.members[key] = memoryMember{source: source, joinedAt: s.now()}
+    }
+    return nil
+}
+
+func (s *MemoryWorkspaceStore) IsWorkspaceMember(ctx context.Context, workspaceID, userID uuid.UUID) (bool, error) {
+    s.mu.RLock()
+    defer s.mu.RUnlock()
+
+    _, ok := s.members[memoryMemberKey{workspaceID, userID}]
+    return ok, nil
+}
+
+func (s *MemoryWorkspaceStore) RemoveWorkspaceMember(ctx context.Context, workspaceID, userID uuid.UUID) error {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    delete(s.members, memoryMemberKey{workspaceID, userID})
+    return nil
+}
+
+func (s *MemoryWorkspaceStore) PrimaryWorkspace(ctx context.Context, userID uuid.UUID) (uuid.UUID, error) {
+    s.mu.RLock()
+    defer s.mu.RUnlock()
+
+    var primary memoryMemberKey
+    var joinedAt time.Time
+    found := false
+    for key, member := range s.members {
+        if key.userID != userID {
+            continue
+        }
+        earlier := member.joinedAt.Before(joinedAt) ||
+            member.joinedAt.Equal(joinedAt) && key.workspaceID.String() < primary.workspaceID.String()
+        if !found || earlier {
+            primary, joinedAt, found = key, member.joinedAt, true
+        }
+    }
+    if !found {
+        return uuid.Nil, ErrWorkspaceNotFound
+    }
+    return primary.workspaceID, nil
+}
+
+func (s *MemoryWorkspaceStore) WorkspacePlan(ctx context.Context, workspaceID uuid.UUID) (string, error) {
+    s.mu.RLock()
+    defer s.mu.RUnlock()
+
+    return s.plans[workspaceID], nil
+}
+
+func (s *MemoryWorkspaceStore) SetWorkspacePlan(ctx context.Context, workspaceID uuid.UUID, plan string) error {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    s.plans[workspaceID] = plan
+    return nil
+}
\ No newline at end of file
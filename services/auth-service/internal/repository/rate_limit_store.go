@@ -0,0 +1,107 @@
+package repository
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "time"
+
+    "github.com/redis/go-redis/v9"
+    "github.com/Shridhar2104/chat-platform/shared/database"
+)
+
+// RedisRateLimitStore is the RateLimitStore shared by every replica
+type RedisRateLimitStore struct {
+    redis *database.RedisClient
+}
+
+func NewRedisRateLimitStore(redis *database.RedisClient) *RedisRateLimitStore {
+    return &RedisRateLimitStore{redis: redis}
+}
+
+func (s *RedisRateLimitStore) Increment(ctx context.Context, window time.Duration, keys ...string) ([]int64, error) {
+    pipe := s.redis.Client.Pipeline()
+    counters := make([]*redis.IntCmd, len(keys))
+    for i, key := range keys {
+        counters[i] = pipe.Incr(ctx, key)
+    }
+    if _, err := pipe.Exec(ctx); err != nil {
+        return nil, fmt.Errorf("failed to increment counters: %w", err)
+    }
+
+    // Fixed windows start with the first request
+    counts := make([]int64, len(keys))
+    for i, counter := range counters {
+        counts[i] = counter.Val()
+        if counts[i] == 1 {
+            s.redis.Client.Expire(ctx, keys[i], window)
+        }
+    }
+    return counts, nil
+}
+
+func (s *RedisRateLimitStore) Counter(ctx context.Context, key string) (int64, error) {
+    count, err := s.redis.Client.Get(ctx, key).Int64()
+    if errors.Is(err, redis.Nil) {
+        return 0, nil
+    }
+    if err != nil {
+        return 0, fmt.Errorf("failed to read counter: %w", err)
+    }
+    return count, nil
+}
+
+func (s *RedisRateLimitStore) BucketTokens(ctx context.Context, key string) (float64, bool, error) {
+    tokens, err := s.redis.Client.HGet(ctx, key, "tokens").Float64()
+    if errors.Is(err, redis.Nil) {
+        return 0, false, nil
+    }
+    if err != nil {
+        return 0, false, fmt.Errorf("failed to read bucket: %w", err)
+    }
+    return tokens, true, nil
+}
+
+func (s *RedisRateLimitStore) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+    if err := s.redis.Client.Set(ctx, key, value, ttl).Err(); err != nil {
+        return fmt.Errorf("failed to store %s: %w", key, err)
+    }
+    return nil
+}
+
+func (s *RedisRateLimitStore) Get(ctx context.Context, key string) ([]byte, error) {
+    return s.read(s.redis.Client.Get(ctx, key))
+}
+
+func (s *RedisRateLimitStore) Take(ctx context.Context, key string) ([]byte, error) {
+    return s.read(s.redis.Client.GetDel(ctx, key))
+}
+
+func (s *RedisRateLimitStore) Replace(ctx context.Context, key string, value []byte) error {
+    err := s.redis.Client.SetArgs(ctx, key, value, redis.SetArgs{Mode: "XX", KeepTTL: true}).Err()
+    if errors.Is(err, redis.Nil) {
+        return ErrKeyNotFound
+    }
+    if err != nil {
+        return fmt.Errorf("failed to replace %s: %w", key, err)
+    }
+    return nil
+}
+
+func (s *RedisRateLimitStore) Delete(ctx context.Context, key string) error {
+    if err := s.redis.Client.Del(ctx, key).Err(); err != nil {
+        return fmt.Errorf("failed to delete %s: %w", key, err)
+    }
+    return nil
+}
+
+func (s *RedisRateLimitStore) read(cmd *redis.StringCmd) ([]byte, error) {
+    data, err := cmd.Bytes()
+    if errors.Is(err, redis.Nil) {
+        return nil, ErrKeyNotFound
+    }
+    if err != nil {
+        return nil, fmt.Errorf("failed to read key: %w", err)
+    }
+    return data, nil
+}
\ No newline at end of file
@@ -0,0 +1,76 @@
+package repository
+
+import (
+    "context"
+    "database/sql"
+    "fmt"
+    "time"
+
+    "github.com/google/uuid"
+    "github.com/Shridhar2104/chat-platform/auth-service/internal/tracing"
+    "github.com/Shridhar2104/chat-platform/shared/database"
+    "github.com/Shridhar2104/chat-platform/shared/models"
+)
+
+type SAMLRepository struct {
+    db *database.PostgresDB
+}
+
+func NewSAMLRepository(db *database.PostgresDB) *SAMLRepository {
+    return &SAMLRepository{db: db}
+}
+
+func (r *SAMLRepository) GetIdentityProviderByWorkspace(ctx context.Context, workspaceID uuid.UUID) (*models.SAMLIdentityProvider, error) {
+    ctx, span := startQuery(ctx, "SELECT", "saml_identity_providers")
+    defer span.End()
+
+    var idp models.SAMLIdentityProvider
+    query := `
+        SELECT id, workspace_id, entity_id, sso_url, certificate, email_attribute, display_name_attribute, enabled, created_at, updated_at
+        FROM saml_identity_providers WHERE workspace_id = $1
+    `
+    err := r.db.DB.GetContext(ctx, &idp, query, workspaceID)
+    if err != nil {
+        if err == sql.ErrNoRows {
+            return nil, ErrIdentityProviderNotFound
+        }
+        tracing.RecordError(span, err)
+        return nil, fmt.Errorf("failed to get identity provider: %w", err)
+    }
+    return &idp, nil
+}
+
+func (r *SAMLRepository) UpsertIdentityProvider(ctx context.Context, idp *models.SAMLIdentityProvider) error {
+    ctx, span := startQuery(ctx, "INSERT", "saml_identity_providers")
+    defer span.End()
+
+    query := `
+        INSERT INTO saml_identity_providers (id, workspace_id, entity_id, sso_url, certificate, email_attribute, display_name_attribute, enabled, created_at, updated_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+        ON CONFLICT (workspace_id) DO UPDATE SET
+            entity_id = EXCLUDED.entity_id,
+            sso_url = EXCLUDED.sso_url,
+            certificate = EXCLUDED.certificate,
+            email_attribute = EXCLUDED.email_attribute,
+            display_name_attribute = EXCLUDED.display_name_attribute,
+            enabled = EXCLUDED.enabled,
+            updated_at = EXCLUDED.updated_at
+    `
+    _, err := r.db.DB.ExecContext(ctx, query,
+        idp.ID,
+        idp.WorkspaceID,
+        idp.EntityID,
+        idp.SSOURL,
+        idp.Certificate,
+        idp.EmailAttribute,
+        idp.DisplayNameAttribute,
+        idp.Enabled,
+        idp.CreatedAt,
+        time.Now(),
+    )
+    if err != nil {
+        tracing.RecordError(span, err)
+        return fmt.Errorf("failed to upsert identity provider: %w", err)
+    }
+    return nil
+}
@@ -0,0 +1,404 @@
+package repository
+
+import (
+    "encoding/json"
+    "fmt"
+    "strings"
+    "time"
+)
+
+// SCIMFilter is a parsed SCIM 2.0 filter expression (RFC 7644 section 3.4.2.2)
+type SCIMFilter interface {
+    toSQL(columns map[string]scimColumn, args *[]interface{}) (string, error)
+}
+
+type scimColumnKind int
+
+const (
+    scimColumnText scimColumnKind = iota
+    scimColumnBool
+    scimColumnTime
+    // scimColumnMember is a multi-valued reference, Expr is a format string
+    // that receives the placeholder and only supports eq
+    scimColumnMember
+)
+
+type scimColumn struct {
+    Expr string
+    Kind scimColumnKind
+}
+
+// Filterable attributes, keyed by lower-cased SCIM attribute path
+var scimUserColumns = map[string]scimColumn{
+    "id":                {Expr: "u.id::text", Kind: scimColumnText},
+    "username":          {Expr: "u.email", Kind: scimColumnText},
+    "emails":            {Expr: "u.email", Kind: scimColumnText},
+    "emails.value":      {Expr: "u.email", Kind: scimColumnText},
+    "displayname":       {Expr: "u.display_name", Kind: scimColumnText},
+    "name.formatted":    {Expr: "u.display_name", Kind: scimColumnText},
+    "externalid":        {Expr: "u.external_id", Kind: scimColumnText},
+    "active":            {Expr: "u.active", Kind: scimColumnBool},
+    "meta.created":      {Expr: "u.created_at", Kind: scimColumnTime},
+    "meta.lastmodified": {Expr: "u.updated_at", Kind: scimColumnTime},
+}
+
+var scimGroupColumns = map[string]scimColumn{
+    "id":                {Expr: "g.id::text", Kind: scimColumnText},
+    "displayname":       {Expr: "g.display_name", Kind: scimColumnText},
+    "externalid":        {Expr: "g.external_id", Kind: scimColumnText},
+    "members":           {Expr: "EXISTS (SELECT 1 FROM scim_group_members m WHERE m.group_id = g.id AND m.user_id::text = %s)", Kind: scimColumnMember},
+    "members.value":     {Expr: "EXISTS (SELECT 1 FROM scim_group_members m WHERE m.group_id = g.id AND m.user_id::text = %s)", Kind: scimColumnMember},
+    "meta.created":      {Expr: "g.created_at", Kind: scimColumnTime},
+    "meta.lastmodified": {Expr: "g.updated_at", Kind: scimColumnTime},
+}
+
+type scimLogicalExpr struct {
+    op          string
+    left, right SCIMFilter
+}
+
+type scimNotExpr struct {
+    expr SCIMFilter
+}
+
+type scimAttrExpr struct {
+    path  string
+    op    string
+    value interface{}
+}
+
+// FilterError reports a filter that cannot be compiled for the resource, its
+// message is safe to return to SCIM clients
+type FilterError struct {
+    Detail string
+}
+
+func (e *FilterError) Error() string {
+    return e.Detail
+}
+
+// compileSCIMFilter turns filter into a WHERE clause over columns, every
+// failure is a *FilterError
+func compileSCIMFilter(filter SCIMFilter, columns map[string]scimColumn, args *[]interface{}) (string, error) {
+    clause, err := filter.toSQL(columns, args)
+    if err != nil {
+        return "", &FilterError{Detail: err.Error()}
+    }
+    return clause, nil
+}
+
+// ParseSCIMFilter parses filters such as `userName eq "bjensen" and active eq true`
+func ParseSCIMFilter(input string) (SCIMFilter, error) {
+    tokens, err := tokenizeSCIMFilter(input)
+    if err != nil {
+        return nil, err
+    }
+
+    parser := &scimFilterParser{tokens: tokens}
+    filter, err := parser.parseOr()
+    if err != nil {
+        return nil, err
+    }
+    if parser.pos != len(parser.tokens) {
+        return nil, fmt.Errorf("unexpected token %q in filter", parser.tokens[parser.pos].text)
+    }
+    return filter, nil
+}
+
+type scimToken struct {
+    text   string
+    quoted bool
+}
+
+func tokenizeSCIMFilter(input string) ([]scimToken, error) {
+    var tokens []scimToken
+
+    for i := 0; i < len(input); {
+        switch ch := input[i]; {
+        case ch == ' ' || ch == '\t' || ch == '\n':
+            i++
+        case ch == '(' || ch == ')':
+            tokens = append(tokens, scimToken{text: string(ch)})
+            i++
+        case ch == '"':
+            // String literals follow JSON escaping rules
+            end := i + 1
+            for end < len(input) && input[end] != '"' {
+                if input[end] == '\\' {
+                    end++
+                }
+                end++
+            }
+            if end >= len(input) {
+                return nil, fmt.Errorf("unterminated string in filter")
+            }
+            var value string
+            if err := json.Unmarshal([]byte(input[i:end+1]), &value); err != nil {
+                return nil, fmt.Errorf("invalid string in filter: %w", err)
+            }
+            tokens = append(tokens, scimToken{text: value, quoted: true})
+            i = end + 1
+        default:
+            end := i
+            for end < len(input) && !strings.ContainsRune(" \t\n()\"", rune(input[end])) {
+                end++
+            }
+            tokens = append(tokens, scimToken{text: input[i:end]})
+            i = end
+        }
+    }
+
+    return tokens, nil
+}
+
+type scimFilterParser struct {
+    tokens []scimToken
+    pos    int
+}
+
+func (p *scimFilterParser) peekKeyword(keyword string) bool {
+    if p.pos >= len(p.tokens) || p.tokens[p.pos].quoted {
+        return false
+    }
+    return strings.EqualFold(p.tokens[p.pos].text, keyword)
+}
+
+func (p *scimFilterParser) next() (scimToken, error) {
+    if p.pos >= len(p.tokens) {
+        return scimToken{}, fmt.Errorf("unexpected end of filter")
+    }
+    token := p.tokens[p.pos]
+    p.pos++
+    return token, nil
+}
+
+func (p *scimFilterParser) parseOr() (SCIMFilter, error) {
+    left, err := p.parseAnd()
+    if err != nil {
+        return nil, err
+    }
+    for p.peekKeyword("or") {
+        p.pos++
+        right, err := p.parseAnd()
+        if err != nil {
+            return nil, err
+        }
+        left = &scimLogicalExpr{op: "OR", left: left, right: right}
+    }
+    return left, nil
+}
+
+func (p *scimFilterParser) parseAnd() (SCIMFilter, error) {
+    left, err := p.parseUnary()
+    if err != nil {
+        return nil, err
+    }
+    for p.peekKeyword("and") {
+        p.pos++
+        right, err := p.parseUnary()
+        if err != nil {
+            return nil, err
+        }
+        left = &scimLogicalExpr{op: "AND", left: left, right: right}
+    }
+    return left, nil
+}
+
+func (p *scimFilterParser) parseUnary() (SCIMFilter, error) {
+    if p.peekKeyword("not") {
+        p.pos++
+        expr, err := p.parseGroup()
+        if err != nil {
+            return nil, err
+        }
+        return &scimNotExpr{expr: expr}, nil
+    }
+    if p.peekKeyword("(") {
+        return p.parseGroup()
+    }
+    return p.parseAttrExpr()
+}
+
+func (p *scimFilterParser) parseGroup() (SCIMFilter, error) {
+    if !p.peekKeyword("(") {
+        return nil, fmt.Errorf("expected ( in filter")
+    }
+    p.pos++
+    expr, err := p.parseOr()
+    if err != nil {
+        return nil, err
+    }
+    if !p.peekKeyword(")") {
+        return nil, fmt.Errorf("expected ) in filter")
+    }
+    p.pos++
+    return expr, nil
+}
+
+func (p *scimFilterParser) parseAttrExpr() (SCIMFilter, error) {
+    path, err := p.next()
+    if err != nil {
+        return nil, err
+    }
+    if path.quoted {
+        return nil, fmt.Errorf("expected attribute name, got string")
+    }
+
+    opToken, err := p.next()
+    if err != nil {
+        return nil, err
+    }
+    op := strings.ToLower(opToken.text)
+
+    switch op {
+    case "pr":
+        return &scimAttrExpr{path: strings.ToLower(path.text), op: op}, nil
+    case "eq", "ne", "co", "sw", "ew", "gt", "ge", "lt", "le":
+    default:
+        return nil, fmt.Errorf("unsupported filter operator %q", opToken.text)
+    }
+
+    valueToken, err := p.next()
+    if err != nil {
+        return nil, err
+    }
+
+    var value interface{}
+    switch {
+    case valueToken.quoted:
+        value = valueToken.text
+    case strings.EqualFold(valueToken.text, "true"):
+        value = true
+    case strings.EqualFold(valueToken.text, "false"):
+        value = false
+    case strings.EqualFold(valueToken.text, "null"):
+        value = nil
+    default:
+        return nil, fmt.Errorf("unsupported filter value %q", valueToken.text)
+    }
+
+    return &scimAttrExpr{path: strings.ToLower(path.text), op: op, value: value}, nil
+}
+
+func (e *scimLogicalExpr) toSQL(columns map[string]scimColumn, args *[]interface{}) (string, error) {
+    left, err := e.left.toSQL(columns, args)
+    if err != nil {
+        return "", err
+    }
+    right, err := e.right.toSQL(columns, args)
+    if err != nil {
+        return "", err
+    }
+    return fmt.Sprintf("(%s %s %s)", left, e.op, right), nil
+}
+
+func (e *scimNotExpr) toSQL(columns map[string]scimColumn, args *[]interface{}) (string, error) {
+    inner, err := e.expr.toSQL(columns, args)
+    if err != nil {
+        return "", err
+    }
+    return fmt.Sprintf("(NOT %s)", inner), nil
+}
+
+func (e *scimAttrExpr) toSQL(columns map[string]scimColumn, args *[]interface{}) (string, error) {
+    column, ok := columns[e.path]
+    if !ok {
+        return "", fmt.Errorf("unsupported filter attribute %q", e.path)
+    }
+
+    placeholder := func(value interface{}) string {
+        *args = append(*args, value)
+        return fmt.Sprintf("$%d", len(*args))
+    }
+
+    if e.op == "pr" {
+        if column.Kind == scimColumnMember {
+            return "EXISTS (SELECT 1 FROM scim_group_members m WHERE m.group_id = g.id)", nil
+        }
+        if column.Kind == scimColumnText {
+            return fmt.Sprintf("(%s IS NOT NULL AND %s <> '')", column.Expr, column.Expr), nil
+        }
+        return fmt.Sprintf("%s IS NOT NULL", column.Expr), nil
+    }
+
+    if e.value == nil {
+        switch e.op {
+        case "eq":
+            return fmt.Sprintf("%s IS NULL", column.Expr), nil
+        case "ne":
+            return fmt.Sprintf("%s IS NOT NULL", column.Expr), nil
+        }
+        return "", fmt.Errorf("operator %s does not support null", e.op)
+    }
+
+    switch column.Kind {
+    case scimColumnMember:
+        value, ok := e.value.(string)
+        if !ok || e.op != "eq" {
+            return "", fmt.Errorf("attribute %q only supports eq with a string value", e.path)
+        }
+        return fmt.Sprintf(column.Expr, placeholder(value)), nil
+
+    case scimColumnBool:
+        value, ok := e.value.(bool)
+        if !ok {
+            return "", fmt.Errorf("attribute %q requires a boolean value", e.path)
+        }
+        switch e.op {
+        case "eq":
+            return fmt.Sprintf("%s = %s", column.Expr, placeholder(value)), nil
+        case "ne":
+            return fmt.Sprintf("%s <> %s", column.Expr, placeholder(value)), nil
+        }
+        return "", fmt.Errorf("operator %s is not supported for boolean attributes", e.op)
+
+    case scimColumnTime:
+        text, ok := e.value.(string)
+        if !ok {
+            return "", fmt.Errorf("attribute %q requires a date-time value", e.path)
+        }
+        value, err := time.Parse(time.RFC3339, text)
+        if err != nil {
+            return "", fmt.Errorf("invalid date-time %q in filter", text)
+        }
+        operators := map[string]string{"eq": "=", "ne": "<>", "gt": ">", "ge": ">=", "lt": "<", "le": "<="}
+        sqlOp, ok := operators[e.op]
+        if !ok {
+            return "", fmt.Errorf("operator %s is not supported for date-time attributes", e.op)
+        }
+        return fmt.Sprintf("%s %s %s", column.Expr, sqlOp, placeholder(value)), nil
+    }
+
+    value, ok := e.value.(string)
+    if !ok {
+        return "", fmt.Errorf("attribute %q requires a string value", e.path)
+    }
+
+    // String attributes we expose are caseExact=false
+    switch e.op {
+    case "eq":
+        return fmt.Sprintf("LOWER(%s) = LOWER(%s)", column.Expr, placeholder(value)), nil
+    case "ne":
+        return fmt.Sprintf("(%s IS NULL OR LOWER(%s) <> LOWER(%s))", column.Expr, column.Expr, placeholder(value)), nil
+    case "co":
+        return fmt.Sprintf("%s ILIKE %s", column.Expr, placeholder("%"+escapeLike(value)+"%")), nil
+    case "sw":
+        return fmt.Sprintf("%s ILIKE %s", column.Expr, placeholder(escapeLike(value)+"%")), nil
+    case "ew":
+        return fmt.Sprintf("%s ILIKE %s", column.Expr, placeholder("%"+escapeLike(value))), nil
+    case "gt":
+        return fmt.Sprintf("LOWER(%s) > LOWER(%s)", column.Expr, placeholder(value)), nil
+    case "ge":
+        return fmt.Sprintf("LOWER(%s) >= LOWER(%s)", column.Expr, placeholder(value)), nil
+    case "lt":
+        return fmt.Sprintf("LOWER(%s) < LOWER(%s)", column.Expr, placeholder(value)), nil
+    case "le":
+        return fmt.Sprintf("LOWER(%s) <= LOWER(%s)", column.Expr, placeholder(value)), nil
+    }
+    return "", fmt.Errorf("unsupported filter operator %s", e.op)
+}
+
+func escapeLike(value string) string {
+    replacer := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+    return replacer.Replace(value)
+}
@@ -0,0 +1,168 @@
+package repository
+
+import (
+    "errors"
+    "reflect"
+    "testing"
+    "time"
+)
+
+func TestSCIMFilterToSQL(t *testing.T) {
+    created := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+    tests := []struct {
+        name     string
+        filter   string
+        columns  map[string]scimColumn
+        wantSQL  string
+        wantArgs []interface{}
+    }{
+        {name: "eq", filter: `userName eq "Ann@Acme.com"`, wantSQL: "LOWER(u.email) = LOWER($1)", wantArgs: []interface{}{"Ann@Acme.com"}},
+        {name: "ne", filter: `externalId ne "x"`, wantSQL: "(u.external_id IS NULL OR LOWER(u.external_id) <> LOWER($1))", wantArgs: []interface{}{"x"}},
+        {name: "co", filter: `displayName co "an"`, wantSQL: "u.display_name ILIKE $1", wantArgs: []interface{}{"%an%"}},
+        {name: "sw", filter: `displayName sw "an"`, wantSQL: "u.display_name ILIKE $1", wantArgs: []interface{}{"an%"}},
+        {name: "ew", filter: `emails.value ew "@acme.com"`, wantSQL: "u.email ILIKE $1", wantArgs: []interface{}{"%@acme.com"}},
+        {name: "gt", filter: `userName gt "m"`, wantSQL: "LOWER(u.email) > LOWER($1)", wantArgs: []interface{}{"m"}},
+        {name: "ge", filter: `userName ge "m"`, wantSQL: "LOWER(u.email) >= LOWER($1)", wantArgs: []interface{}{"m"}},
+        {name: "lt", filter: `userName lt "m"`, wantSQL: "LOWER(u.email) < LOWER($1)", wantArgs: []interface{}{"m"}},
+        {name: "le", filter: `userName le "m"`, wantSQL: "LOWER(u.email) <= LOWER($1)", wantArgs: []interface{}{"m"}},
+        {name: "pr on text", filter: `externalId pr`, wantSQL: "(u.external_id IS NOT NULL AND u.external_id <> '')"},
+        {name: "pr on time", filter: `meta.created pr`, wantSQL: "u.created_at IS NOT NULL"},
+        {name: "pr on members", filter: `members pr`, columns: scimGroupColumns, wantSQL: "EXISTS (SELECT 1 FROM scim_group_members m WHERE m.group_id = g.id)"},
+        {name: "operators and attributes are case insensitive", filter: `USERNAME EQ "a"`, wantSQL: "LOWER(u.email) = LOWER($1)", wantArgs: []interface{}{"a"}},
+        {name: "bool", filter: `active eq TRUE`, wantSQL: "u.active = $1", wantArgs: []interface{}{true}},
+        {name: "bool ne", filter: `active ne false`, wantSQL: "u.active <> $1", wantArgs: []interface{}{false}},
+        {name: "time", filter: `meta.lastModified gt "2024-01-02T03:04:05Z"`, wantSQL: "u.updated_at > $1", wantArgs: []interface{}{created}},
+        {name: "null eq", filter: `externalId eq null`, wantSQL: "u.external_id IS NULL"},
+        {name: "null ne", filter: `externalId ne null`, wantSQL: "u.external_id IS NOT NULL"},
+        {name: "member eq", filter: `members eq "42"`, columns: scimGroupColumns, wantSQL: "EXISTS (SELECT 1 FROM scim_group_members m WHERE m.group_id = g.id AND m.user_id::text = $1)", wantArgs: []interface{}{"42"}},
+        {
+            name:     "and binds tighter than or",
+            filter:   `userName eq "a" or userName eq "b" and active eq true`,
+            wantSQL:  "(LOWER(u.email) = LOWER($1) OR (LOWER(u.email) = LOWER($2) AND u.active = $3))",
+            wantArgs: []interface{}{"a", "b", true},
+        },
+        {
+            name:     "parentheses override precedence",
+            filter:   `(userName eq "a" or userName eq "b") and active eq true`,
+            wantSQL:  "((LOWER(u.email) = LOWER($1) OR LOWER(u.email) = LOWER($2)) AND u.active = $3)",
+            wantArgs: []interface{}{"a", "b", true},
+        },
+        {
+            name:     "not",
+            filter:   `not (active eq false) AND displayName pr`,
+            wantSQL:  "((NOT u.active = $1) AND (u.display_name IS NOT NULL AND u.display_name <> ''))",
+            wantArgs: []interface{}{false},
+        },
+        {name: "escaped quote", filter: `displayName eq "say \"hi\""`, wantSQL: "LOWER(u.display_name) = LOWER($1)", wantArgs: []interface{}{`say "hi"`}},
+        {name: "keywords inside strings", filter: `displayName eq "a or b) and (c"`, wantSQL: "LOWER(u.display_name) = LOWER($1)", wantArgs: []interface{}{"a or b) and (c"}},
+        {name: "like wildcards are escaped", filter: `displayName co "50%_\\"`, wantSQL: "u.display_name ILIKE $1", wantArgs: []interface{}{`%50\%\_\\%`}},
+        {
+            name:     "injection stays in a placeholder",
+            filter:   `userName eq "x' OR '1'='1"`,
+            wantSQL:  "LOWER(u.email) = LOWER($1)",
+            wantArgs: []interface{}{"x' OR '1'='1"},
+        },
+        {
+            name:     "injection with a statement terminator",
+            filter:   `displayName sw "'); DROP TABLE users; --"`,
+            wantSQL:  "u.display_name ILIKE $1",
+            wantArgs: []interface{}{"'); DROP TABLE users; --%"},
+        },
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            filter, err := ParseSCIMFilter(tt.filter)
+            if err != nil {
+                t.Fatalf("ParseSCIMFilter(%s) failed: %v", tt.filter, err)
+            }
+            columns := tt.columns
+            if columns == nil {
+                columns = scimUserColumns
+            }
+            var args []interface{}
+            sql, err := filter.toSQL(columns, &args)
+            if err != nil {
+                t.Fatalf("toSQL(%s) failed: %v", tt.filter, err)
+            }
+            if sql != tt.wantSQL {
+                t.Errorf("toSQL(%s) = %s, want %s", tt.filter, sql, tt.wantSQL)
+            }
+            if !reflect.DeepEqual(args, tt.wantArgs) {
+                t.Errorf("toSQL(%s) args = %#v, want %#v", tt.filter, args, tt.wantArgs)
+            }
+        })
+    }
+}
+
+func TestSCIMFilterParseErrors(t *testing.T) {
+    tests := []struct {
+        name   string
+        filter string
+    }{
+        {name: "empty", filter: ``},
+        {name: "unterminated string", filter: `userName eq "ann`},
+        {name: "escaped closing quote", filter: `userName eq "ann\"`},
+        {name: "invalid escape", filter: `userName eq "\q"`},
+        {name: "unsupported operator", filter: `userName like "a"`},
+        {name: "missing value", filter: `userName eq`},
+        {name: "unquoted value", filter: `userName eq ann`},
+        {name: "quoted attribute", filter: `"userName" eq "a"`},
+        {name: "trailing tokens", filter: `userName eq "a" "b"`},
+        {name: "dangling and", filter: `userName eq "a" and`},
+        {name: "unbalanced parenthesis", filter: `(userName eq "a"`},
+        {name: "stray closing parenthesis", filter: `userName eq "a")`},
+        {name: "not without parentheses", filter: `not active eq true`},
+        {name: "injected sql after expression", filter: `userName eq "a"; DROP TABLE users`},
+        {name: "parenthesis in attribute", filter: `u.email);-- eq "x"`},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            if _, err := ParseSCIMFilter(tt.filter); err == nil {
+                t.Errorf("ParseSCIMFilter(%s) succeeded, want an error", tt.filter)
+            }
+        })
+    }
+}
+
+func TestSCIMFilterToSQLErrors(t *testing.T) {
+    tests := []struct {
+        name    string
+        filter  string
+        columns map[string]scimColumn
+    }{
+        {name: "unsupported attribute", filter: `password eq "x"`},
+        {name: "attribute name injection", filter: `u.email;-- eq "x"`},
+        {name: "group attribute on users", filter: `members eq "1"`},
+        {name: "user attribute on groups", filter: `userName eq "a"`, columns: scimGroupColumns},
+        {name: "null with ordering operator", filter: `externalId gt null`},
+        {name: "string for bool", filter: `active eq "true"`},
+        {name: "ordering on bool", filter: `active gt true`},
+        {name: "bool for string", filter: `userName eq true`},
+        {name: "invalid date-time", filter: `meta.created gt "yesterday"`},
+        {name: "co on date-time", filter: `meta.created co "2024"`},
+        {name: "member with ne", filter: `members ne "1"`, columns: scimGroupColumns},
+        {name: "later operand fails", filter: `userName eq "a" or secret pr`},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            filter, err := ParseSCIMFilter(tt.filter)
+            if err != nil {
+                t.Fatalf("ParseSCIMFilter(%s) failed: %v", tt.filter, err)
+            }
+            columns := tt.columns
+            if columns == nil {
+                columns = scimUserColumns
+            }
+            var args []interface{}
+            sql, err := compileSCIMFilter(filter, columns, &args)
+            var filterErr *FilterError
+            if !errors.As(err, &filterErr) {
+                t.Errorf("compileSCIMFilter(%s) = %s, %v, want a FilterError", tt.filter, sql, err)
+            }
+        })
+    }
+}
\ No newline at end of file
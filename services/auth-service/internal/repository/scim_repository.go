@@ -0,0 +1,384 @@
+package repository
+
+import (
+    "context"
+    "database/sql"
+    "fmt"
+    "time"
+
+    "github.com/google/uuid"
+    "github.com/jmoiron/sqlx"
+    "github.com/Shridhar2104/chat-platform/auth-service/internal/tracing"
+    "github.com/Shridhar2104/chat-platform/shared/models"
+)
+
+func (r *UserRepository) GetSCIMTokenByHash(ctx context.Context, tokenHash string) (*models.SCIMToken, error) {
+    ctx, span := startQuery(ctx, "SELECT", "scim_tokens")
+    defer span.End()
+
+    var token models.SCIMToken
+    query := `
+        SELECT id, workspace_id, token_hash, description, revoked, created_at, last_used_at
+        FROM scim_tokens WHERE token_hash = $1 AND revoked = false
+    `
+    err := r.db.DB.GetContext(ctx, &token, query, tokenHash)
+    if err != nil {
+        if err == sql.ErrNoRows {
+            return nil, ErrSCIMTokenNotFound
+        }
+        tracing.RecordError(span, err)
+        return nil, fmt.Errorf("failed to get scim token: %w", err)
+    }
+    return &token, nil
+}
+
+func (r *UserRepository) TouchSCIMToken(ctx context.Context, tokenID uuid.UUID) error {
+    ctx, span := startQuery(ctx, "UPDATE", "scim_tokens")
+    defer span.End()
+
+    query := `UPDATE scim_tokens SET last_used_at = $1 WHERE id = $2`
+    _, err := r.db.DB.ExecContext(ctx, query, time.Now(), tokenID)
+    if err != nil {
+        tracing.RecordError(span, err)
+        return fmt.Errorf("failed to update scim token: %w", err)
+    }
+    return nil
+}
+
+func (r *UserRepository) AddWorkspaceMember(ctx context.Context, workspaceID, userID uuid.UUID, source string) error {
+    ctx, span := startQuery(ctx, "INSERT", "workspace_members")
+    defer span.End()
+
+    query := `
+        INSERT INTO workspace_members (workspace_id, user_id, source, created_at)
+        VALUES ($1, $2, $3, $4)
+        ON CONFLICT (workspace_id, user_id) DO NOTHING
+    `
+    _, err := r.db.DB.ExecContext(ctx, query, workspaceID, userID, source, time.Now())
+    if err != nil {
+        tracing.RecordError(span, err)
+        return fmt.Errorf("failed to add workspace member: %w", err)
+    }
+    return nil
+}
+
+// WorkspaceMemberSource returns how the user joined the workspace, "scim"
+// when the workspace's provisioning created the account
+func (r *UserRepository) WorkspaceMemberSource(ctx context.Context, workspaceID, userID uuid.UUID) (string, error) {
+    ctx, span := startQuery(ctx, "SELECT", "workspace_members")
+    defer span.End()
+
+    var source string
+    query := `SELECT source FROM workspace_members WHERE workspace_id = $1 AND user_id = $2`
+    if err := r.db.DB.GetContext(ctx, &source, query, workspaceID, userID); err != nil {
+        if err == sql.ErrNoRows {
+            return "", ErrUserNotFound
+        }
+        tracing.RecordError(span, err)
+        return "", fmt.Errorf("failed to get workspace membership: %w", err)
+    }
+    return source, nil
+}
+
+func (r *UserRepository) IsWorkspaceMember(ctx context.Context, workspaceID, userID uuid.UUID) (bool, error) {
+    ctx, span := startQuery(ctx, "SELECT", "workspace_members")
+    defer span.End()
+
+    var member bool
+    query := `SELECT EXISTS(SELECT 1 FROM workspace_members WHERE workspace_id = $1 AND user_id = $2)`
+    if err := r.db.DB.GetContext(ctx, &member, query, workspaceID, userID); err != nil {
+        tracing.RecordError(span, err)
+        return false, fmt.Errorf("failed to check workspace membership: %w", err)
+    }
+    return member, nil
+}
+
+// RemoveWorkspaceMember removes the membership along with the workspace's group memberships
+func (r *UserRepository) RemoveWorkspaceMember(ctx context.Context, workspaceID, userID uuid.UUID) error {
+    ctx, span := startQuery(ctx, "DELETE", "workspace_members")
+    defer span.End()
+
+    tx, err := r.db.DB.BeginTxx(ctx, nil)
+    if err != nil {
+        tracing.RecordError(span, err)
+        return fmt.Errorf("failed to begin transaction: %w", err)
+    }
+    defer tx.Rollback()
+
+    if _, err := tx.ExecContext(ctx, `
+        DELETE FROM scim_group_members
+        WHERE user_id = $1 AND group_id IN (SELECT id FROM scim_groups WHERE workspace_id = $2)
+    `, userID, workspaceID); err != nil {
+        tracing.RecordError(span, err)
+        return fmt.Errorf("failed to remove group memberships: %w", err)
+    }
+    if _, err := tx.ExecContext(ctx, `DELETE FROM workspace_members WHERE workspace_id = $1 AND user_id = $2`, workspaceID, userID); err != nil {
+        tracing.RecordError(span, err)
+        return fmt.Errorf("failed to remove workspace member: %w", err)
+    }
+
+    if err := tx.Commit(); err != nil {
+        tracing.RecordError(span, err)
+        return fmt.Errorf("failed to commit transaction: %w", err)
+    }
+    return nil
+}
+
+func (r *UserRepository) GetWorkspaceUser(ctx context.Context, workspaceID, userID uuid.UUID) (*models.User, error) {
+    ctx, span := startQuery(ctx, "SELECT", "users")
+    defer span.End()
+
+    var user models.User
+    query := `
+        SELECT u.id, u.email, u.password_hash, u.display_name, u.avatar_url, u.email_verified, u.active, u.external_id, u.created_at, u.updated_at
+        FROM users u
+        JOIN workspace_members wm ON wm.user_id = u.id
+        WHERE wm.workspace_id = $1 AND u.id = $2
+    `
+    err := r.db.DB.GetContext(ctx, &user, query, workspaceID, userID)
+    if err != nil {
+        if err == sql.ErrNoRows {
+            return nil, ErrUserNotFound
+        }
+        tracing.RecordError(span, err)
+        return nil, fmt.Errorf("failed to get workspace user: %w", err)
+    }
+    return &user, nil
+}
+
+// ListWorkspaceUsers returns one page of workspace members and the total match count.
+// startIndex is 1-based as in SCIM.
+func (r *UserRepository) ListWorkspaceUsers(ctx context.Context, workspaceID uuid.UUID, filter SCIMFilter, startIndex, count int) ([]models.User, int, error) {
+    args := []interface{}{workspaceID}
+    where := "wm.workspace_id = $1"
+    if filter != nil {
+        clause, err := compileSCIMFilter(filter, scimUserColumns, &args)
+        if err != nil {
+            return nil, 0, err
+        }
+        where += " AND " + clause
+    }
+
+    ctx, span := startQuery(ctx, "SELECT", "users")
+    defer span.End()
+
+    var total int
+    countQuery := `SELECT COUNT(*) FROM users u JOIN workspace_members wm ON wm.user_id = u.id WHERE ` + where
+    if err := r.db.DB.GetContext(ctx, &total, countQuery, args...); err != nil {
+        tracing.RecordError(span, err)
+        return nil, 0, fmt.Errorf("failed to count workspace users: %w", err)
+    }
+
+    users := []models.User{}
+    query := fmt.Sprintf(`
+        SELECT u.id, u.email, u.password_hash, u.display_name, u.avatar_url, u.email_verified, u.active, u.external_id, u.created_at, u.updated_at
+        FROM users u
+        JOIN workspace_members wm ON wm.user_id = u.id
+        WHERE %s
+        ORDER BY u.created_at, u.id
+        LIMIT %d OFFSET %d
+    `, where, count, startIndex-1)
+    if err := r.db.DB.SelectContext(ctx, &users, query, args...); err != nil {
+        tracing.RecordError(span, err)
+        return nil, 0, fmt.Errorf("failed to list workspace users: %w", err)
+    }
+
+    return users, total, nil
+}
+
+func (r *UserRepository) CreateGroup(ctx context.Context, group *models.Group) error {
+    ctx, span := startQuery(ctx, "INSERT", "scim_groups")
+    defer span.End()
+
+    tx, err := r.db.DB.BeginTxx(ctx, nil)
+    if err != nil {
+        tracing.RecordError(span, err)
+        return fmt.Errorf("failed to begin transaction: %w", err)
+    }
+    defer tx.Rollback()
+
+    query := `
+        INSERT INTO scim_groups (id, workspace_id, display_name, external_id, created_at, updated_at)
+        VALUES ($1, $2, $3, $4, $5, $6)
+    `
+    if _, err := tx.ExecContext(ctx, query, group.ID, group.WorkspaceID, group.DisplayName, group.ExternalID, group.CreatedAt, group.UpdatedAt); err != nil {
+        tracing.RecordError(span, err)
+        return writeError("create group", err)
+    }
+    if err := insertGroupMembers(ctx, tx, group.ID, group.MemberIDs); err != nil {
+        tracing.RecordError(span, err)
+        return err
+    }
+
+    if err := tx.Commit(); err != nil {
+        tracing.RecordError(span, err)
+        return fmt.Errorf("failed to commit transaction: %w", err)
+    }
+    return nil
+}
+
+func (r *UserRepository) GetGroup(ctx context.Context, workspaceID, groupID uuid.UUID) (*models.Group, error) {
+    ctx, span := startQuery(ctx, "SELECT", "scim_groups")
+    defer span.End()
+
+    var group models.Group
+    query := `
+        SELECT id, workspace_id, display_name, external_id, created_at, updated_at
+        FROM scim_groups WHERE workspace_id = $1 AND id = $2
+    `
+    err := r.db.DB.GetContext(ctx, &group, query, workspaceID, groupID)
+    if err != nil {
+        if err == sql.ErrNoRows {
+            return nil, ErrGroupNotFound
+        }
+        tracing.RecordError(span, err)
+        return nil, fmt.Errorf("failed to get group: %w", err)
+    }
+
+    if err := r.loadGroupMembers(ctx, []*models.Group{&group}); err != nil {
+        tracing.RecordError(span, err)
+        return nil, err
+    }
+    return &group, nil
+}
+
+func (r *UserRepository) ListGroups(ctx context.Context, workspaceID uuid.UUID, filter SCIMFilter, startIndex, count int) ([]models.Group, int, error) {
+    args := []interface{}{workspaceID}
+    where := "g.workspace_id = $1"
+    if filter != nil {
+        clause, err := compileSCIMFilter(filter, scimGroupColumns, &args)
+        if err != nil {
+            return nil, 0, err
+        }
+        where += " AND " + clause
+    }
+
+    ctx, span := startQuery(ctx, "SELECT", "scim_groups")
+    defer span.End()
+
+    var total int
+    if err := r.db.DB.GetContext(ctx, &total, `SELECT COUNT(*) FROM scim_groups g WHERE `+where, args...); err != nil {
+        tracing.RecordError(span, err)
+        return nil, 0, fmt.Errorf("failed to count groups: %w", err)
+    }
+
+    groups := []models.Group{}
+    query := fmt.Sprintf(`
+        SELECT g.id, g.workspace_id, g.display_name, g.external_id, g.created_at, g.updated_at
+        FROM scim_groups g
+        WHERE %s
+        ORDER BY g.created_at, g.id
+        LIMIT %d OFFSET %d
+    `, where, count, startIndex-1)
+    if err := r.db.DB.SelectContext(ctx, &groups, query, args...); err != nil {
+        tracing.RecordError(span, err)
+        return nil, 0, fmt.Errorf("failed to list groups: %w", err)
+    }
+
+    pointers := make([]*models.Group, len(groups))
+    for i := range groups {
+        pointers[i] = &groups[i]
+    }
+    if err := r.loadGroupMembers(ctx, pointers); err != nil {
+        tracing.RecordError(span, err)
+        return nil, 0, err
+    }
+
+    return groups, total, nil
+}
+
+// UpdateGroup saves the group attributes and replaces its member list
+func (r *UserRepository) UpdateGroup(ctx context.Context, group *models.Group) error {
+    ctx, span := startQuery(ctx, "UPDATE", "scim_groups")
+    defer span.End()
+
+    tx, err := r.db.DB.BeginTxx(ctx, nil)
+    if err != nil {
+        tracing.RecordError(span, err)
+        return fmt.Errorf("failed to begin transaction: %w", err)
+    }
+    defer tx.Rollback()
+
+    query := `UPDATE scim_groups SET display_name = $1, external_id = $2, updated_at = $3 WHERE id = $4`
+    if _, err := tx.ExecContext(ctx, query, group.DisplayName, group.ExternalID, time.Now(), group.ID); err != nil {
+        tracing.RecordError(span, err)
+        return writeError("update group", err)
+    }
+    if _, err := tx.ExecContext(ctx, `DELETE FROM scim_group_members WHERE group_id = $1`, group.ID); err != nil {
+        tracing.RecordError(span, err)
+        return fmt.Errorf("failed to clear group members: %w", err)
+    }
+    if err := insertGroupMembers(ctx, tx, group.ID, group.MemberIDs); err != nil {
+        tracing.RecordError(span, err)
+        return err
+    }
+
+    if err := tx.Commit(); err != nil {
+        tracing.RecordError(span, err)
+        return fmt.Errorf("failed to commit transaction: %w", err)
+    }
+    return nil
+}
+
+func (r *UserRepository) DeleteGroup(ctx context.Context, workspaceID, groupID uuid.UUID) error {
+    ctx, span := startQuery(ctx, "DELETE", "scim_groups")
+    defer span.End()
+
+    query := `DELETE FROM scim_groups WHERE workspace_id = $1 AND id = $2`
+    _, err := r.db.DB.ExecContext(ctx, query, workspaceID, groupID)
+    if err != nil {
+        tracing.RecordError(span, err)
+        return fmt.Errorf("failed to delete group: %w", err)
+    }
+    return nil
+}
+
+func (r *UserRepository) loadGroupMembers(ctx context.Context, groups []*models.Group) error {
+    if len(groups) == 0 {
+        return nil
+    }
+
+    byID := make(map[uuid.UUID]*models.Group, len(groups))
+    ids := make([]uuid.UUID, 0, len(groups))
+    for _, group := range groups {
+        group.MemberIDs = []uuid.UUID{}
+        byID[group.ID] = group
+        ids = append(ids, group.ID)
+    }
+
+    ctx, span := startQuery(ctx, "SELECT", "scim_group_members")
+    defer span.End()
+
+    query, args, err := sqlx.In(`SELECT group_id, user_id FROM scim_group_members WHERE group_id IN (?) ORDER BY user_id`, ids)
+    if err != nil {
+        tracing.RecordError(span, err)
+        return fmt.Errorf("failed to build group members query: %w", err)
+    }
+
+    var rows []struct {
+        GroupID uuid.UUID `db:"group_id"`
+        UserID  uuid.UUID `db:"user_id"`
+    }
+    if err := r.db.DB.SelectContext(ctx, &rows, r.db.DB.Rebind(query), args...); err != nil {
+        tracing.RecordError(span, err)
+        return fmt.Errorf("failed to load group members: %w", err)
+    }
+
+    for _, row := range rows {
+        byID[row.GroupID].MemberIDs = append(byID[row.GroupID].MemberIDs, row.UserID)
+    }
+    return nil
+}
+
+func insertGroupMembers(ctx context.Context, tx *sqlx.Tx, groupID uuid.UUID, memberIDs []uuid.UUID) error {
+    query := `
+        INSERT INTO scim_group_members (group_id, user_id) VALUES ($1, $2)
+        ON CONFLICT (group_id, user_id) DO NOTHING
+    `
+    for _, userID := range memberIDs {
+        if _, err := tx.ExecContext(ctx, query, groupID, userID); err != nil {
+            return fmt.Errorf("failed to add group member: %w", err)
+        }
+    }
+    return nil
+}
@@ -0,0 +1,96 @@
+package repository
+
+import (
+    "context"
+    "time"
+
+    "github.com/google/uuid"
+    "github.com/Shridhar2104/chat-platform/shared/models"
+)
+
+// UserStore holds accounts and their login history. UserRepository is the
+// Postgres implementation and MemoryUserStore the in-memory one; both pass
+// the contract tests in stores_test.go.
+type UserStore interface {
+    CreateUser(ctx context.Context, user *models.User) error
+    GetUserByEmail(ctx context.Context, email string) (*models.User, error)
+    GetUserByID(ctx context.Context, userID uuid.UUID) (*models.User, error)
+    GetUsersByIDs(ctx context.Context, userIDs []uuid.UUID) ([]models.User, error)
+    EmailExists(ctx context.Context, email string) (bool, error)
+    UpdateUserPassword(ctx context.Context, userID uuid.UUID, passwordHash string) error
+    CreateLoginEvent(ctx context.Context, event *models.LoginEvent) error
+    GetRecentLoginEvents(ctx context.Context, userID uuid.UUID, limit int) ([]models.LoginEvent, error)
+}
+
+// SessionStore holds refresh token sessions, looked up by token hash
+type SessionStore interface {
+    CreateSession(ctx context.Context, session *models.UserSession) error
+    GetSessionByRefreshToken(ctx context.Context, refreshTokenHash string) (*models.UserSession, error)
+    DeleteSession(ctx context.Context, sessionID uuid.UUID) error
+    DeleteUserSessions(ctx context.Context, userID uuid.UUID, deviceID string) error
+    DeleteAllUserSessions(ctx context.Context, userID uuid.UUID) error
+}
+
+// WorkspaceStore holds workspace memberships and the subscription plan of
+// each workspace. UserRepository is the Postgres implementation and
+// MemoryWorkspaceStore the in-memory one.
+type WorkspaceStore interface {
+    AddWorkspaceMember(ctx context.Context, workspaceID, userID uuid.UUID, source string) error
+    IsWorkspaceMember(ctx context.Context, workspaceID, userID uuid.UUID) (bool, error)
+    RemoveWorkspaceMember(ctx context.Context, workspaceID, userID uuid.UUID) error
+    // PrimaryWorkspace returns the workspace the user joined first,
+    // ErrWorkspaceNotFound when they belong to none
+    PrimaryWorkspace(ctx context.Context, userID uuid.UUID) (uuid.UUID, error)
+    // WorkspacePlan returns the plan of the workspace, empty when none is set
+    WorkspacePlan(ctx context.Context, workspaceID uuid.UUID) (string, error)
+    SetWorkspacePlan(ctx context.Context, workspaceID uuid.UUID, plan string) error
+}
+
+// DomainStore holds the email domains workspaces claim. A domain can be
+// claimed by many workspaces but verified by only one, writes breaking
+// either rule return ErrDuplicate.
+type DomainStore interface {
+    CreateDomainPolicy(ctx context.Context, policy *models.DomainPolicy) error
+    GetDomainPolicy(ctx context.Context, workspaceID uuid.UUID, domain string) (*models.DomainPolicy, error)
+    ListDomainPolicies(ctx context.Context, workspaceID uuid.UUID) ([]models.DomainPolicy, error)
+    GetVerifiedDomainPolicies(ctx context.Context, domains []string) ([]models.DomainPolicy, error)
+    UpdateDomainPolicy(ctx context.Context, policy *models.DomainPolicy) error
+    MarkDomainVerified(ctx context.Context, policyID uuid.UUID) error
+    DeleteDomainPolicy(ctx context.Context, workspaceID uuid.UUID, domain string) error
+}
+
+// RateLimitStore holds the short-lived counters and single-use records
+// behind rate limiting, abuse challenges and login step-up. Keys expire on
+// their own; reads of missing or expired keys return ErrKeyNotFound.
+type RateLimitStore interface {
+    // Increment adds one to each counter, starting its window on first use
+    Increment(ctx context.Context, window time.Duration, keys ...string) ([]int64, error)
+    // Counter reads a counter, 0 when it does not exist
+    Counter(ctx context.Context, key string) (int64, error)
+    // BucketTokens reads the tokens left in a token bucket, ok is false when there is none
+    BucketTokens(ctx context.Context, key string) (tokens float64, ok bool, err error)
+
+    Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+    Get(ctx context.Context, key string) ([]byte, error)
+    // Take reads and deletes a single-use record
+    Take(ctx context.Context, key string) ([]byte, error)
+    // Replace overwrites an existing record, keeping its expiry
+    Replace(ctx context.Context, key string, value []byte) error
+    Delete(ctx context.Context, key string) error
+}
+
+var (
+    _ UserStore    = (*UserRepository)(nil)
+    _ SessionStore = (*UserRepository)(nil)
+    _ UserStore    = (*MemoryUserStore)(nil)
+    _ SessionStore = (*MemorySessionStore)(nil)
+
+    _ WorkspaceStore = (*UserRepository)(nil)
+    _ WorkspaceStore = (*MemoryWorkspaceStore)(nil)
+
+    _ DomainStore = (*DomainRepository)(nil)
+    _ DomainStore = (*MemoryDomainStore)(nil)
+
+    _ RateLimitStore = (*RedisRateLimitStore)(nil)
+    _ RateLimitStore = (*MemoryRateLimitStore)(nil)
+)
\ No newline at end of file
@@ -0,0 +1,492 @@
+package repository
+
+import (
+    "context"
+    "errors"
+    "os"
+    "testing"
+    "time"
+
+    "github.com/alicebob/miniredis/v2"
+    "github.com/google/uuid"
+    "github.com/redis/go-redis/v9"
+    "github.com/Shridhar2104/chat-platform/auth-service/migrations"
+    "github.com/Shridhar2104/chat-platform/shared/database"
+    "github.com/Shridhar2104/chat-platform/shared/models"
+)
+
+// The contract suites below run against every implementation of a store
+// interface. The Postgres implementations only run when TEST_DATABASE_URL
+// points at a database the tests may migrate and write to.
+
+func TestMemoryUserAndSessionStores(t *testing.T) {
+    testUserStore(t, NewMemoryUserStore())
+    testSessionStore(t, NewMemoryUserStore(), NewMemorySessionStore())
+}
+
+func TestMemoryWorkspaceStore(t *testing.T) {
+    users := NewMemoryUserStore()
+    store := NewMemoryWorkspaceStore()
+    now := time.Now()
+    store.now = func() time.Time {
+        now = now.Add(time.Second)
+        return now
+    }
+    testWorkspaceStore(t, users, store)
+}
+
+func TestMemoryDomainStore(t *testing.T) {
+    testDomainStore(t, NewMemoryDomainStore())
+}
+
+func TestPostgresUserAndSessionStores(t *testing.T) {
+    databaseURL := os.Getenv("TEST_DATABASE_URL")
+    if databaseURL == "" {
+        t.Skip("TEST_DATABASE_URL not set")
+    }
+
+    db, err := database.NewPostgresConnection(databaseURL)
+    if err != nil {
+        t.Fatalf("connect: %v", err)
+    }
+    t.Cleanup(func() { db.Close() })
+
+    migrator, err := database.NewMigrator(db.DB, migrations.FS)
+    if err != nil {
+        t.Fatalf("load migrations: %v", err)
+    }
+    if _, err := migrator.Up(context.Background()); err != nil {
+        t.Fatalf("migrate: %v", err)
+    }
+
+    repo := NewUserRepository(db)
+    testUserStore(t, repo)
+    testSessionStore(t, repo, repo)
+    testWorkspaceStore(t, repo, repo)
+    testDomainStore(t, NewDomainRepository(db))
+}
+
+func TestMemoryRateLimitStore(t *testing.T) {
+    store := NewMemoryRateLimitStore()
+    now := time.Now()
+    store.now = func() time.Time { return now }
+
+    testRateLimitStore(t, store, func(d time.Duration) { now = now.Add(d) })
+}
+
+func TestRedisRateLimitStore(t *testing.T) {
+    server := miniredis.RunT(t)
+    client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+    t.Cleanup(func() { client.Close() })
+
+    testRateLimitStore(t, NewRedisRateLimitStore(&database.RedisClient{Client: client}), server.FastForward)
+}
+
+// newTestUser returns a user with a unique email so suites can share a database
+func newTestUser() *models.User {
+    now := time.Now().UTC().Truncate(time.Second)
+    return &models.User{
+        ID:           uuid.New(),
+        Email:        uuid.NewString() + "@example.com",
+        PasswordHash: "hash",
+        DisplayName:  "Test User",
+        Active:       true,
+        CreatedAt:    now,
+        UpdatedAt:    now,
+    }
+}
+
+func testUserStore(t *testing.T, store UserStore) {
+    ctx := context.Background()
+
+    t.Run("create and read", func(t *testing.T) {
+        user := newTestUser()
+        if err := store.CreateUser(ctx, user); err != nil {
+            t.Fatalf("CreateUser: %v", err)
+        }
+
+        byEmail, err := store.GetUserByEmail(ctx, user.Email)
+        if err != nil || byEmail.ID != user.ID || byEmail.DisplayName != user.DisplayName {
+            t.Errorf("GetUserByEmail = %+v, %v", byEmail, err)
+        }
+        byID, err := store.GetUserByID(ctx, user.ID)
+        if err != nil || byID.Email != user.Email || !byID.Active {
+            t.Errorf("GetUserByID = %+v, %v", byID, err)
+        }
+        if exists, err := store.EmailExists(ctx, user.Email); err != nil || !exists {
+            t.Errorf("EmailExists = %v, %v", exists, err)
+        }
+    })
+
+    t.Run("missing users", func(t *testing.T) {
+        if _, err := store.GetUserByEmail(ctx, uuid.NewString()+"@example.com"); !errors.Is(err, ErrUserNotFound) {
+            t.Errorf("GetUserByEmail error = %v, want ErrUserNotFound", err)
+        }
+        if _, err := store.GetUserByID(ctx, uuid.New()); !errors.Is(err, ErrUserNotFound) {
+            t.Errorf("GetUserByID error = %v, want ErrUserNotFound", err)
+        }
+        if exists, err := store.EmailExists(ctx, uuid.NewString()+"@example.com"); err != nil || exists {
+            t.Errorf("EmailExists = %v, %v", exists, err)
+        }
+    })
+
+    t.Run("duplicate email", func(t *testing.T) {
+        user := newTestUser()
+        if err := store.CreateUser(ctx, user); err != nil {
+            t.Fatalf("CreateUser: %v", err)
+        }
+        duplicate := newTestUser()
+        duplicate.Email = user.Email
+        if err := store.CreateUser(ctx, duplicate); !errors.Is(err, ErrDuplicate) {
+            t.Errorf("CreateUser error = %v, want ErrDuplicate", err)
+        }
+    })
+
+    t.Run("batch lookup", func(t *testing.T) {
+        first, second := newTestUser(), newTestUser()
+        for _, user := range []*models.User{first, second} {
+            if err := store.CreateUser(ctx, user); err != nil {
+                t.Fatalf("CreateUser: %v", err)
+            }
+        }
+
+        users, err := store.GetUsersByIDs(ctx, []uuid.UUID{first.ID, uuid.New(), second.ID})
+        if err != nil || len(users) != 2 {
+            t.Fatalf("GetUsersByIDs = %d users, %v", len(users), err)
+        }
+        if users, err := store.GetUsersByIDs(ctx, nil); err != nil || users == nil || len(users) != 0 {
+            t.Errorf("GetUsersByIDs(nil) = %v, %v, want an empty slice", users, err)
+        }
+    })
+
+    t.Run("update password", func(t *testing.T) {
+        user := newTestUser()
+        if err := store.CreateUser(ctx, user); err != nil {
+            t.Fatalf("CreateUser: %v", err)
+        }
+        if err := store.UpdateUserPassword(ctx, user.ID, "new-hash"); err != nil {
+            t.Fatalf("UpdateUserPassword: %v", err)
+        }
+        if updated, _ := store.GetUserByID(ctx, user.ID); updated.PasswordHash != "new-hash" {
+            t.Errorf("password hash = %q", updated.PasswordHash)
+        }
+    })
+
+    t.Run("login events newest first", func(t *testing.T) {
+        user := newTestUser()
+        if err := store.CreateUser(ctx, user); err != nil {
+            t.Fatalf("CreateUser: %v", err)
+        }
+        start := time.Now().UTC().Truncate(time.Second)
+        for i := 0; i < 3; i++ {
+            event := &models.LoginEvent{
+                ID:        uuid.New(),
+                UserID:    user.ID,
+                DeviceID:  "device-" + string(rune('a'+i)),
+                IPAddress: "203.0.113.7",
+                IPPrefix:  "203.0.113.0/24",
+                RiskLevel: "low",
+                CreatedAt: start.Add(time.Duration(i) * time.Minute),
+            }
+            if err := store.CreateLoginEvent(ctx, event); err != nil {
+                t.Fatalf("CreateLoginEvent: %v", err)
+            }
+        }
+
+        events, err := store.GetRecentLoginEvents(ctx, user.ID, 2)
+        if err != nil || len(events) != 2 {
+            t.Fatalf("GetRecentLoginEvents = %d events, %v", len(events), err)
+        }
+        if events[0].DeviceID != "device-c" || events[1].DeviceID != "device-b" {
+            t.Errorf("got devices %s, %s", events[0].DeviceID, events[1].DeviceID)
+        }
+    })
+}
+
+func testSessionStore(t *testing.T, users UserStore, store SessionStore) {
+    ctx := context.Background()
+
+    newSession := func(t *testing.T, userID uuid.UUID, deviceID string, expiresIn time.Duration) *models.UserSession {
+        t.Helper()
+        now := time.Now().UTC().Truncate(time.Second)
+        session := &models.UserSession{
+            ID:               uuid.New(),
+            UserID:           userID,
+            DeviceID:         deviceID,
+            RefreshTokenHash: uuid.NewString(),
+            ExpiresAt:        now.Add(expiresIn),
+            CreatedAt:        now,
+        }
+        if err := store.CreateSession(ctx, session); err != nil {
+            t.Fatalf("CreateSession: %v", err)
+        }
+        return session
+    }
+    newUser := func(t *testing.T) uuid.UUID {
+        t.Helper()
+        user := newTestUser()
+        if err := users.CreateUser(ctx, user); err != nil {
+            t.Fatalf("CreateUser: %v", err)
+        }
+        return user.ID
+    }
+
+    t.Run("lookup by refresh token", func(t *testing.T) {
+        session := newSession(t, newUser(t), "laptop", time.Hour)
+
+        found, err := store.GetSessionByRefreshToken(ctx, session.RefreshTokenHash)
+        if err != nil || found.ID != session.ID || found.DeviceID != "laptop" {
+            t.Errorf("GetSessionByRefreshToken = %+v, %v", found, err)
+        }
+        if _, err := store.GetSessionByRefreshToken(ctx, "unknown"); !errors.Is(err, ErrSessionNotFound) {
+            t.Errorf("unknown token error = %v, want ErrSessionNotFound", err)
+        }
+    })
+
+    t.Run("expired sessions are not found", func(t *testing.T) {
+        session := newSession(t, newUser(t), "laptop", -time.Hour)
+        if _, err := store.GetSessionByRefreshToken(ctx, session.RefreshTokenHash); !errors.Is(err, ErrSessionNotFound) {
+            t.Errorf("error = %v, want ErrSessionNotFound", err)
+        }
+    })
+
+    t.Run("delete", func(t *testing.T) {
+        userID := newUser(t)
+        single := newSession(t, userID, "phone", time.Hour)
+        laptop := newSession(t, userID, "laptop", time.Hour)
+        tablet := newSession(t, userID, "tablet", time.Hour)
+
+        if err := store.DeleteSession(ctx, single.ID); err != nil {
+            t.Fatalf("DeleteSession: %v", err)
+        }
+        if err := store.DeleteUserSessions(ctx, userID, "laptop"); err != nil {
+            t.Fatalf("DeleteUserSessions: %v", err)
+        }
+        for _, deleted := range []*models.UserSession{single, laptop} {
+            if _, err := store.GetSessionByRefreshToken(ctx, deleted.RefreshTokenHash); !errors.Is(err, ErrSessionNotFound) {
+                t.Errorf("session on %s still found", deleted.DeviceID)
+            }
+        }
+        if _, err := store.GetSessionByRefreshToken(ctx, tablet.RefreshTokenHash); err != nil {
+            t.Errorf("session on another device was deleted: %v", err)
+        }
+
+        if err := store.DeleteAllUserSessions(ctx, userID); err != nil {
+            t.Fatalf("DeleteAllUserSessions: %v", err)
+        }
+        if _, err := store.GetSessionByRefreshToken(ctx, tablet.RefreshTokenHash); !errors.Is(err, ErrSessionNotFound) {
+            t.Errorf("session survived DeleteAllUserSessions")
+        }
+    })
+}
+
+func testWorkspaceStore(t *testing.T, users UserStore, store WorkspaceStore) {
+    ctx := context.Background()
+    user := newTestUser()
+    if err := users.CreateUser(ctx, user); err != nil {
+        t.Fatalf("CreateUser: %v", err)
+    }
+
+    if _, err := store.PrimaryWorkspace(ctx, user.ID); !errors.Is(err, ErrWorkspaceNotFound) {
+        t.Errorf("PrimaryWorkspace without membership error = %v, want ErrWorkspaceNotFound", err)
+    }
+
+    first, second := uuid.New(), uuid.New()
+    if err := store.AddWorkspaceMember(ctx, first, user.ID, "domain"); err != nil {
+        t.Fatalf("AddWorkspaceMember: %v", err)
+    }
+    // Postgres orders memberships by their timestamp
+    time.Sleep(10 * time.Millisecond)
+    if err := store.AddWorkspaceMember(ctx, second, user.ID, "manual"); err != nil {
+        t.Fatalf("AddWorkspaceMember: %v", err)
+    }
+    if err := store.AddWorkspaceMember(ctx, first, user.ID, "manual"); err != nil {
+        t.Errorf("repeated AddWorkspaceMember: %v", err)
+    }
+
+    if member, err := store.IsWorkspaceMember(ctx, second, user.ID); err != nil || !member {
+        t.Errorf("IsWorkspaceMember = %v, %v", member, err)
+    }
+    if member, err := store.IsWorkspaceMember(ctx, uuid.New(), user.ID); err != nil || member {
+        t.Errorf("IsWorkspaceMember of another workspace = %v, %v", member, err)
+    }
+    if primary, err := store.PrimaryWorkspace(ctx, user.ID); err != nil || primary != first {
+        t.Errorf("PrimaryWorkspace = %v, %v, want the first workspace joined", primary, err)
+    }
+
+    if err := store.RemoveWorkspaceMember(ctx, first, user.ID); err != nil {
+        t.Fatalf("RemoveWorkspaceMember: %v", err)
+    }
+    if member, err := store.IsWorkspaceMember(ctx, first, user.ID); err != nil || member {
+        t.Errorf("IsWorkspaceMember after removal = %v, %v", member, err)
+    }
+    if primary, err := store.PrimaryWorkspace(ctx, user.ID); err != nil || primary != second {
+        t.Errorf("PrimaryWorkspace after removal = %v, %v, want the second workspace", primary, err)
+    }
+
+    if plan, err := store.WorkspacePlan(ctx, first); err != nil || plan != "" {
+        t.Errorf("WorkspacePlan before it is set = %q, %v", plan, err)
+    }
+    for _, want := range []string{"pro", "enterprise"} {
+        if err := store.SetWorkspacePlan(ctx, first, want); err != nil {
+            t.Fatalf("SetWorkspacePlan: %v", err)
+        }
+        if plan, err := store.WorkspacePlan(ctx, first); err != nil || plan != want {
+            t.Errorf("WorkspacePlan = %q, %v, want %q", plan, err, want)
+        }
+    }
+}
+
+func newTestDomainPolicy(workspaceID uuid.UUID, domain string) *models.DomainPolicy {
+    return &models.DomainPolicy{
+        ID:                uuid.New(),
+        WorkspaceID:       workspaceID,
+        Domain:            domain,
+        VerificationToken: "token",
+        AllowSignup:       true,
+        CreatedAt:         time.Now(),
+        UpdatedAt:         time.Now(),
+    }
+}
+
+func testDomainStore(t *testing.T, store DomainStore) {
+    ctx := context.Background()
+    // Postgres keeps rows between runs, so every run claims fresh domains
+    domain := uuid.NewString()[:8] + ".example.com"
+    workspace, rival := uuid.New(), uuid.New()
+
+    policy := newTestDomainPolicy(workspace, domain)
+    if err := store.CreateDomainPolicy(ctx, policy); err != nil {
+        t.Fatalf("CreateDomainPolicy: %v", err)
+    }
+    if err := store.CreateDomainPolicy(ctx, newTestDomainPolicy(workspace, domain)); !errors.Is(err, ErrDuplicate) {
+        t.Errorf("second claim by the same workspace error = %v, want ErrDuplicate", err)
+    }
+    rivalPolicy := newTestDomainPolicy(rival, domain)
+    if err := store.CreateDomainPolicy(ctx, rivalPolicy); err != nil {
+        t.Fatalf("claim by another workspace: %v", err)
+    }
+
+    if _, err := store.GetDomainPolicy(ctx, workspace, "missing."+domain); !errors.Is(err, ErrDomainPolicyNotFound) {
+        t.Errorf("GetDomainPolicy of an unclaimed domain error = %v, want ErrDomainPolicyNotFound", err)
+    }
+    if verified, err := store.GetVerifiedDomainPolicies(ctx, []string{domain}); err != nil || len(verified) != 0 {
+        t.Errorf("verified policies before verification = %v, %v", verified, err)
+    }
+
+    if err := store.MarkDomainVerified(ctx, policy.ID); err != nil {
+        t.Fatalf("MarkDomainVerified: %v", err)
+    }
+    if err := store.MarkDomainVerified(ctx, rivalPolicy.ID); !errors.Is(err, ErrDuplicate) {
+        t.Errorf("verifying a domain twice error = %v, want ErrDuplicate", err)
+    }
+    verified, err := store.GetVerifiedDomainPolicies(ctx, []string{domain, "other.example.com"})
+    if err != nil || len(verified) != 1 || verified[0].ID != policy.ID || verified[0].VerifiedAt == nil {
+        t.Errorf("verified policies = %+v, %v", verified, err)
+    }
+
+    policy.AutoJoin, policy.EnforceSSO = true, true
+    if err := store.UpdateDomainPolicy(ctx, policy); err != nil {
+        t.Fatalf("UpdateDomainPolicy: %v", err)
+    }
+    got, err := store.GetDomainPolicy(ctx, workspace, domain)
+    if err != nil || !got.AutoJoin || !got.EnforceSSO || !got.Verified {
+        t.Errorf("GetDomainPolicy after update = %+v, %v", got, err)
+    }
+
+    // Listed by domain, and the domain starts with a hex digit
+    second := newTestDomainPolicy(workspace, "www."+domain)
+    if err := store.CreateDomainPolicy(ctx, second); err != nil {
+        t.Fatalf("CreateDomainPolicy: %v", err)
+    }
+    listed, err := store.ListDomainPolicies(ctx, workspace)
+    if err != nil || len(listed) != 2 || listed[0].Domain != domain || listed[1].Domain != second.Domain {
+        t.Errorf("ListDomainPolicies = %+v, %v, want both domains in order", listed, err)
+    }
+
+    if err := store.DeleteDomainPolicy(ctx, workspace, domain); err != nil {
+        t.Fatalf("DeleteDomainPolicy: %v", err)
+    }
+    if _, err := store.GetDomainPolicy(ctx, workspace, domain); !errors.Is(err, ErrDomainPolicyNotFound) {
+        t.Errorf("GetDomainPolicy after delete error = %v, want ErrDomainPolicyNotFound", err)
+    }
+    // The rival can verify once the domain is released
+    if err := store.MarkDomainVerified(ctx, rivalPolicy.ID); err != nil {
+        t.Errorf("MarkDomainVerified after release: %v", err)
+    }
+}
+
+func testRateLimitStore(t *testing.T, store RateLimitStore, advance func(time.Duration)) {
+    ctx := context.Background()
+
+    t.Run("counters share a fixed window", func(t *testing.T) {
+        counts, err := store.Increment(ctx, time.Minute, "counter:a", "counter:b")
+        if err != nil || len(counts) != 2 || counts[0] != 1 || counts[1] != 1 {
+            t.Fatalf("Increment = %v, %v", counts, err)
+        }
+        advance(30 * time.Second)
+        counts, _ = store.Increment(ctx, time.Minute, "counter:a")
+        if counts[0] != 2 {
+            t.Errorf("second increment = %d, want 2", counts[0])
+        }
+        if count, err := store.Counter(ctx, "counter:a"); err != nil || count != 2 {
+            t.Errorf("Counter = %d, %v", count, err)
+        }
+
+        // The window started with the first increment, not the latest one
+        advance(31 * time.Second)
+        if count, err := store.Counter(ctx, "counter:a"); err != nil || count != 0 {
+            t.Errorf("Counter after window = %d, %v", count, err)
+        }
+        if counts, _ := store.Increment(ctx, time.Minute, "counter:a"); counts[0] != 1 {
+            t.Errorf("increment after window = %d, want 1", counts[0])
+        }
+    })
+
+    t.Run("missing bucket", func(t *testing.T) {
+        if _, ok, err := store.BucketTokens(ctx, "rate_limit:bucket:ip:198.51.100.1"); ok || err != nil {
+            t.Errorf("BucketTokens ok = %v, err = %v", ok, err)
+        }
+    })
+
+    t.Run("records", func(t *testing.T) {
+        if err := store.Set(ctx, "record", []byte("v1"), time.Minute); err != nil {
+            t.Fatalf("Set: %v", err)
+        }
+        if value, err := store.Get(ctx, "record"); err != nil || string(value) != "v1" {
+            t.Errorf("Get = %q, %v", value, err)
+        }
+        if err := store.Replace(ctx, "record", []byte("v2")); err != nil {
+            t.Fatalf("Replace: %v", err)
+        }
+        if value, err := store.Take(ctx, "record"); err != nil || string(value) != "v2" {
+            t.Errorf("Take = %q, %v", value, err)
+        }
+        if _, err := store.Take(ctx, "record"); !errors.Is(err, ErrKeyNotFound) {
+            t.Errorf("second Take error = %v, want ErrKeyNotFound", err)
+        }
+        if err := store.Replace(ctx, "record", []byte("v3")); !errors.Is(err, ErrKeyNotFound) {
+            t.Errorf("Replace of missing key error = %v, want ErrKeyNotFound", err)
+        }
+    })
+
+    t.Run("replace keeps the expiry", func(t *testing.T) {
+        store.Set(ctx, "expiring", []byte("v1"), time.Minute)
+        advance(45 * time.Second)
+        store.Replace(ctx, "expiring", []byte("v2"))
+        advance(30 * time.Second)
+        if _, err := store.Get(ctx, "expiring"); !errors.Is(err, ErrKeyNotFound) {
+            t.Errorf("Get error = %v, want ErrKeyNotFound", err)
+        }
+    })
+
+    t.Run("delete", func(t *testing.T) {
+        store.Set(ctx, "deleted", []byte("v1"), time.Minute)
+        if err := store.Delete(ctx, "deleted"); err != nil {
+            t.Fatalf("Delete: %v", err)
+        }
+        if _, err := store.Get(ctx, "deleted"); !errors.Is(err, ErrKeyNotFound) {
+            t.Errorf("Get error = %v, want ErrKeyNotFound", err)
+        }
+    })
+}
\ No newline at end of file
@@ -1,11 +1,16 @@
 package repository
 
 import (
+    "context"
     "database/sql"
     "fmt"
     "time"
 
     "github.com/google/uuid"
+    "github.com/jmoiron/sqlx"
+    semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+    "go.opentelemetry.io/otel/trace"
+    "github.com/Shridhar2104/chat-platform/auth-service/internal/tracing"
     "github.com/Shridhar2104/chat-platform/shared/database"
     "github.com/Shridhar2104/chat-platform/shared/models"
 )
@@ -18,73 +23,175 @@ func NewUserRepository(db *database.PostgresDB) *UserRepository {
     return &UserRepository{db: db}
 }
 
-func (r *UserRepository) CreateUser(user *models.User) error {
+// startQuery opens a client span for one Postgres statement
+func startQuery(ctx context.Context, operation, table string) (context.Context, trace.Span) {
+    return tracing.StartDB(ctx, semconv.DBSystemPostgreSQL, operation, table)
+}
+
+func (r *UserRepository) CreateUser(ctx context.Context, user *models.User) error {
+    ctx, span := startQuery(ctx, "INSERT", "users")
+    defer span.End()
+
     query := `
-        INSERT INTO users (id, email, password_hash, display_name, email_verified, created_at, updated_at)
-        VALUES ($1, $2, $3, $4, $5, $6, $7)
+        INSERT INTO users (id, email, password_hash, display_name, email_verified, active, external_id, created_at, updated_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
     `
-    _, err := r.db.DB.Exec(query,
+    _, err := r.db.DB.ExecContext(ctx, query,
         user.ID,
         user.Email,
         user.PasswordHash,
         user.DisplayName,
         user.EmailVerified,
+        user.Active,
+        user.ExternalID,
         user.CreatedAt,
         user.UpdatedAt,
     )
     if err != nil {
-        return fmt.Errorf("failed to create user: %w", err)
+        tracing.RecordError(span, err)
+        return writeError("create user", err)
     }
     return nil
 }
 
-func (r *UserRepository) GetUserByEmail(email string) (*models.User, error) {
+func (r *UserRepository) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
+    ctx, span := startQuery(ctx, "SELECT", "users")
+    defer span.End()
+
     var user models.User
     query := `
-        SELECT id, email, password_hash, display_name, avatar_url, email_verified, created_at, updated_at
+        SELECT id, email, password_hash, display_name, avatar_url, email_verified, active, external_id, created_at, updated_at
         FROM users WHERE email = $1
     `
-    err := r.db.DB.Get(&user, query, email)
+    err := r.db.DB.GetContext(ctx, &user, query, email)
     if err != nil {
         if err == sql.ErrNoRows {
-            return nil, fmt.Errorf("user not found")
+            return nil, ErrUserNotFound
         }
+        tracing.RecordError(span, err)
         return nil, fmt.Errorf("failed to get user by email: %w", err)
     }
     return &user, nil
 }
 
-func (r *UserRepository) GetUserByID(userID uuid.UUID) (*models.User, error) {
+func (r *UserRepository) GetUserByID(ctx context.Context, userID uuid.UUID) (*models.User, error) {
+    ctx, span := startQuery(ctx, "SELECT", "users")
+    defer span.End()
+
     var user models.User
     query := `
-        SELECT id, email, password_hash, display_name, avatar_url, email_verified, created_at, updated_at
+        SELECT id, email, password_hash, display_name, avatar_url, email_verified, active, external_id, created_at, updated_at
         FROM users WHERE id = $1
     `
-    err := r.db.DB.Get(&user, query, userID)
+    err := r.db.DB.GetContext(ctx, &user, query, userID)
     if err != nil {
         if err == sql.ErrNoRows {
-            return nil, fmt.Errorf("user not found")
+            return nil, ErrUserNotFound
         }
+        tracing.RecordError(span, err)
         return nil, fmt.Errorf("failed to get user by ID: %w", err)
     }
     return &user, nil
 }
 
-func (r *UserRepository) UpdateUserPassword(userID uuid.UUID, passwordHash string) error {
+// GetUsersByIDs returns the users that exist among the given IDs
+func (r *UserRepository) GetUsersByIDs(ctx context.Context, userIDs []uuid.UUID) ([]models.User, error) {
+    ctx, span := startQuery(ctx, "SELECT", "users")
+    defer span.End()
+
+    users := []models.User{}
+    if len(userIDs) == 0 {
+        return users, nil
+    }
+
+    query, args, err := sqlx.In(`
+        SELECT id, email, password_hash, display_name, avatar_url, email_verified, active, external_id, created_at, updated_at
+        FROM users WHERE id IN (?)
+    `, userIDs)
+    if err != nil {
+        tracing.RecordError(span, err)
+        return nil, fmt.Errorf("failed to build users query: %w", err)
+    }
+    if err := r.db.DB.SelectContext(ctx, &users, r.db.DB.Rebind(query), args...); err != nil {
+        tracing.RecordError(span, err)
+        return nil, fmt.Errorf("failed to get users by ID: %w", err)
+    }
+    return users, nil
+}
+
+func (r *UserRepository) UpdateUserPassword(ctx context.Context, userID uuid.UUID, passwordHash string) error {
+    ctx, span := startQuery(ctx, "UPDATE", "users")
+    defer span.End()
+
     query := `UPDATE users SET password_hash = $1, updated_at = $2 WHERE id = $3`
-    _, err := r.db.DB.Exec(query, passwordHash, time.Now(), userID)
+    _, err := r.db.DB.ExecContext(ctx, query, passwordHash, time.Now(), userID)
     if err != nil {
+        tracing.RecordError(span, err)
         return fmt.Errorf("failed to update user password: %w", err)
     }
     return nil
 }
 
-func (r *UserRepository) CreateSession(session *models.UserSession) error {
+func (r *UserRepository) UpdateUser(ctx context.Context, user *models.User) error {
+    ctx, span := startQuery(ctx, "UPDATE", "users")
+    defer span.End()
+
+    query := `
+        UPDATE users SET email = $1, display_name = $2, active = $3, external_id = $4, updated_at = $5
+        WHERE id = $6
+    `
+    _, err := r.db.DB.ExecContext(ctx, query,
+        user.Email,
+        user.DisplayName,
+        user.Active,
+        user.ExternalID,
+        time.Now(),
+        user.ID,
+    )
+    if err != nil {
+        tracing.RecordError(span, err)
+        return writeError("update user", err)
+    }
+    return nil
+}
+
+// DeactivateUser disables the account and revokes every session in one transaction
+func (r *UserRepository) DeactivateUser(ctx context.Context, userID uuid.UUID) error {
+    ctx, span := startQuery(ctx, "UPDATE", "users")
+    defer span.End()
+
+    tx, err := r.db.DB.BeginTxx(ctx, nil)
+    if err != nil {
+        tracing.RecordError(span, err)
+        return fmt.Errorf("failed to begin transaction: %w", err)
+    }
+    defer tx.Rollback()
+
+    if _, err := tx.ExecContext(ctx, `UPDATE users SET active = false, updated_at = $1 WHERE id = $2`, time.Now(), userID); err != nil {
+        tracing.RecordError(span, err)
+        return fmt.Errorf("failed to deactivate user: %w", err)
+    }
+    if _, err := tx.ExecContext(ctx, `DELETE FROM user_sessions WHERE user_id = $1`, userID); err != nil {
+        tracing.RecordError(span, err)
+        return fmt.Errorf("failed to revoke user sessions: %w", err)
+    }
+
+    if err := tx.Commit(); err != nil {
+        tracing.RecordError(span, err)
+        return fmt.Errorf("failed to commit transaction: %w", err)
+    }
+    return nil
+}
+
+func (r *UserRepository) CreateSession(ctx context.Context, session *models.UserSession) error {
+    ctx, span := startQuery(ctx, "INSERT", "user_sessions")
+    defer span.End()
+
     query := `
         INSERT INTO user_sessions (id, user_id, device_id, refresh_token_hash, expires_at, created_at)
         VALUES ($1, $2, $3, $4, $5, $6)
     `
-    _, err := r.db.DB.Exec(query,
+    _, err := r.db.DB.ExecContext(ctx, query,
         session.ID,
         session.UserID,
         session.DeviceID,
@@ -93,52 +200,131 @@ func (r *UserRepository) CreateSession(session *models.UserSession) error {
         session.CreatedAt,
     )
     if err != nil {
+        tracing.RecordError(span, err)
         return fmt.Errorf("failed to create session: %w", err)
     }
     return nil
 }
 
-func (r *UserRepository) GetSessionByRefreshToken(refreshTokenHash string) (*models.UserSession, error) {
+func (r *UserRepository) GetSessionByRefreshToken(ctx context.Context, refreshTokenHash string) (*models.UserSession, error) {
+    ctx, span := startQuery(ctx, "SELECT", "user_sessions")
+    defer span.End()
+
     var session models.UserSession
     query := `
         SELECT id, user_id, device_id, refresh_token_hash, expires_at, created_at
         FROM user_sessions 
         WHERE refresh_token_hash = $1 AND expires_at > NOW()
     `
-    err := r.db.DB.Get(&session, query, refreshTokenHash)
+    err := r.db.DB.GetContext(ctx, &session, query, refreshTokenHash)
     if err != nil {
         if err == sql.ErrNoRows {
-            return nil, fmt.Errorf("session not found or expired")
+            return nil, ErrSessionNotFound
         }
+        tracing.RecordError(span, err)
         return nil, fmt.Errorf("failed to get session: %w", err)
     }
     return &session, nil
 }
 
-func (r *UserRepository) DeleteSession(sessionID uuid.UUID) error {
+func (r *UserRepository) DeleteSession(ctx context.Context, sessionID uuid.UUID) error {
+    ctx, span := startQuery(ctx, "DELETE", "user_sessions")
+    defer span.End()
+
     query := `DELETE FROM user_sessions WHERE id = $1`
-    _, err := r.db.DB.Exec(query, sessionID)
+    _, err := r.db.DB.ExecContext(ctx, query, sessionID)
     if err != nil {
+        tracing.RecordError(span, err)
         return fmt.Errorf("failed to delete session: %w", err)
     }
     return nil
 }
 
-func (r *UserRepository) DeleteUserSessions(userID uuid.UUID, deviceID string) error {
+func (r *UserRepository) DeleteUserSessions(ctx context.Context, userID uuid.UUID, deviceID string) error {
+    ctx, span := startQuery(ctx, "DELETE", "user_sessions")
+    defer span.End()
+
     query := `DELETE FROM user_sessions WHERE user_id = $1 AND device_id = $2`
-    _, err := r.db.DB.Exec(query, userID, deviceID)
+    _, err := r.db.DB.ExecContext(ctx, query, userID, deviceID)
     if err != nil {
+        tracing.RecordError(span, err)
         return fmt.Errorf("failed to delete user sessions: %w", err)
     }
     return nil
 }
 
-func (r *UserRepository) EmailExists(email string) (bool, error) {
+func (r *UserRepository) DeleteAllUserSessions(ctx context.Context, userID uuid.UUID) error {
+    ctx, span := startQuery(ctx, "DELETE", "user_sessions")
+    defer span.End()
+
+    query := `DELETE FROM user_sessions WHERE user_id = $1`
+    _, err := r.db.DB.ExecContext(ctx, query, userID)
+    if err != nil {
+        tracing.RecordError(span, err)
+        return fmt.Errorf("failed to delete user sessions: %w", err)
+    }
+    return nil
+}
+
+func (r *UserRepository) EmailExists(ctx context.Context, email string) (bool, error) {
+    ctx, span := startQuery(ctx, "SELECT", "users")
+    defer span.End()
+
     var count int
     query := `SELECT COUNT(*) FROM users WHERE email = $1`
-    err := r.db.DB.Get(&count, query, email)
+    err := r.db.DB.GetContext(ctx, &count, query, email)
     if err != nil {
+        tracing.RecordError(span, err)
         return false, fmt.Errorf("failed to check email existence: %w", err)
     }
     return count > 0, nil
-}
\ No newline at end of file
+}
+
+func (r *UserRepository) CreateLoginEvent(ctx context.Context, event *models.LoginEvent) error {
+    ctx, span := startQuery(ctx, "INSERT", "login_events")
+    defer span.End()
+
+    query := `
+        INSERT INTO login_events (id, user_id, device_id, ip_address, ip_prefix, country_code, city, latitude, longitude, risk_score, risk_level, created_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+    `
+    _, err := r.db.DB.ExecContext(ctx, query,
+        event.ID,
+        event.UserID,
+        event.DeviceID,
+        event.IPAddress,
+        event.IPPrefix,
+        event.CountryCode,
+        event.City,
+        event.Latitude,
+        event.Longitude,
+        event.RiskScore,
+        event.RiskLevel,
+        event.CreatedAt,
+    )
+    if err != nil {
+        tracing.RecordError(span, err)
+        return fmt.Errorf("failed to create login event: %w", err)
+    }
+    return nil
+}
+
+func (r *UserRepository) GetRecentLoginEvents(ctx context.Context, userID uuid.UUID, limit int) ([]models.LoginEvent, error) {
+    ctx, span := startQuery(ctx, "SELECT", "login_events")
+    defer span.End()
+
+    var events []models.LoginEvent
+    query := `
+        SELECT id, user_id, device_id, ip_address, ip_prefix, country_code, city, latitude, longitude, risk_score, risk_level, created_at
+        FROM login_events
+        WHERE user_id = $1
+        ORDER BY created_at DESC
+        LIMIT $2
+    `
+    err := r.db.DB.SelectContext(ctx, &events, query, userID, limit)
+    if err != nil {
+        tracing.RecordError(span, err)
+        return nil, fmt.Errorf("failed to get login events: %w", err)
+    }
+    return events, nil
+}
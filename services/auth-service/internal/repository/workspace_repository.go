@@ -0,0 +1,64 @@
+package repository
+
+import (
+    "context"
+    "database/sql"
+    "fmt"
+    "time"
+
+    "github.com/google/uuid"
+    "github.com/Shridhar2104/chat-platform/auth-service/internal/tracing"
+)
+
+func (r *UserRepository) PrimaryWorkspace(ctx context.Context, userID uuid.UUID) (uuid.UUID, error) {
+    ctx, span := startQuery(ctx, "SELECT", "workspace_members")
+    defer span.End()
+
+    var workspaceID uuid.UUID
+    query := `
+        SELECT workspace_id FROM workspace_members
+        WHERE user_id = $1
+        ORDER BY created_at, workspace_id
+        LIMIT 1
+    `
+    if err := r.db.DB.GetContext(ctx, &workspaceID, query, userID); err != nil {
+        if err == sql.ErrNoRows {
+            return uuid.Nil, ErrWorkspaceNotFound
+        }
+        tracing.RecordError(span, err)
+        return uuid.Nil, fmt.Errorf("failed to get primary workspace: %w", err)
+    }
+    return workspaceID, nil
+}
+
+func (r *UserRepository) WorkspacePlan(ctx context.Context, workspaceID uuid.UUID) (string, error) {
+    ctx, span := startQuery(ctx, "SELECT", "workspace_plans")
+    defer span.End()
+
+    var plan string
+    query := `SELECT plan FROM workspace_plans WHERE workspace_id = $1`
+    if err := r.db.DB.GetContext(ctx, &plan, query, workspaceID); err != nil {
+        if err == sql.ErrNoRows {
+            return "", nil
+        }
+        tracing.RecordError(span, err)
+        return "", fmt.Errorf("failed to get workspace plan: %w", err)
+    }
+    return plan, nil
+}
+
+func (r *UserRepository) SetWorkspacePlan(ctx context.Context, workspaceID uuid.UUID, plan string) error {
+    ctx, span := startQuery(ctx, "INSERT", "workspace_plans")
+    defer span.End()
+
+    query := `
+        INSERT INTO workspace_plans (workspace_id, plan, updated_at)
+        VALUES ($1, $2, $3)
+        ON CONFLICT (workspace_id) DO UPDATE SET plan = EXCLUDED.plan, updated_at = EXCLUDED.updated_at
+    `
+    if _, err := r.db.DB.ExecContext(ctx, query, workspaceID, plan, time.Now()); err != nil {
+        tracing.RecordError(span, err)
+        return fmt.Errorf("failed to set workspace plan: %w", err)
+    }
+    return nil
+}
\ No newline at end of file
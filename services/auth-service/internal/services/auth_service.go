@@ -1,45 +1,100 @@
 package services
 
 import (
+    "context"
     "crypto/rand"
     "crypto/sha256"
+    "crypto/subtle"
     "encoding/hex"
+    "encoding/json"
+    "errors"
     "fmt"
+    "math/big"
     "time"
 
     "golang.org/x/crypto/bcrypt"
     "github.com/google/uuid"
-    "github.com/Shridhar2104/chat-platform/shared/database"
     "github.com/Shridhar2104/chat-platform/shared/models"
+    "github.com/Shridhar2104/chat-platform/auth-service/internal/logging"
     "github.com/Shridhar2104/chat-platform/auth-service/internal/repository"
+    "github.com/Shridhar2104/chat-platform/auth-service/internal/tracing"
+    "go.opentelemetry.io/otel/attribute"
 )
 
 type AuthService struct {
-    userRepo   *repository.UserRepository
-    jwtService *JWTService
-    redis      *database.RedisClient
+    userRepo    repository.UserStore
+    sessionRepo repository.SessionStore
+    limitStore  repository.RateLimitStore
+    jwtService  *JWTService
+    riskEngine  *RiskEngine
+    notifier    Notifier
+    domains     *DomainPolicyService
+    workspaces  repository.WorkspaceStore
 }
 
-func NewAuthService(userRepo *repository.UserRepository, jwtService *JWTService, redis *database.RedisClient) *AuthService {
+const (
+    loginChallengeTTL         = 10 * time.Minute
+    loginChallengeMaxAttempts = 5
+    loginChallengeKeyPrefix   = "login_challenge"
+)
+
+// StepUpRequiredError is returned by Login when the attempt is high risk and
+// must be confirmed with the code sent to the user's email
+type StepUpRequiredError struct {
+    ChallengeID string
+    Method      string
+    ExpiresAt   time.Time
+}
+
+func (e *StepUpRequiredError) Error() string {
+    return "step-up verification required"
+}
+
+type loginChallenge struct {
+    UserID    uuid.UUID `json:"user_id"`
+    DeviceID  string    `json:"device_id"`
+    IPAddress string    `json:"ip_address"`
+    CodeHash  string    `json:"code_hash"`
+    RiskScore int       `json:"risk_score"`
+}
+
+// NewAuthService wires the auth flows, riskEngine, domains and workspaces
+// may be nil to turn off risk scoring, domain policies and workspace claims
+func NewAuthService(userRepo repository.UserStore, sessionRepo repository.SessionStore, limitStore repository.RateLimitStore, jwtService *JWTService, riskEngine *RiskEngine, notifier Notifier, domains *DomainPolicyService, workspaces repository.WorkspaceStore) *AuthService {
     return &AuthService{
-        userRepo:   userRepo,
-        jwtService: jwtService,
-        redis:      redis,
+        userRepo:    userRepo,
+        sessionRepo: sessionRepo,
+        limitStore:  limitStore,
+        jwtService:  jwtService,
+        riskEngine:  riskEngine,
+        notifier:    notifier,
+        domains:     domains,
+        workspaces:  workspaces,
     }
 }
 
-func (s *AuthService) Register(email, password, displayName string) (*models.User, string, string, time.Time, error) {
+func (s *AuthService) Register(ctx context.Context, email, password, displayName string) (*models.User, string, string, time.Time, error) {
+    ctx, span := tracing.Start(ctx, "AuthService.Register")
+    defer span.End()
+
     // Check if email already exists
-    exists, err := s.userRepo.EmailExists(email)
+    exists, err := s.userRepo.EmailExists(ctx, email)
     if err != nil {
         return nil, "", "", time.Time{}, fmt.Errorf("failed to check email existence: %w", err)
     }
     if exists {
-        return nil, "", "", time.Time{}, fmt.Errorf("email already registered")
+        return nil, "", "", time.Time{}, ErrEmailAlreadyRegistered
+    }
+
+    // Apply the signup rules of the email domain
+    if s.domains != nil {
+        if err := s.domains.CheckSignup(ctx, email); err != nil {
+            return nil, "", "", time.Time{}, err
+        }
     }
 
     // Hash password
-    passwordHash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+    passwordHash, err := hashPassword(ctx, password)
     if err != nil {
         return nil, "", "", time.Time{}, fmt.Errorf("failed to hash password: %w", err)
     }
@@ -51,18 +106,24 @@ func (s *AuthService) Register(email, password, displayName string) (*models.Use
         PasswordHash:  string(passwordHash),
         DisplayName:   displayName,
         EmailVerified: false,
+        Active:        true,
         CreatedAt:     time.Now(),
         UpdatedAt:     time.Now(),
     }
 
-    err = s.userRepo.CreateUser(user)
+    err = s.userRepo.CreateUser(ctx, user)
     if err != nil {
+        // A concurrent registration won the race for this email
+        if errors.Is(err, repository.ErrDuplicate) {
+            return nil, "", "", time.Time{}, ErrEmailAlreadyRegistered
+        }
         return nil, "", "", time.Time{}, fmt.Errorf("failed to create user: %w", err)
     }
+    s.autoJoinWorkspace(ctx, user)
 
     // Generate tokens
     deviceID := uuid.New().String() // Temporary device ID for registration
-    accessToken, refreshToken, expiresAt, err := s.jwtService.GenerateTokenPair(user.ID, user.Email, deviceID)
+    accessToken, refreshToken, expiresAt, err := s.jwtService.GenerateTokenPair(user.ID, user.Email, deviceID, s.tokenWorkspace(ctx, user.ID, uuid.Nil))
     if err != nil {
         return nil, "", "", time.Time{}, fmt.Errorf("failed to generate tokens: %w", err)
     }
@@ -70,23 +131,218 @@ func (s *AuthService) Register(email, password, displayName string) (*models.Use
     return user, accessToken, refreshToken, expiresAt, nil
 }
 
-func (s *AuthService) Login(email, password, deviceID string) (*models.User, string, string, time.Time, error) {
+func (s *AuthService) Login(ctx context.Context, email, password, deviceID, ipAddress string) (*models.User, string, string, time.Time, error) {
+    ctx, span := tracing.Start(ctx, "AuthService.Login", attribute.String("auth.device_id", deviceID))
+    defer span.End()
+
+    // Domains that enforce SSO never accept passwords
+    if s.domains != nil {
+        if err := s.domains.CheckPasswordLogin(ctx, email); err != nil {
+            return nil, "", "", time.Time{}, err
+        }
+    }
+
     // Get user by email
-    user, err := s.userRepo.GetUserByEmail(email)
+    user, err := s.userRepo.GetUserByEmail(ctx, email)
     if err != nil {
-        return nil, "", "", time.Time{}, fmt.Errorf("invalid credentials")
+        if errors.Is(err, repository.ErrUserNotFound) {
+            return nil, "", "", time.Time{}, ErrInvalidCredentials
+        }
+        return nil, "", "", time.Time{}, err
     }
 
     // Verify password
-    err = bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password))
+    err = comparePassword(ctx, user.PasswordHash, password)
     if err != nil {
-        return nil, "", "", time.Time{}, fmt.Errorf("invalid credentials")
+        return nil, "", "", time.Time{}, ErrInvalidCredentials
+    }
+
+    // Deprovisioned accounts cannot sign in
+    if !user.Active {
+        return nil, "", "", time.Time{}, ErrAccountDisabled
+    }
+
+    // Score the attempt against the user's login history
+    if s.riskEngine != nil {
+        history, err := s.userRepo.GetRecentLoginEvents(ctx, user.ID, s.riskEngine.HistorySize())
+        if err != nil {
+            return nil, "", "", time.Time{}, fmt.Errorf("failed to load login history: %w", err)
+        }
+
+        assessment := s.riskEngine.Assess(LoginAttempt{
+            DeviceID:  deviceID,
+            IPAddress: ipAddress,
+            Time:      time.Now(),
+        }, history)
+
+        level := assessment.Level
+        if level == RiskLevelHigh && !s.riskEngine.StepUpEnabled() {
+            // The user could never receive the code, notify them instead
+            level = RiskLevelMedium
+        }
+        switch level {
+        case RiskLevelHigh:
+            return nil, "", "", time.Time{}, s.startLoginChallenge(ctx, user, deviceID, ipAddress, assessment)
+        case RiskLevelMedium:
+            event := s.recordLoginEvent(ctx, user.ID, deviceID, ipAddress, assessment)
+            if s.notifier != nil {
+                logger := logging.FromContext(ctx)
+                go func() {
+                    if err := s.notifier.SendNewSignInNotification(user, event); err != nil {
+                        logger.Error("Failed to send new sign-in notification", "user_id", user.ID, "error", err)
+                    }
+                }()
+            }
+        default:
+            s.recordLoginEvent(ctx, user.ID, deviceID, ipAddress, assessment)
+        }
+    }
+
+    accessToken, refreshToken, expiresAt, err := s.createSession(ctx, user, deviceID, uuid.Nil)
+    if err != nil {
+        return nil, "", "", time.Time{}, err
+    }
+
+    return user, accessToken, refreshToken, expiresAt, nil
+}
+
+// VerifyLoginChallenge completes a high-risk login with the emailed confirmation code
+func (s *AuthService) VerifyLoginChallenge(ctx context.Context, challengeID, code string) (*models.User, string, string, time.Time, error) {
+    ctx, span := tracing.Start(ctx, "AuthService.VerifyLoginChallenge")
+    defer span.End()
+
+    key := fmt.Sprintf("%s:%s", loginChallengeKeyPrefix, challengeID)
+
+    data, err := s.limitStore.Get(ctx, key)
+    if err != nil {
+        return nil, "", "", time.Time{}, ErrChallengeNotFound
+    }
+
+    var challenge loginChallenge
+    if err := json.Unmarshal(data, &challenge); err != nil {
+        return nil, "", "", time.Time{}, fmt.Errorf("failed to decode challenge: %w", err)
+    }
+
+    // Count the attempt atomically before comparing, so parallel guesses
+    // cannot all read the same count
+    attemptsKey := key + ":attempts"
+    attempts, err := s.limitStore.Increment(ctx, loginChallengeTTL, attemptsKey)
+    if err != nil {
+        return nil, "", "", time.Time{}, fmt.Errorf("failed to count challenge attempt: %w", err)
+    }
+    if attempts[0] > loginChallengeMaxAttempts {
+        s.limitStore.Delete(ctx, key)
+        return nil, "", "", time.Time{}, ErrChallengeNotFound
     }
 
+    if subtle.ConstantTimeCompare([]byte(s.hashToken(code)), []byte(challenge.CodeHash)) != 1 {
+        if attempts[0] == loginChallengeMaxAttempts {
+            s.limitStore.Delete(ctx, key)
+        }
+        return nil, "", "", time.Time{}, ErrInvalidConfirmationCode
+    }
+
+    // Challenges are single use, only the request that takes it signs in
+    if _, err := s.limitStore.Take(ctx, key); err != nil {
+        return nil, "", "", time.Time{}, ErrChallengeNotFound
+    }
+
+    user, err := s.userRepo.GetUserByID(ctx, challenge.UserID)
+    if err != nil {
+        return nil, "", "", time.Time{}, err
+    }
+    if !user.Active {
+        return nil, "", "", time.Time{}, ErrAccountDisabled
+    }
+
+    s.recordLoginEvent(ctx, user.ID, challenge.DeviceID, challenge.IPAddress, RiskAssessment{
+        Score:    challenge.RiskScore,
+        Level:    RiskLevelHigh,
+        IPPrefix: ipPrefix(challenge.IPAddress),
+        Location: s.lookupLocation(challenge.IPAddress),
+    })
+
+    accessToken, refreshToken, expiresAt, err := s.createSession(ctx, user, challenge.DeviceID, uuid.Nil)
+    if err != nil {
+        return nil, "", "", time.Time{}, err
+    }
+
+    return user, accessToken, refreshToken, expiresAt, nil
+}
+
+// LoginWithExternalIdentity signs in a user asserted by the workspace's
+// identity provider, provisioning the account on first login. Assertions are
+// refused unless the workspace owns the email, see CheckExternalIdentity.
+func (s *AuthService) LoginWithExternalIdentity(ctx context.Context, workspaceID uuid.UUID, email, displayName, deviceID string) (*models.User, string, string, time.Time, error) {
+    ctx, span := tracing.Start(ctx, "AuthService.LoginWithExternalIdentity", attribute.String("auth.device_id", deviceID))
+    defer span.End()
+
+    user, err := s.userRepo.GetUserByEmail(ctx, email)
+    if err != nil && !errors.Is(err, repository.ErrUserNotFound) {
+        return nil, "", "", time.Time{}, err
+    }
+    if s.domains == nil {
+        // Without domain policies no workspace can prove it owns the email
+        return nil, "", "", time.Time{}, fmt.Errorf("%w: email domain is not verified for this workspace", ErrSSOAssertionRejected)
+    }
+    if err := s.domains.CheckExternalIdentity(ctx, workspaceID, email, user); err != nil {
+        return nil, "", "", time.Time{}, err
+    }
+    if user == nil {
+        if displayName == "" {
+            displayName = email
+        }
+
+        // SSO users never sign in with a password, so store an unusable random one
+        randomPassword, err := s.generateSecureToken()
+        if err != nil {
+            return nil, "", "", time.Time{}, fmt.Errorf("failed to generate password: %w", err)
+        }
+        passwordHash, err := hashPassword(ctx, randomPassword)
+        if err != nil {
+            return nil, "", "", time.Time{}, fmt.Errorf("failed to hash password: %w", err)
+        }
+
+        user = &models.User{
+            ID:            uuid.New(),
+            Email:         email,
+            PasswordHash:  string(passwordHash),
+            DisplayName:   displayName,
+            EmailVerified: true,
+            Active:        true,
+            CreatedAt:     time.Now(),
+            UpdatedAt:     time.Now(),
+        }
+        if err := s.userRepo.CreateUser(ctx, user); err != nil {
+            return nil, "", "", time.Time{}, fmt.Errorf("failed to create user: %w", err)
+        }
+        s.autoJoinWorkspace(ctx, user)
+    }
+    if !user.Active {
+        return nil, "", "", time.Time{}, ErrAccountDisabled
+    }
+    // The identity provider vouches for the user, so they join its workspace
+    if s.workspaces != nil {
+        if err := s.workspaces.AddWorkspaceMember(ctx, workspaceID, user.ID, "sso"); err != nil {
+            return nil, "", "", time.Time{}, fmt.Errorf("failed to add workspace member: %w", err)
+        }
+    }
+
+    accessToken, refreshToken, expiresAt, err := s.createSession(ctx, user, deviceID, workspaceID)
+    if err != nil {
+        return nil, "", "", time.Time{}, err
+    }
+
+    return user, accessToken, refreshToken, expiresAt, nil
+}
+
+// createSession issues a token pair and stores the refresh token session.
+// The tokens act in workspaceID, or the user's first workspace when nil.
+func (s *AuthService) createSession(ctx context.Context, user *models.User, deviceID string, workspaceID uuid.UUID) (string, string, time.Time, error) {
     // Generate tokens
-    accessToken, refreshToken, expiresAt, err := s.jwtService.GenerateTokenPair(user.ID, user.Email, deviceID)
+    accessToken, refreshToken, expiresAt, err := s.jwtService.GenerateTokenPair(user.ID, user.Email, deviceID, s.tokenWorkspace(ctx, user.ID, workspaceID))
     if err != nil {
-        return nil, "", "", time.Time{}, fmt.Errorf("failed to generate tokens: %w", err)
+        return "", "", time.Time{}, fmt.Errorf("failed to generate tokens: %w", err)
     }
 
     // Store refresh token session
@@ -100,41 +356,177 @@ func (s *AuthService) Login(email, password, deviceID string) (*models.User, str
         CreatedAt:        time.Now(),
     }
 
-    err = s.userRepo.CreateSession(session)
+    err = s.sessionRepo.CreateSession(ctx, session)
     if err != nil {
-        return nil, "", "", time.Time{}, fmt.Errorf("failed to create session: %w", err)
+        return "", "", time.Time{}, fmt.Errorf("failed to create session: %w", err)
     }
 
-    return user, accessToken, refreshToken, expiresAt, nil
+    return accessToken, refreshToken, expiresAt, nil
+}
+
+// tokenWorkspace picks the workspace and plan put on the user's tokens:
+// preferred while the user is still a member of it, else the first
+// workspace they joined. A failed lookup only costs the caller their plan's
+// quotas, so it is logged and the tokens carry no workspace.
+func (s *AuthService) tokenWorkspace(ctx context.Context, userID, preferred uuid.UUID) *TokenWorkspace {
+    if s.workspaces == nil {
+        return nil
+    }
+    logger := logging.FromContext(ctx)
+
+    workspaceID := uuid.Nil
+    if preferred != uuid.Nil {
+        member, err := s.workspaces.IsWorkspaceMember(ctx, preferred, userID)
+        if err != nil {
+            logger.Warn("Failed to check workspace membership", "user_id", userID, "workspace_id", preferred, "error", err)
+            return nil
+        }
+        if member {
+            workspaceID = preferred
+        }
+    }
+    if workspaceID == uuid.Nil {
+        primary, err := s.workspaces.PrimaryWorkspace(ctx, userID)
+        if err != nil {
+            if !errors.Is(err, repository.ErrWorkspaceNotFound) {
+                logger.Warn("Failed to look up workspace", "user_id", userID, "error", err)
+            }
+            return nil
+        }
+        workspaceID = primary
+    }
+
+    plan, err := s.workspaces.WorkspacePlan(ctx, workspaceID)
+    if err != nil {
+        logger.Warn("Failed to look up workspace plan", "workspace_id", workspaceID, "error", err)
+    }
+    return &TokenWorkspace{ID: workspaceID, Plan: plan}
+}
+
+// autoJoinWorkspace adds a new account to the workspace claiming its email
+// domain. It only runs when the account is created so that members an admin
+// removed are not added back on their next login, failures are only logged
+func (s *AuthService) autoJoinWorkspace(ctx context.Context, user *models.User) {
+    if s.domains == nil {
+        return
+    }
+    if err := s.domains.AutoJoin(ctx, user); err != nil {
+        logging.FromContext(ctx).Warn("Failed to auto-join user to workspace", "user_id", user.ID, "error", err)
+    }
 }
 
-func (s *AuthService) RefreshToken(refreshToken, deviceID string) (string, string, time.Time, error) {
+func (s *AuthService) startLoginChallenge(ctx context.Context, user *models.User, deviceID, ipAddress string, assessment RiskAssessment) error {
+    challengeID, err := s.generateSecureToken()
+    if err != nil {
+        return fmt.Errorf("failed to generate challenge: %w", err)
+    }
+
+    code, err := generateConfirmationCode()
+    if err != nil {
+        return fmt.Errorf("failed to generate confirmation code: %w", err)
+    }
+
+    data, err := json.Marshal(loginChallenge{
+        UserID:    user.ID,
+        DeviceID:  deviceID,
+        IPAddress: ipAddress,
+        CodeHash:  s.hashToken(code),
+        RiskScore: assessment.Score,
+    })
+    if err != nil {
+        return fmt.Errorf("failed to encode challenge: %w", err)
+    }
+
+    key := fmt.Sprintf("%s:%s", loginChallengeKeyPrefix, challengeID)
+    if err := s.limitStore.Set(ctx, key, data, loginChallengeTTL); err != nil {
+        return fmt.Errorf("failed to store challenge: %w", err)
+    }
+
+    if s.notifier != nil {
+        if err := s.notifier.SendLoginConfirmation(user, code); err != nil {
+            return fmt.Errorf("failed to send login confirmation: %w", err)
+        }
+    }
+
+    return &StepUpRequiredError{
+        ChallengeID: challengeID,
+        Method:      "email_code",
+        ExpiresAt:   time.Now().Add(loginChallengeTTL),
+    }
+}
+
+// recordLoginEvent stores the login in the user's history, failures are only logged
+func (s *AuthService) recordLoginEvent(ctx context.Context, userID uuid.UUID, deviceID, ipAddress string, assessment RiskAssessment) *models.LoginEvent {
+    event := &models.LoginEvent{
+        ID:        uuid.New(),
+        UserID:    userID,
+        DeviceID:  deviceID,
+        IPAddress: ipAddress,
+        IPPrefix:  assessment.IPPrefix,
+        RiskScore: assessment.Score,
+        RiskLevel: string(assessment.Level),
+        CreatedAt: time.Now(),
+    }
+
+    if location := assessment.Location; location != nil {
+        event.CountryCode = &location.CountryCode
+        event.City = &location.City
+        event.Latitude = &location.Latitude
+        event.Longitude = &location.Longitude
+    }
+
+    if err := s.userRepo.CreateLoginEvent(ctx, event); err != nil {
+        logging.FromContext(ctx).Error("Failed to record login event", "user_id", userID, "error", err)
+    }
+
+    return event
+}
+
+func (s *AuthService) lookupLocation(ipAddress string) *GeoLocation {
+    if s.riskEngine == nil || s.riskEngine.geo == nil {
+        return nil
+    }
+    location, err := s.riskEngine.geo.Lookup(ipAddress)
+    if err != nil {
+        return nil
+    }
+    return location
+}
+
+func (s *AuthService) RefreshToken(ctx context.Context, refreshToken, deviceID string) (string, string, time.Time, error) {
+    ctx, span := tracing.Start(ctx, "AuthService.RefreshToken", attribute.String("auth.device_id", deviceID))
+    defer span.End()
+
     // Validate refresh token
     refreshClaims, err := s.jwtService.ValidateRefreshToken(refreshToken)
     if err != nil {
-        return "", "", time.Time{}, fmt.Errorf("invalid refresh token")
+        return "", "", time.Time{}, ErrInvalidRefreshToken
     }
 
     // Verify device ID matches
     if refreshClaims.DeviceID != deviceID {
-        return "", "", time.Time{}, fmt.Errorf("device ID mismatch")
+        return "", "", time.Time{}, ErrDeviceMismatch
     }
 
     // Check if session exists in database
     refreshTokenHash := s.hashToken(refreshToken)
-    session, err := s.userRepo.GetSessionByRefreshToken(refreshTokenHash)
+    session, err := s.sessionRepo.GetSessionByRefreshToken(ctx, refreshTokenHash)
     if err != nil {
-        return "", "", time.Time{}, fmt.Errorf("session not found or expired")
+        return "", "", time.Time{}, err
     }
 
     // Get user details
-    user, err := s.userRepo.GetUserByID(session.UserID)
+    user, err := s.userRepo.GetUserByID(ctx, session.UserID)
     if err != nil {
-        return "", "", time.Time{}, fmt.Errorf("user not found")
+        return "", "", time.Time{}, err
+    }
+    if !user.Active {
+        return "", "", time.Time{}, ErrAccountDisabled
     }
 
-    // Generate new token pair
-    newAccessToken, newRefreshToken, expiresAt, err := s.jwtService.GenerateTokenPair(user.ID, user.Email, deviceID)
+    // Generate new token pair, re-reading the plan in case it changed
+    workspaceID, _ := uuid.Parse(refreshClaims.WorkspaceID)
+    newAccessToken, newRefreshToken, expiresAt, err := s.jwtService.GenerateTokenPair(user.ID, user.Email, deviceID, s.tokenWorkspace(ctx, user.ID, workspaceID))
     if err != nil {
         return "", "", time.Time{}, fmt.Errorf("failed to generate new tokens: %w", err)
     }
@@ -145,9 +537,9 @@ func (s *AuthService) RefreshToken(refreshToken, deviceID string) (string, strin
     session.ExpiresAt = time.Now().Add(7 * 24 * time.Hour)
 
     // Delete old session and create new one
-    s.userRepo.DeleteSession(session.ID)
+    s.sessionRepo.DeleteSession(ctx, session.ID)
     session.ID = uuid.New()
-    err = s.userRepo.CreateSession(session)
+    err = s.sessionRepo.CreateSession(ctx, session)
     if err != nil {
         return "", "", time.Time{}, fmt.Errorf("failed to update session: %w", err)
     }
@@ -155,35 +547,100 @@ func (s *AuthService) RefreshToken(refreshToken, deviceID string) (string, strin
     return newAccessToken, newRefreshToken, expiresAt, nil
 }
 
-func (s *AuthService) Logout(userID uuid.UUID, deviceID string) error {
-    return s.userRepo.DeleteUserSessions(userID, deviceID)
+func (s *AuthService) Logout(ctx context.Context, userID uuid.UUID, deviceID string) error {
+    ctx, span := tracing.Start(ctx, "AuthService.Logout", attribute.String("enduser.id", userID.String()))
+    defer span.End()
+
+    return s.sessionRepo.DeleteUserSessions(ctx, userID, deviceID)
+}
+
+func (s *AuthService) GetUserByID(ctx context.Context, userID uuid.UUID) (*models.User, error) {
+    ctx, span := tracing.Start(ctx, "AuthService.GetUserByID", attribute.String("enduser.id", userID.String()))
+    defer span.End()
+
+    return s.userRepo.GetUserByID(ctx, userID)
+}
+
+// ValidateAccessToken checks an access token and that its account is still active
+func (s *AuthService) ValidateAccessToken(ctx context.Context, accessToken string) (*Claims, *models.User, error) {
+    ctx, span := tracing.Start(ctx, "AuthService.ValidateAccessToken")
+    defer span.End()
+
+    claims, err := s.jwtService.ValidateAccessToken(accessToken)
+    if err != nil {
+        return nil, nil, ErrInvalidToken
+    }
+
+    user, err := s.userRepo.GetUserByID(ctx, claims.UserID)
+    if err != nil {
+        if errors.Is(err, repository.ErrUserNotFound) {
+            return nil, nil, ErrInvalidToken
+        }
+        return nil, nil, err
+    }
+    if !user.Active {
+        return nil, nil, ErrAccountDisabled
+    }
+
+    return claims, user, nil
+}
+
+func (s *AuthService) GetUsersByIDs(ctx context.Context, userIDs []uuid.UUID) ([]models.User, error) {
+    ctx, span := tracing.Start(ctx, "AuthService.GetUsersByIDs", attribute.Int("auth.user_count", len(userIDs)))
+    defer span.End()
+
+    return s.userRepo.GetUsersByIDs(ctx, userIDs)
 }
 
-func (s *AuthService) GetUserByID(userID uuid.UUID) (*models.User, error) {
-    return s.userRepo.GetUserByID(userID)
+// RevokeSessions deletes the user's sessions for one device, or all of them when deviceID is empty
+func (s *AuthService) RevokeSessions(ctx context.Context, userID uuid.UUID, deviceID string) error {
+    ctx, span := tracing.Start(ctx, "AuthService.RevokeSessions", attribute.String("enduser.id", userID.String()))
+    defer span.End()
+
+    if deviceID == "" {
+        return s.sessionRepo.DeleteAllUserSessions(ctx, userID)
+    }
+    return s.sessionRepo.DeleteUserSessions(ctx, userID, deviceID)
 }
 
-func (s *AuthService) ChangePassword(userID uuid.UUID, currentPassword, newPassword string) error {
+func (s *AuthService) ChangePassword(ctx context.Context, userID uuid.UUID, currentPassword, newPassword string) error {
+    ctx, span := tracing.Start(ctx, "AuthService.ChangePassword", attribute.String("enduser.id", userID.String()))
+    defer span.End()
+
     // Get user
-    user, err := s.userRepo.GetUserByID(userID)
+    user, err := s.userRepo.GetUserByID(ctx, userID)
     if err != nil {
-        return fmt.Errorf("user not found")
+        return err
     }
 
     // Verify current password
-    err = bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(currentPassword))
+    err = comparePassword(ctx, user.PasswordHash, currentPassword)
     if err != nil {
-        return fmt.Errorf("current password is incorrect")
+        return ErrIncorrectPassword
     }
 
     // Hash new password
-    newPasswordHash, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+    newPasswordHash, err := hashPassword(ctx, newPassword)
     if err != nil {
         return fmt.Errorf("failed to hash new password: %w", err)
     }
 
     // Update password
-    return s.userRepo.UpdateUserPassword(userID, string(newPasswordHash))
+    return s.userRepo.UpdateUserPassword(ctx, userID, string(newPasswordHash))
+}
+
+// hashPassword and comparePassword run bcrypt under their own spans since
+// they dominate register and login latency
+func hashPassword(ctx context.Context, password string) ([]byte, error) {
+    _, span := tracing.Start(ctx, "bcrypt.GenerateFromPassword")
+    defer span.End()
+    return bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+}
+
+func comparePassword(ctx context.Context, hash, password string) error {
+    _, span := tracing.Start(ctx, "bcrypt.CompareHashAndPassword")
+    defer span.End()
+    return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
 }
 
 func (s *AuthService) hashToken(token string) string {
@@ -198,4 +655,13 @@ func (s *AuthService) generateSecureToken() (string, error) {
         return "", err
     }
     return hex.EncodeToString(bytes), nil
+}
+
+// generateConfirmationCode returns a random 6 digit code
+func generateConfirmationCode() (string, error) {
+    n, err := rand.Int(rand.Reader, big.NewInt(1000000))
+    if err != nil {
+        return "", err
+    }
+    return fmt.Sprintf("%06d", n.Int64()), nil
 }
\ No newline at end of file
@@ -0,0 +1,376 @@
+package services
+
+import (
+    "context"
+    "crypto/rand"
+    "crypto/rsa"
+    "errors"
+    "sync"
+    "sync/atomic"
+    "testing"
+    "time"
+
+    "github.com/Shridhar2104/chat-platform/auth-service/internal/repository"
+    "github.com/Shridhar2104/chat-platform/shared/models"
+)
+
+// recordingNotifier keeps the last confirmation code instead of emailing it
+type recordingNotifier struct {
+    code string
+}
+
+func (n *recordingNotifier) SendNewSignInNotification(user *models.User, event *models.LoginEvent) error {
+    return nil
+}
+
+func (n *recordingNotifier) SendLoginConfirmation(user *models.User, code string) error {
+    n.code = code
+    return nil
+}
+
+// newTestAuthService wires AuthService to in-memory stores, riskEngine may be nil
+func newTestAuthService(t *testing.T, riskEngine *RiskEngine) (*AuthService, *recordingNotifier) {
+    t.Helper()
+
+    key, err := rsa.GenerateKey(rand.Reader, 2048)
+    if err != nil {
+        t.Fatalf("failed to generate signing key: %v", err)
+    }
+    jwtService := NewJWTService("test-secret", NewKeySet(key), 15*time.Minute, time.Hour)
+    notifier := &recordingNotifier{}
+
+    service := NewAuthService(repository.NewMemoryUserStore(), repository.NewMemorySessionStore(),
+        repository.NewMemoryRateLimitStore(), jwtService, riskEngine, notifier, nil, repository.NewMemoryWorkspaceStore())
+    return service, notifier
+}
+
+func TestAuthFlow(t *testing.T) {
+    ctx := context.Background()
+    service, _ := newTestAuthService(t, nil)
+
+    user, _, _, _, err := service.Register(ctx, "alice@example.com", "password123", "Alice")
+    if err != nil {
+        t.Fatalf("Register: %v", err)
+    }
+    if _, _, _, _, err := service.Register(ctx, "alice@example.com", "password123", "Alice"); !errors.Is(err, ErrEmailAlreadyRegistered) {
+        t.Errorf("second Register error = %v, want ErrEmailAlreadyRegistered", err)
+    }
+
+    if _, _, _, _, err := service.Login(ctx, "alice@example.com", "wrong", "laptop", "203.0.113.7"); !errors.Is(err, ErrInvalidCredentials) {
+        t.Errorf("Login with wrong password error = %v, want ErrInvalidCredentials", err)
+    }
+    if _, _, _, _, err := service.Login(ctx, "bob@example.com", "password123", "laptop", "203.0.113.7"); !errors.Is(err, ErrInvalidCredentials) {
+        t.Errorf("Login for unknown user error = %v, want ErrInvalidCredentials", err)
+    }
+
+    _, accessToken, refreshToken, _, err := service.Login(ctx, "alice@example.com", "password123", "laptop", "203.0.113.7")
+    if err != nil {
+        t.Fatalf("Login: %v", err)
+    }
+    claims, validated, err := service.ValidateAccessToken(ctx, accessToken)
+    if err != nil || validated.ID != user.ID || claims.DeviceID != "laptop" {
+        t.Fatalf("ValidateAccessToken = %+v, %v", claims, err)
+    }
+
+    if _, _, _, err := service.RefreshToken(ctx, refreshToken, "phone"); !errors.Is(err, ErrDeviceMismatch) {
+        t.Errorf("RefreshToken from another device error = %v, want ErrDeviceMismatch", err)
+    }
+    // Give the rotated token a different issued-at than the original
+    time.Sleep(time.Second)
+    _, rotated, _, err := service.RefreshToken(ctx, refreshToken, "laptop")
+    if err != nil {
+        t.Fatalf("RefreshToken: %v", err)
+    }
+    if _, _, _, err := service.RefreshToken(ctx, refreshToken, "laptop"); !errors.Is(err, repository.ErrSessionNotFound) {
+        t.Errorf("reused refresh token error = %v, want ErrSessionNotFound", err)
+    }
+
+    if err := service.ChangePassword(ctx, user.ID, "wrong", "new-password"); !errors.Is(err, ErrIncorrectPassword) {
+        t.Errorf("ChangePassword with wrong password error = %v, want ErrIncorrectPassword", err)
+    }
+    if err := service.ChangePassword(ctx, user.ID, "password123", "new-password"); err != nil {
+        t.Fatalf("ChangePassword: %v", err)
+    }
+    if _, _, _, _, err := service.Login(ctx, "alice@example.com", "new-password", "laptop", "203.0.113.7"); err != nil {
+        t.Errorf("Login with new password: %v", err)
+    }
+
+    if err := service.Logout(ctx, user.ID, "laptop"); err != nil {
+        t.Fatalf("Logout: %v", err)
+    }
+    if _, _, _, err := service.RefreshToken(ctx, rotated, "laptop"); !errors.Is(err, repository.ErrSessionNotFound) {
+        t.Errorf("RefreshToken after logout error = %v, want ErrSessionNotFound", err)
+    }
+}
+
+func TestLoginStepUpChallenge(t *testing.T) {
+    ctx := context.Background()
+    config := DefaultRiskConfig()
+    config.HighThreshold = 50
+    service, notifier := newTestAuthService(t, NewRiskEngine(config, nil))
+
+    if _, _, _, _, err := service.Register(ctx, "alice@example.com", "password123", "Alice"); err != nil {
+        t.Fatalf("Register: %v", err)
+    }
+    // The first login builds the history later attempts are compared against
+    if _, _, _, _, err := service.Login(ctx, "alice@example.com", "password123", "laptop", "203.0.113.7"); err != nil {
+        t.Fatalf("Login: %v", err)
+    }
+
+    // A new device on a new network scores 50
+    _, _, _, _, err := service.Login(ctx, "alice@example.com", "password123", "phone", "198.51.100.20")
+    var stepUp *StepUpRequiredError
+    if !errors.As(err, &stepUp) {
+        t.Fatalf("Login error = %v, want StepUpRequiredError", err)
+    }
+
+    if _, _, _, _, err := service.VerifyLoginChallenge(ctx, stepUp.ChallengeID, "000000x"); !errors.Is(err, ErrInvalidConfirmationCode) {
+        t.Errorf("wrong code error = %v, want ErrInvalidConfirmationCode", err)
+    }
+    user, accessToken, _, _, err := service.VerifyLoginChallenge(ctx, stepUp.ChallengeID, notifier.code)
+    if err != nil || accessToken == "" || user.Email != "alice@example.com" {
+        t.Fatalf("VerifyLoginChallenge = %v, %v", user, err)
+    }
+    if _, _, _, _, err := service.VerifyLoginChallenge(ctx, stepUp.ChallengeID, notifier.code); !errors.Is(err, ErrChallengeNotFound) {
+        t.Errorf("reused challenge error = %v, want ErrChallengeNotFound", err)
+    }
+}
+
+func TestLoginChallengeBoundsParallelGuesses(t *testing.T) {
+    ctx := context.Background()
+    config := DefaultRiskConfig()
+    config.HighThreshold = 50
+    service, notifier := newTestAuthService(t, NewRiskEngine(config, nil))
+
+    if _, _, _, _, err := service.Register(ctx, "alice@example.com", "password123", "Alice"); err != nil {
+        t.Fatalf("Register: %v", err)
+    }
+    if _, _, _, _, err := service.Login(ctx, "alice@example.com", "password123", "laptop", "203.0.113.7"); err != nil {
+        t.Fatalf("Login: %v", err)
+    }
+    _, _, _, _, err := service.Login(ctx, "alice@example.com", "password123", "phone", "198.51.100.20")
+    var stepUp *StepUpRequiredError
+    if !errors.As(err, &stepUp) {
+        t.Fatalf("Login error = %v, want StepUpRequiredError", err)
+    }
+
+    var wg sync.WaitGroup
+    var rejected atomic.Int32
+    for i := 0; i < 4*loginChallengeMaxAttempts; i++ {
+        wg.Add(1)
+        go func() {
+            defer wg.Done()
+            if _, _, _, _, err := service.VerifyLoginChallenge(ctx, stepUp.ChallengeID, "wrong"); errors.Is(err, ErrInvalidConfirmationCode) {
+                rejected.Add(1)
+            }
+        }()
+    }
+    wg.Wait()
+
+    if got := rejected.Load(); got > loginChallengeMaxAttempts {
+        t.Errorf("%d guesses were compared, want at most %d", got, loginChallengeMaxAttempts)
+    }
+    if _, _, _, _, err := service.VerifyLoginChallenge(ctx, stepUp.ChallengeID, notifier.code); !errors.Is(err, ErrChallengeNotFound) {
+        t.Errorf("code after exhausting attempts error = %v, want ErrChallengeNotFound", err)
+    }
+}
+
+func TestLoginWithoutStepUp(t *testing.T) {
+    ctx := context.Background()
+    config := DefaultRiskConfig()
+    config.HighThreshold = 50
+    config.StepUp = false
+    service, notifier := newTestAuthService(t, NewRiskEngine(config, nil))
+
+    if _, _, _, _, err := service.Register(ctx, "alice@example.com", "password123", "Alice"); err != nil {
+        t.Fatalf("Register: %v", err)
+    }
+    if _, _, _, _, err := service.Login(ctx, "alice@example.com", "password123", "laptop", "203.0.113.7"); err != nil {
+        t.Fatalf("Login: %v", err)
+    }
+
+    // Scored high, but no code can be delivered so the login goes through
+    _, accessToken, _, _, err := service.Login(ctx, "alice@example.com", "password123", "phone", "198.51.100.20")
+    if err != nil || accessToken == "" {
+        t.Fatalf("high-risk Login = %v, want a session without step-up", err)
+    }
+    if notifier.code != "" {
+        t.Error("expected no confirmation code to be sent")
+    }
+}
+
+func TestTokensCarryWorkspacePlan(t *testing.T) {
+    ctx := context.Background()
+    service, _ := newTestAuthService(t, nil)
+
+    user, accessToken, _, _, err := service.Register(ctx, "alice@example.com", "password123", "Alice")
+    if err != nil {
+        t.Fatalf("Register: %v", err)
+    }
+    claims, err := service.jwtService.ValidateAccessToken(accessToken)
+    if err != nil || claims.WorkspaceID != "" || claims.Plan != "" {
+        t.Fatalf("claims without a workspace = %+v, %v", claims, err)
+    }
+
+    if err := service.workspaces.AddWorkspaceMember(ctx, testWorkspaceID, user.ID, "invite"); err != nil {
+        t.Fatalf("AddWorkspaceMember: %v", err)
+    }
+    if err := service.workspaces.SetWorkspacePlan(ctx, testWorkspaceID, "pro"); err != nil {
+        t.Fatalf("SetWorkspacePlan: %v", err)
+    }
+    _, accessToken, refreshToken, _, err := service.Login(ctx, "alice@example.com", "password123", "laptop", "203.0.113.7")
+    if err != nil {
+        t.Fatalf("Login: %v", err)
+    }
+    claims, err = service.jwtService.ValidateAccessToken(accessToken)
+    if err != nil || claims.WorkspaceID != testWorkspaceID.String() || claims.Plan != "pro" {
+        t.Fatalf("claims of a pro member = %+v, %v", claims, err)
+    }
+
+    // Refreshing keeps the workspace and picks up plan changes
+    if err := service.workspaces.SetWorkspacePlan(ctx, testWorkspaceID, "enterprise"); err != nil {
+        t.Fatalf("SetWorkspacePlan: %v", err)
+    }
+    time.Sleep(time.Second)
+    accessToken, _, _, err = service.RefreshToken(ctx, refreshToken, "laptop")
+    if err != nil {
+        t.Fatalf("RefreshToken: %v", err)
+    }
+    claims, err = service.jwtService.ValidateAccessToken(accessToken)
+    if err != nil || claims.WorkspaceID != testWorkspaceID.String() || claims.Plan != "enterprise" {
+        t.Errorf("claims after refresh = %+v, %v", claims, err)
+    }
+}
+
+// unavailableUserStore fails email lookups the way a database timeout would
+type unavailableUserStore struct {
+    *repository.MemoryUserStore
+}
+
+func (s unavailableUserStore) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
+    return nil, errUnavailable
+}
+
+var errUnavailable = errors.New("connection timed out")
+
+func TestExternalLoginDoesNotProvisionOnLookupFailure(t *testing.T) {
+    ctx := context.Background()
+    store := unavailableUserStore{repository.NewMemoryUserStore()}
+    jwtService := NewJWTService("test-secret", nil, 15*time.Minute, time.Hour)
+    service := NewAuthService(store, repository.NewMemorySessionStore(), repository.NewMemoryRateLimitStore(), jwtService, nil, &recordingNotifier{}, nil, nil)
+
+    if _, _, _, _, err := service.LoginWithExternalIdentity(ctx, testWorkspaceID, "alice@example.com", "Alice", "laptop"); !errors.Is(err, errUnavailable) {
+        t.Fatalf("LoginWithExternalIdentity error = %v, want the lookup failure", err)
+    }
+    if exists, _ := store.EmailExists(ctx, "alice@example.com"); exists {
+        t.Error("a failed lookup provisioned a new account")
+    }
+}
+
+func TestExternalLoginRequiresDomainPolicies(t *testing.T) {
+    ctx := context.Background()
+    service, _ := newTestAuthService(t, nil)
+    victim, _, _, _, err := service.Register(ctx, "ceo@victim.com", "password123", "CEO")
+    if err != nil {
+        t.Fatalf("Register: %v", err)
+    }
+
+    // No workspace can prove it owns victim.com, so its IdP cannot sign the CEO in
+    _, accessToken, _, _, err := service.LoginWithExternalIdentity(ctx, testWorkspaceID, victim.Email, "", "laptop")
+    if !errors.Is(err, ErrSSOAssertionRejected) || accessToken != "" {
+        t.Fatalf("LoginWithExternalIdentity error = %v, want ErrSSOAssertionRejected", err)
+    }
+}
+
+// newDomainAuthService wires newTestAuthService to in-memory domain policies
+func newDomainAuthService(t *testing.T, restrictSignups bool) (*AuthService, *DomainPolicyService, *StaticTXTResolver) {
+    t.Helper()
+    service, _ := newTestAuthService(t, nil)
+    resolver := NewStaticTXTResolver()
+    service.domains = NewDomainPolicyService(repository.NewMemoryDomainStore(), service.workspaces, resolver, restrictSignups)
+    return service, service.domains, resolver
+}
+
+// verifyTestDomain claims and verifies the domain for testWorkspaceID
+func verifyTestDomain(t *testing.T, domains *DomainPolicyService, resolver *StaticTXTResolver, domain string, allowSignup, enforceSSO bool) {
+    t.Helper()
+    ctx := context.Background()
+    policy, err := domains.ClaimDomain(ctx, testWorkspaceID, domain, allowSignup, enforceSSO, false)
+    if err != nil {
+        t.Fatalf("ClaimDomain: %v", err)
+    }
+    record := domains.Verification(policy)
+    resolver.SetTXT(record.RecordName, record.RecordValue)
+    if _, err := domains.VerifyDomain(ctx, testWorkspaceID, domain); err != nil {
+        t.Fatalf("VerifyDomain: %v", err)
+    }
+}
+
+func TestRegisterEnforcesDomainPolicies(t *testing.T) {
+    ctx := context.Background()
+
+    service, domains, resolver := newDomainAuthService(t, true)
+    if _, _, _, _, err := service.Register(ctx, "alice@acme.com", "password123", "Alice"); !errors.Is(err, ErrSignupNotAllowed) {
+        t.Fatalf("Register from an unverified domain error = %v, want ErrSignupNotAllowed", err)
+    }
+    verifyTestDomain(t, domains, resolver, "acme.com", true, false)
+    if _, _, _, _, err := service.Register(ctx, "alice@acme.com", "password123", "Alice"); err != nil {
+        t.Fatalf("Register from a verified domain: %v", err)
+    }
+
+    service, domains, resolver = newDomainAuthService(t, false)
+    verifyTestDomain(t, domains, resolver, "acme.com", false, false)
+    if _, _, _, _, err := service.Register(ctx, "bob@acme.com", "password123", "Bob"); !errors.Is(err, ErrSignupNotAllowed) {
+        t.Errorf("Register from a domain blocking signups error = %v, want ErrSignupNotAllowed", err)
+    }
+    if _, _, _, _, err := service.Register(ctx, "bob@example.com", "password123", "Bob"); err != nil {
+        t.Errorf("Register from an unclaimed domain: %v", err)
+    }
+}
+
+func TestLoginEnforcesDomainSSO(t *testing.T) {
+    ctx := context.Background()
+    service, domains, resolver := newDomainAuthService(t, false)
+    if _, _, _, _, err := service.Register(ctx, "alice@acme.com", "password123", "Alice"); err != nil {
+        t.Fatalf("Register: %v", err)
+    }
+
+    // Claiming the domain afterwards moves existing accounts onto SSO
+    verifyTestDomain(t, domains, resolver, "acme.com", true, true)
+    _, accessToken, _, _, err := service.Login(ctx, "alice@acme.com", "password123", "laptop", "203.0.113.7")
+    var ssoRequired *SSORequiredError
+    if !errors.As(err, &ssoRequired) || accessToken != "" {
+        t.Fatalf("Login error = %v, want SSORequiredError", err)
+    }
+    if ssoRequired.WorkspaceID != testWorkspaceID {
+        t.Errorf("SSORequiredError workspace = %s, want %s", ssoRequired.WorkspaceID, testWorkspaceID)
+    }
+}
+
+func TestAutoJoinOnlyOnAccountCreation(t *testing.T) {
+    ctx := context.Background()
+    service, domains, resolver := newDomainAuthService(t, false)
+    verifyTestDomain(t, domains, resolver, "acme.com", true, false)
+    if _, err := domains.UpdatePolicy(ctx, testWorkspaceID, "acme.com", true, false, true); err != nil {
+        t.Fatalf("UpdatePolicy: %v", err)
+    }
+
+    user, _, _, _, err := service.Register(ctx, "alice@acme.com", "password123", "Alice")
+    if err != nil {
+        t.Fatalf("Register: %v", err)
+    }
+    if member, err := service.workspaces.IsWorkspaceMember(ctx, testWorkspaceID, user.ID); err != nil || !member {
+        t.Fatalf("IsWorkspaceMember after Register = %v, %v", member, err)
+    }
+
+    // An admin removing the member must stick across later logins
+    if err := service.workspaces.RemoveWorkspaceMember(ctx, testWorkspaceID, user.ID); err != nil {
+        t.Fatalf("RemoveWorkspaceMember: %v", err)
+    }
+    if _, _, _, _, err := service.Login(ctx, "alice@acme.com", "password123", "laptop", "203.0.113.7"); err != nil {
+        t.Fatalf("Login: %v", err)
+    }
+    if member, err := service.workspaces.IsWorkspaceMember(ctx, testWorkspaceID, user.ID); err != nil || member {
+        t.Errorf("IsWorkspaceMember after Login = %v, %v, want the removal kept", member, err)
+    }
+}
\ No newline at end of file
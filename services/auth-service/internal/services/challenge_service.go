@@ -0,0 +1,310 @@
+package services
+
+import (
+    "context"
+    "crypto/rand"
+    "crypto/sha256"
+    "encoding/hex"
+    "encoding/json"
+    "fmt"
+    "math"
+    "math/bits"
+    "net/http"
+    "net/url"
+    "strconv"
+    "strings"
+    "time"
+
+    "github.com/google/uuid"
+    "github.com/Shridhar2104/chat-platform/auth-service/internal/repository"
+    "github.com/Shridhar2104/chat-platform/auth-service/internal/tracing"
+)
+
+const (
+    powChallengeKeyPrefix = "pow_challenge"
+    abuseKeyPrefix        = "abuse"
+    rateLimitBucketPrefix = "rate_limit:bucket"
+)
+
+// ChallengeConfig controls when abusive traffic has to solve a challenge.
+// Thresholds count requests per Window; difficulty is in leading zero bits.
+type ChallengeConfig struct {
+    Window            time.Duration
+    IPThreshold       int
+    SubnetThreshold   int
+    GlobalThreshold   int
+    FailureThreshold  int
+    BaseDifficulty    int
+    MaxDifficulty     int
+    ChallengeTTL      time.Duration
+    RateLimitCapacity int
+}
+
+func DefaultChallengeConfig() ChallengeConfig {
+    return ChallengeConfig{
+        Window:            10 * time.Minute,
+        IPThreshold:       10,
+        SubnetThreshold:   30,
+        GlobalThreshold:   300,
+        FailureThreshold:  5,
+        BaseDifficulty:    16,
+        MaxDifficulty:     24,
+        ChallengeTTL:      5 * time.Minute,
+        RateLimitCapacity: 60,
+    }
+}
+
+// AbuseSignals are the counters a challenge decision is based on
+type AbuseSignals struct {
+    IPAttempts     int64
+    SubnetAttempts int64
+    GlobalAttempts int64
+    IPFailures     int64
+    // BucketFill is the remaining fraction of the client's rate limit bucket, 1 when unknown
+    BucketFill float64
+}
+
+// ProofOfWorkChallenge asks the client for a nonce such that
+// sha256(prefix + nonce) starts with Difficulty zero bits
+type ProofOfWorkChallenge struct {
+    ID         string    `json:"id"`
+    Route      string    `json:"route"`
+    Prefix     string    `json:"prefix"`
+    Difficulty int       `json:"difficulty"`
+    ExpiresAt  time.Time `json:"expires_at"`
+}
+
+// CaptchaVerifier checks a CAPTCHA response token with its provider
+type CaptchaVerifier interface {
+    Verify(ctx context.Context, token, remoteIP string) (bool, error)
+}
+
+// SiteVerifyCaptcha implements the siteverify API shared by reCAPTCHA, hCaptcha and Turnstile
+type SiteVerifyCaptcha struct {
+    verifyURL string
+    secret    string
+    client    *http.Client
+}
+
+func NewSiteVerifyCaptcha(verifyURL, secret string) *SiteVerifyCaptcha {
+    return &SiteVerifyCaptcha{
+        verifyURL: verifyURL,
+        secret:    secret,
+        client:    &http.Client{Timeout: 5 * time.Second, Transport: tracing.Transport(nil)},
+    }
+}
+
+func (v *SiteVerifyCaptcha) Verify(ctx context.Context, token, remoteIP string) (bool, error) {
+    form := url.Values{
+        "secret":   {v.secret},
+        "response": {token},
+        "remoteip": {remoteIP},
+    }
+    req, err := http.NewRequestWithContext(ctx, http.MethodPost, v.verifyURL, strings.NewReader(form.Encode()))
+    if err != nil {
+        return false, fmt.Errorf("failed to build captcha request: %w", err)
+    }
+    req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+    resp, err := v.client.Do(req)
+    if err != nil {
+        return false, fmt.Errorf("captcha verification failed: %w", err)
+    }
+    defer resp.Body.Close()
+
+    var result struct {
+        Success bool `json:"success"`
+    }
+    if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+        return false, fmt.Errorf("failed to decode captcha response: %w", err)
+    }
+    return result.Success, nil
+}
+
+type ChallengeService struct {
+    store   repository.RateLimitStore
+    config  ChallengeConfig
+    captcha CaptchaVerifier
+}
+
+// NewChallengeService creates the challenge service, captcha may be nil
+func NewChallengeService(store repository.RateLimitStore, config ChallengeConfig, captcha CaptchaVerifier) *ChallengeService {
+    return &ChallengeService{
+        store:   store,
+        config:  config,
+        captcha: captcha,
+    }
+}
+
+func (s *ChallengeService) CaptchaEnabled() bool {
+    return s.captcha != nil
+}
+
+// RecordAttempt counts a request to the route and returns the current abuse signals
+func (s *ChallengeService) RecordAttempt(ctx context.Context, route, ipAddress string) (AbuseSignals, error) {
+    keys := []string{
+        s.abuseKey(route, "ip", ipAddress),
+        s.abuseKey(route, "subnet", ipPrefix(ipAddress)),
+        s.abuseKey(route, "global", "all"),
+    }
+
+    counts, err := s.store.Increment(ctx, s.config.Window, keys...)
+    if err != nil {
+        return AbuseSignals{}, fmt.Errorf("failed to record attempt: %w", err)
+    }
+
+    signals := AbuseSignals{
+        IPAttempts:     counts[0],
+        SubnetAttempts: counts[1],
+        GlobalAttempts: counts[2],
+        BucketFill:     1,
+    }
+    if failures, err := s.store.Counter(ctx, s.abuseKey(route, "fail", ipAddress)); err == nil {
+        signals.IPFailures = failures
+    }
+    bucketKey := fmt.Sprintf("%s:ip:%s", rateLimitBucketPrefix, ipAddress)
+    if tokens, ok, err := s.store.BucketTokens(ctx, bucketKey); err == nil && ok && s.config.RateLimitCapacity > 0 {
+        signals.BucketFill = math.Max(0, math.Min(1, tokens/float64(s.config.RateLimitCapacity)))
+    }
+    return signals, nil
+}
+
+// RecordFailure counts a rejected request from the client
+func (s *ChallengeService) RecordFailure(ctx context.Context, route, ipAddress string) {
+    s.store.Increment(ctx, s.config.Window, s.abuseKey(route, "fail", ipAddress))
+}
+
+// RequiredDifficulty returns the proof-of-work difficulty for the signals, 0 when no challenge is needed
+func (s *ChallengeService) RequiredDifficulty(signals AbuseSignals) int {
+    return requiredDifficulty(s.config, signals)
+}
+
+func requiredDifficulty(config ChallengeConfig, signals AbuseSignals) int {
+    pressure := math.Max(
+        math.Max(ratio(signals.IPAttempts, config.IPThreshold), ratio(signals.SubnetAttempts, config.SubnetThreshold)),
+        math.Max(ratio(signals.GlobalAttempts, config.GlobalThreshold), ratio(signals.IPFailures, config.FailureThreshold)),
+    )
+
+    // A bucket drained to 20% counts as reaching the threshold
+    pressure = math.Max(pressure, (1-signals.BucketFill)/0.8)
+
+    if pressure < 1 {
+        return 0
+    }
+
+    // Each doubling of pressure makes the puzzle four times harder
+    difficulty := config.BaseDifficulty + 2*int(math.Log2(pressure))
+    if difficulty > config.MaxDifficulty {
+        difficulty = config.MaxDifficulty
+    }
+    return difficulty
+}
+
+func ratio(count int64, threshold int) float64 {
+    if threshold <= 0 {
+        return 0
+    }
+    return float64(count) / float64(threshold)
+}
+
+// IssueChallenge creates a single-use proof-of-work challenge for the route
+func (s *ChallengeService) IssueChallenge(ctx context.Context, route string, difficulty int) (*ProofOfWorkChallenge, error) {
+    prefix := make([]byte, 16)
+    if _, err := rand.Read(prefix); err != nil {
+        return nil, fmt.Errorf("failed to generate challenge: %w", err)
+    }
+
+    challenge := &ProofOfWorkChallenge{
+        ID:         uuid.New().String(),
+        Route:      route,
+        Prefix:     hex.EncodeToString(prefix),
+        Difficulty: difficulty,
+        ExpiresAt:  time.Now().Add(s.config.ChallengeTTL),
+    }
+
+    data, err := json.Marshal(challenge)
+    if err != nil {
+        return nil, fmt.Errorf("failed to encode challenge: %w", err)
+    }
+
+    key := fmt.Sprintf("%s:%s", powChallengeKeyPrefix, challenge.ID)
+    if err := s.store.Set(ctx, key, data, s.config.ChallengeTTL); err != nil {
+        return nil, fmt.Errorf("failed to store challenge: %w", err)
+    }
+    return challenge, nil
+}
+
+// VerifySolution consumes the challenge and checks the nonce against it
+func (s *ChallengeService) VerifySolution(ctx context.Context, route, challengeID, nonce string, minDifficulty int) error {
+    key := fmt.Sprintf("%s:%s", powChallengeKeyPrefix, challengeID)
+    data, err := s.store.Take(ctx, key)
+    if err != nil {
+        return fmt.Errorf("challenge not found or expired")
+    }
+
+    var challenge ProofOfWorkChallenge
+    if err := json.Unmarshal(data, &challenge); err != nil {
+        return fmt.Errorf("failed to decode challenge: %w", err)
+    }
+
+    if challenge.Route != route {
+        return fmt.Errorf("challenge issued for a different route")
+    }
+    // Pressure may have grown since the challenge was issued
+    if challenge.Difficulty < minDifficulty {
+        return fmt.Errorf("challenge difficulty too low")
+    }
+    if !SolvesChallenge(challenge.Prefix, nonce, challenge.Difficulty) {
+        return fmt.Errorf("invalid challenge solution")
+    }
+    return nil
+}
+
+// VerifyCaptcha checks a CAPTCHA token with the configured verifier
+func (s *ChallengeService) VerifyCaptcha(ctx context.Context, token, remoteIP string) error {
+    if s.captcha == nil {
+        return fmt.Errorf("captcha not configured")
+    }
+    ok, err := s.captcha.Verify(ctx, token, remoteIP)
+    if err != nil {
+        return err
+    }
+    if !ok {
+        return fmt.Errorf("invalid captcha")
+    }
+    return nil
+}
+
+func (s *ChallengeService) abuseKey(route, kind, value string) string {
+    return fmt.Sprintf("%s:%s:%s:%s", abuseKeyPrefix, strings.Trim(route, "/"), kind, value)
+}
+
+// SolvesChallenge reports whether sha256(prefix + nonce) has at least difficulty leading zero bits
+func SolvesChallenge(prefix, nonce string, difficulty int) bool {
+    if nonce == "" || len(nonce) > 64 {
+        return false
+    }
+    sum := sha256.Sum256([]byte(prefix + nonce))
+    return leadingZeroBits(sum[:]) >= difficulty
+}
+
+// SolveChallenge brute-forces a nonce, as a reference for clients
+func SolveChallenge(prefix string, difficulty int) string {
+    for i := 0; ; i++ {
+        nonce := strconv.Itoa(i)
+        if SolvesChallenge(prefix, nonce, difficulty) {
+            return nonce
+        }
+    }
+}
+
+func leadingZeroBits(hash []byte) int {
+    count := 0
+    for _, b := range hash {
+        if b != 0 {
+            return count + bits.LeadingZeros8(b)
+        }
+        count += 8
+    }
+    return count
+}
@@ -0,0 +1,148 @@
+package services
+
+import (
+    "context"
+    "testing"
+
+    "github.com/alicebob/miniredis/v2"
+    "github.com/redis/go-redis/v9"
+    "github.com/Shridhar2104/chat-platform/auth-service/internal/repository"
+    "github.com/Shridhar2104/chat-platform/shared/database"
+)
+
+func newTestRedis(t *testing.T) (*repository.RedisRateLimitStore, *miniredis.Miniredis) {
+    t.Helper()
+
+    server := miniredis.RunT(t)
+    client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+    t.Cleanup(func() { client.Close() })
+    return repository.NewRedisRateLimitStore(&database.RedisClient{Client: client}), server
+}
+
+func TestLeadingZeroBits(t *testing.T) {
+    tests := []struct {
+        hash []byte
+        want int
+    }{
+        {hash: []byte{0xff}, want: 0},
+        {hash: []byte{0x0f}, want: 4},
+        {hash: []byte{0x00, 0x01}, want: 15},
+        {hash: []byte{0x00, 0x00}, want: 16},
+    }
+    for _, tt := range tests {
+        if got := leadingZeroBits(tt.hash); got != tt.want {
+            t.Errorf("leadingZeroBits(%x) = %d, want %d", tt.hash, got, tt.want)
+        }
+    }
+}
+
+func TestRequiredDifficultyScalesWithPressure(t *testing.T) {
+    config := DefaultChallengeConfig()
+
+    tests := []struct {
+        name    string
+        signals AbuseSignals
+        want    int
+    }{
+        {name: "quiet client", signals: AbuseSignals{IPAttempts: 3, BucketFill: 1}, want: 0},
+        {name: "ip threshold reached", signals: AbuseSignals{IPAttempts: 10, BucketFill: 1}, want: 16},
+        {name: "double the ip threshold", signals: AbuseSignals{IPAttempts: 20, BucketFill: 1}, want: 18},
+        {name: "rotating ips trip the global counter", signals: AbuseSignals{IPAttempts: 1, GlobalAttempts: 1200, BucketFill: 1}, want: 20},
+        {name: "repeated failures", signals: AbuseSignals{IPAttempts: 6, IPFailures: 5, BucketFill: 1}, want: 16},
+        {name: "drained rate limit bucket", signals: AbuseSignals{IPAttempts: 1, BucketFill: 0.1}, want: 16},
+        {name: "capped at max difficulty", signals: AbuseSignals{SubnetAttempts: 100000, BucketFill: 1}, want: 24},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            if got := requiredDifficulty(config, tt.signals); got != tt.want {
+                t.Errorf("requiredDifficulty = %d, want %d", got, tt.want)
+            }
+        })
+    }
+}
+
+func TestRecordAttemptTracksSignals(t *testing.T) {
+    ctx := context.Background()
+    client, server := newTestRedis(t)
+    config := DefaultChallengeConfig()
+    service := NewChallengeService(client, config, nil)
+
+    server.HSet("rate_limit:bucket:ip:203.0.113.7", "tokens", "15")
+    service.RecordFailure(ctx, "login", "203.0.113.7")
+    service.RecordAttempt(ctx, "login", "203.0.113.8")
+
+    signals, err := service.RecordAttempt(ctx, "login", "203.0.113.7")
+    if err != nil {
+        t.Fatalf("RecordAttempt returned error: %v", err)
+    }
+
+    if signals.IPAttempts != 1 || signals.SubnetAttempts != 2 || signals.GlobalAttempts != 2 || signals.IPFailures != 1 {
+        t.Errorf("unexpected signals %+v", signals)
+    }
+    if signals.BucketFill != 0.25 {
+        t.Errorf("BucketFill = %v, want 0.25", signals.BucketFill)
+    }
+    if ttl := server.TTL("abuse:login:ip:203.0.113.7"); ttl != config.Window {
+        t.Errorf("counter TTL = %v, want %v", ttl, config.Window)
+    }
+}
+
+func TestProofOfWorkChallengeRoundTrip(t *testing.T) {
+    ctx := context.Background()
+    client, _ := newTestRedis(t)
+    service := NewChallengeService(client, DefaultChallengeConfig(), nil)
+
+    challenge, err := service.IssueChallenge(ctx, "register", 8)
+    if err != nil {
+        t.Fatalf("IssueChallenge returned error: %v", err)
+    }
+    nonce := SolveChallenge(challenge.Prefix, challenge.Difficulty)
+
+    if err := service.VerifySolution(ctx, "login", challenge.ID, nonce, 8); err == nil {
+        t.Error("solution accepted for a different route")
+    }
+
+    challenge, _ = service.IssueChallenge(ctx, "register", 8)
+    nonce = SolveChallenge(challenge.Prefix, challenge.Difficulty)
+    if err := service.VerifySolution(ctx, "register", challenge.ID, nonce, 12); err == nil {
+        t.Error("solution accepted below the current difficulty")
+    }
+
+    challenge, _ = service.IssueChallenge(ctx, "register", 8)
+    if err := service.VerifySolution(ctx, "register", challenge.ID, "not-a-solution", 8); err == nil {
+        t.Error("invalid nonce accepted")
+    }
+
+    challenge, _ = service.IssueChallenge(ctx, "register", 8)
+    nonce = SolveChallenge(challenge.Prefix, challenge.Difficulty)
+    if err := service.VerifySolution(ctx, "register", challenge.ID, nonce, 8); err != nil {
+        t.Fatalf("valid solution rejected: %v", err)
+    }
+    if err := service.VerifySolution(ctx, "register", challenge.ID, nonce, 8); err == nil {
+        t.Error("challenge accepted twice")
+    }
+}
+
+type stubCaptcha struct{ valid string }
+
+func (s stubCaptcha) Verify(ctx context.Context, token, remoteIP string) (bool, error) {
+    return token == s.valid, nil
+}
+
+func TestVerifyCaptcha(t *testing.T) {
+    ctx := context.Background()
+    client, _ := newTestRedis(t)
+
+    if err := NewChallengeService(client, DefaultChallengeConfig(), nil).VerifyCaptcha(ctx, "token", "203.0.113.7"); err == nil {
+        t.Error("captcha accepted without a verifier")
+    }
+
+    service := NewChallengeService(client, DefaultChallengeConfig(), stubCaptcha{valid: "good"})
+    if err := service.VerifyCaptcha(ctx, "good", "203.0.113.7"); err != nil {
+        t.Errorf("valid captcha rejected: %v", err)
+    }
+    if err := service.VerifyCaptcha(ctx, "bad", "203.0.113.7"); err == nil {
+        t.Error("invalid captcha accepted")
+    }
+}
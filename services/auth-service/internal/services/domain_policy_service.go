@@ -0,0 +1,390 @@
+package services
+
+import (
+    "context"
+    "crypto/rand"
+    "encoding/hex"
+    "errors"
+    "fmt"
+    "net"
+    "strings"
+    "sync"
+    "time"
+
+    "github.com/google/uuid"
+    "github.com/Shridhar2104/chat-platform/auth-service/internal/repository"
+    "github.com/Shridhar2104/chat-platform/shared/models"
+)
+
+const (
+    // DomainVerificationPrefix is the label under which workspaces publish their TXT token
+    DomainVerificationPrefix = "_chat-platform-verification"
+    domainVerificationValue  = "chat-platform-verification="
+    dnsLookupTimeout         = 5 * time.Second
+)
+
+// SSORequiredError is returned when the email domain is claimed by a
+// workspace that requires signing in through its identity provider
+type SSORequiredError struct {
+    WorkspaceID uuid.UUID
+    Domain      string
+}
+
+func (e *SSORequiredError) Error() string {
+    return "single sign-on required for this email domain"
+}
+
+// RedirectPath is where the client should send the user to sign in
+func (e *SSORequiredError) RedirectPath() string {
+    return fmt.Sprintf("/api/v1/auth/saml/%s/login", e.WorkspaceID)
+}
+
+// TXTResolver looks up DNS TXT records
+type TXTResolver interface {
+    LookupTXT(ctx context.Context, name string) ([]string, error)
+}
+
+// DNSResolver resolves TXT records through the system resolver
+type DNSResolver struct {
+    resolver *net.Resolver
+}
+
+func NewDNSResolver() *DNSResolver {
+    return &DNSResolver{resolver: net.DefaultResolver}
+}
+
+func (r *DNSResolver) LookupTXT(ctx context.Context, name string) ([]string, error) {
+    ctx, cancel := context.WithTimeout(ctx, dnsLookupTimeout)
+    defer cancel()
+    return r.resolver.LookupTXT(ctx, name)
+}
+
+// StaticTXTResolver serves TXT records from memory, for tests and local setups without DNS
+type StaticTXTResolver struct {
+    mu      sync.RWMutex
+    records map[string][]string
+}
+
+func NewStaticTXTResolver() *StaticTXTResolver {
+    return &StaticTXTResolver{records: make(map[string][]string)}
+}
+
+func (r *StaticTXTResolver) SetTXT(name string, values ...string) {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+    r.records[strings.ToLower(strings.TrimSuffix(name, "."))] = values
+}
+
+func (r *StaticTXTResolver) LookupTXT(ctx context.Context, name string) ([]string, error) {
+    r.mu.RLock()
+    defer r.mu.RUnlock()
+    values, ok := r.records[strings.ToLower(strings.TrimSuffix(name, "."))]
+    if !ok {
+        return nil, &net.DNSError{Err: "no such host", Name: name, IsNotFound: true}
+    }
+    return values, nil
+}
+
+// DomainVerification tells a workspace admin which TXT record to publish
+type DomainVerification struct {
+    RecordName  string
+    RecordValue string
+}
+
+// WorkspaceMembers is the membership storage DomainPolicyService needs,
+// every repository.WorkspaceStore implements it
+type WorkspaceMembers interface {
+    AddWorkspaceMember(ctx context.Context, workspaceID, userID uuid.UUID, source string) error
+    IsWorkspaceMember(ctx context.Context, workspaceID, userID uuid.UUID) (bool, error)
+}
+
+type DomainPolicyService struct {
+    domainRepo      repository.DomainStore
+    members         WorkspaceMembers
+    resolver        TXTResolver
+    restrictSignups bool
+}
+
+// NewDomainPolicyService creates the policy service. When restrictSignups is
+// set, self-service signups are only accepted from verified domains that allow them.
+func NewDomainPolicyService(domainRepo repository.DomainStore, members WorkspaceMembers, resolver TXTResolver, restrictSignups bool) *DomainPolicyService {
+    return &DomainPolicyService{
+        domainRepo:      domainRepo,
+        members:         members,
+        resolver:        resolver,
+        restrictSignups: restrictSignups,
+    }
+}
+
+// ClaimDomain registers an unverified domain for the workspace
+func (s *DomainPolicyService) ClaimDomain(ctx context.Context, workspaceID uuid.UUID, domain string, allowSignup, enforceSSO, autoJoin bool) (*models.DomainPolicy, error) {
+    domain, err := NormalizeDomain(domain)
+    if err != nil {
+        return nil, err
+    }
+
+    if _, err := s.domainRepo.GetDomainPolicy(ctx, workspaceID, domain); err == nil {
+        return nil, ErrDomainAlreadyClaimed
+    }
+
+    token := make([]byte, 16)
+    if _, err := rand.Read(token); err != nil {
+        return nil, fmt.Errorf("failed to generate verification token: %w", err)
+    }
+
+    policy := &models.DomainPolicy{
+        ID:                uuid.New(),
+        WorkspaceID:       workspaceID,
+        Domain:            domain,
+        VerificationToken: hex.EncodeToString(token),
+        AllowSignup:       allowSignup,
+        EnforceSSO:        enforceSSO,
+        AutoJoin:          autoJoin,
+        CreatedAt:         time.Now(),
+        UpdatedAt:         time.Now(),
+    }
+    if err := s.domainRepo.CreateDomainPolicy(ctx, policy); err != nil {
+        return nil, err
+    }
+    return policy, nil
+}
+
+// Verification returns the TXT record that proves ownership of the policy's domain
+func (s *DomainPolicyService) Verification(policy *models.DomainPolicy) DomainVerification {
+    return DomainVerification{
+        RecordName:  DomainVerificationPrefix + "." + policy.Domain,
+        RecordValue: domainVerificationValue + policy.VerificationToken,
+    }
+}
+
+// VerifyDomain checks the published TXT record and marks the domain verified
+func (s *DomainPolicyService) VerifyDomain(ctx context.Context, workspaceID uuid.UUID, domain string) (*models.DomainPolicy, error) {
+    policy, err := s.GetPolicy(ctx, workspaceID, domain)
+    if err != nil {
+        return nil, err
+    }
+    if policy.Verified {
+        return policy, nil
+    }
+
+    if err := VerifyTXTRecord(ctx, s.resolver, policy.Domain, policy.VerificationToken); err != nil {
+        return nil, err
+    }
+
+    if err := s.domainRepo.MarkDomainVerified(ctx, policy.ID); err != nil {
+        if errors.Is(err, repository.ErrDuplicate) {
+            return nil, ErrDomainVerifiedElsewhere
+        }
+        return nil, err
+    }
+
+    now := time.Now()
+    policy.Verified = true
+    policy.VerifiedAt = &now
+    return policy, nil
+}
+
+func (s *DomainPolicyService) GetPolicy(ctx context.Context, workspaceID uuid.UUID, domain string) (*models.DomainPolicy, error) {
+    domain, err := NormalizeDomain(domain)
+    if err != nil {
+        return nil, err
+    }
+    return s.domainRepo.GetDomainPolicy(ctx, workspaceID, domain)
+}
+
+func (s *DomainPolicyService) ListPolicies(ctx context.Context, workspaceID uuid.UUID) ([]models.DomainPolicy, error) {
+    return s.domainRepo.ListDomainPolicies(ctx, workspaceID)
+}
+
+func (s *DomainPolicyService) UpdatePolicy(ctx context.Context, workspaceID uuid.UUID, domain string, allowSignup, enforceSSO, autoJoin bool) (*models.DomainPolicy, error) {
+    policy, err := s.GetPolicy(ctx, workspaceID, domain)
+    if err != nil {
+        return nil, err
+    }
+
+    policy.AllowSignup = allowSignup
+    policy.EnforceSSO = enforceSSO
+    policy.AutoJoin = autoJoin
+    if err := s.domainRepo.UpdateDomainPolicy(ctx, policy); err != nil {
+        return nil, err
+    }
+    return policy, nil
+}
+
+func (s *DomainPolicyService) DeletePolicy(ctx context.Context, workspaceID uuid.UUID, domain string) error {
+    policy, err := s.GetPolicy(ctx, workspaceID, domain)
+    if err != nil {
+        return err
+    }
+    return s.domainRepo.DeleteDomainPolicy(ctx, workspaceID, policy.Domain)
+}
+
+// CheckSignup decides whether a self-service signup is allowed for the email
+func (s *DomainPolicyService) CheckSignup(ctx context.Context, email string) error {
+    policy, err := s.policyForEmail(ctx, email)
+    if err != nil {
+        return err
+    }
+    return signupDecision(policy, s.restrictSignups)
+}
+
+// CheckPasswordLogin rejects password logins for domains that enforce SSO
+func (s *DomainPolicyService) CheckPasswordLogin(ctx context.Context, email string) error {
+    policy, err := s.policyForEmail(ctx, email)
+    if err != nil {
+        return err
+    }
+    return passwordLoginDecision(policy)
+}
+
+// AutoJoin adds the user to the workspace that claims their email domain
+func (s *DomainPolicyService) AutoJoin(ctx context.Context, user *models.User) error {
+    policy, err := s.policyForEmail(ctx, user.Email)
+    if err != nil || policy == nil || !policy.AutoJoin {
+        return err
+    }
+    return s.members.AddWorkspaceMember(ctx, policy.WorkspaceID, user.ID, "domain")
+}
+
+// OwnsEmail reports whether the workspace verified the domain of the email
+func (s *DomainPolicyService) OwnsEmail(ctx context.Context, workspaceID uuid.UUID, email string) (bool, error) {
+    policy, err := s.policyForEmail(ctx, email)
+    if err != nil {
+        return false, err
+    }
+    return policy != nil && policy.WorkspaceID == workspaceID, nil
+}
+
+// CheckExternalIdentity decides whether a workspace's identity provider may
+// sign in the email. Any workspace can configure an IdP, so an assertion is
+// only trusted for domains the workspace verified or for an existing account
+// that already belongs to it. user is nil when no account has the email yet.
+func (s *DomainPolicyService) CheckExternalIdentity(ctx context.Context, workspaceID uuid.UUID, email string, user *models.User) error {
+    policy, err := s.policyForEmail(ctx, email)
+    if err != nil {
+        return err
+    }
+    member := false
+    if user != nil && (policy == nil || policy.WorkspaceID != workspaceID) {
+        if member, err = s.members.IsWorkspaceMember(ctx, workspaceID, user.ID); err != nil {
+            return err
+        }
+    }
+    return externalIdentityDecision(policy, workspaceID, member)
+}
+
+// policyForEmail returns the most specific verified policy for the email's domain, or nil
+func (s *DomainPolicyService) policyForEmail(ctx context.Context, email string) (*models.DomainPolicy, error) {
+    domain, err := EmailDomain(email)
+    if err != nil {
+        return nil, nil
+    }
+
+    policies, err := s.domainRepo.GetVerifiedDomainPolicies(ctx, parentDomains(domain))
+    if err != nil {
+        return nil, err
+    }
+    return selectPolicy(policies, domain), nil
+}
+
+func signupDecision(policy *models.DomainPolicy, restrictSignups bool) error {
+    if policy == nil {
+        if restrictSignups {
+            return ErrSignupNotAllowed
+        }
+        return nil
+    }
+    if policy.EnforceSSO {
+        return &SSORequiredError{WorkspaceID: policy.WorkspaceID, Domain: policy.Domain}
+    }
+    if !policy.AllowSignup {
+        return ErrSignupNotAllowed
+    }
+    return nil
+}
+
+func passwordLoginDecision(policy *models.DomainPolicy) error {
+    if policy != nil && policy.EnforceSSO {
+        return &SSORequiredError{WorkspaceID: policy.WorkspaceID, Domain: policy.Domain}
+    }
+    return nil
+}
+
+func externalIdentityDecision(policy *models.DomainPolicy, workspaceID uuid.UUID, member bool) error {
+    if member || (policy != nil && policy.WorkspaceID == workspaceID) {
+        return nil
+    }
+    return fmt.Errorf("%w: email domain is not verified for this workspace", ErrSSOAssertionRejected)
+}
+
+// selectPolicy picks the policy for the longest domain suffix of domain
+func selectPolicy(policies []models.DomainPolicy, domain string) *models.DomainPolicy {
+    var best *models.DomainPolicy
+    for i := range policies {
+        policy := &policies[i]
+        if policy.Domain != domain && !strings.HasSuffix(domain, "."+policy.Domain) {
+            continue
+        }
+        if best == nil || len(policy.Domain) > len(best.Domain) {
+            best = policy
+        }
+    }
+    return best
+}
+
+// VerifyTXTRecord checks that the domain publishes the expected verification token
+func VerifyTXTRecord(ctx context.Context, resolver TXTResolver, domain, token string) error {
+    records, err := resolver.LookupTXT(ctx, DomainVerificationPrefix + "." + domain)
+    if err != nil {
+        return ErrVerificationRecordNotFound
+    }
+
+    expected := domainVerificationValue + token
+    for _, record := range records {
+        if strings.TrimSpace(strings.Trim(record, `"`)) == expected {
+            return nil
+        }
+    }
+    return ErrVerificationRecordMismatch
+}
+
+// NormalizeDomain lowercases a domain and rejects values that are not hostnames
+func NormalizeDomain(domain string) (string, error) {
+    domain = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(domain)), ".")
+    if domain == "" || len(domain) > 253 || !strings.Contains(domain, ".") {
+        return "", ErrInvalidDomain
+    }
+    for _, label := range strings.Split(domain, ".") {
+        if label == "" || len(label) > 63 || strings.HasPrefix(label, "-") || strings.HasSuffix(label, "-") {
+            return "", ErrInvalidDomain
+        }
+        for _, r := range label {
+            if !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '-') {
+                return "", ErrInvalidDomain
+            }
+        }
+    }
+    return domain, nil
+}
+
+// EmailDomain returns the normalized domain part of an email address
+func EmailDomain(email string) (string, error) {
+    at := strings.LastIndex(email, "@")
+    if at < 0 {
+        return "", ErrInvalidEmail
+    }
+    return NormalizeDomain(email[at+1:])
+}
+
+// parentDomains lists the domain and each parent that still has two labels
+func parentDomains(domain string) []string {
+    domains := []string{domain}
+    for {
+        dot := strings.Index(domain, ".")
+        if dot < 0 || !strings.Contains(domain[dot+1:], ".") {
+            return domains
+        }
+        domain = domain[dot+1:]
+        domains = append(domains, domain)
+    }
+}
@@ -0,0 +1,184 @@
+package services
+
+import (
+    "context"
+    "errors"
+    "reflect"
+    "testing"
+
+    "github.com/google/uuid"
+    "github.com/Shridhar2104/chat-platform/shared/models"
+)
+
+func TestVerifyTXTRecord(t *testing.T) {
+    resolver := NewStaticTXTResolver()
+    resolver.SetTXT("_chat-platform-verification.acme.com", "v=spf1 -all", "chat-platform-verification=abc123")
+    resolver.SetTXT("_chat-platform-verification.quoted.com.", `"chat-platform-verification=abc123"`)
+    resolver.SetTXT("_chat-platform-verification.other.com", "chat-platform-verification=zzz")
+
+    tests := []struct {
+        name    string
+        domain  string
+        wantErr bool
+    }{
+        {name: "matching record among others", domain: "acme.com"},
+        {name: "quoted record with trailing dot", domain: "quoted.com"},
+        {name: "token mismatch", domain: "other.com", wantErr: true},
+        {name: "no record", domain: "missing.com", wantErr: true},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            err := VerifyTXTRecord(context.Background(), resolver, tt.domain, "abc123")
+            if (err != nil) != tt.wantErr {
+                t.Errorf("VerifyTXTRecord(%s) error = %v, wantErr %v", tt.domain, err, tt.wantErr)
+            }
+        })
+    }
+}
+
+func TestNormalizeDomain(t *testing.T) {
+    tests := []struct {
+        input   string
+        want    string
+        wantErr bool
+    }{
+        {input: " Acme.COM. ", want: "acme.com"},
+        {input: "eng.acme.co.uk", want: "eng.acme.co.uk"},
+        {input: "localhost", wantErr: true},
+        {input: "acme..com", wantErr: true},
+        {input: "-acme.com", wantErr: true},
+        {input: "acme.com/path", wantErr: true},
+        {input: "", wantErr: true},
+    }
+
+    for _, tt := range tests {
+        got, err := NormalizeDomain(tt.input)
+        if (err != nil) != tt.wantErr {
+            t.Errorf("NormalizeDomain(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+            continue
+        }
+        if got != tt.want {
+            t.Errorf("NormalizeDomain(%q) = %q, want %q", tt.input, got, tt.want)
+        }
+    }
+
+    if domain, err := EmailDomain("Jane.Doe@Eng.Acme.com"); err != nil || domain != "eng.acme.com" {
+        t.Errorf("EmailDomain = %q, %v, want eng.acme.com", domain, err)
+    }
+    if _, err := EmailDomain("not-an-email"); err == nil {
+        t.Error("EmailDomain accepted an address without @")
+    }
+}
+
+func TestParentDomains(t *testing.T) {
+    got := parentDomains("a.eng.acme.com")
+    want := []string{"a.eng.acme.com", "eng.acme.com", "acme.com"}
+    if !reflect.DeepEqual(got, want) {
+        t.Errorf("parentDomains = %v, want %v", got, want)
+    }
+}
+
+func TestSelectPolicyPrefersMostSpecificDomain(t *testing.T) {
+    policies := []models.DomainPolicy{
+        {Domain: "acme.com"},
+        {Domain: "eng.acme.com"},
+        {Domain: "notacme.com"},
+    }
+
+    if got := selectPolicy(policies, "eng.acme.com"); got == nil || got.Domain != "eng.acme.com" {
+        t.Errorf("selectPolicy(eng.acme.com) = %v, want eng.acme.com", got)
+    }
+    if got := selectPolicy(policies, "sales.acme.com"); got == nil || got.Domain != "acme.com" {
+        t.Errorf("selectPolicy(sales.acme.com) = %v, want acme.com", got)
+    }
+    if got := selectPolicy(policies, "xacme.com"); got != nil {
+        t.Errorf("selectPolicy(xacme.com) = %v, want nil", got.Domain)
+    }
+}
+
+func TestSignupAndLoginDecisions(t *testing.T) {
+    workspaceID := uuid.New()
+    open := &models.DomainPolicy{WorkspaceID: workspaceID, Domain: "acme.com", AllowSignup: true}
+    closed := &models.DomainPolicy{WorkspaceID: workspaceID, Domain: "acme.com", AllowSignup: false}
+    sso := &models.DomainPolicy{WorkspaceID: workspaceID, Domain: "acme.com", AllowSignup: true, EnforceSSO: true}
+
+    tests := []struct {
+        name        string
+        policy      *models.DomainPolicy
+        restrict    bool
+        wantSignup  string
+        wantSSO     bool
+        wantLoginOK bool
+    }{
+        {name: "unclaimed domain", policy: nil, wantLoginOK: true},
+        {name: "unclaimed domain with restriction", policy: nil, restrict: true, wantSignup: "signups from this email domain are not allowed", wantLoginOK: true},
+        {name: "approved domain with restriction", policy: open, restrict: true, wantLoginOK: true},
+        {name: "domain blocking signups", policy: closed, wantSignup: "signups from this email domain are not allowed", wantLoginOK: true},
+        {name: "domain enforcing SSO", policy: sso, wantSSO: true},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            err := signupDecision(tt.policy, tt.restrict)
+            var ssoRequired *SSORequiredError
+            switch {
+            case tt.wantSSO:
+                if !errors.As(err, &ssoRequired) {
+                    t.Fatalf("signup error = %v, want SSORequiredError", err)
+                }
+            case tt.wantSignup != "":
+                if err == nil || err.Error() != tt.wantSignup {
+                    t.Fatalf("signup error = %v, want %q", err, tt.wantSignup)
+                }
+            default:
+                if err != nil {
+                    t.Fatalf("signup error = %v, want nil", err)
+                }
+            }
+
+            err = passwordLoginDecision(tt.policy)
+            if tt.wantLoginOK {
+                if err != nil {
+                    t.Fatalf("login error = %v, want nil", err)
+                }
+                return
+            }
+            if !errors.As(err, &ssoRequired) {
+                t.Fatalf("login error = %v, want SSORequiredError", err)
+            }
+            if ssoRequired.RedirectPath() != "/api/v1/auth/saml/"+workspaceID.String()+"/login" {
+                t.Errorf("RedirectPath = %s", ssoRequired.RedirectPath())
+            }
+        })
+    }
+}
+
+
+func TestExternalIdentityDecision(t *testing.T) {
+    workspaceID, otherWorkspaceID := uuid.New(), uuid.New()
+    own := &models.DomainPolicy{WorkspaceID: workspaceID, Domain: "acme.com", Verified: true}
+    foreign := &models.DomainPolicy{WorkspaceID: otherWorkspaceID, Domain: "victim.com", Verified: true}
+
+    tests := []struct {
+        name    string
+        policy  *models.DomainPolicy
+        member  bool
+        wantErr bool
+    }{
+        {name: "domain verified by the workspace", policy: own},
+        {name: "domain verified by another workspace", policy: foreign, wantErr: true},
+        {name: "unclaimed domain", policy: nil, wantErr: true},
+        {name: "existing member with an unclaimed domain", policy: nil, member: true},
+        {name: "existing member under another workspace's domain", policy: foreign, member: true},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            err := externalIdentityDecision(tt.policy, workspaceID, tt.member)
+            if tt.wantErr != errors.Is(err, ErrSSOAssertionRejected) {
+                t.Errorf("externalIdentityDecision error = %v, wantErr %v", err, tt.wantErr)
+            }
+        })
+    }
+}
\ No newline at end of file
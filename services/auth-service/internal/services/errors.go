@@ -0,0 +1,42 @@
+package services
+
+import (
+    "errors"
+
+    "github.com/Shridhar2104/chat-platform/auth-service/internal/repository"
+)
+
+// Sentinel errors returned by the services. Handlers map them to HTTP
+// statuses and stable error codes in one place, so the messages here are
+// safe to show to clients.
+var (
+    ErrEmailAlreadyRegistered  = errors.New("email already registered")
+    ErrInvalidCredentials      = errors.New("invalid credentials")
+    ErrAccountDisabled         = errors.New("account disabled")
+    ErrIncorrectPassword       = errors.New("current password is incorrect")
+    ErrInvalidToken            = errors.New("invalid token")
+    ErrInvalidRefreshToken     = errors.New("invalid refresh token")
+    ErrDeviceMismatch          = errors.New("device ID mismatch")
+    ErrChallengeNotFound       = errors.New("challenge not found or expired")
+    ErrInvalidConfirmationCode = errors.New("invalid confirmation code")
+
+    ErrSignupNotAllowed           = errors.New("signups from this email domain are not allowed")
+    ErrInvalidDomain              = errors.New("invalid domain")
+    ErrInvalidEmail               = errors.New("invalid email address")
+    ErrDomainAlreadyClaimed       = errors.New("domain already claimed")
+    ErrDomainVerifiedElsewhere    = errors.New("domain already verified by another workspace")
+    ErrVerificationRecordNotFound = errors.New("verification record not found")
+    ErrVerificationRecordMismatch = errors.New("verification record does not match")
+
+    ErrIdentityProviderDisabled = errors.New("identity provider is disabled")
+    // ErrInvalidIdentityProvider wraps every reason an IdP configuration is refused
+    ErrInvalidIdentityProvider = errors.New("invalid identity provider")
+    // ErrSSOAssertionRejected wraps every reason a SAML response is refused
+    ErrSSOAssertionRejected = errors.New("sso assertion rejected")
+
+    // Lookups pass the repository sentinels through unchanged
+    ErrUserNotFound             = repository.ErrUserNotFound
+    ErrSessionNotFound          = repository.ErrSessionNotFound
+    ErrDomainPolicyNotFound     = repository.ErrDomainPolicyNotFound
+    ErrIdentityProviderNotFound = repository.ErrIdentityProviderNotFound
+)
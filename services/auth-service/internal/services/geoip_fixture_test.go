@@ -0,0 +1,196 @@
+package services
+
+import (
+    "bytes"
+    "encoding/binary"
+    "math"
+    "net"
+    "os"
+    "path/filepath"
+    "sort"
+    "testing"
+)
+
+// fixtureCity is a single network entry written to the fixture GeoIP database
+type fixtureCity struct {
+    CIDR        string
+    CountryCode string
+    City        string
+    Latitude    float64
+    Longitude   float64
+}
+
+var fixtureCities = []fixtureCity{
+    {CIDR: "203.0.113.0/24", CountryCode: "DE", City: "Berlin", Latitude: 52.52, Longitude: 13.40},
+    {CIDR: "192.0.2.0/24", CountryCode: "DE", City: "Hamburg", Latitude: 53.55, Longitude: 9.99},
+    {CIDR: "198.51.100.0/24", CountryCode: "US", City: "New York", Latitude: 40.71, Longitude: -74.00},
+}
+
+type fixtureTrieNode struct {
+    children   [2]*fixtureTrieNode
+    dataOffset int
+    leaf       bool
+}
+
+// writeFixtureGeoIPDatabase writes a minimal IPv4 MaxMind DB (format 2.0,
+// 24-bit records) containing fixtureCities and returns its path
+func writeFixtureGeoIPDatabase(t *testing.T) string {
+    t.Helper()
+
+    var data bytes.Buffer
+    root := &fixtureTrieNode{}
+
+    for _, city := range fixtureCities {
+        _, network, err := net.ParseCIDR(city.CIDR)
+        if err != nil {
+            t.Fatalf("invalid fixture network %s: %v", city.CIDR, err)
+        }
+
+        offset := data.Len()
+        encodeMMDBValue(&data, map[string]interface{}{
+            "city":     map[string]interface{}{"names": map[string]interface{}{"en": city.City}},
+            "country":  map[string]interface{}{"iso_code": city.CountryCode},
+            "location": map[string]interface{}{"latitude": city.Latitude, "longitude": city.Longitude},
+        })
+
+        ones, _ := network.Mask.Size()
+        ip := network.IP.To4()
+        node := root
+        for bit := 0; bit < ones; bit++ {
+            b := (ip[bit/8] >> (7 - uint(bit%8))) & 1
+            if node.children[b] == nil {
+                node.children[b] = &fixtureTrieNode{}
+            }
+            node = node.children[b]
+        }
+        node.leaf = true
+        node.dataOffset = offset
+    }
+
+    // Number the internal nodes breadth first, root is node 0
+    ids := map[*fixtureTrieNode]int{}
+    var nodes []*fixtureTrieNode
+    queue := []*fixtureTrieNode{root}
+    for len(queue) > 0 {
+        node := queue[0]
+        queue = queue[1:]
+        ids[node] = len(nodes)
+        nodes = append(nodes, node)
+        for _, child := range node.children {
+            if child != nil && !child.leaf {
+                queue = append(queue, child)
+            }
+        }
+    }
+
+    nodeCount := len(nodes)
+    record := func(child *fixtureTrieNode) uint32 {
+        switch {
+        case child == nil:
+            return uint32(nodeCount)
+        case child.leaf:
+            return uint32(nodeCount + 16 + child.dataOffset)
+        default:
+            return uint32(ids[child])
+        }
+    }
+
+    var file bytes.Buffer
+    for _, node := range nodes {
+        for _, child := range node.children {
+            value := record(child)
+            file.Write([]byte{byte(value >> 16), byte(value >> 8), byte(value)})
+        }
+    }
+    file.Write(make([]byte, 16))
+    file.Write(data.Bytes())
+    file.WriteString("\xab\xcd\xefMaxMind.com")
+    encodeMMDBValue(&file, map[string]interface{}{
+        "node_count":                  uint32(nodeCount),
+        "record_size":                 uint16(24),
+        "ip_version":                  uint16(4),
+        "database_type":               "GeoLite2-City",
+        "languages":                   []interface{}{"en"},
+        "binary_format_major_version": uint16(2),
+        "binary_format_minor_version": uint16(0),
+        "build_epoch":                 uint64(1700000000),
+        "description":                 map[string]interface{}{"en": "auth-service test fixture"},
+    })
+
+    path := filepath.Join(t.TempDir(), "GeoLite2-City-Test.mmdb")
+    if err := os.WriteFile(path, file.Bytes(), 0o600); err != nil {
+        t.Fatalf("failed to write fixture database: %v", err)
+    }
+    return path
+}
+
+func encodeMMDBValue(buf *bytes.Buffer, value interface{}) {
+    switch v := value.(type) {
+    case string:
+        writeMMDBControl(buf, 2, len(v))
+        buf.WriteString(v)
+    case float64:
+        writeMMDBControl(buf, 3, 8)
+        binary.Write(buf, binary.BigEndian, math.Float64bits(v))
+    case uint16:
+        writeMMDBUint(buf, 5, uint64(v))
+    case uint32:
+        writeMMDBUint(buf, 6, uint64(v))
+    case uint64:
+        writeMMDBUint(buf, 9, v)
+    case []interface{}:
+        writeMMDBControl(buf, 11, len(v))
+        for _, item := range v {
+            encodeMMDBValue(buf, item)
+        }
+    case map[string]interface{}:
+        keys := make([]string, 0, len(v))
+        for key := range v {
+            keys = append(keys, key)
+        }
+        sort.Strings(keys)
+
+        writeMMDBControl(buf, 7, len(v))
+        for _, key := range keys {
+            encodeMMDBValue(buf, key)
+            encodeMMDBValue(buf, v[key])
+        }
+    default:
+        panic("unsupported fixture value type")
+    }
+}
+
+func writeMMDBUint(buf *bytes.Buffer, typeNum int, value uint64) {
+    var raw []byte
+    for value > 0 {
+        raw = append([]byte{byte(value)}, raw...)
+        value >>= 8
+    }
+    writeMMDBControl(buf, typeNum, len(raw))
+    buf.Write(raw)
+}
+
+func writeMMDBControl(buf *bytes.Buffer, typeNum, size int) {
+    var control byte
+    if typeNum <= 7 {
+        control = byte(typeNum << 5)
+    }
+
+    var extra []byte
+    switch {
+    case size < 29:
+        control |= byte(size)
+    case size < 29+256:
+        control |= 29
+        extra = []byte{byte(size - 29)}
+    default:
+        control |= 30
+        extra = []byte{byte((size - 285) >> 8), byte(size - 285)}
+    }
+
+    buf.WriteByte(control)
+    if typeNum > 7 {
+        buf.WriteByte(byte(typeNum - 7))
+    }
+    buf.Write(extra)
+}
@@ -0,0 +1,75 @@
+package services
+
+import (
+    "fmt"
+    "net"
+
+    "github.com/oschwald/maxminddb-golang"
+)
+
+type GeoLocation struct {
+    CountryCode string
+    City        string
+    Latitude    float64
+    Longitude   float64
+}
+
+// GeoLocator resolves an IP address to an approximate location
+type GeoLocator interface {
+    Lookup(ipAddress string) (*GeoLocation, error)
+}
+
+// MaxMindGeoLocator reads a local MaxMind-format (.mmdb) city database
+type MaxMindGeoLocator struct {
+    reader *maxminddb.Reader
+}
+
+// cityRecord mirrors the subset of the GeoIP2/GeoLite2 City schema we use
+type cityRecord struct {
+    City struct {
+        Names map[string]string `maxminddb:"names"`
+    } `maxminddb:"city"`
+    Country struct {
+        ISOCode string `maxminddb:"iso_code"`
+    } `maxminddb:"country"`
+    Location struct {
+        Latitude  *float64 `maxminddb:"latitude"`
+        Longitude *float64 `maxminddb:"longitude"`
+    } `maxminddb:"location"`
+}
+
+func NewMaxMindGeoLocator(databasePath string) (*MaxMindGeoLocator, error) {
+    reader, err := maxminddb.Open(databasePath)
+    if err != nil {
+        return nil, fmt.Errorf("failed to open geoip database: %w", err)
+    }
+    return &MaxMindGeoLocator{reader: reader}, nil
+}
+
+func (g *MaxMindGeoLocator) Lookup(ipAddress string) (*GeoLocation, error) {
+    ip := net.ParseIP(ipAddress)
+    if ip == nil {
+        return nil, fmt.Errorf("invalid ip address: %s", ipAddress)
+    }
+
+    var record cityRecord
+    if err := g.reader.Lookup(ip, &record); err != nil {
+        return nil, fmt.Errorf("failed to lookup ip address: %w", err)
+    }
+
+    // Records without coordinates are useless for travel calculations
+    if record.Location.Latitude == nil || record.Location.Longitude == nil {
+        return nil, fmt.Errorf("location not found")
+    }
+
+    return &GeoLocation{
+        CountryCode: record.Country.ISOCode,
+        City:        record.City.Names["en"],
+        Latitude:    *record.Location.Latitude,
+        Longitude:   *record.Location.Longitude,
+    }, nil
+}
+
+func (g *MaxMindGeoLocator) Close() error {
+    return g.reader.Close()
+}
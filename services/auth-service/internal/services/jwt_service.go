@@ -2,48 +2,85 @@ package services
 
 import (
     "fmt"
+    "sync"
     "time"
 
     "github.com/golang-jwt/jwt/v5"
     "github.com/google/uuid"
 )
 
+// Access tokens are RS256-signed so other services can verify them from the
+// published JWKS; refresh tokens never leave this service and stay HS256
 type JWTService struct {
+    mu                sync.RWMutex
     secretKey         string
+    // previousSecret still verifies refresh tokens issued before a rotation
+    previousSecret    string
+    keys              *KeySet
     accessTokenTTL    time.Duration
     refreshTokenTTL   time.Duration
 }
 
+const (
+    DefaultAccessScope = "profile chat"
+    DefaultUserRole    = "user"
+)
+
 type Claims struct {
     UserID   uuid.UUID `json:"user_id"`
     Email    string    `json:"email"`
     DeviceID string    `json:"device_id"`
+    Scope    string    `json:"scope,omitempty"`
+    Roles    []string  `json:"roles,omitempty"`
+    // Workspace and subscription plan of the caller, when the issuer knows
+    // them; rate limit quotas are chosen by plan
+    WorkspaceID string `json:"workspace_id,omitempty"`
+    Plan        string `json:"plan,omitempty"`
     jwt.RegisteredClaims
 }
 
 type RefreshClaims struct {
     UserID   uuid.UUID `json:"user_id"`
     DeviceID string    `json:"device_id"`
+    // Refreshed tokens stay in the workspace the session signed in to
+    WorkspaceID string `json:"workspace_id,omitempty"`
     jwt.RegisteredClaims
 }
 
-func NewJWTService(secretKey string, accessTTL, refreshTTL time.Duration) *JWTService {
+// TokenWorkspace is the workspace an access token acts in and its plan
+type TokenWorkspace struct {
+    ID   uuid.UUID
+    Plan string
+}
+
+func NewJWTService(secretKey string, keys *KeySet, accessTTL, refreshTTL time.Duration) *JWTService {
     return &JWTService{
         secretKey:       secretKey,
+        keys:            keys,
         accessTokenTTL:  accessTTL,
         refreshTokenTTL: refreshTTL,
     }
 }
 
-func (j *JWTService) GenerateTokenPair(userID uuid.UUID, email, deviceID string) (string, string, time.Time, error) {
+// GenerateTokenPair issues an access and refresh token, workspace may be nil
+// for users outside any workspace
+func (j *JWTService) GenerateTokenPair(userID uuid.UUID, email, deviceID string, workspace *TokenWorkspace) (string, string, time.Time, error) {
     // Generate access token
     now := time.Now()
     expiresAt := now.Add(j.accessTokenTTL)
+    var workspaceID, plan string
+    if workspace != nil {
+        workspaceID, plan = workspace.ID.String(), workspace.Plan
+    }
 
     accessClaims := Claims{
-        UserID:   userID,
-        Email:    email,
-        DeviceID: deviceID,
+        UserID:      userID,
+        Email:       email,
+        DeviceID:    deviceID,
+        Scope:       DefaultAccessScope,
+        Roles:       []string{DefaultUserRole},
+        WorkspaceID: workspaceID,
+        Plan:        plan,
         RegisteredClaims: jwt.RegisteredClaims{
             ExpiresAt: jwt.NewNumericDate(expiresAt),
             IssuedAt:  jwt.NewNumericDate(now),
@@ -53,8 +90,9 @@ func (j *JWTService) GenerateTokenPair(userID uuid.UUID, email, deviceID string)
         },
     }
 
-    accessToken := jwt.NewWithClaims(jwt.SigningMethodHS256, accessClaims)
-    accessTokenString, err := accessToken.SignedString([]byte(j.secretKey))
+    accessToken := jwt.NewWithClaims(jwt.SigningMethodRS256, accessClaims)
+    accessToken.Header["kid"] = j.keys.active.ID
+    accessTokenString, err := accessToken.SignedString(j.keys.active.PrivateKey)
     if err != nil {
         return "", "", time.Time{}, fmt.Errorf("failed to sign access token: %w", err)
     }
@@ -62,8 +100,9 @@ func (j *JWTService) GenerateTokenPair(userID uuid.UUID, email, deviceID string)
     // Generate refresh token
     refreshExpiresAt := now.Add(j.refreshTokenTTL)
     refreshClaims := RefreshClaims{
-        UserID:   userID,
-        DeviceID: deviceID,
+        UserID:      userID,
+        DeviceID:    deviceID,
+        WorkspaceID: workspaceID,
         RegisteredClaims: jwt.RegisteredClaims{
             ExpiresAt: jwt.NewNumericDate(refreshExpiresAt),
             IssuedAt:  jwt.NewNumericDate(now),
@@ -74,7 +113,7 @@ func (j *JWTService) GenerateTokenPair(userID uuid.UUID, email, deviceID string)
     }
 
     refreshToken := jwt.NewWithClaims(jwt.SigningMethodHS256, refreshClaims)
-    refreshTokenString, err := refreshToken.SignedString([]byte(j.secretKey))
+    refreshTokenString, err := refreshToken.SignedString(j.refreshSigningKey())
     if err != nil {
         return "", "", time.Time{}, fmt.Errorf("failed to sign refresh token: %w", err)
     }
@@ -84,10 +123,15 @@ func (j *JWTService) GenerateTokenPair(userID uuid.UUID, email, deviceID string)
 
 func (j *JWTService) ValidateAccessToken(tokenString string) (*Claims, error) {
     token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-        if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+        if token.Method != jwt.SigningMethodRS256 {
             return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
         }
-        return []byte(j.secretKey), nil
+        keyID, _ := token.Header["kid"].(string)
+        key, ok := j.keys.PublicKey(keyID)
+        if !ok {
+            return nil, fmt.Errorf("unknown key id %q", keyID)
+        }
+        return key, nil
     })
 
     if err != nil {
@@ -96,7 +140,7 @@ func (j *JWTService) ValidateAccessToken(tokenString string) (*Claims, error) {
 
     claims, ok := token.Claims.(*Claims)
     if !ok || !token.Valid {
-        return nil, fmt.Errorf("invalid token")
+        return nil, ErrInvalidToken
     }
 
     return claims, nil
@@ -107,7 +151,7 @@ func (j *JWTService) ValidateRefreshToken(tokenString string) (*RefreshClaims, e
         if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
             return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
         }
-        return []byte(j.secretKey), nil
+        return j.refreshVerificationKeys(), nil
     })
 
     if err != nil {
@@ -116,8 +160,40 @@ func (j *JWTService) ValidateRefreshToken(tokenString string) (*RefreshClaims, e
 
     claims, ok := token.Claims.(*RefreshClaims)
     if !ok || !token.Valid {
-        return nil, fmt.Errorf("invalid refresh token")
+        return nil, ErrInvalidRefreshToken
     }
 
     return claims, nil
+}
+
+// RotateSecret switches refresh tokens to a new HMAC secret. Tokens signed
+// with the secret it replaces stay valid until the next rotation.
+func (j *JWTService) RotateSecret(secret string) {
+    j.mu.Lock()
+    defer j.mu.Unlock()
+    if secret == j.secretKey {
+        return
+    }
+    j.previousSecret = j.secretKey
+    j.secretKey = secret
+}
+
+func (j *JWTService) refreshSigningKey() []byte {
+    j.mu.RLock()
+    defer j.mu.RUnlock()
+    return []byte(j.secretKey)
+}
+
+func (j *JWTService) refreshVerificationKeys() jwt.VerificationKeySet {
+    j.mu.RLock()
+    defer j.mu.RUnlock()
+    keys := jwt.VerificationKeySet{Keys: []jwt.VerificationKey{[]byte(j.secretKey)}}
+    if j.previousSecret != "" {
+        keys.Keys = append(keys.Keys, []byte(j.previousSecret))
+    }
+    return keys
+}
+
+func (j *JWTService) KeySet() *KeySet {
+    return j.keys
 }
\ No newline at end of file
@@ -0,0 +1,44 @@
+package services
+
+import (
+    "crypto/rand"
+    "crypto/rsa"
+    "testing"
+    "time"
+
+    "github.com/google/uuid"
+)
+
+func TestRotateSecretKeepsPreviousRefreshTokensValid(t *testing.T) {
+    key, err := rsa.GenerateKey(rand.Reader, 2048)
+    if err != nil {
+        t.Fatal(err)
+    }
+    jwtService := NewJWTService("first-secret", NewKeySet(key), 15*time.Minute, time.Hour)
+    userID := uuid.New()
+
+    _, beforeRotation, _, err := jwtService.GenerateTokenPair(userID, "user@example.com", "device", nil)
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    jwtService.RotateSecret("second-secret")
+    _, afterRotation, _, err := jwtService.GenerateTokenPair(userID, "user@example.com", "device", nil)
+    if err != nil {
+        t.Fatal(err)
+    }
+    for name, token := range map[string]string{"before": beforeRotation, "after": afterRotation} {
+        if claims, err := jwtService.ValidateRefreshToken(token); err != nil || claims.UserID != userID {
+            t.Errorf("refresh token issued %s the rotation rejected: %v", name, err)
+        }
+    }
+
+    // A second rotation retires the first secret
+    jwtService.RotateSecret("third-secret")
+    if _, err := jwtService.ValidateRefreshToken(beforeRotation); err == nil {
+        t.Error("expected a token signed with a retired secret to be rejected")
+    }
+    if _, err := jwtService.ValidateRefreshToken(afterRotation); err != nil {
+        t.Errorf("token signed with the previous secret rejected: %v", err)
+    }
+}
@@ -0,0 +1,121 @@
+package services
+
+import (
+    "crypto/rand"
+    "crypto/rsa"
+    "crypto/x509"
+    "encoding/pem"
+    "fmt"
+    "log/slog"
+    "os"
+
+    "github.com/Shridhar2104/chat-platform/shared/authclient"
+)
+
+// SigningKey is the RSA key access tokens are signed with, ID is its RFC 7638 thumbprint
+type SigningKey struct {
+    ID         string
+    PrivateKey *rsa.PrivateKey
+}
+
+// KeySet holds the active signing key and the public halves of retired keys,
+// which stay published until the tokens they signed have expired
+type KeySet struct {
+    active  *SigningKey
+    retired map[string]*rsa.PublicKey
+}
+
+func NewKeySet(active *rsa.PrivateKey, retired ...*rsa.PublicKey) *KeySet {
+    keys := &KeySet{
+        active:  &SigningKey{ID: authclient.RSAThumbprint(&active.PublicKey), PrivateKey: active},
+        retired: make(map[string]*rsa.PublicKey, len(retired)),
+    }
+    for _, key := range retired {
+        keys.retired[authclient.RSAThumbprint(key)] = key
+    }
+    return keys
+}
+
+// LoadKeySet reads PEM keys from disk. Without an active key path an ephemeral
+// key is generated, which only suits a single development instance.
+func LoadKeySet(activePath string, retiredPaths []string) (*KeySet, error) {
+    var active *rsa.PrivateKey
+    if activePath == "" {
+        slog.Warn("JWT_SIGNING_KEY_PATH not set, using an ephemeral signing key")
+        key, err := rsa.GenerateKey(rand.Reader, 2048)
+        if err != nil {
+            return nil, fmt.Errorf("failed to generate signing key: %w", err)
+        }
+        active = key
+    } else {
+        key, err := readPEMKey(activePath)
+        if err != nil {
+            return nil, err
+        }
+        private, ok := key.(*rsa.PrivateKey)
+        if !ok {
+            return nil, fmt.Errorf("signing key %s is not an RSA private key", activePath)
+        }
+        active = private
+    }
+
+    var retired []*rsa.PublicKey
+    for _, path := range retiredPaths {
+        key, err := readPEMKey(path)
+        if err != nil {
+            return nil, err
+        }
+        switch k := key.(type) {
+        case *rsa.PrivateKey:
+            retired = append(retired, &k.PublicKey)
+        case *rsa.PublicKey:
+            retired = append(retired, k)
+        default:
+            return nil, fmt.Errorf("retired key %s is not an RSA key", path)
+        }
+    }
+
+    return NewKeySet(active, retired...), nil
+}
+
+// PublicKey returns the verification key for a key ID
+func (k *KeySet) PublicKey(keyID string) (*rsa.PublicKey, bool) {
+    if keyID == k.active.ID {
+        return &k.active.PrivateKey.PublicKey, true
+    }
+    key, ok := k.retired[keyID]
+    return key, ok
+}
+
+// JWKS returns the public key set served to other services
+func (k *KeySet) JWKS() authclient.JWKSet {
+    set := authclient.JWKSet{Keys: []authclient.JWK{authclient.NewRSAJWK(k.active.ID, &k.active.PrivateKey.PublicKey)}}
+    for id, key := range k.retired {
+        set.Keys = append(set.Keys, authclient.NewRSAJWK(id, key))
+    }
+    return set
+}
+
+func readPEMKey(path string) (interface{}, error) {
+    data, err := os.ReadFile(path)
+    if err != nil {
+        return nil, fmt.Errorf("failed to read key %s: %w", path, err)
+    }
+    block, _ := pem.Decode(data)
+    if block == nil {
+        return nil, fmt.Errorf("no PEM data in %s", path)
+    }
+
+    switch block.Type {
+    case "RSA PRIVATE KEY":
+        return x509.ParsePKCS1PrivateKey(block.Bytes)
+    case "PRIVATE KEY":
+        return x509.ParsePKCS8PrivateKey(block.Bytes)
+    case "RSA PUBLIC KEY":
+        return x509.ParsePKCS1PublicKey(block.Bytes)
+    case "PUBLIC KEY":
+        return x509.ParsePKIXPublicKey(block.Bytes)
+    default:
+        return nil, fmt.Errorf("unsupported PEM block %q in %s", block.Type, path)
+    }
+}
@@ -0,0 +1,108 @@
+package services
+
+import (
+    "bytes"
+    "context"
+    "encoding/json"
+    "fmt"
+    "log/slog"
+    "net/http"
+    "time"
+
+    "github.com/google/uuid"
+    "github.com/Shridhar2104/chat-platform/auth-service/internal/tracing"
+    "github.com/Shridhar2104/chat-platform/shared/models"
+)
+
+// Notifier delivers security notifications to users
+type Notifier interface {
+    SendNewSignInNotification(user *models.User, event *models.LoginEvent) error
+    SendLoginConfirmation(user *models.User, code string) error
+}
+
+// LogNotifier writes notifications to the service log until an email provider is wired in
+type LogNotifier struct{}
+
+func NewLogNotifier() *LogNotifier {
+    return &LogNotifier{}
+}
+
+func (n *LogNotifier) SendNewSignInNotification(user *models.User, event *models.LoginEvent) error {
+    slog.Info("New sign-in notification", "user_id", user.ID, "ip_address", event.IPAddress, "device_id", event.DeviceID)
+    return nil
+}
+
+func (n *LogNotifier) SendLoginConfirmation(user *models.User, code string) error {
+    slog.Info("Login confirmation code issued", "user_id", user.ID)
+    return nil
+}
+
+// WebhookNotifier posts notifications as JSON to a delivery service that
+// emails them to the user
+type WebhookNotifier struct {
+    url    string
+    token  string
+    client *http.Client
+}
+
+// webhookNotification is the body posted for each notification
+type webhookNotification struct {
+    Type      string    `json:"type"`
+    UserID    uuid.UUID `json:"user_id"`
+    Email     string    `json:"email"`
+    Code      string    `json:"code,omitempty"`
+    IPAddress string    `json:"ip_address,omitempty"`
+    DeviceID  string    `json:"device_id,omitempty"`
+}
+
+func NewWebhookNotifier(url, token string) *WebhookNotifier {
+    return &WebhookNotifier{
+        url:    url,
+        token:  token,
+        client: &http.Client{Timeout: 5 * time.Second, Transport: tracing.Transport(nil)},
+    }
+}
+
+func (n *WebhookNotifier) SendNewSignInNotification(user *models.User, event *models.LoginEvent) error {
+    return n.post(webhookNotification{
+        Type:      "new_sign_in",
+        UserID:    user.ID,
+        Email:     user.Email,
+        IPAddress: event.IPAddress,
+        DeviceID:  event.DeviceID,
+    })
+}
+
+func (n *WebhookNotifier) SendLoginConfirmation(user *models.User, code string) error {
+    return n.post(webhookNotification{
+        Type:   "login_confirmation",
+        UserID: user.ID,
+        Email:  user.Email,
+        Code:   code,
+    })
+}
+
+func (n *WebhookNotifier) post(notification webhookNotification) error {
+    body, err := json.Marshal(notification)
+    if err != nil {
+        return fmt.Errorf("failed to encode notification: %w", err)
+    }
+    req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, n.url, bytes.NewReader(body))
+    if err != nil {
+        return fmt.Errorf("failed to build notification request: %w", err)
+    }
+    req.Header.Set("Content-Type", "application/json")
+    if n.token != "" {
+        req.Header.Set("Authorization", "Bearer "+n.token)
+    }
+
+    resp, err := n.client.Do(req)
+    if err != nil {
+        return fmt.Errorf("failed to deliver notification: %w", err)
+    }
+    defer resp.Body.Close()
+    if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+        return fmt.Errorf("notification webhook returned %s", resp.Status)
+    }
+    return nil
+}
\ No newline at end of file
@@ -0,0 +1,53 @@
+package services
+
+import (
+    "encoding/json"
+    "net/http"
+    "net/http/httptest"
+    "testing"
+
+    "github.com/google/uuid"
+    "github.com/Shridhar2104/chat-platform/shared/models"
+)
+
+func TestWebhookNotifier(t *testing.T) {
+    var got webhookNotification
+    var authorization string
+    status := http.StatusAccepted
+    server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        authorization = r.Header.Get("Authorization")
+        got = webhookNotification{}
+        if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+            t.Errorf("failed to decode notification: %v", err)
+        }
+        w.WriteHeader(status)
+    }))
+    t.Cleanup(server.Close)
+
+    notifier := NewWebhookNotifier(server.URL, "hook-token")
+    user := &models.User{ID: uuid.New(), Email: "alice@example.com"}
+
+    if err := notifier.SendLoginConfirmation(user, "123456"); err != nil {
+        t.Fatalf("SendLoginConfirmation failed: %v", err)
+    }
+    if got.Type != "login_confirmation" || got.Code != "123456" || got.Email != user.Email || got.UserID != user.ID {
+        t.Errorf("notification = %+v", got)
+    }
+    if authorization != "Bearer hook-token" {
+        t.Errorf("Authorization = %q", authorization)
+    }
+
+    event := &models.LoginEvent{IPAddress: "198.51.100.20", DeviceID: "phone"}
+    if err := notifier.SendNewSignInNotification(user, event); err != nil {
+        t.Fatalf("SendNewSignInNotification failed: %v", err)
+    }
+    if got.Type != "new_sign_in" || got.IPAddress != event.IPAddress || got.DeviceID != event.DeviceID || got.Code != "" {
+        t.Errorf("notification = %+v", got)
+    }
+
+    // A code the delivery service did not accept must fail the step-up
+    status = http.StatusBadGateway
+    if err := notifier.SendLoginConfirmation(user, "123456"); err == nil {
+        t.Error("expected an error when the webhook rejects the notification")
+    }
+}
\ No newline at end of file
@@ -0,0 +1,216 @@
+package services
+
+import (
+    "math"
+    "net"
+    "time"
+
+    "github.com/Shridhar2104/chat-platform/shared/models"
+)
+
+type RiskLevel string
+
+const (
+    RiskLevelLow    RiskLevel = "low"
+    RiskLevelMedium RiskLevel = "medium"
+    RiskLevelHigh   RiskLevel = "high"
+)
+
+const (
+    RiskReasonNewDevice        = "new_device"
+    RiskReasonNewIPRange       = "new_ip_range"
+    RiskReasonNewCountry       = "new_country"
+    RiskReasonImpossibleTravel = "impossible_travel"
+)
+
+// RiskConfig holds the scoring rules used by RiskEngine
+type RiskConfig struct {
+    NewDeviceScore        int
+    NewIPRangeScore       int
+    NewCountryScore       int
+    ImpossibleTravelScore int
+
+    MediumThreshold int
+    HighThreshold   int
+
+    // Travel faster than this between two logins is considered impossible
+    MaxTravelSpeedKMH float64
+    // Distances below this are treated as GeoIP noise and never flagged
+    MinTravelDistanceKM float64
+
+    // Number of previous logins to compare against
+    HistorySize int
+
+    // StepUp confirms high-risk logins with a code sent by the notifier.
+    // Without it they are only notified, like medium-risk ones.
+    StepUp bool
+}
+
+func DefaultRiskConfig() RiskConfig {
+    return RiskConfig{
+        NewDeviceScore:        30,
+        NewIPRangeScore:       20,
+        NewCountryScore:       20,
+        ImpossibleTravelScore: 60,
+        MediumThreshold:       30,
+        HighThreshold:         60,
+        MaxTravelSpeedKMH:     900,
+        MinTravelDistanceKM:   300,
+        HistorySize:           50,
+        StepUp:                true,
+    }
+}
+
+type LoginAttempt struct {
+    DeviceID  string
+    IPAddress string
+    Time      time.Time
+}
+
+type RiskAssessment struct {
+    Score    int
+    Level    RiskLevel
+    Reasons  []string
+    IPPrefix string
+    Location *GeoLocation
+}
+
+type RiskEngine struct {
+    config RiskConfig
+    geo    GeoLocator
+}
+
+// NewRiskEngine creates a risk engine, geo may be nil to disable location rules
+func NewRiskEngine(config RiskConfig, geo GeoLocator) *RiskEngine {
+    return &RiskEngine{
+        config: config,
+        geo:    geo,
+    }
+}
+
+func (e *RiskEngine) HistorySize() int {
+    return e.config.HistorySize
+}
+
+// StepUpEnabled reports whether high-risk logins must be confirmed with a code
+func (e *RiskEngine) StepUpEnabled() bool {
+    return e.config.StepUp
+}
+
+// Assess scores a login attempt against the user's previous logins (newest first)
+func (e *RiskEngine) Assess(attempt LoginAttempt, history []models.LoginEvent) RiskAssessment {
+    assessment := RiskAssessment{
+        Level:    RiskLevelLow,
+        IPPrefix: ipPrefix(attempt.IPAddress),
+    }
+
+    if e.geo != nil {
+        if location, err := e.geo.Lookup(attempt.IPAddress); err == nil {
+            assessment.Location = location
+        }
+    }
+
+    // Nothing to compare against on the very first login
+    if len(history) == 0 {
+        return assessment
+    }
+
+    knownDevice := false
+    knownPrefix := false
+    knownCountry := false
+    for _, event := range history {
+        if event.DeviceID == attempt.DeviceID {
+            knownDevice = true
+        }
+        if event.IPPrefix == assessment.IPPrefix {
+            knownPrefix = true
+        }
+        if assessment.Location != nil && event.CountryCode != nil && *event.CountryCode == assessment.Location.CountryCode {
+            knownCountry = true
+        }
+    }
+
+    if !knownDevice {
+        assessment.addReason(RiskReasonNewDevice, e.config.NewDeviceScore)
+    }
+    if !knownPrefix {
+        assessment.addReason(RiskReasonNewIPRange, e.config.NewIPRangeScore)
+    }
+    if assessment.Location != nil && assessment.Location.CountryCode != "" && !knownCountry {
+        assessment.addReason(RiskReasonNewCountry, e.config.NewCountryScore)
+    }
+    if e.isImpossibleTravel(attempt, assessment.Location, history) {
+        assessment.addReason(RiskReasonImpossibleTravel, e.config.ImpossibleTravelScore)
+    }
+
+    switch {
+    case assessment.Score >= e.config.HighThreshold:
+        assessment.Level = RiskLevelHigh
+    case assessment.Score >= e.config.MediumThreshold:
+        assessment.Level = RiskLevelMedium
+    }
+
+    return assessment
+}
+
+// isImpossibleTravel compares against the most recent login that has coordinates
+func (e *RiskEngine) isImpossibleTravel(attempt LoginAttempt, location *GeoLocation, history []models.LoginEvent) bool {
+    if location == nil {
+        return false
+    }
+
+    for _, event := range history {
+        if event.Latitude == nil || event.Longitude == nil {
+            continue
+        }
+
+        distance := haversineKM(*event.Latitude, *event.Longitude, location.Latitude, location.Longitude)
+        if distance < e.config.MinTravelDistanceKM {
+            return false
+        }
+
+        // Clamp to one minute so back-to-back logins don't divide by zero
+        elapsed := attempt.Time.Sub(event.CreatedAt)
+        if elapsed < time.Minute {
+            elapsed = time.Minute
+        }
+
+        return distance/elapsed.Hours() > e.config.MaxTravelSpeedKMH
+    }
+
+    return false
+}
+
+func (a *RiskAssessment) addReason(reason string, score int) {
+    a.Reasons = append(a.Reasons, reason)
+    a.Score += score
+}
+
+// ipPrefix groups addresses into /24 (IPv4) or /48 (IPv6) ranges
+func ipPrefix(ipAddress string) string {
+    ip := net.ParseIP(ipAddress)
+    if ip == nil {
+        return ipAddress
+    }
+
+    if ip4 := ip.To4(); ip4 != nil {
+        network := &net.IPNet{IP: ip4.Mask(net.CIDRMask(24, 32)), Mask: net.CIDRMask(24, 32)}
+        return network.String()
+    }
+
+    network := &net.IPNet{IP: ip.Mask(net.CIDRMask(48, 128)), Mask: net.CIDRMask(48, 128)}
+    return network.String()
+}
+
+// haversineKM returns the great-circle distance between two coordinates
+func haversineKM(lat1, lon1, lat2, lon2 float64) float64 {
+    const earthRadiusKM = 6371.0
+
+    dLat := (lat2 - lat1) * math.Pi / 180
+    dLon := (lon2 - lon1) * math.Pi / 180
+
+    a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+        math.Cos(lat1*math.Pi/180)*math.Cos(lat2*math.Pi/180)*math.Sin(dLon/2)*math.Sin(dLon/2)
+
+    return earthRadiusKM * 2 * math.Atan2(math.Sqrt(a), math.Sqrt(1-a))
+}
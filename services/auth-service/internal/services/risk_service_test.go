@@ -0,0 +1,198 @@
+package services
+
+import (
+    "testing"
+    "time"
+
+    "github.com/google/uuid"
+    "github.com/Shridhar2104/chat-platform/shared/models"
+)
+
+func newFixtureRiskEngine(t *testing.T, config RiskConfig) *RiskEngine {
+    t.Helper()
+
+    geo, err := NewMaxMindGeoLocator(writeFixtureGeoIPDatabase(t))
+    if err != nil {
+        t.Fatalf("failed to open fixture database: %v", err)
+    }
+    t.Cleanup(func() { geo.Close() })
+
+    return NewRiskEngine(config, geo)
+}
+
+// loginEventAt builds a history entry located with the fixture database
+func loginEventAt(t *testing.T, engine *RiskEngine, deviceID, ipAddress string, at time.Time) models.LoginEvent {
+    t.Helper()
+
+    event := models.LoginEvent{
+        ID:        uuid.New(),
+        DeviceID:  deviceID,
+        IPAddress: ipAddress,
+        IPPrefix:  ipPrefix(ipAddress),
+        CreatedAt: at,
+    }
+    if location, err := engine.geo.Lookup(ipAddress); err == nil {
+        event.CountryCode = &location.CountryCode
+        event.City = &location.City
+        event.Latitude = &location.Latitude
+        event.Longitude = &location.Longitude
+    }
+    return event
+}
+
+func TestMaxMindGeoLocatorLookup(t *testing.T) {
+    geo, err := NewMaxMindGeoLocator(writeFixtureGeoIPDatabase(t))
+    if err != nil {
+        t.Fatalf("failed to open fixture database: %v", err)
+    }
+    defer geo.Close()
+
+    location, err := geo.Lookup("203.0.113.10")
+    if err != nil {
+        t.Fatalf("Lookup returned error: %v", err)
+    }
+    if location.CountryCode != "DE" || location.City != "Berlin" {
+        t.Errorf("got %s/%s, want DE/Berlin", location.CountryCode, location.City)
+    }
+    if location.Latitude != 52.52 || location.Longitude != 13.40 {
+        t.Errorf("got coordinates %v,%v, want 52.52,13.40", location.Latitude, location.Longitude)
+    }
+
+    if _, err := geo.Lookup("10.0.0.1"); err == nil {
+        t.Error("expected error for address missing from database")
+    }
+    if _, err := geo.Lookup("not-an-ip"); err == nil {
+        t.Error("expected error for invalid address")
+    }
+}
+
+func TestRiskEngineAssess(t *testing.T) {
+    now := time.Date(2026, 1, 15, 12, 0, 0, 0, time.UTC)
+    engine := newFixtureRiskEngine(t, DefaultRiskConfig())
+
+    tests := []struct {
+        name    string
+        attempt LoginAttempt
+        history []models.LoginEvent
+        level   RiskLevel
+        reasons []string
+    }{
+        {
+            name:    "first login has nothing to compare against",
+            attempt: LoginAttempt{DeviceID: "laptop", IPAddress: "203.0.113.10", Time: now},
+            level:   RiskLevelLow,
+        },
+        {
+            name:    "known device and network",
+            attempt: LoginAttempt{DeviceID: "laptop", IPAddress: "203.0.113.20", Time: now},
+            history: []models.LoginEvent{
+                loginEventAt(t, engine, "laptop", "203.0.113.10", now.Add(-24*time.Hour)),
+            },
+            level: RiskLevelLow,
+        },
+        {
+            name:    "new device on known network",
+            attempt: LoginAttempt{DeviceID: "phone", IPAddress: "203.0.113.20", Time: now},
+            history: []models.LoginEvent{
+                loginEventAt(t, engine, "laptop", "203.0.113.10", now.Add(-24*time.Hour)),
+            },
+            level:   RiskLevelMedium,
+            reasons: []string{RiskReasonNewDevice},
+        },
+        {
+            name:    "new network in nearby city",
+            attempt: LoginAttempt{DeviceID: "laptop", IPAddress: "192.0.2.5", Time: now},
+            history: []models.LoginEvent{
+                loginEventAt(t, engine, "laptop", "203.0.113.10", now.Add(-time.Hour)),
+            },
+            level:   RiskLevelLow,
+            reasons: []string{RiskReasonNewIPRange},
+        },
+        {
+            name:    "impossible travel from Berlin to New York",
+            attempt: LoginAttempt{DeviceID: "unknown", IPAddress: "198.51.100.7", Time: now},
+            history: []models.LoginEvent{
+                loginEventAt(t, engine, "laptop", "203.0.113.10", now.Add(-time.Hour)),
+            },
+            level:   RiskLevelHigh,
+            reasons: []string{RiskReasonNewDevice, RiskReasonNewIPRange, RiskReasonNewCountry, RiskReasonImpossibleTravel},
+        },
+        {
+            name:    "plausible travel from Berlin to New York",
+            attempt: LoginAttempt{DeviceID: "laptop", IPAddress: "198.51.100.7", Time: now},
+            history: []models.LoginEvent{
+                loginEventAt(t, engine, "laptop", "203.0.113.10", now.Add(-48*time.Hour)),
+            },
+            level:   RiskLevelMedium,
+            reasons: []string{RiskReasonNewIPRange, RiskReasonNewCountry},
+        },
+        {
+            name:    "unlocated address skips location rules",
+            attempt: LoginAttempt{DeviceID: "laptop", IPAddress: "10.0.0.1", Time: now},
+            history: []models.LoginEvent{
+                loginEventAt(t, engine, "laptop", "203.0.113.10", now.Add(-time.Minute)),
+            },
+            level:   RiskLevelLow,
+            reasons: []string{RiskReasonNewIPRange},
+        },
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            assessment := engine.Assess(tt.attempt, tt.history)
+
+            if assessment.Level != tt.level {
+                t.Errorf("level = %s (score %d), want %s", assessment.Level, assessment.Score, tt.level)
+            }
+            if len(assessment.Reasons) != len(tt.reasons) {
+                t.Fatalf("reasons = %v, want %v", assessment.Reasons, tt.reasons)
+            }
+            for i := range tt.reasons {
+                if assessment.Reasons[i] != tt.reasons[i] {
+                    t.Errorf("reasons = %v, want %v", assessment.Reasons, tt.reasons)
+                    break
+                }
+            }
+        })
+    }
+}
+
+func TestRiskEngineConfigurableRules(t *testing.T) {
+    now := time.Date(2026, 1, 15, 12, 0, 0, 0, time.UTC)
+
+    config := DefaultRiskConfig()
+    config.NewDeviceScore = 0
+    config.MaxTravelSpeedKMH = 10000
+    engine := newFixtureRiskEngine(t, config)
+
+    history := []models.LoginEvent{
+        loginEventAt(t, engine, "laptop", "203.0.113.10", now.Add(-time.Hour)),
+    }
+    assessment := engine.Assess(LoginAttempt{DeviceID: "phone", IPAddress: "198.51.100.7", Time: now}, history)
+
+    // Berlin to New York in an hour is ~6400 km/h, allowed by the raised limit
+    for _, reason := range assessment.Reasons {
+        if reason == RiskReasonImpossibleTravel {
+            t.Errorf("impossible travel flagged despite raised speed limit")
+        }
+    }
+    if assessment.Score != config.NewIPRangeScore+config.NewCountryScore {
+        t.Errorf("score = %d, want %d", assessment.Score, config.NewIPRangeScore+config.NewCountryScore)
+    }
+    if assessment.Level != RiskLevelMedium {
+        t.Errorf("level = %s, want %s", assessment.Level, RiskLevelMedium)
+    }
+}
+
+func TestIPPrefix(t *testing.T) {
+    tests := map[string]string{
+        "203.0.113.77":        "203.0.113.0/24",
+        "2001:db8:abcd:12::1": "2001:db8:abcd::/48",
+        "garbage":             "garbage",
+    }
+    for input, want := range tests {
+        if got := ipPrefix(input); got != want {
+            t.Errorf("ipPrefix(%q) = %q, want %q", input, got, want)
+        }
+    }
+}
@@ -0,0 +1,782 @@
+package services
+
+import (
+    "bytes"
+    "compress/flate"
+    "context"
+    "crypto/x509"
+    "encoding/base64"
+    "encoding/json"
+    "encoding/pem"
+    "encoding/xml"
+    "errors"
+    "fmt"
+    "net/url"
+    "strings"
+    "time"
+
+    "github.com/beevik/etree"
+    "github.com/google/uuid"
+    dsig "github.com/russellhaering/goxmldsig"
+    "github.com/Shridhar2104/chat-platform/shared/database"
+    "github.com/Shridhar2104/chat-platform/shared/models"
+    "github.com/Shridhar2104/chat-platform/auth-service/internal/repository"
+)
+
+const (
+    samlProtocolNamespace  = "urn:oasis:names:tc:SAML:2.0:protocol"
+    samlAssertionNamespace = "urn:oasis:names:tc:SAML:2.0:assertion"
+    samlMetadataNamespace  = "urn:oasis:names:tc:SAML:2.0:metadata"
+    xmlDSigNamespace       = "http://www.w3.org/2000/09/xmldsig#"
+
+    samlStatusSuccess      = "urn:oasis:names:tc:SAML:2.0:status:Success"
+    samlBearerConfirmation = "urn:oasis:names:tc:SAML:2.0:cm:bearer"
+    samlHTTPPostBinding    = "urn:oasis:names:tc:SAML:2.0:bindings:HTTP-POST"
+    samlHTTPRedirect       = "urn:oasis:names:tc:SAML:2.0:bindings:HTTP-Redirect"
+    samlNameIDFormatEmail  = "urn:oasis:names:tc:SAML:1.1:nameid-format:emailAddress"
+
+    samlRequestTTL       = 10 * time.Minute
+    samlClockSkew        = 3 * time.Minute
+    samlRequestKeyPrefix = "saml_request"
+    samlReplayKeyPrefix  = "saml_assertion"
+)
+
+// SAMLAssertion holds the validated contents of an IdP assertion
+type SAMLAssertion struct {
+    ID           string
+    Issuer       string
+    NameID       string
+    InResponseTo string
+    NotOnOrAfter time.Time
+    Attributes   map[string][]string
+}
+
+// SAMLStore is the storage behind SAMLService, SAMLRepository implements it
+type SAMLStore interface {
+    GetIdentityProviderByWorkspace(ctx context.Context, workspaceID uuid.UUID) (*models.SAMLIdentityProvider, error)
+    UpsertIdentityProvider(ctx context.Context, idp *models.SAMLIdentityProvider) error
+}
+
+var _ SAMLStore = (*repository.SAMLRepository)(nil)
+
+// IdentityProviderSettings configure a workspace's IdP, either from its
+// metadata document or from an entity ID, SSO URL and certificate
+type IdentityProviderSettings struct {
+    Metadata             string
+    EntityID             string
+    SSOURL               string
+    Certificate          string
+    EmailAttribute       string
+    DisplayNameAttribute string
+    Enabled              bool
+}
+
+type SAMLService struct {
+    samlRepo    SAMLStore
+    authService *AuthService
+    redis       *database.RedisClient
+    baseURL     string
+}
+
+type pendingSAMLRequest struct {
+    WorkspaceID uuid.UUID `json:"workspace_id"`
+    DeviceID    string    `json:"device_id"`
+}
+
+func NewSAMLService(samlRepo SAMLStore, authService *AuthService, redis *database.RedisClient, baseURL string) *SAMLService {
+    return &SAMLService{
+        samlRepo:    samlRepo,
+        authService: authService,
+        redis:       redis,
+        baseURL:     strings.TrimRight(baseURL, "/"),
+    }
+}
+
+// EntityID is the SP entity ID for a workspace, it doubles as the metadata URL
+func (s *SAMLService) EntityID(workspaceID uuid.UUID) string {
+    return fmt.Sprintf("%s/api/v1/auth/saml/%s/metadata", s.baseURL, workspaceID)
+}
+
+// ACSURL is where the IdP posts its response for a workspace
+func (s *SAMLService) ACSURL(workspaceID uuid.UUID) string {
+    return fmt.Sprintf("%s/api/v1/auth/saml/%s/acs", s.baseURL, workspaceID)
+}
+
+// Metadata generates the SP metadata document for a workspace
+func (s *SAMLService) Metadata(workspaceID uuid.UUID) ([]byte, error) {
+    metadata := spMetadata{
+        XMLNS:    samlMetadataNamespace,
+        EntityID: s.EntityID(workspaceID),
+        SPSSODescriptor: spSSODescriptor{
+            AuthnRequestsSigned:        false,
+            WantAssertionsSigned:       true,
+            ProtocolSupportEnumeration: samlProtocolNamespace,
+            NameIDFormats:              []string{samlNameIDFormatEmail},
+            AssertionConsumerServices: []indexedEndpoint{{
+                Binding:   samlHTTPPostBinding,
+                Location:  s.ACSURL(workspaceID),
+                Index:     0,
+                IsDefault: true,
+            }},
+        },
+    }
+
+    data, err := xml.MarshalIndent(metadata, "", "  ")
+    if err != nil {
+        return nil, fmt.Errorf("failed to marshal metadata: %w", err)
+    }
+    return append([]byte(xml.Header), data...), nil
+}
+
+// CreateAuthnRequest builds an HTTP-Redirect binding URL that starts SSO at the workspace IdP
+func (s *SAMLService) CreateAuthnRequest(ctx context.Context, workspaceID uuid.UUID, deviceID string) (string, error) {
+    idp, err := s.getEnabledIdentityProvider(ctx, workspaceID)
+    if err != nil {
+        return "", err
+    }
+
+    requestID, err := s.authService.generateSecureToken()
+    if err != nil {
+        return "", fmt.Errorf("failed to generate request ID: %w", err)
+    }
+    // IDs must be valid xsd:ID values, which cannot start with a digit
+    requestID = "id-" + requestID
+
+    request := authnRequest{
+        XMLNS:                       samlProtocolNamespace,
+        SAMLNS:                      samlAssertionNamespace,
+        ID:                          requestID,
+        Version:                     "2.0",
+        IssueInstant:                time.Now().UTC().Format(time.RFC3339),
+        Destination:                 idp.SSOURL,
+        ProtocolBinding:             samlHTTPPostBinding,
+        AssertionConsumerServiceURL: s.ACSURL(workspaceID),
+        Issuer:                      s.EntityID(workspaceID),
+        NameIDPolicy: nameIDPolicy{
+            Format:      samlNameIDFormatEmail,
+            AllowCreate: true,
+        },
+    }
+
+    data, err := xml.Marshal(request)
+    if err != nil {
+        return "", fmt.Errorf("failed to marshal authn request: %w", err)
+    }
+
+    // HTTP-Redirect binding: raw DEFLATE, then base64, then URL encoding
+    var compressed bytes.Buffer
+    writer, err := flate.NewWriter(&compressed, flate.BestCompression)
+    if err != nil {
+        return "", fmt.Errorf("failed to compress authn request: %w", err)
+    }
+    writer.Write(data)
+    writer.Close()
+
+    pending, err := json.Marshal(pendingSAMLRequest{WorkspaceID: workspaceID, DeviceID: deviceID})
+    if err != nil {
+        return "", fmt.Errorf("failed to encode pending request: %w", err)
+    }
+    key := fmt.Sprintf("%s:%s", samlRequestKeyPrefix, requestID)
+    if err := s.redis.Client.Set(ctx, key, pending, samlRequestTTL).Err(); err != nil {
+        return "", fmt.Errorf("failed to store authn request: %w", err)
+    }
+
+    redirectURL, err := url.Parse(idp.SSOURL)
+    if err != nil {
+        return "", fmt.Errorf("invalid identity provider SSO URL: %w", err)
+    }
+    query := redirectURL.Query()
+    query.Set("SAMLRequest", base64.StdEncoding.EncodeToString(compressed.Bytes()))
+    query.Set("RelayState", requestID)
+    redirectURL.RawQuery = query.Encode()
+
+    return redirectURL.String(), nil
+}
+
+// HandleResponse validates a POSTed SAMLResponse and signs the asserted user in
+func (s *SAMLService) HandleResponse(ctx context.Context, workspaceID uuid.UUID, encodedResponse string) (*models.User, string, string, time.Time, error) {
+    idp, err := s.getEnabledIdentityProvider(ctx, workspaceID)
+    if err != nil {
+        return nil, "", "", time.Time{}, err
+    }
+
+    rawResponse, err := base64.StdEncoding.DecodeString(encodedResponse)
+    if err != nil {
+        return nil, "", "", time.Time{}, fmt.Errorf("%w: invalid SAML response encoding", ErrSSOAssertionRejected)
+    }
+
+    validator, err := newSAMLValidator(idp, s.EntityID(workspaceID), s.ACSURL(workspaceID))
+    if err != nil {
+        return nil, "", "", time.Time{}, err
+    }
+
+    assertion, err := validator.Validate(rawResponse, time.Now())
+    if err != nil {
+        return nil, "", "", time.Time{}, fmt.Errorf("%w: %v", ErrSSOAssertionRejected, err)
+    }
+
+    // Only accept responses to requests we issued (no IdP-initiated SSO)
+    requestKey := fmt.Sprintf("%s:%s", samlRequestKeyPrefix, assertion.InResponseTo)
+    data, err := s.redis.Client.GetDel(ctx, requestKey).Bytes()
+    if err != nil {
+        return nil, "", "", time.Time{}, fmt.Errorf("%w: unknown or expired SAML request", ErrSSOAssertionRejected)
+    }
+    var pending pendingSAMLRequest
+    if err := json.Unmarshal(data, &pending); err != nil {
+        return nil, "", "", time.Time{}, fmt.Errorf("failed to decode pending request: %w", err)
+    }
+    if pending.WorkspaceID != workspaceID {
+        return nil, "", "", time.Time{}, fmt.Errorf("%w: SAML response does not match workspace", ErrSSOAssertionRejected)
+    }
+
+    // Reject replays of an assertion that was already consumed
+    replayKey := fmt.Sprintf("%s:%s", samlReplayKeyPrefix, assertion.ID)
+    replayTTL := time.Until(assertion.NotOnOrAfter) + samlClockSkew
+    fresh, err := s.redis.Client.SetNX(ctx, replayKey, 1, replayTTL).Result()
+    if err != nil {
+        return nil, "", "", time.Time{}, fmt.Errorf("failed to record assertion: %w", err)
+    }
+    if !fresh {
+        return nil, "", "", time.Time{}, fmt.Errorf("%w: SAML assertion already used", ErrSSOAssertionRejected)
+    }
+
+    email, displayName, err := MapSAMLUser(idp, assertion)
+    if err != nil {
+        return nil, "", "", time.Time{}, fmt.Errorf("%w: %v", ErrSSOAssertionRejected, err)
+    }
+
+    return s.authService.LoginWithExternalIdentity(ctx, workspaceID, email, displayName, pending.DeviceID)
+}
+
+func (s *SAMLService) getEnabledIdentityProvider(ctx context.Context, workspaceID uuid.UUID) (*models.SAMLIdentityProvider, error) {
+    idp, err := s.samlRepo.GetIdentityProviderByWorkspace(ctx, workspaceID)
+    if err != nil {
+        return nil, err
+    }
+    if !idp.Enabled {
+        return nil, ErrIdentityProviderDisabled
+    }
+    return idp, nil
+}
+
+// IdentityProvider returns the workspace's IdP configuration
+func (s *SAMLService) IdentityProvider(ctx context.Context, workspaceID uuid.UUID) (*models.SAMLIdentityProvider, error) {
+    return s.samlRepo.GetIdentityProviderByWorkspace(ctx, workspaceID)
+}
+
+// ConfigureIdentityProvider validates and stores the workspace's IdP. A
+// configuration that could never verify an assertion is rejected here
+// rather than at the first sign-in.
+func (s *SAMLService) ConfigureIdentityProvider(ctx context.Context, workspaceID uuid.UUID, settings IdentityProviderSettings) (*models.SAMLIdentityProvider, error) {
+    idp := &models.SAMLIdentityProvider{
+        ID:                   uuid.New(),
+        WorkspaceID:          workspaceID,
+        EntityID:             strings.TrimSpace(settings.EntityID),
+        SSOURL:               strings.TrimSpace(settings.SSOURL),
+        Certificate:          strings.TrimSpace(settings.Certificate),
+        EmailAttribute:       strings.TrimSpace(settings.EmailAttribute),
+        DisplayNameAttribute: strings.TrimSpace(settings.DisplayNameAttribute),
+        Enabled:              settings.Enabled,
+        CreatedAt:            time.Now(),
+    }
+    if settings.Metadata != "" {
+        if idp.EntityID != "" || idp.SSOURL != "" || idp.Certificate != "" {
+            return nil, fmt.Errorf("%w: give either metadata or entity_id, sso_url and certificate", ErrInvalidIdentityProvider)
+        }
+        metadata, err := parseIdPMetadata(settings.Metadata)
+        if err != nil {
+            return nil, err
+        }
+        idp.EntityID, idp.SSOURL, idp.Certificate = metadata.EntityID, metadata.SSOURL, metadata.Certificate
+    }
+    if err := validateIdentityProvider(idp, time.Now()); err != nil {
+        return nil, err
+    }
+
+    existing, err := s.samlRepo.GetIdentityProviderByWorkspace(ctx, workspaceID)
+    switch {
+    case err == nil:
+        idp.ID, idp.CreatedAt = existing.ID, existing.CreatedAt
+    case !errors.Is(err, repository.ErrIdentityProviderNotFound):
+        return nil, err
+    }
+    if err := s.samlRepo.UpsertIdentityProvider(ctx, idp); err != nil {
+        return nil, err
+    }
+    idp.UpdatedAt = time.Now()
+    return idp, nil
+}
+
+// validateIdentityProvider checks the settings SSO depends on: an issuer to
+// match, an https SSO URL to redirect to and a current signing certificate
+func validateIdentityProvider(idp *models.SAMLIdentityProvider, now time.Time) error {
+    if idp.EntityID == "" || len(idp.EntityID) > 1024 {
+        return fmt.Errorf("%w: entity_id is required and at most 1024 characters", ErrInvalidIdentityProvider)
+    }
+    ssoURL, err := url.Parse(idp.SSOURL)
+    if err != nil || ssoURL.Scheme != "https" || ssoURL.Host == "" {
+        return fmt.Errorf("%w: sso_url must be an https URL", ErrInvalidIdentityProvider)
+    }
+
+    certificates, err := parseIdPCertificates(idp.Certificate)
+    if err != nil {
+        return fmt.Errorf("%w: %v", ErrInvalidIdentityProvider, err)
+    }
+    for _, certificate := range certificates {
+        if now.Before(certificate.NotAfter) {
+            return nil
+        }
+    }
+    return fmt.Errorf("%w: every signing certificate has expired", ErrInvalidIdentityProvider)
+}
+
+// idpSettings are the values taken from an IdP metadata document
+type idpSettings struct {
+    EntityID    string
+    SSOURL      string
+    Certificate string
+}
+
+// parseIdPMetadata reads the entity ID, the HTTP-Redirect SSO endpoint and
+// the signing certificates from an IdP's EntityDescriptor
+func parseIdPMetadata(data string) (*idpSettings, error) {
+    var metadata idpMetadata
+    if err := xml.Unmarshal([]byte(data), &metadata); err != nil {
+        return nil, fmt.Errorf("%w: malformed metadata: %v", ErrInvalidIdentityProvider, err)
+    }
+    if metadata.XMLName.Space != samlMetadataNamespace || metadata.XMLName.Local != "EntityDescriptor" {
+        return nil, fmt.Errorf("%w: metadata must be an EntityDescriptor", ErrInvalidIdentityProvider)
+    }
+    descriptor := metadata.IDPSSODescriptor
+    if descriptor == nil {
+        return nil, fmt.Errorf("%w: metadata has no IDPSSODescriptor", ErrInvalidIdentityProvider)
+    }
+
+    settings := &idpSettings{EntityID: strings.TrimSpace(metadata.EntityID)}
+    // AuthnRequests are sent with the HTTP-Redirect binding
+    for _, service := range descriptor.SingleSignOnServices {
+        if service.Binding == samlHTTPRedirect {
+            settings.SSOURL = strings.TrimSpace(service.Location)
+            break
+        }
+    }
+    if settings.SSOURL == "" {
+        return nil, fmt.Errorf("%w: metadata has no HTTP-Redirect SingleSignOnService", ErrInvalidIdentityProvider)
+    }
+
+    var certificates []byte
+    for _, key := range descriptor.KeyDescriptors {
+        if key.Use != "" && key.Use != "signing" {
+            continue
+        }
+        for _, encoded := range key.Certificates {
+            der, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(encoded), ""))
+            if err != nil {
+                return nil, fmt.Errorf("%w: invalid certificate encoding in metadata", ErrInvalidIdentityProvider)
+            }
+            certificates = append(certificates, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})...)
+        }
+    }
+    if len(certificates) == 0 {
+        return nil, fmt.Errorf("%w: metadata has no signing certificate", ErrInvalidIdentityProvider)
+    }
+    settings.Certificate = strings.TrimSpace(string(certificates))
+    return settings, nil
+}
+
+// MapSAMLUser maps assertion attributes onto the fields of models.User
+func MapSAMLUser(idp *models.SAMLIdentityProvider, assertion *SAMLAssertion) (string, string, error) {
+    email := assertion.NameID
+    if idp.EmailAttribute != "" {
+        email = firstAttribute(assertion, idp.EmailAttribute)
+    }
+    email = strings.ToLower(strings.TrimSpace(email))
+    if email == "" || !strings.Contains(email, "@") {
+        return "", "", fmt.Errorf("SAML assertion does not contain an email address")
+    }
+
+    displayName := ""
+    if idp.DisplayNameAttribute != "" {
+        displayName = strings.TrimSpace(firstAttribute(assertion, idp.DisplayNameAttribute))
+    }
+
+    return email, displayName, nil
+}
+
+func firstAttribute(assertion *SAMLAssertion, name string) string {
+    if values := assertion.Attributes[name]; len(values) > 0 {
+        return values[0]
+    }
+    return ""
+}
+
+// samlValidator checks a SAML response against a single IdP configuration
+type samlValidator struct {
+    idpEntityID  string
+    certificates []*x509.Certificate
+    spEntityID   string
+    acsURL       string
+}
+
+func newSAMLValidator(idp *models.SAMLIdentityProvider, spEntityID, acsURL string) (*samlValidator, error) {
+    certificates, err := parseIdPCertificates(idp.Certificate)
+    if err != nil {
+        return nil, err
+    }
+    return &samlValidator{
+        idpEntityID:  idp.EntityID,
+        certificates: certificates,
+        spEntityID:   spEntityID,
+        acsURL:       acsURL,
+    }, nil
+}
+
+// Validate verifies the signature and conditions of a decoded SAML response.
+// Only content covered by a valid signature is trusted.
+func (v *samlValidator) Validate(rawResponse []byte, now time.Time) (*SAMLAssertion, error) {
+    doc := etree.NewDocument()
+    if err := doc.ReadFromBytes(rawResponse); err != nil {
+        return nil, fmt.Errorf("malformed SAML response: %w", err)
+    }
+
+    response := doc.Root()
+    if response == nil || response.Tag != "Response" || response.NamespaceURI() != samlProtocolNamespace {
+        return nil, fmt.Errorf("malformed SAML response: missing Response element")
+    }
+    if len(childElements(response, samlAssertionNamespace, "EncryptedAssertion")) > 0 {
+        return nil, fmt.Errorf("encrypted SAML assertions are not supported")
+    }
+
+    dsigContext := dsig.NewDefaultValidationContext(&dsig.MemoryX509CertificateStore{Roots: v.certificates})
+    dsigContext.Clock = dsig.NewFakeClockAt(now)
+
+    var assertionElement *etree.Element
+    if len(childElements(response, xmlDSigNamespace, "Signature")) > 0 {
+        validated, err := dsigContext.Validate(response)
+        if err != nil {
+            return nil, fmt.Errorf("invalid SAML response signature: %w", err)
+        }
+        response = validated
+
+        assertions := childElements(response, samlAssertionNamespace, "Assertion")
+        if len(assertions) != 1 {
+            return nil, fmt.Errorf("SAML response must contain exactly one assertion")
+        }
+        assertionElement = assertions[0]
+    } else {
+        assertions := childElements(response, samlAssertionNamespace, "Assertion")
+        if len(assertions) != 1 {
+            return nil, fmt.Errorf("SAML response must contain exactly one assertion")
+        }
+
+        validated, err := dsigContext.Validate(detachWithNamespaces(assertions[0]))
+        if err != nil {
+            return nil, fmt.Errorf("invalid SAML assertion signature: %w", err)
+        }
+        assertionElement = validated
+    }
+
+    if err := v.checkStatus(response); err != nil {
+        return nil, err
+    }
+    if destination := response.SelectAttrValue("Destination", ""); destination != "" && destination != v.acsURL {
+        return nil, fmt.Errorf("SAML response destination mismatch")
+    }
+
+    parsed, err := decodeAssertion(assertionElement)
+    if err != nil {
+        return nil, err
+    }
+
+    assertion, err := v.checkAssertion(parsed, now)
+    if err != nil {
+        return nil, err
+    }
+
+    if inResponseTo := response.SelectAttrValue("InResponseTo", ""); inResponseTo != "" && inResponseTo != assertion.InResponseTo {
+        return nil, fmt.Errorf("SAML response InResponseTo mismatch")
+    }
+
+    return assertion, nil
+}
+
+func (v *samlValidator) checkStatus(response *etree.Element) error {
+    for _, status := range childElements(response, samlProtocolNamespace, "Status") {
+        for _, code := range childElements(status, samlProtocolNamespace, "StatusCode") {
+            if code.SelectAttrValue("Value", "") == samlStatusSuccess {
+                return nil
+            }
+            return fmt.Errorf("SAML login failed with status %s", code.SelectAttrValue("Value", ""))
+        }
+    }
+    return fmt.Errorf("SAML response is missing a status")
+}
+
+func (v *samlValidator) checkAssertion(parsed *samlAssertionXML, now time.Time) (*SAMLAssertion, error) {
+    if parsed.ID == "" {
+        return nil, fmt.Errorf("SAML assertion is missing an ID")
+    }
+    if parsed.Issuer != v.idpEntityID {
+        return nil, fmt.Errorf("SAML assertion issuer mismatch")
+    }
+
+    conditions := parsed.Conditions
+    if conditions == nil {
+        return nil, fmt.Errorf("SAML assertion is missing conditions")
+    }
+    if !conditions.NotBefore.IsZero() && now.Add(samlClockSkew).Before(conditions.NotBefore) {
+        return nil, fmt.Errorf("SAML assertion is not yet valid")
+    }
+    if !conditions.NotOnOrAfter.IsZero() && !now.Add(-samlClockSkew).Before(conditions.NotOnOrAfter) {
+        return nil, fmt.Errorf("SAML assertion has expired")
+    }
+
+    audienceMatched := false
+    for _, restriction := range conditions.AudienceRestrictions {
+        for _, audience := range restriction.Audiences {
+            if strings.TrimSpace(audience) == v.spEntityID {
+                audienceMatched = true
+            }
+        }
+    }
+    if !audienceMatched {
+        return nil, fmt.Errorf("SAML assertion audience mismatch")
+    }
+
+    // Require a bearer confirmation addressed to our ACS that is still valid
+    var confirmation *samlSubjectConfirmationData
+    for i := range parsed.Subject.SubjectConfirmations {
+        sc := &parsed.Subject.SubjectConfirmations[i]
+        if sc.Method != samlBearerConfirmation || sc.Data == nil {
+            continue
+        }
+        if sc.Data.Recipient != v.acsURL || sc.Data.InResponseTo == "" {
+            continue
+        }
+        if !now.Add(-samlClockSkew).Before(sc.Data.NotOnOrAfter) {
+            continue
+        }
+        confirmation = sc.Data
+        break
+    }
+    if confirmation == nil {
+        return nil, fmt.Errorf("SAML assertion has no valid bearer subject confirmation")
+    }
+
+    notOnOrAfter := confirmation.NotOnOrAfter
+    if !conditions.NotOnOrAfter.IsZero() && conditions.NotOnOrAfter.Before(notOnOrAfter) {
+        notOnOrAfter = conditions.NotOnOrAfter
+    }
+
+    attributes := make(map[string][]string)
+    for _, statement := range parsed.AttributeStatements {
+        for _, attribute := range statement.Attributes {
+            for _, value := range attribute.Values {
+                attributes[attribute.Name] = append(attributes[attribute.Name], strings.TrimSpace(value))
+            }
+        }
+    }
+
+    return &SAMLAssertion{
+        ID:           parsed.ID,
+        Issuer:       parsed.Issuer,
+        NameID:       strings.TrimSpace(parsed.Subject.NameID),
+        InResponseTo: confirmation.InResponseTo,
+        NotOnOrAfter: notOnOrAfter,
+        Attributes:   attributes,
+    }, nil
+}
+
+// parseIdPCertificates accepts PEM blocks or the bare base64 DER found in IdP metadata
+func parseIdPCertificates(data string) ([]*x509.Certificate, error) {
+    var certificates []*x509.Certificate
+
+    rest := []byte(strings.TrimSpace(data))
+    for {
+        var block *pem.Block
+        block, rest = pem.Decode(rest)
+        if block == nil {
+            break
+        }
+        certificate, err := x509.ParseCertificate(block.Bytes)
+        if err != nil {
+            return nil, fmt.Errorf("invalid identity provider certificate: %w", err)
+        }
+        certificates = append(certificates, certificate)
+    }
+
+    if len(certificates) == 0 {
+        der, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(data), ""))
+        if err != nil {
+            return nil, fmt.Errorf("invalid identity provider certificate encoding")
+        }
+        certificate, err := x509.ParseCertificate(der)
+        if err != nil {
+            return nil, fmt.Errorf("invalid identity provider certificate: %w", err)
+        }
+        certificates = append(certificates, certificate)
+    }
+
+    return certificates, nil
+}
+
+func childElements(parent *etree.Element, namespace, tag string) []*etree.Element {
+    var matches []*etree.Element
+    for _, child := range parent.ChildElements() {
+        if child.Tag == tag && child.NamespaceURI() == namespace {
+            matches = append(matches, child)
+        }
+    }
+    return matches
+}
+
+// detachWithNamespaces copies an element and re-declares the namespaces it
+// inherited from its ancestors so it can be verified and parsed on its own
+func detachWithNamespaces(el *etree.Element) *etree.Element {
+    detached := el.Copy()
+
+    declared := make(map[string]bool)
+    for _, attr := range detached.Attr {
+        if attr.Space == "xmlns" || (attr.Space == "" && attr.Key == "xmlns") {
+            declared[attr.Space+":"+attr.Key] = true
+        }
+    }
+
+    for parent := el.Parent(); parent != nil; parent = parent.Parent() {
+        for _, attr := range parent.Attr {
+            isNamespace := attr.Space == "xmlns" || (attr.Space == "" && attr.Key == "xmlns")
+            if !isNamespace || declared[attr.Space+":"+attr.Key] {
+                continue
+            }
+            declared[attr.Space+":"+attr.Key] = true
+            if attr.Space == "" {
+                detached.CreateAttr("xmlns", attr.Value)
+            } else {
+                detached.CreateAttr("xmlns:"+attr.Key, attr.Value)
+            }
+        }
+    }
+
+    return detached
+}
+
+func decodeAssertion(el *etree.Element) (*samlAssertionXML, error) {
+    doc := etree.NewDocument()
+    doc.SetRoot(detachWithNamespaces(el))
+    data, err := doc.WriteToBytes()
+    if err != nil {
+        return nil, fmt.Errorf("failed to serialize SAML assertion: %w", err)
+    }
+
+    var assertion samlAssertionXML
+    if err := xml.Unmarshal(data, &assertion); err != nil {
+        return nil, fmt.Errorf("malformed SAML assertion: %w", err)
+    }
+    return &assertion, nil
+}
+
+type samlAssertionXML struct {
+    XMLName             xml.Name                 `xml:"urn:oasis:names:tc:SAML:2.0:assertion Assertion"`
+    ID                  string                   `xml:"ID,attr"`
+    Issuer              string                   `xml:"urn:oasis:names:tc:SAML:2.0:assertion Issuer"`
+    Subject             samlSubject              `xml:"urn:oasis:names:tc:SAML:2.0:assertion Subject"`
+    Conditions          *samlConditions          `xml:"urn:oasis:names:tc:SAML:2.0:assertion Conditions"`
+    AttributeStatements []samlAttributeStatement `xml:"urn:oasis:names:tc:SAML:2.0:assertion AttributeStatement"`
+}
+
+type samlSubject struct {
+    NameID               string                    `xml:"urn:oasis:names:tc:SAML:2.0:assertion NameID"`
+    SubjectConfirmations []samlSubjectConfirmation `xml:"urn:oasis:names:tc:SAML:2.0:assertion SubjectConfirmation"`
+}
+
+type samlSubjectConfirmation struct {
+    Method string                       `xml:"Method,attr"`
+    Data   *samlSubjectConfirmationData `xml:"urn:oasis:names:tc:SAML:2.0:assertion SubjectConfirmationData"`
+}
+
+type samlSubjectConfirmationData struct {
+    Recipient    string    `xml:"Recipient,attr"`
+    InResponseTo string    `xml:"InResponseTo,attr"`
+    NotOnOrAfter time.Time `xml:"NotOnOrAfter,attr"`
+}
+
+type samlConditions struct {
+    NotBefore            time.Time                 `xml:"NotBefore,attr"`
+    NotOnOrAfter         time.Time                 `xml:"NotOnOrAfter,attr"`
+    AudienceRestrictions []samlAudienceRestriction `xml:"urn:oasis:names:tc:SAML:2.0:assertion AudienceRestriction"`
+}
+
+type samlAudienceRestriction struct {
+    Audiences []string `xml:"urn:oasis:names:tc:SAML:2.0:assertion Audience"`
+}
+
+type samlAttributeStatement struct {
+    Attributes []samlAttribute `xml:"urn:oasis:names:tc:SAML:2.0:assertion Attribute"`
+}
+
+type samlAttribute struct {
+    Name   string   `xml:"Name,attr"`
+    Values []string `xml:"urn:oasis:names:tc:SAML:2.0:assertion AttributeValue"`
+}
+
+type authnRequest struct {
+    XMLName                     xml.Name     `xml:"samlp:AuthnRequest"`
+    XMLNS                       string       `xml:"xmlns:samlp,attr"`
+    SAMLNS                      string       `xml:"xmlns:saml,attr"`
+    ID                          string       `xml:"ID,attr"`
+    Version                     string       `xml:"Version,attr"`
+    IssueInstant                string       `xml:"IssueInstant,attr"`
+    Destination                 string       `xml:"Destination,attr"`
+    ProtocolBinding             string       `xml:"ProtocolBinding,attr"`
+    AssertionConsumerServiceURL string       `xml:"AssertionConsumerServiceURL,attr"`
+    Issuer                      string       `xml:"saml:Issuer"`
+    NameIDPolicy                nameIDPolicy `xml:"samlp:NameIDPolicy"`
+}
+
+type nameIDPolicy struct {
+    Format      string `xml:"Format,attr"`
+    AllowCreate bool   `xml:"AllowCreate,attr"`
+}
+
+type spMetadata struct {
+    XMLName         xml.Name        `xml:"md:EntityDescriptor"`
+    XMLNS           string          `xml:"xmlns:md,attr"`
+    EntityID        string          `xml:"entityID,attr"`
+    SPSSODescriptor spSSODescriptor `xml:"md:SPSSODescriptor"`
+}
+
+type spSSODescriptor struct {
+    AuthnRequestsSigned        bool              `xml:"AuthnRequestsSigned,attr"`
+    WantAssertionsSigned       bool              `xml:"WantAssertionsSigned,attr"`
+    ProtocolSupportEnumeration string            `xml:"protocolSupportEnumeration,attr"`
+    NameIDFormats              []string          `xml:"md:NameIDFormat"`
+    AssertionConsumerServices  []indexedEndpoint `xml:"md:AssertionConsumerService"`
+}
+
+type indexedEndpoint struct {
+    Binding   string `xml:"Binding,attr"`
+    Location  string `xml:"Location,attr"`
+    Index     int    `xml:"index,attr"`
+    IsDefault bool   `xml:"isDefault,attr"`
+}
+
+type idpMetadata struct {
+    XMLName          xml.Name             `xml:"EntityDescriptor"`
+    EntityID         string               `xml:"entityID,attr"`
+    IDPSSODescriptor *idpSSODescriptorXML `xml:"IDPSSODescriptor"`
+}
+
+type idpSSODescriptorXML struct {
+    KeyDescriptors       []idpKeyDescriptor `xml:"KeyDescriptor"`
+    SingleSignOnServices []idpEndpoint      `xml:"SingleSignOnService"`
+}
+
+type idpKeyDescriptor struct {
+    Use          string   `xml:"use,attr"`
+    Certificates []string `xml:"KeyInfo>X509Data>X509Certificate"`
+}
+
+type idpEndpoint struct {
+    Binding  string `xml:"Binding,attr"`
+    Location string `xml:"Location,attr"`
+}
\ No newline at end of file
@@ -0,0 +1,531 @@
+package services
+
+import (
+    "context"
+    "crypto/rand"
+    "crypto/rsa"
+    "crypto/x509"
+    "crypto/x509/pkix"
+    "encoding/base64"
+    "encoding/pem"
+    "errors"
+    "fmt"
+    "encoding/xml"
+    "math/big"
+    "strings"
+    "testing"
+    "time"
+
+    "github.com/beevik/etree"
+    "github.com/google/uuid"
+    dsig "github.com/russellhaering/goxmldsig"
+    "github.com/Shridhar2104/chat-platform/auth-service/internal/repository"
+    "github.com/Shridhar2104/chat-platform/shared/models"
+)
+
+const (
+    testIdPEntityID = "https://idp.example.com/saml"
+    testSPBaseURL   = "https://chat.example.com"
+    testRequestID   = "id-7b3f2c"
+)
+
+var testWorkspaceID = uuid.MustParse("0f8fad5b-d9cb-469f-a165-70867728950e")
+
+// testIdP is a locally generated identity provider used to sign fixtures
+type testIdP struct {
+    key     *rsa.PrivateKey
+    certDER []byte
+}
+
+func newTestIdP(t *testing.T) *testIdP {
+    t.Helper()
+
+    key, err := rsa.GenerateKey(rand.Reader, 2048)
+    if err != nil {
+        t.Fatalf("failed to generate IdP key: %v", err)
+    }
+
+    template := &x509.Certificate{
+        SerialNumber: big.NewInt(1),
+        Subject:      pkix.Name{CommonName: "idp.example.com"},
+        NotBefore:    time.Now().Add(-24 * time.Hour),
+        NotAfter:     time.Now().Add(365 * 24 * time.Hour),
+        KeyUsage:     x509.KeyUsageDigitalSignature,
+    }
+    certDER, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+    if err != nil {
+        t.Fatalf("failed to create IdP certificate: %v", err)
+    }
+
+    return &testIdP{key: key, certDER: certDER}
+}
+
+func (idp *testIdP) config() *models.SAMLIdentityProvider {
+    return &models.SAMLIdentityProvider{
+        ID:                   uuid.New(),
+        WorkspaceID:          testWorkspaceID,
+        EntityID:             testIdPEntityID,
+        SSOURL:               "https://idp.example.com/saml/sso",
+        Certificate:          string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: idp.certDER})),
+        EmailAttribute:       "email",
+        DisplayNameAttribute: "displayName",
+        Enabled:              true,
+    }
+}
+
+func (idp *testIdP) sign(t *testing.T, el *etree.Element) *etree.Element {
+    t.Helper()
+
+    ctx, err := dsig.NewSigningContext(idp.key, [][]byte{idp.certDER})
+    if err != nil {
+        t.Fatalf("failed to create signing context: %v", err)
+    }
+    ctx.Canonicalizer = dsig.MakeC14N10ExclusiveCanonicalizerWithPrefixList("")
+
+    signed, err := ctx.SignEnveloped(el)
+    if err != nil {
+        t.Fatalf("failed to sign fixture: %v", err)
+    }
+    return signed
+}
+
+type responseFixture struct {
+    issuer        string
+    audience      string
+    recipient     string
+    inResponseTo  string
+    email         string
+    status        string
+    notBefore     time.Time
+    notOnOrAfter  time.Time
+    signAssertion bool
+    signResponse  bool
+}
+
+func defaultResponseFixture(now time.Time) responseFixture {
+    service := NewSAMLService(nil, nil, nil, testSPBaseURL)
+    return responseFixture{
+        issuer:        testIdPEntityID,
+        audience:      service.EntityID(testWorkspaceID),
+        recipient:     service.ACSURL(testWorkspaceID),
+        inResponseTo:  testRequestID,
+        email:         "Alice@Example.com",
+        status:        samlStatusSuccess,
+        notBefore:     now.Add(-time.Minute),
+        notOnOrAfter:  now.Add(5 * time.Minute),
+        signAssertion: true,
+    }
+}
+
+func (idp *testIdP) buildAssertion(t *testing.T, f responseFixture) *etree.Element {
+    t.Helper()
+
+    assertion := etree.NewElement("saml:Assertion")
+    assertion.CreateAttr("xmlns:saml", samlAssertionNamespace)
+    assertion.CreateAttr("ID", "id-assertion-"+uuid.NewString())
+    assertion.CreateAttr("Version", "2.0")
+    assertion.CreateAttr("IssueInstant", f.notBefore.UTC().Format(time.RFC3339))
+    assertion.CreateElement("saml:Issuer").SetText(f.issuer)
+
+    subject := assertion.CreateElement("saml:Subject")
+    nameID := subject.CreateElement("saml:NameID")
+    nameID.CreateAttr("Format", samlNameIDFormatEmail)
+    nameID.SetText(f.email)
+    confirmation := subject.CreateElement("saml:SubjectConfirmation")
+    confirmation.CreateAttr("Method", samlBearerConfirmation)
+    data := confirmation.CreateElement("saml:SubjectConfirmationData")
+    data.CreateAttr("Recipient", f.recipient)
+    data.CreateAttr("InResponseTo", f.inResponseTo)
+    data.CreateAttr("NotOnOrAfter", f.notOnOrAfter.UTC().Format(time.RFC3339))
+
+    conditions := assertion.CreateElement("saml:Conditions")
+    conditions.CreateAttr("NotBefore", f.notBefore.UTC().Format(time.RFC3339))
+    conditions.CreateAttr("NotOnOrAfter", f.notOnOrAfter.UTC().Format(time.RFC3339))
+    conditions.CreateElement("saml:AudienceRestriction").CreateElement("saml:Audience").SetText(f.audience)
+
+    statement := assertion.CreateElement("saml:AttributeStatement")
+    for name, value := range map[string]string{"email": f.email, "displayName": "Alice Liddell"} {
+        attribute := statement.CreateElement("saml:Attribute")
+        attribute.CreateAttr("Name", name)
+        attribute.CreateElement("saml:AttributeValue").SetText(value)
+    }
+
+    if f.signAssertion {
+        return idp.sign(t, assertion)
+    }
+    return assertion
+}
+
+func (idp *testIdP) buildResponse(t *testing.T, f responseFixture, assertions ...*etree.Element) []byte {
+    t.Helper()
+
+    response := etree.NewElement("samlp:Response")
+    response.CreateAttr("xmlns:samlp", samlProtocolNamespace)
+    response.CreateAttr("xmlns:saml", samlAssertionNamespace)
+    response.CreateAttr("ID", "id-response-"+uuid.NewString())
+    response.CreateAttr("Version", "2.0")
+    response.CreateAttr("IssueInstant", f.notBefore.UTC().Format(time.RFC3339))
+    response.CreateAttr("Destination", f.recipient)
+    response.CreateAttr("InResponseTo", f.inResponseTo)
+    response.CreateElement("saml:Issuer").SetText(f.issuer)
+    response.CreateElement("samlp:Status").CreateElement("samlp:StatusCode").CreateAttr("Value", f.status)
+
+    if len(assertions) == 0 {
+        assertions = []*etree.Element{idp.buildAssertion(t, f)}
+    }
+    for _, assertion := range assertions {
+        response.AddChild(assertion)
+    }
+
+    if f.signResponse {
+        response = idp.sign(t, response)
+    }
+
+    doc := etree.NewDocument()
+    doc.SetRoot(response)
+    data, err := doc.WriteToBytes()
+    if err != nil {
+        t.Fatalf("failed to serialize response: %v", err)
+    }
+    return data
+}
+
+func newTestValidator(t *testing.T, idp *models.SAMLIdentityProvider) *samlValidator {
+    t.Helper()
+
+    service := NewSAMLService(nil, nil, nil, testSPBaseURL)
+    validator, err := newSAMLValidator(idp, service.EntityID(testWorkspaceID), service.ACSURL(testWorkspaceID))
+    if err != nil {
+        t.Fatalf("failed to create validator: %v", err)
+    }
+    return validator
+}
+
+func TestSAMLValidatorAcceptsSignedResponses(t *testing.T) {
+    now := time.Now()
+    idp := newTestIdP(t)
+    validator := newTestValidator(t, idp.config())
+
+    tests := []struct {
+        name          string
+        signAssertion bool
+        signResponse  bool
+    }{
+        {name: "signed assertion", signAssertion: true},
+        {name: "signed response", signResponse: true},
+        {name: "signed response and assertion", signAssertion: true, signResponse: true},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            fixture := defaultResponseFixture(now)
+            fixture.signAssertion = tt.signAssertion
+            fixture.signResponse = tt.signResponse
+
+            assertion, err := validator.Validate(idp.buildResponse(t, fixture), now)
+            if err != nil {
+                t.Fatalf("Validate returned error: %v", err)
+            }
+            if assertion.Issuer != testIdPEntityID {
+                t.Errorf("issuer = %q, want %q", assertion.Issuer, testIdPEntityID)
+            }
+            if assertion.InResponseTo != testRequestID {
+                t.Errorf("InResponseTo = %q, want %q", assertion.InResponseTo, testRequestID)
+            }
+
+            email, displayName, err := MapSAMLUser(idp.config(), assertion)
+            if err != nil {
+                t.Fatalf("MapSAMLUser returned error: %v", err)
+            }
+            if email != "alice@example.com" || displayName != "Alice Liddell" {
+                t.Errorf("mapped %q/%q, want alice@example.com/Alice Liddell", email, displayName)
+            }
+        })
+    }
+}
+
+func TestSAMLValidatorRejectsInvalidResponses(t *testing.T) {
+    now := time.Now()
+    idp := newTestIdP(t)
+    otherIdP := newTestIdP(t)
+    validator := newTestValidator(t, idp.config())
+
+    tests := []struct {
+        name  string
+        build func(t *testing.T) []byte
+    }{
+        {
+            name: "unsigned",
+            build: func(t *testing.T) []byte {
+                fixture := defaultResponseFixture(now)
+                fixture.signAssertion = false
+                return idp.buildResponse(t, fixture)
+            },
+        },
+        {
+            name: "signed by untrusted key",
+            build: func(t *testing.T) []byte {
+                return otherIdP.buildResponse(t, defaultResponseFixture(now))
+            },
+        },
+        {
+            name: "tampered after signing",
+            build: func(t *testing.T) []byte {
+                data := idp.buildResponse(t, defaultResponseFixture(now))
+                return []byte(strings.ReplaceAll(string(data), "Alice@Example.com", "mallory@example.com"))
+            },
+        },
+        {
+            name: "wrapped with unsigned assertion",
+            build: func(t *testing.T) []byte {
+                fixture := defaultResponseFixture(now)
+                signed := idp.buildAssertion(t, fixture)
+                fixture.signAssertion = false
+                fixture.email = "mallory@example.com"
+                return idp.buildResponse(t, fixture, idp.buildAssertion(t, fixture), signed)
+            },
+        },
+        {
+            name: "wrong issuer",
+            build: func(t *testing.T) []byte {
+                fixture := defaultResponseFixture(now)
+                fixture.issuer = "https://evil.example.com"
+                return idp.buildResponse(t, fixture)
+            },
+        },
+        {
+            name: "wrong audience",
+            build: func(t *testing.T) []byte {
+                fixture := defaultResponseFixture(now)
+                fixture.audience = "https://other-sp.example.com"
+                return idp.buildResponse(t, fixture)
+            },
+        },
+        {
+            name: "wrong recipient",
+            build: func(t *testing.T) []byte {
+                fixture := defaultResponseFixture(now)
+                fixture.recipient = "https://other-sp.example.com/acs"
+                return idp.buildResponse(t, fixture)
+            },
+        },
+        {
+            name: "expired",
+            build: func(t *testing.T) []byte {
+                fixture := defaultResponseFixture(now)
+                fixture.notBefore = now.Add(-time.Hour)
+                fixture.notOnOrAfter = now.Add(-30 * time.Minute)
+                return idp.buildResponse(t, fixture)
+            },
+        },
+        {
+            name: "not yet valid",
+            build: func(t *testing.T) []byte {
+                fixture := defaultResponseFixture(now)
+                fixture.notBefore = now.Add(time.Hour)
+                fixture.notOnOrAfter = now.Add(2 * time.Hour)
+                return idp.buildResponse(t, fixture)
+            },
+        },
+        {
+            name: "failed status",
+            build: func(t *testing.T) []byte {
+                fixture := defaultResponseFixture(now)
+                fixture.status = "urn:oasis:names:tc:SAML:2.0:status:Requester"
+                return idp.buildResponse(t, fixture)
+            },
+        },
+        {
+            name: "not xml",
+            build: func(t *testing.T) []byte {
+                return []byte("not a saml response")
+            },
+        },
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            if _, err := validator.Validate(tt.build(t), now); err == nil {
+                t.Fatal("expected Validate to fail")
+            }
+        })
+    }
+}
+
+func TestMapSAMLUserFallsBackToNameID(t *testing.T) {
+    idp := &models.SAMLIdentityProvider{}
+    assertion := &SAMLAssertion{NameID: " Bob@Example.com "}
+
+    email, displayName, err := MapSAMLUser(idp, assertion)
+    if err != nil {
+        t.Fatalf("MapSAMLUser returned error: %v", err)
+    }
+    if email != "bob@example.com" || displayName != "" {
+        t.Errorf("mapped %q/%q, want bob@example.com and empty display name", email, displayName)
+    }
+
+    if _, _, err := MapSAMLUser(idp, &SAMLAssertion{NameID: "not-an-email"}); err == nil {
+        t.Error("expected error for NameID without an email address")
+    }
+}
+
+func TestSAMLServiceMetadata(t *testing.T) {
+    service := NewSAMLService(nil, nil, nil, testSPBaseURL+"/")
+
+    data, err := service.Metadata(testWorkspaceID)
+    if err != nil {
+        t.Fatalf("Metadata returned error: %v", err)
+    }
+
+    var metadata struct {
+        EntityID string `xml:"entityID,attr"`
+        SP       struct {
+            WantAssertionsSigned bool `xml:"WantAssertionsSigned,attr"`
+            ACS                  struct {
+                Binding  string `xml:"Binding,attr"`
+                Location string `xml:"Location,attr"`
+            } `xml:"urn:oasis:names:tc:SAML:2.0:metadata AssertionConsumerService"`
+        } `xml:"urn:oasis:names:tc:SAML:2.0:metadata SPSSODescriptor"`
+    }
+    if err := xml.Unmarshal(data, &metadata); err != nil {
+        t.Fatalf("metadata is not valid XML: %v", err)
+    }
+
+    if metadata.EntityID != testSPBaseURL+"/api/v1/auth/saml/"+testWorkspaceID.String()+"/metadata" {
+        t.Errorf("entityID = %q", metadata.EntityID)
+    }
+    if metadata.SP.ACS.Location != service.ACSURL(testWorkspaceID) || metadata.SP.ACS.Binding != samlHTTPPostBinding {
+        t.Errorf("unexpected ACS endpoint %+v", metadata.SP.ACS)
+    }
+    if !metadata.SP.WantAssertionsSigned {
+        t.Error("metadata should require signed assertions")
+    }
+}
+
+// fakeSAMLStore keeps identity providers in memory
+type fakeSAMLStore map[uuid.UUID]models.SAMLIdentityProvider
+
+func (s fakeSAMLStore) GetIdentityProviderByWorkspace(ctx context.Context, workspaceID uuid.UUID) (*models.SAMLIdentityProvider, error) {
+    idp, ok := s[workspaceID]
+    if !ok {
+        return nil, repository.ErrIdentityProviderNotFound
+    }
+    return &idp, nil
+}
+
+func (s fakeSAMLStore) UpsertIdentityProvider(ctx context.Context, idp *models.SAMLIdentityProvider) error {
+    if existing, ok := s[idp.WorkspaceID]; ok {
+        idp.ID, idp.CreatedAt = existing.ID, existing.CreatedAt
+    }
+    s[idp.WorkspaceID] = *idp
+    return nil
+}
+
+// idpMetadataXML is an IdP EntityDescriptor with one SSO endpoint and key
+func idpMetadataXML(binding, use string, certDER []byte) string {
+    return fmt.Sprintf(`<md:EntityDescriptor xmlns:md="urn:oasis:names:tc:SAML:2.0:metadata" xmlns:ds="http://www.w3.org/2000/09/xmldsig#" entityID="%s">
+  <md:IDPSSODescriptor protocolSupportEnumeration="urn:oasis:names:tc:SAML:2.0:protocol">
+    <md:KeyDescriptor use="%s"><ds:KeyInfo><ds:X509Data><ds:X509Certificate>%s</ds:X509Certificate></ds:X509Data></ds:KeyInfo></md:KeyDescriptor>
+    <md:SingleSignOnService Binding="%s" Location="https://idp.example.com/saml/sso"/>
+  </md:IDPSSODescriptor>
+</md:EntityDescriptor>`, testIdPEntityID, use, base64.StdEncoding.EncodeToString(certDER), binding)
+}
+
+func TestConfigureIdentityProvider(t *testing.T) {
+    ctx := context.Background()
+    store := fakeSAMLStore{}
+    service := NewSAMLService(store, nil, nil, testSPBaseURL)
+    idp := newTestIdP(t)
+
+    if _, err := service.IdentityProvider(ctx, testWorkspaceID); !errors.Is(err, ErrIdentityProviderNotFound) {
+        t.Fatalf("IdentityProvider before configuring = %v, want ErrIdentityProviderNotFound", err)
+    }
+
+    configured, err := service.ConfigureIdentityProvider(ctx, testWorkspaceID, IdentityProviderSettings{
+        EntityID:       testIdPEntityID,
+        SSOURL:         "https://idp.example.com/saml/sso",
+        Certificate:    idp.config().Certificate,
+        EmailAttribute: "email",
+        Enabled:        true,
+    })
+    if err != nil {
+        t.Fatalf("ConfigureIdentityProvider: %v", err)
+    }
+    if stored := store[testWorkspaceID]; stored.EntityID != testIdPEntityID || stored.EmailAttribute != "email" || !stored.Enabled {
+        t.Errorf("stored identity provider = %+v", stored)
+    }
+
+    // Metadata replaces the settings of the same row, and a rotated key is picked up
+    rotated := newTestIdP(t)
+    reconfigured, err := service.ConfigureIdentityProvider(ctx, testWorkspaceID, IdentityProviderSettings{
+        Metadata: idpMetadataXML(samlHTTPRedirect, "signing", rotated.certDER),
+        Enabled:  true,
+    })
+    if err != nil {
+        t.Fatalf("ConfigureIdentityProvider from metadata: %v", err)
+    }
+    if reconfigured.ID != configured.ID {
+        t.Errorf("reconfiguring created a new identity provider %s, want %s", reconfigured.ID, configured.ID)
+    }
+    if reconfigured.EntityID != testIdPEntityID || reconfigured.SSOURL != "https://idp.example.com/saml/sso" {
+        t.Errorf("settings from metadata = %+v", reconfigured)
+    }
+
+    // The stored certificate verifies responses signed by the rotated key
+    validator := newTestValidator(t, reconfigured)
+    now := time.Now()
+    fixture := defaultResponseFixture(now)
+    fixture.signAssertion = true
+    if _, err := validator.Validate(rotated.buildResponse(t, fixture), now); err != nil {
+        t.Errorf("response signed by the configured key rejected: %v", err)
+    }
+}
+
+func TestConfigureIdentityProviderRejectsInvalidSettings(t *testing.T) {
+    idp := newTestIdP(t)
+    certificate := idp.config().Certificate
+
+    expiredKey, err := rsa.GenerateKey(rand.Reader, 2048)
+    if err != nil {
+        t.Fatalf("failed to generate key: %v", err)
+    }
+    template := &x509.Certificate{
+        SerialNumber: big.NewInt(2),
+        Subject:      pkix.Name{CommonName: "idp.example.com"},
+        NotBefore:    time.Now().Add(-48 * time.Hour),
+        NotAfter:     time.Now().Add(-24 * time.Hour),
+    }
+    expiredDER, err := x509.CreateCertificate(rand.Reader, template, template, &expiredKey.PublicKey, expiredKey)
+    if err != nil {
+        t.Fatalf("failed to create certificate: %v", err)
+    }
+    expired := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: expiredDER}))
+
+    tests := []struct {
+        name     string
+        settings IdentityProviderSettings
+    }{
+        {"missing entity ID", IdentityProviderSettings{SSOURL: "https://idp.example.com/sso", Certificate: certificate}},
+        {"plain http SSO URL", IdentityProviderSettings{EntityID: testIdPEntityID, SSOURL: "http://idp.example.com/sso", Certificate: certificate}},
+        {"relative SSO URL", IdentityProviderSettings{EntityID: testIdPEntityID, SSOURL: "/sso", Certificate: certificate}},
+        {"certificate is not DER", IdentityProviderSettings{EntityID: testIdPEntityID, SSOURL: "https://idp.example.com/sso", Certificate: "bm90IGEgY2VydGlmaWNhdGU="}},
+        {"expired certificate", IdentityProviderSettings{EntityID: testIdPEntityID, SSOURL: "https://idp.example.com/sso", Certificate: expired}},
+        {"metadata and fields", IdentityProviderSettings{Metadata: idpMetadataXML(samlHTTPRedirect, "signing", idp.certDER), Certificate: certificate}},
+        {"malformed metadata", IdentityProviderSettings{Metadata: "<md:EntityDescriptor"}},
+        {"metadata of a service provider", IdentityProviderSettings{Metadata: `<md:EntityDescriptor xmlns:md="urn:oasis:names:tc:SAML:2.0:metadata" entityID="x"><md:SPSSODescriptor/></md:EntityDescriptor>`}},
+        {"metadata without a redirect endpoint", IdentityProviderSettings{Metadata: idpMetadataXML(samlHTTPPostBinding, "signing", idp.certDER)}},
+        {"metadata with only an encryption key", IdentityProviderSettings{Metadata: idpMetadataXML(samlHTTPRedirect, "encryption", idp.certDER)}},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            store := fakeSAMLStore{}
+            service := NewSAMLService(store, nil, nil, testSPBaseURL)
+            if _, err := service.ConfigureIdentityProvider(context.Background(), testWorkspaceID, tt.settings); !errors.Is(err, ErrInvalidIdentityProvider) {
+                t.Errorf("error = %v, want ErrInvalidIdentityProvider", err)
+            }
+            if len(store) != 0 {
+                t.Error("an invalid identity provider was stored")
+            }
+        })
+    }
+}
\ No newline at end of file
@@ -0,0 +1,711 @@
+package services
+
+import (
+    "context"
+    "crypto/rand"
+    "crypto/sha256"
+    "encoding/hex"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "net/http"
+    "regexp"
+    "strconv"
+    "strings"
+    "time"
+
+    "github.com/google/uuid"
+    "golang.org/x/crypto/bcrypt"
+    apimodels "github.com/Shridhar2104/chat-platform/auth-service/internal/models"
+    "github.com/Shridhar2104/chat-platform/auth-service/internal/repository"
+    "github.com/Shridhar2104/chat-platform/shared/models"
+)
+
+const (
+    SCIMDefaultCount = 100
+    SCIMMaxCount     = 200
+)
+
+// SCIMError carries the HTTP status and scimType of a failed SCIM operation
+type SCIMError struct {
+    Status   int
+    ScimType string
+    Detail   string
+}
+
+func (e *SCIMError) Error() string {
+    return e.Detail
+}
+
+func scimNotFound(resource string) error {
+    return &SCIMError{Status: http.StatusNotFound, Detail: resource + " not found"}
+}
+
+func scimBadRequest(scimType, detail string) error {
+    return &SCIMError{Status: http.StatusBadRequest, ScimType: scimType, Detail: detail}
+}
+
+var memberValuePath = regexp.MustCompile(`(?i)^members\[\s*value\s+eq\s+"([^"]+)"\s*\]$`)
+
+// memberSourceSCIM marks memberships created along with the account by SCIM
+const memberSourceSCIM = "scim"
+
+// SCIMStore is the storage behind SCIMService, UserRepository implements it
+type SCIMStore interface {
+    GetSCIMTokenByHash(ctx context.Context, tokenHash string) (*models.SCIMToken, error)
+    TouchSCIMToken(ctx context.Context, tokenID uuid.UUID) error
+
+    CreateUser(ctx context.Context, user *models.User) error
+    GetUserByEmail(ctx context.Context, email string) (*models.User, error)
+    EmailExists(ctx context.Context, email string) (bool, error)
+    UpdateUser(ctx context.Context, user *models.User) error
+    DeactivateUser(ctx context.Context, userID uuid.UUID) error
+
+    AddWorkspaceMember(ctx context.Context, workspaceID, userID uuid.UUID, source string) error
+    RemoveWorkspaceMember(ctx context.Context, workspaceID, userID uuid.UUID) error
+    WorkspaceMemberSource(ctx context.Context, workspaceID, userID uuid.UUID) (string, error)
+    GetWorkspaceUser(ctx context.Context, workspaceID, userID uuid.UUID) (*models.User, error)
+    ListWorkspaceUsers(ctx context.Context, workspaceID uuid.UUID, filter repository.SCIMFilter, startIndex, count int) ([]models.User, int, error)
+
+    CreateGroup(ctx context.Context, group *models.Group) error
+    GetGroup(ctx context.Context, workspaceID, groupID uuid.UUID) (*models.Group, error)
+    ListGroups(ctx context.Context, workspaceID uuid.UUID, filter repository.SCIMFilter, startIndex, count int) ([]models.Group, int, error)
+    UpdateGroup(ctx context.Context, group *models.Group) error
+    DeleteGroup(ctx context.Context, workspaceID, groupID uuid.UUID) error
+}
+
+// EmailOwner reports whether a workspace verified the domain of an email,
+// DomainPolicyService implements it
+type EmailOwner interface {
+    OwnsEmail(ctx context.Context, workspaceID uuid.UUID, email string) (bool, error)
+}
+
+var _ SCIMStore = (*repository.UserRepository)(nil)
+
+// SCIMService provisions users and groups for one workspace at a time.
+// Accounts are global and may belong to several workspaces, so a workspace
+// only changes the account itself when it owns it; otherwise it manages no
+// more than its own membership.
+type SCIMService struct {
+    userRepo SCIMStore
+    domains  EmailOwner
+}
+
+func NewSCIMService(userRepo SCIMStore, domains EmailOwner) *SCIMService {
+    return &SCIMService{userRepo: userRepo, domains: domains}
+}
+
+// Authenticate resolves a tenant bearer token to its workspace token record
+func (s *SCIMService) Authenticate(ctx context.Context, bearerToken string) (*models.SCIMToken, error) {
+    hash := sha256.Sum256([]byte(bearerToken))
+    token, err := s.userRepo.GetSCIMTokenByHash(ctx, hex.EncodeToString(hash[:]))
+    if err != nil {
+        return nil, fmt.Errorf("invalid scim token")
+    }
+    s.userRepo.TouchSCIMToken(ctx, token.ID)
+    return token, nil
+}
+
+func (s *SCIMService) ListUsers(ctx context.Context, workspaceID uuid.UUID, filter string, startIndex, count int) ([]models.User, int, error) {
+    parsed, err := parseFilter(filter)
+    if err != nil {
+        return nil, 0, err
+    }
+    users, total, err := s.userRepo.ListWorkspaceUsers(ctx, workspaceID, parsed, startIndex, count)
+    if err != nil {
+        return nil, 0, scimRepositoryError(err)
+    }
+    return users, total, nil
+}
+
+func (s *SCIMService) GetUser(ctx context.Context, workspaceID, userID uuid.UUID) (*models.User, error) {
+    user, err := s.userRepo.GetWorkspaceUser(ctx, workspaceID, userID)
+    if err != nil {
+        if !errors.Is(err, repository.ErrUserNotFound) {
+            return nil, err
+        }
+        return nil, scimNotFound("user")
+    }
+    return user, nil
+}
+
+// CreateUser provisions a new account and adds it to the workspace
+func (s *SCIMService) CreateUser(ctx context.Context, workspaceID uuid.UUID, resource *apimodels.SCIMUser) (*models.User, error) {
+    email, err := scimUserName(resource.UserName)
+    if err != nil {
+        return nil, err
+    }
+
+    exists, err := s.userRepo.EmailExists(ctx, email)
+    if err != nil {
+        return nil, err
+    }
+    if exists {
+        return nil, scimUserNameTaken()
+    }
+
+    // Provisioned users sign in through SSO, so give them an unusable password
+    randomPassword := make([]byte, 32)
+    if _, err := rand.Read(randomPassword); err != nil {
+        return nil, fmt.Errorf("failed to generate password: %w", err)
+    }
+    passwordHash, err := bcrypt.GenerateFromPassword([]byte(hex.EncodeToString(randomPassword)), bcrypt.DefaultCost)
+    if err != nil {
+        return nil, fmt.Errorf("failed to hash password: %w", err)
+    }
+
+    user := &models.User{
+        ID:            uuid.New(),
+        Email:         email,
+        PasswordHash:  string(passwordHash),
+        DisplayName:   scimDisplayName(resource, email),
+        EmailVerified: true,
+        Active:        resource.Active == nil || *resource.Active,
+        ExternalID:    optionalString(resource.ExternalID),
+        CreatedAt:     time.Now(),
+        UpdatedAt:     time.Now(),
+    }
+
+    if err := s.userRepo.CreateUser(ctx, user); err != nil {
+        // A concurrent request took the userName after the check above
+        if errors.Is(err, repository.ErrDuplicate) {
+            return nil, scimUserNameTaken()
+        }
+        return nil, err
+    }
+    if err := s.userRepo.AddWorkspaceMember(ctx, workspaceID, user.ID, memberSourceSCIM); err != nil {
+        return nil, err
+    }
+
+    return user, nil
+}
+
+// ReplaceUser implements PUT semantics for a workspace user
+func (s *SCIMService) ReplaceUser(ctx context.Context, workspaceID, userID uuid.UUID, resource *apimodels.SCIMUser) (*models.User, error) {
+    user, err := s.GetUser(ctx, workspaceID, userID)
+    if err != nil {
+        return nil, err
+    }
+    before := *user
+
+    email, err := scimUserName(resource.UserName)
+    if err != nil {
+        return nil, err
+    }
+
+    user.Email = email
+    user.DisplayName = scimDisplayName(resource, email)
+    user.ExternalID = optionalString(resource.ExternalID)
+    user.Active = resource.Active == nil || *resource.Active
+
+    if err := s.saveUser(ctx, workspaceID, &before, user); err != nil {
+        return nil, err
+    }
+    return user, nil
+}
+
+// PatchUser applies RFC 7644 PATCH operations to a workspace user
+func (s *SCIMService) PatchUser(ctx context.Context, workspaceID, userID uuid.UUID, operations []apimodels.SCIMPatchOperation) (*models.User, error) {
+    user, err := s.GetUser(ctx, workspaceID, userID)
+    if err != nil {
+        return nil, err
+    }
+    before := *user
+
+    for _, operation := range operations {
+        op := strings.ToLower(operation.Op)
+        if op != "add" && op != "replace" && op != "remove" {
+            return nil, scimBadRequest("invalidSyntax", fmt.Sprintf("unsupported patch op %q", operation.Op))
+        }
+
+        if operation.Path == "" {
+            if op == "remove" {
+                return nil, scimBadRequest("noTarget", "remove requires a path")
+            }
+            var values map[string]json.RawMessage
+            if err := json.Unmarshal(operation.Value, &values); err != nil {
+                return nil, scimBadRequest("invalidValue", "patch value must be an object when no path is given")
+            }
+            for path, value := range values {
+                if err := applyUserPatch(user, op, path, value); err != nil {
+                    return nil, err
+                }
+            }
+            continue
+        }
+
+        if err := applyUserPatch(user, op, operation.Path, operation.Value); err != nil {
+            return nil, err
+        }
+    }
+
+    if err := s.saveUser(ctx, workspaceID, &before, user); err != nil {
+        return nil, err
+    }
+    return user, nil
+}
+
+// DeleteUser deprovisions a user from the workspace by removing the
+// membership. An account the workspace owns is also disabled and all of its
+// sessions are revoked.
+func (s *SCIMService) DeleteUser(ctx context.Context, workspaceID, userID uuid.UUID) error {
+    user, err := s.GetUser(ctx, workspaceID, userID)
+    if err != nil {
+        return err
+    }
+    owned, err := s.ownsUser(ctx, workspaceID, user)
+    if err != nil {
+        return err
+    }
+    if owned {
+        if err := s.userRepo.DeactivateUser(ctx, userID); err != nil {
+            return err
+        }
+    }
+    return s.userRepo.RemoveWorkspaceMember(ctx, workspaceID, userID)
+}
+
+// ownsUser reports whether the workspace created the account through SCIM
+// or verified the domain of its email
+func (s *SCIMService) ownsUser(ctx context.Context, workspaceID uuid.UUID, user *models.User) (bool, error) {
+    source, err := s.userRepo.WorkspaceMemberSource(ctx, workspaceID, user.ID)
+    if err != nil {
+        return false, err
+    }
+    if source == memberSourceSCIM {
+        return true, nil
+    }
+    if s.domains == nil {
+        return false, nil
+    }
+    return s.domains.OwnsEmail(ctx, workspaceID, user.Email)
+}
+
+// saveUser stores the changes from before to user. For an account the
+// workspace does not own only active may change, and deactivating it removes
+// the membership instead of disabling the account.
+func (s *SCIMService) saveUser(ctx context.Context, workspaceID uuid.UUID, before, user *models.User) error {
+    owned, err := s.ownsUser(ctx, workspaceID, before)
+    if err != nil {
+        return err
+    }
+    if !owned {
+        if user.Email != before.Email || user.DisplayName != before.DisplayName || !sameString(user.ExternalID, before.ExternalID) || user.Active && !before.Active {
+            return scimBadRequest("mutability", "user is managed outside this workspace, only its membership can change")
+        }
+        if !user.Active {
+            return s.userRepo.RemoveWorkspaceMember(ctx, workspaceID, user.ID)
+        }
+        return nil
+    }
+
+    if existing, err := s.userRepo.GetUserByEmail(ctx, user.Email); err == nil && existing.ID != user.ID {
+        return scimUserNameTaken()
+    }
+
+    if err := s.userRepo.UpdateUser(ctx, user); err != nil {
+        if errors.Is(err, repository.ErrDuplicate) {
+            return scimUserNameTaken()
+        }
+        return err
+    }
+
+    // Disabling an account must cut off existing sessions immediately
+    if before.Active && !user.Active {
+        return s.userRepo.DeactivateUser(ctx, user.ID)
+    }
+    return nil
+}
+
+func applyUserPatch(user *models.User, op, path string, value json.RawMessage) error {
+    switch strings.ToLower(path) {
+    case "active":
+        if op == "remove" {
+            return scimBadRequest("mutability", "active cannot be removed")
+        }
+        active, err := patchBool(value)
+        if err != nil {
+            return err
+        }
+        user.Active = active
+
+    case "username":
+        if op == "remove" {
+            return scimBadRequest("mutability", "userName cannot be removed")
+        }
+        text, err := patchString(value)
+        if err != nil {
+            return err
+        }
+        email, err := scimUserName(text)
+        if err != nil {
+            return err
+        }
+        user.Email = email
+
+    case "displayname", "name.formatted":
+        if op == "remove" {
+            user.DisplayName = user.Email
+            return nil
+        }
+        text, err := patchString(value)
+        if err != nil {
+            return err
+        }
+        if text != "" {
+            user.DisplayName = text
+        }
+
+    case "name":
+        if op == "remove" {
+            return nil
+        }
+        var name apimodels.SCIMName
+        if err := json.Unmarshal(value, &name); err != nil {
+            return scimBadRequest("invalidValue", "name must be an object")
+        }
+        if displayName := scimFormattedName(&name); displayName != "" {
+            user.DisplayName = displayName
+        }
+
+    case "externalid":
+        if op == "remove" {
+            user.ExternalID = nil
+            return nil
+        }
+        text, err := patchString(value)
+        if err != nil {
+            return err
+        }
+        user.ExternalID = optionalString(text)
+
+    default:
+        // userName is the source of truth for the address we store, so email
+        // and name sub-attributes sent alongside it are accepted and ignored
+        lower := strings.ToLower(path)
+        if strings.HasPrefix(lower, "emails") || strings.HasPrefix(lower, "name.") {
+            return nil
+        }
+        return scimBadRequest("invalidPath", fmt.Sprintf("unsupported attribute %q", path))
+    }
+    return nil
+}
+
+func (s *SCIMService) ListGroups(ctx context.Context, workspaceID uuid.UUID, filter string, startIndex, count int) ([]models.Group, int, error) {
+    parsed, err := parseFilter(filter)
+    if err != nil {
+        return nil, 0, err
+    }
+    groups, total, err := s.userRepo.ListGroups(ctx, workspaceID, parsed, startIndex, count)
+    if err != nil {
+        return nil, 0, scimRepositoryError(err)
+    }
+    return groups, total, nil
+}
+
+func (s *SCIMService) GetGroup(ctx context.Context, workspaceID, groupID uuid.UUID) (*models.Group, error) {
+    group, err := s.userRepo.GetGroup(ctx, workspaceID, groupID)
+    if err != nil {
+        if !errors.Is(err, repository.ErrGroupNotFound) {
+            return nil, err
+        }
+        return nil, scimNotFound("group")
+    }
+    return group, nil
+}
+
+func (s *SCIMService) CreateGroup(ctx context.Context, workspaceID uuid.UUID, resource *apimodels.SCIMGroup) (*models.Group, error) {
+    if strings.TrimSpace(resource.DisplayName) == "" {
+        return nil, scimBadRequest("invalidValue", "displayName is required")
+    }
+
+    memberIDs, err := s.resolveMembers(ctx, workspaceID, resource.Members)
+    if err != nil {
+        return nil, err
+    }
+
+    group := &models.Group{
+        ID:          uuid.New(),
+        WorkspaceID: workspaceID,
+        DisplayName: strings.TrimSpace(resource.DisplayName),
+        ExternalID:  optionalString(resource.ExternalID),
+        MemberIDs:   memberIDs,
+        CreatedAt:   time.Now(),
+        UpdatedAt:   time.Now(),
+    }
+    if err := s.userRepo.CreateGroup(ctx, group); err != nil {
+        if errors.Is(err, repository.ErrDuplicate) {
+            return nil, &SCIMError{Status: http.StatusConflict, ScimType: "uniqueness", Detail: "displayName is already in use"}
+        }
+        return nil, err
+    }
+    return group, nil
+}
+
+func (s *SCIMService) ReplaceGroup(ctx context.Context, workspaceID, groupID uuid.UUID, resource *apimodels.SCIMGroup) (*models.Group, error) {
+    group, err := s.GetGroup(ctx, workspaceID, groupID)
+    if err != nil {
+        return nil, err
+    }
+    if strings.TrimSpace(resource.DisplayName) == "" {
+        return nil, scimBadRequest("invalidValue", "displayName is required")
+    }
+
+    memberIDs, err := s.resolveMembers(ctx, workspaceID, resource.Members)
+    if err != nil {
+        return nil, err
+    }
+
+    group.DisplayName = strings.TrimSpace(resource.DisplayName)
+    group.ExternalID = optionalString(resource.ExternalID)
+    group.MemberIDs = memberIDs
+
+    if err := s.userRepo.UpdateGroup(ctx, group); err != nil {
+        return nil, err
+    }
+    return group, nil
+}
+
+// PatchGroup supports the member add/remove/replace operations IdPs send
+func (s *SCIMService) PatchGroup(ctx context.Context, workspaceID, groupID uuid.UUID, operations []apimodels.SCIMPatchOperation) (*models.Group, error) {
+    group, err := s.GetGroup(ctx, workspaceID, groupID)
+    if err != nil {
+        return nil, err
+    }
+
+    members := make(map[uuid.UUID]bool, len(group.MemberIDs))
+    for _, id := range group.MemberIDs {
+        members[id] = true
+    }
+
+    for _, operation := range operations {
+        op := strings.ToLower(operation.Op)
+        path := strings.TrimSpace(operation.Path)
+
+        if path == "" {
+            if op == "remove" {
+                return nil, scimBadRequest("noTarget", "remove requires a path")
+            }
+            var values map[string]json.RawMessage
+            if err := json.Unmarshal(operation.Value, &values); err != nil {
+                return nil, scimBadRequest("invalidValue", "patch value must be an object when no path is given")
+            }
+            for key, value := range values {
+                if err := s.applyGroupPatch(ctx, workspaceID, group, members, op, key, value); err != nil {
+                    return nil, err
+                }
+            }
+            continue
+        }
+
+        if err := s.applyGroupPatch(ctx, workspaceID, group, members, op, path, operation.Value); err != nil {
+            return nil, err
+        }
+    }
+
+    group.MemberIDs = make([]uuid.UUID, 0, len(members))
+    for id := range members {
+        group.MemberIDs = append(group.MemberIDs, id)
+    }
+
+    if err := s.userRepo.UpdateGroup(ctx, group); err != nil {
+        return nil, err
+    }
+    return group, nil
+}
+
+func (s *SCIMService) applyGroupPatch(ctx context.Context, workspaceID uuid.UUID, group *models.Group, members map[uuid.UUID]bool, op, path string, value json.RawMessage) error {
+    if match := memberValuePath.FindStringSubmatch(path); match != nil {
+        if op != "remove" {
+            return scimBadRequest("invalidPath", "member filters are only supported for remove")
+        }
+        id, err := uuid.Parse(match[1])
+        if err != nil {
+            return scimBadRequest("invalidValue", "invalid member id")
+        }
+        delete(members, id)
+        return nil
+    }
+
+    switch strings.ToLower(path) {
+    case "members":
+        var refs []apimodels.SCIMMember
+        if len(value) > 0 {
+            if err := json.Unmarshal(value, &refs); err != nil {
+                return scimBadRequest("invalidValue", "members must be an array")
+            }
+        }
+
+        switch op {
+        case "remove":
+            if len(refs) == 0 {
+                for id := range members {
+                    delete(members, id)
+                }
+                return nil
+            }
+            for _, ref := range refs {
+                if id, err := uuid.Parse(ref.Value); err == nil {
+                    delete(members, id)
+                }
+            }
+        case "add", "replace":
+            ids, err := s.resolveMembers(ctx, workspaceID, refs)
+            if err != nil {
+                return err
+            }
+            if op == "replace" {
+                for id := range members {
+                    delete(members, id)
+                }
+            }
+            for _, id := range ids {
+                members[id] = true
+            }
+        default:
+            return scimBadRequest("invalidSyntax", fmt.Sprintf("unsupported patch op %q", op))
+        }
+
+    case "displayname":
+        if op == "remove" {
+            return scimBadRequest("mutability", "displayName cannot be removed")
+        }
+        text, err := patchString(value)
+        if err != nil {
+            return err
+        }
+        if strings.TrimSpace(text) == "" {
+            return scimBadRequest("invalidValue", "displayName is required")
+        }
+        group.DisplayName = strings.TrimSpace(text)
+
+    case "externalid":
+        if op == "remove" {
+            group.ExternalID = nil
+            return nil
+        }
+        text, err := patchString(value)
+        if err != nil {
+            return err
+        }
+        group.ExternalID = optionalString(text)
+
+    default:
+        return scimBadRequest("invalidPath", fmt.Sprintf("unsupported attribute %q", path))
+    }
+    return nil
+}
+
+func (s *SCIMService) DeleteGroup(ctx context.Context, workspaceID, groupID uuid.UUID) error {
+    if _, err := s.GetGroup(ctx, workspaceID, groupID); err != nil {
+        return err
+    }
+    return s.userRepo.DeleteGroup(ctx, workspaceID, groupID)
+}
+
+// resolveMembers checks that every referenced member belongs to the workspace
+func (s *SCIMService) resolveMembers(ctx context.Context, workspaceID uuid.UUID, refs []apimodels.SCIMMember) ([]uuid.UUID, error) {
+    ids := make([]uuid.UUID, 0, len(refs))
+    for _, ref := range refs {
+        id, err := uuid.Parse(ref.Value)
+        if err != nil {
+            return nil, scimBadRequest("invalidValue", fmt.Sprintf("invalid member id %q", ref.Value))
+        }
+        if _, err := s.userRepo.GetWorkspaceUser(ctx, workspaceID, id); err != nil {
+            return nil, scimBadRequest("invalidValue", fmt.Sprintf("member %s is not part of this workspace", ref.Value))
+        }
+        ids = append(ids, id)
+    }
+    return ids, nil
+}
+
+func parseFilter(filter string) (repository.SCIMFilter, error) {
+    if strings.TrimSpace(filter) == "" {
+        return nil, nil
+    }
+    parsed, err := repository.ParseSCIMFilter(filter)
+    if err != nil {
+        return nil, scimBadRequest("invalidFilter", err.Error())
+    }
+    return parsed, nil
+}
+
+// scimRepositoryError turns filter compilation errors into 400s, anything
+// else stays an internal error
+func scimRepositoryError(err error) error {
+    var filterErr *repository.FilterError
+    if errors.As(err, &filterErr) {
+        return scimBadRequest("invalidFilter", filterErr.Detail)
+    }
+    return err
+}
+
+// scimUserNameTaken is the conflict for a userName another account holds
+func scimUserNameTaken() error {
+    return &SCIMError{Status: http.StatusConflict, ScimType: "uniqueness", Detail: "userName is already in use"}
+}
+
+func scimUserName(userName string) (string, error) {
+    email := strings.ToLower(strings.TrimSpace(userName))
+    if email == "" || !strings.Contains(email, "@") {
+        return "", scimBadRequest("invalidValue", "userName must be an email address")
+    }
+    return email, nil
+}
+
+func scimDisplayName(resource *apimodels.SCIMUser, fallback string) string {
+    if name := strings.TrimSpace(resource.DisplayName); name != "" {
+        return name
+    }
+    if name := scimFormattedName(resource.Name); name != "" {
+        return name
+    }
+    return fallback
+}
+
+func scimFormattedName(name *apimodels.SCIMName) string {
+    if name == nil {
+        return ""
+    }
+    if formatted := strings.TrimSpace(name.Formatted); formatted != "" {
+        return formatted
+    }
+    return strings.TrimSpace(name.GivenName + " " + name.FamilyName)
+}
+
+func sameString(a, b *string) bool {
+    if a == nil || b == nil {
+        return a == b
+    }
+    return *a == *b
+}
+
+func optionalString(value string) *string {
+    if value == "" {
+        return nil
+    }
+    return &value
+}
+
+func patchString(value json.RawMessage) (string, error) {
+    var text string
+    if err := json.Unmarshal(value, &text); err != nil {
+        return "", scimBadRequest("invalidValue", "expected a string value")
+    }
+    return text, nil
+}
+
+// patchBool accepts JSON booleans and the "True"/"False" strings some IdPs send
+func patchBool(value json.RawMessage) (bool, error) {
+    var flag bool
+    if err := json.Unmarshal(value, &flag); err == nil {
+        return flag, nil
+    }
+    var text string
+    if err := json.Unmarshal(value, &text); err == nil {
+        if parsed, err := strconv.ParseBool(text); err == nil {
+            return parsed, nil
+        }
+    }
+    return false, scimBadRequest("invalidValue", "expected a boolean value")
+}
@@ -0,0 +1,391 @@
+package services
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "testing"
+
+    "github.com/google/uuid"
+    apimodels "github.com/Shridhar2104/chat-platform/auth-service/internal/models"
+    "github.com/Shridhar2104/chat-platform/auth-service/internal/repository"
+    "github.com/Shridhar2104/chat-platform/shared/models"
+)
+
+type membershipKey struct {
+    workspaceID uuid.UUID
+    userID      uuid.UUID
+}
+
+// fakeSCIMStore keeps users and memberships in maps; methods the tests do
+// not reach fall through to the nil embedded interface
+type fakeSCIMStore struct {
+    SCIMStore
+    users       map[uuid.UUID]*models.User
+    members     map[membershipKey]string
+    deactivated map[uuid.UUID]bool
+}
+
+func newFakeSCIMStore() *fakeSCIMStore {
+    return &fakeSCIMStore{
+        users:       make(map[uuid.UUID]*models.User),
+        members:     make(map[membershipKey]string),
+        deactivated: make(map[uuid.UUID]bool),
+    }
+}
+
+func (f *fakeSCIMStore) addUser(workspaceID uuid.UUID, email, source string) *models.User {
+    user := &models.User{ID: uuid.New(), Email: email, DisplayName: email, Active: true}
+    f.users[user.ID] = user
+    f.members[membershipKey{workspaceID, user.ID}] = source
+    return user
+}
+
+func (f *fakeSCIMStore) GetWorkspaceUser(ctx context.Context, workspaceID, userID uuid.UUID) (*models.User, error) {
+    if _, ok := f.members[membershipKey{workspaceID, userID}]; !ok {
+        return nil, repository.ErrUserNotFound
+    }
+    user := *f.users[userID]
+    return &user, nil
+}
+
+func (f *fakeSCIMStore) WorkspaceMemberSource(ctx context.Context, workspaceID, userID uuid.UUID) (string, error) {
+    source, ok := f.members[membershipKey{workspaceID, userID}]
+    if !ok {
+        return "", repository.ErrUserNotFound
+    }
+    return source, nil
+}
+
+func (f *fakeSCIMStore) RemoveWorkspaceMember(ctx context.Context, workspaceID, userID uuid.UUID) error {
+    delete(f.members, membershipKey{workspaceID, userID})
+    return nil
+}
+
+func (f *fakeSCIMStore) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
+    for _, user := range f.users {
+        if user.Email == email {
+            return user, nil
+        }
+    }
+    return nil, repository.ErrUserNotFound
+}
+
+func (f *fakeSCIMStore) UpdateUser(ctx context.Context, user *models.User) error {
+    stored := *user
+    f.users[user.ID] = &stored
+    return nil
+}
+
+func (f *fakeSCIMStore) DeactivateUser(ctx context.Context, userID uuid.UUID) error {
+    f.users[userID].Active = false
+    f.deactivated[userID] = true
+    return nil
+}
+
+// fakeEmailOwner owns every address under its domains
+type fakeEmailOwner map[string]uuid.UUID
+
+func (f fakeEmailOwner) OwnsEmail(ctx context.Context, workspaceID uuid.UUID, email string) (bool, error) {
+    domain, err := EmailDomain(email)
+    if err != nil {
+        return false, err
+    }
+    owner, ok := f[domain]
+    return ok && owner == workspaceID, nil
+}
+
+var scimWorkspaceID = uuid.MustParse("6f1e0c58-7a43-4c1b-9a55-4d2e7a0f9b10")
+
+func patchOp(op, path, value string) apimodels.SCIMPatchOperation {
+    operation := apimodels.SCIMPatchOperation{Op: op, Path: path}
+    if value != "" {
+        operation.Value = []byte(value)
+    }
+    return operation
+}
+
+func TestSCIMDeprovisioning(t *testing.T) {
+    otherWorkspace := uuid.New()
+
+    tests := []struct {
+        name        string
+        email       string
+        source      string
+        deprovision func(s *SCIMService, userID uuid.UUID) error
+        wantDisable bool
+    }{
+        {
+            name: "delete provisioned user", email: "ann@acme.com", source: "scim",
+            deprovision: func(s *SCIMService, userID uuid.UUID) error {
+                return s.DeleteUser(context.Background(), scimWorkspaceID, userID)
+            },
+            wantDisable: true,
+        },
+        {
+            name: "delete user under verified domain", email: "bob@owned.com", source: "manual",
+            deprovision: func(s *SCIMService, userID uuid.UUID) error {
+                return s.DeleteUser(context.Background(), scimWorkspaceID, userID)
+            },
+            wantDisable: true,
+        },
+        {
+            name: "delete foreign user", email: "eve@gmail.com", source: "manual",
+            deprovision: func(s *SCIMService, userID uuid.UUID) error {
+                return s.DeleteUser(context.Background(), scimWorkspaceID, userID)
+            },
+        },
+        {
+            name: "delete user under another workspace's domain", email: "sam@other.com", source: "manual",
+            deprovision: func(s *SCIMService, userID uuid.UUID) error {
+                return s.DeleteUser(context.Background(), scimWorkspaceID, userID)
+            },
+        },
+        {
+            name: "patch provisioned user inactive", email: "ann@acme.com", source: "scim",
+            deprovision: func(s *SCIMService, userID uuid.UUID) error {
+                _, err := s.PatchUser(context.Background(), scimWorkspaceID, userID,
+                    []apimodels.SCIMPatchOperation{patchOp("replace", "active", "false")})
+                return err
+            },
+            wantDisable: true,
+        },
+        {
+            name: "patch foreign user inactive", email: "eve@gmail.com", source: "manual",
+            deprovision: func(s *SCIMService, userID uuid.UUID) error {
+                _, err := s.PatchUser(context.Background(), scimWorkspaceID, userID,
+                    []apimodels.SCIMPatchOperation{patchOp("replace", "", `{"active":false}`)})
+                return err
+            },
+        },
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            store := newFakeSCIMStore()
+            user := store.addUser(scimWorkspaceID, tt.email, tt.source)
+            store.members[membershipKey{otherWorkspace, user.ID}] = "manual"
+            service := NewSCIMService(store, fakeEmailOwner{"owned.com": scimWorkspaceID, "other.com": otherWorkspace})
+
+            if err := tt.deprovision(service, user.ID); err != nil {
+                t.Fatalf("deprovision failed: %v", err)
+            }
+            if disabled := !store.users[user.ID].Active; disabled != tt.wantDisable {
+                t.Errorf("account disabled = %v, want %v", disabled, tt.wantDisable)
+            }
+            if store.deactivated[user.ID] != tt.wantDisable {
+                t.Errorf("sessions revoked = %v, want %v", store.deactivated[user.ID], tt.wantDisable)
+            }
+            if _, ok := store.members[membershipKey{otherWorkspace, user.ID}]; !ok {
+                t.Error("expected the membership in the other workspace to be kept")
+            }
+            if !tt.wantDisable {
+                if _, ok := store.members[membershipKey{scimWorkspaceID, user.ID}]; ok {
+                    t.Error("expected the membership to be removed")
+                }
+            }
+        })
+    }
+}
+
+func TestSCIMCannotEditForeignUser(t *testing.T) {
+    tests := []struct {
+        name      string
+        operation apimodels.SCIMPatchOperation
+    }{
+        {name: "userName", operation: patchOp("replace", "userName", `"eve@acme.com"`)},
+        {name: "displayName", operation: patchOp("replace", "displayName", `"Mallory"`)},
+        {name: "externalId", operation: patchOp("add", "externalId", `"ext-1"`)},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            store := newFakeSCIMStore()
+            user := store.addUser(scimWorkspaceID, "eve@gmail.com", "manual")
+            service := NewSCIMService(store, fakeEmailOwner{})
+
+            _, err := service.PatchUser(context.Background(), scimWorkspaceID, user.ID, []apimodels.SCIMPatchOperation{tt.operation})
+            var scimErr *SCIMError
+            if !errors.As(err, &scimErr) || scimErr.ScimType != "mutability" {
+                t.Fatalf("PatchUser error = %v, want a mutability error", err)
+            }
+            if got := store.users[user.ID]; got.Email != "eve@gmail.com" || got.DisplayName != "eve@gmail.com" || got.ExternalID != nil {
+                t.Errorf("foreign account was changed: %+v", got)
+            }
+        })
+    }
+}
+
+func TestSCIMDeleteUnknownUser(t *testing.T) {
+    service := NewSCIMService(newFakeSCIMStore(), fakeEmailOwner{})
+    err := service.DeleteUser(context.Background(), scimWorkspaceID, uuid.New())
+    var scimErr *SCIMError
+    if !errors.As(err, &scimErr) || scimErr.Status != 404 {
+        t.Errorf("DeleteUser error = %v, want not found", err)
+    }
+}
+
+func TestSCIMPatchUser(t *testing.T) {
+    externalID := "ext-0"
+
+    tests := []struct {
+        name         string
+        operations   []apimodels.SCIMPatchOperation
+        want         models.User
+        wantScimType string
+    }{
+        {
+            name:       "replace active",
+            operations: []apimodels.SCIMPatchOperation{patchOp("replace", "active", "false")},
+            want:       models.User{Email: "ann@acme.com", DisplayName: "Ann", ExternalID: &externalID},
+        },
+        {
+            name:       "active as a string",
+            operations: []apimodels.SCIMPatchOperation{patchOp("Replace", "active", `"False"`)},
+            want:       models.User{Email: "ann@acme.com", DisplayName: "Ann", ExternalID: &externalID},
+        },
+        {
+            name:       "replace userName",
+            operations: []apimodels.SCIMPatchOperation{patchOp("replace", "userName", `"Annie@Acme.com"`)},
+            want:       models.User{Email: "annie@acme.com", DisplayName: "Ann", ExternalID: &externalID, Active: true},
+        },
+        {
+            name:       "add displayName",
+            operations: []apimodels.SCIMPatchOperation{patchOp("add", "displayName", `"Ann Smith"`)},
+            want:       models.User{Email: "ann@acme.com", DisplayName: "Ann Smith", ExternalID: &externalID, Active: true},
+        },
+        {
+            name:       "replace name object",
+            operations: []apimodels.SCIMPatchOperation{patchOp("replace", "name", `{"givenName":"Ann","familyName":"Lee"}`)},
+            want:       models.User{Email: "ann@acme.com", DisplayName: "Ann Lee", ExternalID: &externalID, Active: true},
+        },
+        {
+            name:       "remove displayName falls back to the email",
+            operations: []apimodels.SCIMPatchOperation{patchOp("remove", "displayName", "")},
+            want:       models.User{Email: "ann@acme.com", DisplayName: "ann@acme.com", ExternalID: &externalID, Active: true},
+        },
+        {
+            name:       "remove externalId",
+            operations: []apimodels.SCIMPatchOperation{patchOp("remove", "externalId", "")},
+            want:       models.User{Email: "ann@acme.com", DisplayName: "Ann", Active: true},
+        },
+        {
+            name:       "emails are ignored",
+            operations: []apimodels.SCIMPatchOperation{patchOp("replace", `emails[type eq "work"].value`, `"other@acme.com"`)},
+            want:       models.User{Email: "ann@acme.com", DisplayName: "Ann", ExternalID: &externalID, Active: true},
+        },
+        {
+            name: "object without a path",
+            operations: []apimodels.SCIMPatchOperation{
+                patchOp("replace", "", `{"active":false,"displayName":"A. Lee","externalId":"ext-1"}`),
+            },
+            want: models.User{Email: "ann@acme.com", DisplayName: "A. Lee", ExternalID: stringPtr("ext-1")},
+        },
+        {
+            name: "operations apply in order",
+            operations: []apimodels.SCIMPatchOperation{
+                patchOp("replace", "active", "false"),
+                patchOp("replace", "active", "true"),
+            },
+            want: models.User{Email: "ann@acme.com", DisplayName: "Ann", ExternalID: &externalID, Active: true},
+        },
+        {name: "remove active", operations: []apimodels.SCIMPatchOperation{patchOp("remove", "active", "")}, wantScimType: "mutability"},
+        {name: "remove userName", operations: []apimodels.SCIMPatchOperation{patchOp("remove", "userName", "")}, wantScimType: "mutability"},
+        {name: "remove without a path", operations: []apimodels.SCIMPatchOperation{patchOp("remove", "", "")}, wantScimType: "noTarget"},
+        {name: "unsupported path", operations: []apimodels.SCIMPatchOperation{patchOp("replace", "password", `"secret"`)}, wantScimType: "invalidPath"},
+        {name: "unsupported op", operations: []apimodels.SCIMPatchOperation{patchOp("move", "active", "false")}, wantScimType: "invalidSyntax"},
+        {name: "non-object value without a path", operations: []apimodels.SCIMPatchOperation{patchOp("replace", "", `"x"`)}, wantScimType: "invalidValue"},
+        {name: "invalid active value", operations: []apimodels.SCIMPatchOperation{patchOp("replace", "active", `"maybe"`)}, wantScimType: "invalidValue"},
+        {name: "invalid userName", operations: []apimodels.SCIMPatchOperation{patchOp("replace", "userName", `"not-an-email"`)}, wantScimType: "invalidValue"},
+        {name: "userName taken", operations: []apimodels.SCIMPatchOperation{patchOp("replace", "userName", `"bob@acme.com"`)}, wantScimType: "uniqueness"},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            store := newFakeSCIMStore()
+            user := store.addUser(scimWorkspaceID, "ann@acme.com", "scim")
+            user.DisplayName = "Ann"
+            user.ExternalID = stringPtr(externalID)
+            store.addUser(scimWorkspaceID, "bob@acme.com", "scim")
+            service := NewSCIMService(store, fakeEmailOwner{})
+
+            got, err := service.PatchUser(context.Background(), scimWorkspaceID, user.ID, tt.operations)
+            if tt.wantScimType != "" {
+                var scimErr *SCIMError
+                if !errors.As(err, &scimErr) || scimErr.ScimType != tt.wantScimType {
+                    t.Fatalf("PatchUser error = %v, want scimType %s", err, tt.wantScimType)
+                }
+                if stored := store.users[user.ID]; stored.Email != "ann@acme.com" || stored.DisplayName != "Ann" || !stored.Active {
+                    t.Errorf("failed patch changed the account: %+v", stored)
+                }
+                return
+            }
+            if err != nil {
+                t.Fatalf("PatchUser failed: %v", err)
+            }
+
+            stored := store.users[user.ID]
+            for _, u := range []*models.User{got, stored} {
+                if u.Email != tt.want.Email || u.DisplayName != tt.want.DisplayName || u.Active != tt.want.Active || !sameString(u.ExternalID, tt.want.ExternalID) {
+                    t.Errorf("user = {%s %q active=%v external=%v}, want {%s %q active=%v external=%v}",
+                        u.Email, u.DisplayName, u.Active, u.ExternalID, tt.want.Email, tt.want.DisplayName, tt.want.Active, tt.want.ExternalID)
+                }
+            }
+            if revoked := store.deactivated[user.ID]; revoked != !tt.want.Active {
+                t.Errorf("sessions revoked = %v, want %v", revoked, !tt.want.Active)
+            }
+        })
+    }
+}
+
+// racingSCIMStore loses the userName to a concurrent create after the check
+type racingSCIMStore struct {
+    *fakeSCIMStore
+}
+
+func (s racingSCIMStore) EmailExists(ctx context.Context, email string) (bool, error) {
+    return false, nil
+}
+
+func (s racingSCIMStore) CreateUser(ctx context.Context, user *models.User) error {
+    return fmt.Errorf("failed to create user: %w", repository.ErrDuplicate)
+}
+
+func TestSCIMCreateUserRace(t *testing.T) {
+    service := NewSCIMService(racingSCIMStore{newFakeSCIMStore()}, fakeEmailOwner{})
+    _, err := service.CreateUser(context.Background(), scimWorkspaceID, &apimodels.SCIMUser{UserName: "ann@acme.com"})
+    var scimErr *SCIMError
+    if !errors.As(err, &scimErr) || scimErr.Status != 409 || scimErr.ScimType != "uniqueness" {
+        t.Errorf("CreateUser error = %v, want a uniqueness conflict", err)
+    }
+}
+
+// listErrorStore fails every user listing with err
+type listErrorStore struct {
+    *fakeSCIMStore
+    err error
+}
+
+func (s listErrorStore) ListWorkspaceUsers(ctx context.Context, workspaceID uuid.UUID, filter repository.SCIMFilter, startIndex, count int) ([]models.User, int, error) {
+    return nil, 0, s.err
+}
+
+func TestSCIMListUsersErrors(t *testing.T) {
+    filterErr := listErrorStore{newFakeSCIMStore(), &repository.FilterError{Detail: `unsupported filter attribute "secret"`}}
+    _, _, err := NewSCIMService(filterErr, fakeEmailOwner{}).ListUsers(context.Background(), scimWorkspaceID, "secret pr", 1, 10)
+    var scimErr *SCIMError
+    if !errors.As(err, &scimErr) || scimErr.ScimType != "invalidFilter" {
+        t.Errorf("ListUsers error = %v, want invalidFilter", err)
+    }
+
+    // Database failures must not be reported as the client's filter
+    dbErr := listErrorStore{newFakeSCIMStore(), errors.New("pq: relation users does not exist")}
+    _, _, err = NewSCIMService(dbErr, fakeEmailOwner{}).ListUsers(context.Background(), scimWorkspaceID, "", 1, 10)
+    if err == nil || errors.As(err, &scimErr) {
+        t.Errorf("ListUsers error = %v, want an internal error", err)
+    }
+}
+
+func stringPtr(value string) *string {
+    return &value
+}
\ No newline at end of file
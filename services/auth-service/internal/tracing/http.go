@@ -0,0 +1,82 @@
+package tracing
+
+import (
+    "net/http"
+
+    "github.com/gin-gonic/gin"
+    "go.opentelemetry.io/otel"
+    "go.opentelemetry.io/otel/codes"
+    "go.opentelemetry.io/otel/propagation"
+    semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+    "go.opentelemetry.io/otel/trace"
+)
+
+// Middleware starts a server span per request, continuing the caller's trace
+// when it sent a traceparent header. Spans are named after the route pattern.
+func Middleware() gin.HandlerFunc {
+    return func(c *gin.Context) {
+        ctx := otel.GetTextMapPropagator().Extract(c.Request.Context(), propagation.HeaderCarrier(c.Request.Header))
+
+        route := c.FullPath()
+        if route == "" {
+            route = "unmatched"
+        }
+        ctx, span := otel.Tracer(instrumentation).Start(ctx, c.Request.Method+" "+route,
+            trace.WithSpanKind(trace.SpanKindServer),
+            trace.WithAttributes(
+                semconv.HTTPRequestMethodKey.String(c.Request.Method),
+                semconv.HTTPRoute(route),
+                semconv.URLPath(c.Request.URL.Path),
+            ),
+        )
+        defer span.End()
+
+        c.Request = c.Request.WithContext(ctx)
+        c.Next()
+
+        status := c.Writer.Status()
+        span.SetAttributes(semconv.HTTPResponseStatusCode(status))
+        if status >= http.StatusInternalServerError {
+            span.SetStatus(codes.Error, http.StatusText(status))
+        }
+    }
+}
+
+// Transport wraps base so outbound requests get a client span and carry the
+// current trace context, base may be nil for http.DefaultTransport
+func Transport(base http.RoundTripper) http.RoundTripper {
+    if base == nil {
+        base = http.DefaultTransport
+    }
+    return &transport{base: base}
+}
+
+type transport struct {
+    base http.RoundTripper
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+    ctx, span := otel.Tracer(instrumentation).Start(req.Context(), "HTTP "+req.Method,
+        trace.WithSpanKind(trace.SpanKindClient),
+        trace.WithAttributes(
+            semconv.HTTPRequestMethodKey.String(req.Method),
+            semconv.ServerAddress(req.URL.Hostname()),
+        ),
+    )
+    defer span.End()
+
+    // RoundTrippers must not modify the caller's request
+    req = req.Clone(ctx)
+    otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+    resp, err := t.base.RoundTrip(req)
+    if err != nil {
+        RecordError(span, err)
+        return nil, err
+    }
+    span.SetAttributes(semconv.HTTPResponseStatusCode(resp.StatusCode))
+    if resp.StatusCode >= http.StatusInternalServerError {
+        span.SetStatus(codes.Error, http.StatusText(resp.StatusCode))
+    }
+    return resp, nil
+}
\ No newline at end of file
@@ -0,0 +1,127 @@
+// Package tracing configures OpenTelemetry for the service and provides the
+// Gin middleware and HTTP transport that carry W3C trace context in and out.
+package tracing
+
+import (
+    "context"
+    "fmt"
+    "io"
+    "os"
+
+    "go.opentelemetry.io/otel"
+    "go.opentelemetry.io/otel/attribute"
+    "go.opentelemetry.io/otel/codes"
+    "go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+    "go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+    "go.opentelemetry.io/otel/propagation"
+    "go.opentelemetry.io/otel/sdk/resource"
+    sdktrace "go.opentelemetry.io/otel/sdk/trace"
+    semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+    "go.opentelemetry.io/otel/trace"
+    "github.com/Shridhar2104/chat-platform/auth-service/internal/buildinfo"
+)
+
+const (
+    ServiceName     = "auth-service"
+    instrumentation = "github.com/Shridhar2104/chat-platform/auth-service"
+
+    ExporterNone   = "none"
+    ExporterStdout = "stdout"
+    ExporterFile   = "file"
+    ExporterOTLP   = "otlp"
+)
+
+// Config selects where spans go. The OTLP exporter reads its endpoint and
+// headers from the standard OTEL_EXPORTER_OTLP_* variables.
+type Config struct {
+    Exporter    string
+    FilePath    string
+    SampleRatio float64
+    Environment string
+}
+
+// Setup installs the global tracer provider and W3C propagators. The returned
+// function flushes pending spans and must be called on shutdown.
+func Setup(ctx context.Context, config Config) (func(context.Context) error, error) {
+    otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+    var closer io.Closer
+    var exporter sdktrace.SpanExporter
+    var err error
+    switch config.Exporter {
+    case "", ExporterNone:
+        return func(context.Context) error { return nil }, nil
+    case ExporterStdout:
+        exporter, err = stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+    case ExporterFile:
+        if config.FilePath == "" {
+            return nil, fmt.Errorf("tracing file exporter needs a file path")
+        }
+        file, openErr := os.OpenFile(config.FilePath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+        if openErr != nil {
+            return nil, fmt.Errorf("failed to open trace file: %w", openErr)
+        }
+        closer = file
+        exporter, err = stdouttrace.New(stdouttrace.WithWriter(file))
+    case ExporterOTLP:
+        exporter, err = otlptracehttp.New(ctx)
+    default:
+        return nil, fmt.Errorf("unknown tracing exporter %q", config.Exporter)
+    }
+    if err != nil {
+        return nil, fmt.Errorf("failed to create %s trace exporter: %w", config.Exporter, err)
+    }
+
+    res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(semconv.SchemaURL,
+        semconv.ServiceName(ServiceName),
+        semconv.ServiceVersion(buildinfo.Get().Version),
+        semconv.DeploymentEnvironment(config.Environment),
+    ))
+    if err != nil {
+        return nil, fmt.Errorf("failed to build trace resource: %w", err)
+    }
+
+    provider := sdktrace.NewTracerProvider(
+        sdktrace.WithBatcher(exporter),
+        sdktrace.WithResource(res),
+        sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(config.SampleRatio))),
+    )
+    otel.SetTracerProvider(provider)
+
+    return func(ctx context.Context) error {
+        err := provider.Shutdown(ctx)
+        if closer != nil {
+            closer.Close()
+        }
+        return err
+    }, nil
+}
+
+// Start begins an internal span from the service's tracer
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+    return otel.Tracer(instrumentation).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// StartDB begins a client span for a database or cache call, named
+// "operation collection" as the semantic conventions suggest
+func StartDB(ctx context.Context, system attribute.KeyValue, operation, collection string) (context.Context, trace.Span) {
+    name := operation
+    attrs := []attribute.KeyValue{system, semconv.DBOperationName(operation)}
+    if collection != "" {
+        name += " " + collection
+        attrs = append(attrs, semconv.DBCollectionName(collection))
+    }
+    return otel.Tracer(instrumentation).Start(ctx, name,
+        trace.WithSpanKind(trace.SpanKindClient),
+        trace.WithAttributes(attrs...),
+    )
+}
+
+// RecordError marks the span failed, err may be nil
+func RecordError(span trace.Span, err error) {
+    if err == nil {
+        return
+    }
+    span.RecordError(err)
+    span.SetStatus(codes.Error, err.Error())
+}
\ No newline at end of file
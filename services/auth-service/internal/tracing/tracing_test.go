@@ -0,0 +1,131 @@
+package tracing
+
+import (
+    "context"
+    "net/http"
+    "net/http/httptest"
+    "os"
+    "path/filepath"
+    "strings"
+    "testing"
+
+    "github.com/gin-gonic/gin"
+    "go.opentelemetry.io/otel"
+    "go.opentelemetry.io/otel/codes"
+    "go.opentelemetry.io/otel/propagation"
+    sdktrace "go.opentelemetry.io/otel/sdk/trace"
+    "go.opentelemetry.io/otel/sdk/trace/tracetest"
+    semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+)
+
+const parentTraceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+
+func newRecorder(t *testing.T) *tracetest.SpanRecorder {
+    t.Helper()
+    recorder := tracetest.NewSpanRecorder()
+    provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+    otel.SetTracerProvider(provider)
+    otel.SetTextMapPropagator(propagation.TraceContext{})
+    t.Cleanup(func() { provider.Shutdown(context.Background()) })
+    return recorder
+}
+
+func TestMiddlewareContinuesIncomingTrace(t *testing.T) {
+    recorder := newRecorder(t)
+    gin.SetMode(gin.TestMode)
+    router := gin.New()
+    router.Use(Middleware())
+    router.GET("/api/v1/users/:id", func(c *gin.Context) {
+        _, span := Start(c.Request.Context(), "child")
+        span.End()
+        c.Status(http.StatusInternalServerError)
+    })
+
+    req := httptest.NewRequest(http.MethodGet, "/api/v1/users/42", nil)
+    req.Header.Set("traceparent", "00-"+parentTraceID+"-00f067aa0ba902b7-01")
+    router.ServeHTTP(httptest.NewRecorder(), req)
+
+    spans := recorder.Ended()
+    if len(spans) != 2 {
+        t.Fatalf("spans = %d, want handler child and server span", len(spans))
+    }
+    child, server := spans[0], spans[1]
+    if server.Name() != "GET /api/v1/users/:id" {
+        t.Errorf("server span name = %q", server.Name())
+    }
+    if got := server.SpanContext().TraceID().String(); got != parentTraceID {
+        t.Errorf("trace id = %s, want the caller's", got)
+    }
+    if child.Parent().SpanID() != server.SpanContext().SpanID() {
+        t.Error("handler span is not a child of the server span")
+    }
+    if server.Status().Code != codes.Error {
+        t.Errorf("status = %v, want error for a 500", server.Status().Code)
+    }
+    found := false
+    for _, attr := range server.Attributes() {
+        if attr == semconv.HTTPResponseStatusCode(http.StatusInternalServerError) {
+            found = true
+        }
+    }
+    if !found {
+        t.Error("server span is missing the response status code")
+    }
+}
+
+func TestTransportInjectsTraceContext(t *testing.T) {
+    recorder := newRecorder(t)
+    var traceparent string
+    upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        traceparent = r.Header.Get("traceparent")
+    }))
+    defer upstream.Close()
+
+    ctx, parent := Start(context.Background(), "parent")
+    req, _ := http.NewRequestWithContext(ctx, http.MethodPost, upstream.URL, nil)
+    client := &http.Client{Transport: Transport(nil)}
+    resp, err := client.Do(req)
+    if err != nil {
+        t.Fatalf("request failed: %v", err)
+    }
+    resp.Body.Close()
+    parent.End()
+
+    if req.Header.Get("traceparent") != "" {
+        t.Error("transport modified the caller's request")
+    }
+    traceID := parent.SpanContext().TraceID().String()
+    if !strings.Contains(traceparent, traceID) {
+        t.Errorf("traceparent = %q, want trace %s", traceparent, traceID)
+    }
+    if spans := recorder.Ended(); len(spans) != 2 || spans[0].Name() != "HTTP POST" {
+        t.Errorf("expected a client span for the outbound call, got %d spans", len(spans))
+    }
+}
+
+func TestSetupFileExporter(t *testing.T) {
+    path := filepath.Join(t.TempDir(), "traces.jsonl")
+    shutdown, err := Setup(context.Background(), Config{Exporter: ExporterFile, FilePath: path, SampleRatio: 1})
+    if err != nil {
+        t.Fatalf("Setup failed: %v", err)
+    }
+    _, span := Start(context.Background(), "offline")
+    span.End()
+    if err := shutdown(context.Background()); err != nil {
+        t.Fatalf("shutdown failed: %v", err)
+    }
+
+    data, err := os.ReadFile(path)
+    if err != nil {
+        t.Fatalf("trace file not written: %v", err)
+    }
+    if !strings.Contains(string(data), `"Name":"offline"`) {
+        t.Errorf("trace file is missing the span: %s", data)
+    }
+}
+
+func TestSetupRejectsUnknownExporter(t *testing.T) {
+    if _, err := Setup(context.Background(), Config{Exporter: "zipkin"}); err == nil {
+        t.Error("expected an error for an unknown exporter")
+    }
+}
\ No newline at end of file
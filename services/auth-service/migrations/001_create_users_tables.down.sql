@@ -0,0 +1,4 @@
+-- Reverts 001_create_users_tables.up.sql
+DROP FUNCTION IF EXISTS cleanup_expired_sessions();
+DROP TABLE IF EXISTS user_sessions;
+DROP TABLE IF EXISTS users;
\ No newline at end of file
@@ -0,0 +1,2 @@
+-- Reverts 002_create_login_events_table.up.sql
+DROP TABLE IF EXISTS login_events;
\ No newline at end of file
@@ -0,0 +1,18 @@
+-- Login history used for risk-based authentication
+CREATE TABLE IF NOT EXISTS login_events (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID REFERENCES users(id) ON DELETE CASCADE,
+    device_id VARCHAR(255) NOT NULL,
+    ip_address VARCHAR(45) NOT NULL,
+    ip_prefix VARCHAR(64) NOT NULL,
+    country_code VARCHAR(2),
+    city VARCHAR(255),
+    latitude DOUBLE PRECISION,
+    longitude DOUBLE PRECISION,
+    risk_score INTEGER NOT NULL DEFAULT 0,
+    risk_level VARCHAR(16) NOT NULL DEFAULT 'low',
+    created_at TIMESTAMP DEFAULT NOW()
+);
+
+-- Indexes for performance
+CREATE INDEX IF NOT EXISTS idx_login_events_user_id_created_at ON login_events(user_id, created_at DESC);
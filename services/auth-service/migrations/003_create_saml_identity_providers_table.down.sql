@@ -0,0 +1,2 @@
+-- Reverts 003_create_saml_identity_providers_table.up.sql
+DROP TABLE IF EXISTS saml_identity_providers;
\ No newline at end of file
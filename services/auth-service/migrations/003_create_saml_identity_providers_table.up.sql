@@ -0,0 +1,16 @@
+-- SAML identity providers, one per workspace
+CREATE TABLE IF NOT EXISTS saml_identity_providers (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    workspace_id UUID UNIQUE NOT NULL,
+    entity_id VARCHAR(1024) NOT NULL,
+    sso_url TEXT NOT NULL,
+    certificate TEXT NOT NULL,
+    email_attribute VARCHAR(255) NOT NULL DEFAULT '',
+    display_name_attribute VARCHAR(255) NOT NULL DEFAULT '',
+    enabled BOOLEAN DEFAULT true,
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW()
+);
+
+-- Indexes for performance
+CREATE INDEX IF NOT EXISTS idx_saml_identity_providers_workspace_id ON saml_identity_providers(workspace_id);
@@ -0,0 +1,8 @@
+-- Reverts 004_create_scim_tables.up.sql
+DROP TABLE IF EXISTS scim_group_members;
+DROP TABLE IF EXISTS scim_groups;
+DROP TABLE IF EXISTS scim_tokens;
+DROP TABLE IF EXISTS workspace_members;
+
+ALTER TABLE users DROP COLUMN IF EXISTS external_id;
+ALTER TABLE users DROP COLUMN IF EXISTS active;
\ No newline at end of file
@@ -0,0 +1,45 @@
+-- Account state managed by SCIM provisioning
+ALTER TABLE users ADD COLUMN IF NOT EXISTS active BOOLEAN NOT NULL DEFAULT true;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS external_id VARCHAR(255);
+
+-- Workspace membership
+CREATE TABLE IF NOT EXISTS workspace_members (
+    workspace_id UUID NOT NULL,
+    user_id UUID REFERENCES users(id) ON DELETE CASCADE,
+    source VARCHAR(32) NOT NULL DEFAULT 'manual',
+    created_at TIMESTAMP DEFAULT NOW(),
+    PRIMARY KEY (workspace_id, user_id)
+);
+
+-- SCIM bearer tokens, one or more per workspace
+CREATE TABLE IF NOT EXISTS scim_tokens (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    workspace_id UUID NOT NULL,
+    token_hash VARCHAR(255) UNIQUE NOT NULL,
+    description VARCHAR(255) NOT NULL DEFAULT '',
+    revoked BOOLEAN DEFAULT false,
+    created_at TIMESTAMP DEFAULT NOW(),
+    last_used_at TIMESTAMP
+);
+
+-- SCIM groups
+CREATE TABLE IF NOT EXISTS scim_groups (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    workspace_id UUID NOT NULL,
+    display_name VARCHAR(255) NOT NULL,
+    external_id VARCHAR(255),
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW(),
+    UNIQUE (workspace_id, display_name)
+);
+
+CREATE TABLE IF NOT EXISTS scim_group_members (
+    group_id UUID REFERENCES scim_groups(id) ON DELETE CASCADE,
+    user_id UUID REFERENCES users(id) ON DELETE CASCADE,
+    PRIMARY KEY (group_id, user_id)
+);
+
+-- Indexes for performance
+CREATE INDEX IF NOT EXISTS idx_workspace_members_user_id ON workspace_members(user_id);
+CREATE INDEX IF NOT EXISTS idx_scim_groups_workspace_id ON scim_groups(workspace_id);
+CREATE INDEX IF NOT EXISTS idx_scim_group_members_user_id ON scim_group_members(user_id);
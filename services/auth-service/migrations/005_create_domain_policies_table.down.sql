@@ -0,0 +1,2 @@
+-- Reverts 005_create_domain_policies_table.up.sql
+DROP TABLE IF EXISTS domain_policies;
\ No newline at end of file
@@ -0,0 +1,21 @@
+-- Email-domain policies claimed by workspaces
+CREATE TABLE IF NOT EXISTS domain_policies (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    workspace_id UUID NOT NULL,
+    domain VARCHAR(255) NOT NULL,
+    verification_token VARCHAR(255) NOT NULL,
+    verified BOOLEAN NOT NULL DEFAULT false,
+    verified_at TIMESTAMP,
+    allow_signup BOOLEAN NOT NULL DEFAULT true,
+    enforce_sso BOOLEAN NOT NULL DEFAULT false,
+    auto_join BOOLEAN NOT NULL DEFAULT false,
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW(),
+    UNIQUE (workspace_id, domain)
+);
+
+-- A domain can only be verified by one workspace
+CREATE UNIQUE INDEX IF NOT EXISTS idx_domain_policies_verified_domain ON domain_policies(domain) WHERE verified;
+
+-- Indexes for performance
+CREATE INDEX IF NOT EXISTS idx_domain_policies_workspace_id ON domain_policies(workspace_id);
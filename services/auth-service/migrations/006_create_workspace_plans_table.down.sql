@@ -0,0 +1,2 @@
+-- Reverts 006_create_workspace_plans_table.up.sql
+DROP TABLE IF EXISTS workspace_plans;
\ No newline at end of file
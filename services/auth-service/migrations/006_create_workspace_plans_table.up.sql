@@ -0,0 +1,6 @@
+-- Subscription plan of each workspace, rate limit tiers are chosen by plan
+CREATE TABLE IF NOT EXISTS workspace_plans (
+    workspace_id UUID PRIMARY KEY,
+    plan VARCHAR(50) NOT NULL,
+    updated_at TIMESTAMP DEFAULT NOW()
+);
@@ -0,0 +1,9 @@
+// Package migrations embeds the auth service's versioned schema changes.
+// Files are named NNN_description.up.sql with a matching .down.sql, and are
+// applied in version order by the migrate subcommand.
+package migrations
+
+import "embed"
+
+//go:embed *.sql
+var FS embed.FS
\ No newline at end of file
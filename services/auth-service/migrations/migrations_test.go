@@ -0,0 +1,25 @@
+package migrations
+
+import (
+    "testing"
+
+    "github.com/Shridhar2104/chat-platform/shared/database"
+)
+
+func TestEmbeddedMigrationsLoad(t *testing.T) {
+    migrations, err := database.LoadMigrations(FS)
+    if err != nil {
+        t.Fatalf("LoadMigrations: %v", err)
+    }
+    if len(migrations) == 0 {
+        t.Fatal("no migrations embedded")
+    }
+    for i, migration := range migrations {
+        if migration.Version != int64(i+1) {
+            t.Errorf("migration %d_%s breaks the version sequence", migration.Version, migration.Name)
+        }
+        if migration.Down == "" {
+            t.Errorf("migration %d_%s has no down script", migration.Version, migration.Name)
+        }
+    }
+}
\ No newline at end of file
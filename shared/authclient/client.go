@@ -0,0 +1,255 @@
+// Package authclient verifies access tokens issued by the auth service so that
+// downstream services never need the signing secret.
+package authclient
+
+import (
+    "context"
+    "crypto/sha256"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "net/http"
+    "net/url"
+    "slices"
+    "strings"
+    "sync"
+    "time"
+
+    "github.com/golang-jwt/jwt/v5"
+    "github.com/google/uuid"
+)
+
+const DefaultIssuer = "chat-platform-auth"
+
+// maxIntrospectionCacheEntries bounds the tokens whose introspection result is kept
+const maxIntrospectionCacheEntries = 10_000
+
+var (
+    ErrMissingToken      = errors.New("missing access token")
+    ErrInvalidToken      = errors.New("invalid or expired token")
+    ErrInactiveToken     = errors.New("token is no longer active")
+    ErrInsufficientScope = errors.New("insufficient scope")
+)
+
+// Config configures a Client. Only JWKSURL is required.
+type Config struct {
+    // JWKSURL is the auth service key set, e.g. http://auth-service:8080/.well-known/jwks.json
+    JWKSURL string
+    Issuer  string
+
+    // IntrospectionURL enables online checks against the auth service on every
+    // request, which also catches disabled accounts before the token expires
+    IntrospectionURL   string
+    IntrospectionToken string
+    // IntrospectionCacheTTL is how long an active result is reused before the
+    // auth service is asked again, 10s by default; negative disables caching
+    IntrospectionCacheTTL time.Duration
+
+    HTTPClient *http.Client
+    // JWKSCacheTTL is how long fetched keys are trusted before refetching
+    JWKSCacheTTL time.Duration
+    // JWKSMinRefreshInterval limits refetches triggered by unknown key IDs
+    JWKSMinRefreshInterval time.Duration
+    // Leeway tolerates clock skew when checking exp and nbf
+    Leeway time.Duration
+}
+
+type Client struct {
+    config Config
+    keys   *JWKSCache
+    parser *jwt.Parser
+
+    mu           sync.Mutex
+    introspected map[[sha256.Size]byte]introspectionResult
+}
+
+// introspectionResult is a cached answer for an active token
+type introspectionResult struct {
+    scopes  []string
+    roles   []string
+    expires time.Time
+}
+
+type accessClaims struct {
+    UserID   uuid.UUID `json:"user_id"`
+    Email    string    `json:"email"`
+    DeviceID string    `json:"device_id"`
+    Scope    string    `json:"scope"`
+    Roles    []string  `json:"roles"`
+    jwt.RegisteredClaims
+}
+
+type introspectionResponse struct {
+    Active   bool     `json:"active"`
+    Subject  string   `json:"sub"`
+    Email    string   `json:"email"`
+    DeviceID string   `json:"device_id"`
+    Scope    string   `json:"scope"`
+    Roles    []string `json:"roles"`
+    Expires  int64    `json:"exp"`
+}
+
+func New(config Config) (*Client, error) {
+    if config.JWKSURL == "" {
+        return nil, fmt.Errorf("jwks url is required")
+    }
+    if config.Issuer == "" {
+        config.Issuer = DefaultIssuer
+    }
+    if config.HTTPClient == nil {
+        config.HTTPClient = &http.Client{Timeout: 5 * time.Second}
+    }
+    if config.JWKSCacheTTL == 0 {
+        config.JWKSCacheTTL = 10 * time.Minute
+    }
+    if config.JWKSMinRefreshInterval == 0 {
+        config.JWKSMinRefreshInterval = 30 * time.Second
+    }
+    if config.Leeway == 0 {
+        config.Leeway = 30 * time.Second
+    }
+    if config.IntrospectionCacheTTL == 0 {
+        config.IntrospectionCacheTTL = 10 * time.Second
+    }
+
+    return &Client{
+        config: config,
+        keys:   NewJWKSCache(config.JWKSURL, config.HTTPClient, config.JWKSCacheTTL, config.JWKSMinRefreshInterval),
+        parser: jwt.NewParser(
+            jwt.WithValidMethods([]string{jwt.SigningMethodRS256.Alg()}),
+            jwt.WithIssuer(config.Issuer),
+            jwt.WithExpirationRequired(),
+            jwt.WithLeeway(config.Leeway),
+        ),
+        introspected: make(map[[sha256.Size]byte]introspectionResult),
+    }, nil
+}
+
+// Verify checks the token signature and claims offline, then with the auth
+// service when introspection is configured
+func (c *Client) Verify(ctx context.Context, token string) (*Principal, error) {
+    if token == "" {
+        return nil, ErrMissingToken
+    }
+
+    var claims accessClaims
+    var keyErr error
+    _, err := c.parser.ParseWithClaims(token, &claims, func(t *jwt.Token) (interface{}, error) {
+        keyID, _ := t.Header["kid"].(string)
+        if keyID == "" {
+            return nil, fmt.Errorf("token has no key id")
+        }
+        key, err := c.keys.Key(ctx, keyID)
+        keyErr = err
+        return key, err
+    })
+    if keyErr != nil && !errors.Is(keyErr, errUnknownKeyID) {
+        // The key set could not be fetched, the token may well be valid
+        return nil, fmt.Errorf("failed to verify token: %w", keyErr)
+    }
+    if err != nil || claims.UserID == uuid.Nil {
+        return nil, ErrInvalidToken
+    }
+
+    principal := &Principal{
+        UserID:    claims.UserID,
+        Email:     claims.Email,
+        DeviceID:  claims.DeviceID,
+        Scopes:    strings.Fields(claims.Scope),
+        Roles:     claims.Roles,
+        ExpiresAt: claims.ExpiresAt.Time,
+    }
+
+    if c.config.IntrospectionURL != "" {
+        if err := c.introspect(ctx, token, principal); err != nil {
+            return nil, err
+        }
+    }
+    return principal, nil
+}
+
+// introspect asks the auth service whether the token is still active (RFC 7662).
+// Active results are reused for IntrospectionCacheTTL, so a revoked token
+// may be accepted for up to that long.
+func (c *Client) introspect(ctx context.Context, token string, principal *Principal) error {
+    key := sha256.Sum256([]byte(token))
+    if result, ok := c.cachedIntrospection(key); ok {
+        principal.Scopes = slices.Clone(result.scopes)
+        principal.Roles = slices.Clone(result.roles)
+        return nil
+    }
+
+    form := url.Values{"token": {token}, "token_type_hint": {"access_token"}}
+    req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.config.IntrospectionURL, strings.NewReader(form.Encode()))
+    if err != nil {
+        return fmt.Errorf("failed to build introspection request: %w", err)
+    }
+    req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+    if c.config.IntrospectionToken != "" {
+        req.Header.Set("Authorization", "Bearer "+c.config.IntrospectionToken)
+    }
+
+    resp, err := c.config.HTTPClient.Do(req)
+    if err != nil {
+        return fmt.Errorf("introspection failed: %w", err)
+    }
+    defer resp.Body.Close()
+
+    if resp.StatusCode != http.StatusOK {
+        return fmt.Errorf("introspection failed: status %d", resp.StatusCode)
+    }
+
+    var result introspectionResponse
+    if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+        return fmt.Errorf("failed to decode introspection response: %w", err)
+    }
+    if !result.Active || result.Subject != principal.UserID.String() {
+        return ErrInactiveToken
+    }
+
+    // The auth service has the current view of scopes and roles
+    principal.Scopes = strings.Fields(result.Scope)
+    principal.Roles = result.Roles
+    c.cacheIntrospection(key, introspectionResult{
+        scopes:  slices.Clone(principal.Scopes),
+        roles:   slices.Clone(principal.Roles),
+        expires: time.Now().Add(c.config.IntrospectionCacheTTL),
+    }, principal.ExpiresAt)
+    return nil
+}
+
+func (c *Client) cachedIntrospection(key [sha256.Size]byte) (introspectionResult, bool) {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    result, ok := c.introspected[key]
+    if !ok || !time.Now().Before(result.expires) {
+        return introspectionResult{}, false
+    }
+    return result, true
+}
+
+// cacheIntrospection keeps an active result, never past the token's expiry.
+// A full cache drops expired results first and otherwise skips caching.
+func (c *Client) cacheIntrospection(key [sha256.Size]byte, result introspectionResult, tokenExpires time.Time) {
+    if c.config.IntrospectionCacheTTL < 0 {
+        return
+    }
+    if tokenExpires.Before(result.expires) {
+        result.expires = tokenExpires
+    }
+
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    if len(c.introspected) >= maxIntrospectionCacheEntries {
+        now := time.Now()
+        for k, cached := range c.introspected {
+            if !now.Before(cached.expires) {
+                delete(c.introspected, k)
+            }
+        }
+        if len(c.introspected) >= maxIntrospectionCacheEntries {
+            return
+        }
+    }
+    c.introspected[key] = result
+}
@@ -0,0 +1,454 @@
+package authclient
+
+import (
+    "context"
+    "crypto/rand"
+    "crypto/rsa"
+    "encoding/json"
+    "errors"
+    "net/http"
+    "net/http/httptest"
+    "sync"
+    "sync/atomic"
+    "testing"
+    "time"
+
+    "github.com/gin-gonic/gin"
+    "github.com/golang-jwt/jwt/v5"
+    "github.com/google/uuid"
+)
+
+// authServer stands in for the auth service's JWKS and introspection endpoints
+type authServer struct {
+    *httptest.Server
+
+    mu       sync.Mutex
+    keys     []*rsa.PrivateKey
+    inactive map[string]bool
+    fetches  atomic.Int32
+    // introspections counts introspection requests
+    introspections atomic.Int32
+}
+
+func newAuthServer(t *testing.T) *authServer {
+    t.Helper()
+
+    s := &authServer{inactive: map[string]bool{}}
+    s.rotate(t)
+
+    mux := http.NewServeMux()
+    mux.HandleFunc("/.well-known/jwks.json", func(w http.ResponseWriter, r *http.Request) {
+        s.fetches.Add(1)
+        s.mu.Lock()
+        defer s.mu.Unlock()
+
+        var set JWKSet
+        for _, key := range s.keys {
+            set.Keys = append(set.Keys, NewRSAJWK(RSAThumbprint(&key.PublicKey), &key.PublicKey))
+        }
+        json.NewEncoder(w).Encode(set)
+    })
+    mux.HandleFunc("/introspect", func(w http.ResponseWriter, r *http.Request) {
+        s.introspections.Add(1)
+        if r.Header.Get("Authorization") != "Bearer service-secret" {
+            w.WriteHeader(http.StatusUnauthorized)
+            return
+        }
+
+        token := r.PostFormValue("token")
+        var claims accessClaims
+        jwt.NewParser().ParseUnverified(token, &claims)
+
+        s.mu.Lock()
+        active := !s.inactive[claims.UserID.String()]
+        s.mu.Unlock()
+        if !active {
+            json.NewEncoder(w).Encode(introspectionResponse{Active: false})
+            return
+        }
+        json.NewEncoder(w).Encode(introspectionResponse{
+            Active:  true,
+            Subject: claims.UserID.String(),
+            Email:   claims.Email,
+            Scope:   "profile",
+            Roles:   []string{"user"},
+        })
+    })
+
+    s.Server = httptest.NewServer(mux)
+    t.Cleanup(s.Close)
+    return s
+}
+
+// rotate makes a new signing key active while keeping the old ones published
+func (s *authServer) rotate(t *testing.T) {
+    t.Helper()
+    key, err := rsa.GenerateKey(rand.Reader, 2048)
+    if err != nil {
+        t.Fatalf("failed to generate key: %v", err)
+    }
+    s.mu.Lock()
+    s.keys = append(s.keys, key)
+    s.mu.Unlock()
+}
+
+func (s *authServer) sign(t *testing.T, userID uuid.UUID, scope string, roles ...string) string {
+    t.Helper()
+
+    s.mu.Lock()
+    key := s.keys[len(s.keys)-1]
+    s.mu.Unlock()
+
+    token := jwt.NewWithClaims(jwt.SigningMethodRS256, accessClaims{
+        UserID:   userID,
+        Email:    "alice@example.com",
+        DeviceID: "laptop",
+        Scope:    scope,
+        Roles:    roles,
+        RegisteredClaims: jwt.RegisteredClaims{
+            Issuer:    DefaultIssuer,
+            Subject:   userID.String(),
+            ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+            IssuedAt:  jwt.NewNumericDate(time.Now()),
+        },
+    })
+    token.Header["kid"] = RSAThumbprint(&key.PublicKey)
+    signed, err := token.SignedString(key)
+    if err != nil {
+        t.Fatalf("failed to sign token: %v", err)
+    }
+    return signed
+}
+
+// newTestClient builds a client for the server; with introspect set it
+// introspects every token, caching results for cacheTTL
+func newTestClient(t *testing.T, server *authServer, introspect bool, cacheTTL ...time.Duration) *Client {
+    t.Helper()
+
+    config := Config{
+        JWKSURL:                server.URL + "/.well-known/jwks.json",
+        JWKSMinRefreshInterval: time.Nanosecond,
+    }
+    if introspect {
+        config.IntrospectionURL = server.URL + "/introspect"
+        config.IntrospectionToken = "service-secret"
+        config.IntrospectionCacheTTL = -1
+        if len(cacheTTL) > 0 {
+            config.IntrospectionCacheTTL = cacheTTL[0]
+        }
+    }
+    client, err := New(config)
+    if err != nil {
+        t.Fatalf("New returned error: %v", err)
+    }
+    return client
+}
+
+func TestVerify(t *testing.T) {
+    server := newAuthServer(t)
+    client := newTestClient(t, server, false)
+    userID := uuid.New()
+
+    principal, err := client.Verify(context.Background(), server.sign(t, userID, "profile chat", "user", "admin"))
+    if err != nil {
+        t.Fatalf("Verify returned error: %v", err)
+    }
+    if principal.UserID != userID || principal.DeviceID != "laptop" {
+        t.Errorf("got principal %+v", principal)
+    }
+    if !principal.HasScope("chat") || principal.HasScope("billing") {
+        t.Errorf("scopes = %v", principal.Scopes)
+    }
+    if !principal.HasRole("admin") {
+        t.Errorf("roles = %v", principal.Roles)
+    }
+
+    if _, err := client.Verify(context.Background(), ""); !errors.Is(err, ErrMissingToken) {
+        t.Errorf("empty token: got %v, want ErrMissingToken", err)
+    }
+    if _, err := client.Verify(context.Background(), "not-a-jwt"); !errors.Is(err, ErrInvalidToken) {
+        t.Errorf("garbage token: got %v, want ErrInvalidToken", err)
+    }
+
+    hmac, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, accessClaims{UserID: userID}).SignedString([]byte("secret"))
+    if _, err := client.Verify(context.Background(), hmac); !errors.Is(err, ErrInvalidToken) {
+        t.Errorf("HS256 token: got %v, want ErrInvalidToken", err)
+    }
+}
+
+func TestVerifyFollowsKeyRotation(t *testing.T) {
+    server := newAuthServer(t)
+    client := newTestClient(t, server, false)
+    userID := uuid.New()
+
+    oldToken := server.sign(t, userID, "profile")
+    if _, err := client.Verify(context.Background(), oldToken); err != nil {
+        t.Fatalf("Verify returned error: %v", err)
+    }
+    if _, err := client.Verify(context.Background(), oldToken); err != nil {
+        t.Fatalf("Verify returned error: %v", err)
+    }
+    if got := server.fetches.Load(); got != 1 {
+        t.Fatalf("JWKS fetched %d times, want 1 while cached", got)
+    }
+
+    server.rotate(t)
+    if _, err := client.Verify(context.Background(), server.sign(t, userID, "profile")); err != nil {
+        t.Fatalf("token signed with rotated key: %v", err)
+    }
+    if got := server.fetches.Load(); got != 2 {
+        t.Errorf("JWKS fetched %d times, want a refetch for the unknown key id", got)
+    }
+    if _, err := client.Verify(context.Background(), oldToken); err != nil {
+        t.Errorf("token signed with retired key: %v", err)
+    }
+}
+
+func TestVerifyUnavailableKeySet(t *testing.T) {
+    server := newAuthServer(t)
+    client := newTestClient(t, server, false)
+    token := server.sign(t, uuid.New(), "profile")
+    server.Close()
+
+    _, err := client.Verify(context.Background(), token)
+    if err == nil || errors.Is(err, ErrInvalidToken) {
+        t.Errorf("got %v, want a key fetch error", err)
+    }
+}
+
+func TestJWKSCacheServesKeysDuringRefresh(t *testing.T) {
+    key, err := rsa.GenerateKey(rand.Reader, 2048)
+    if err != nil {
+        t.Fatalf("failed to generate key: %v", err)
+    }
+    keyID := RSAThumbprint(&key.PublicKey)
+
+    release := make(chan struct{})
+    var fetches atomic.Int32
+    server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        // Every fetch after the first hangs until released
+        if fetches.Add(1) > 1 {
+            <-release
+        }
+        json.NewEncoder(w).Encode(JWKSet{Keys: []JWK{NewRSAJWK(keyID, &key.PublicKey)}})
+    }))
+    defer server.Close()
+
+    cache := NewJWKSCache(server.URL, server.Client(), time.Hour, time.Hour)
+    if _, err := cache.Key(context.Background(), keyID); err != nil {
+        t.Fatalf("Key returned error: %v", err)
+    }
+    // Allow exactly one more refresh
+    cache.lastAttempt = time.Time{}
+
+    // Lookups of an unknown key ID share one slow refresh
+    var wg sync.WaitGroup
+    for i := 0; i < 5; i++ {
+        wg.Add(1)
+        go func() {
+            defer wg.Done()
+            cache.Key(context.Background(), "unknown")
+        }()
+    }
+    for fetches.Load() < 2 {
+        time.Sleep(time.Millisecond)
+    }
+
+    done := make(chan error, 1)
+    go func() {
+        _, err := cache.Key(context.Background(), keyID)
+        done <- err
+    }()
+    select {
+    case err := <-done:
+        if err != nil {
+            t.Errorf("Key for a cached key returned error: %v", err)
+        }
+    case <-time.After(time.Second):
+        t.Error("a cached key waited for the refresh")
+    }
+
+    close(release)
+    wg.Wait()
+    if got := fetches.Load(); got != 2 {
+        t.Errorf("JWKS fetched %d times, want one refresh for concurrent lookups", got)
+    }
+}
+
+func TestVerifyIntrospection(t *testing.T) {
+    server := newAuthServer(t)
+    client := newTestClient(t, server, true)
+    userID := uuid.New()
+    token := server.sign(t, userID, "profile chat", "user", "admin")
+
+    principal, err := client.Verify(context.Background(), token)
+    if err != nil {
+        t.Fatalf("Verify returned error: %v", err)
+    }
+    if principal.HasScope("chat") || principal.HasRole("admin") {
+        t.Errorf("introspection result should replace claims, got scopes %v roles %v", principal.Scopes, principal.Roles)
+    }
+
+    server.mu.Lock()
+    server.inactive[userID.String()] = true
+    server.mu.Unlock()
+    if _, err := client.Verify(context.Background(), token); !errors.Is(err, ErrInactiveToken) {
+        t.Errorf("got %v, want ErrInactiveToken", err)
+    }
+}
+
+func TestVerifyCachesIntrospection(t *testing.T) {
+    server := newAuthServer(t)
+    client := newTestClient(t, server, true, time.Minute)
+    userID := uuid.New()
+    token := server.sign(t, userID, "profile")
+
+    for i := 0; i < 3; i++ {
+        principal, err := client.Verify(context.Background(), token)
+        if err != nil || !principal.HasRole("user") {
+            t.Fatalf("Verify #%d = %+v, %v", i, principal, err)
+        }
+    }
+    if got := server.introspections.Load(); got != 1 {
+        t.Errorf("introspections = %d, want active results to be reused", got)
+    }
+
+    // Other tokens are introspected on their own
+    if _, err := client.Verify(context.Background(), server.sign(t, uuid.New(), "profile")); err != nil {
+        t.Fatalf("Verify returned error: %v", err)
+    }
+    if got := server.introspections.Load(); got != 2 {
+        t.Errorf("introspections = %d, want 2", got)
+    }
+
+    // Inactive results are never cached
+    server.mu.Lock()
+    server.inactive[userID.String()] = true
+    server.mu.Unlock()
+    inactive := server.sign(t, userID, "chat")
+    for i := 0; i < 2; i++ {
+        if _, err := client.Verify(context.Background(), inactive); !errors.Is(err, ErrInactiveToken) {
+            t.Errorf("got %v, want ErrInactiveToken", err)
+        }
+    }
+    if got := server.introspections.Load(); got != 4 {
+        t.Errorf("introspections = %d, want inactive tokens checked every time", got)
+    }
+}
+
+func TestBearerToken(t *testing.T) {
+    tests := []struct {
+        header string
+        token  string
+        ok     bool
+    }{
+        {"Bearer abc", "abc", true},
+        {"bearer abc", "abc", true},
+        {"BEARER abc", "abc", true},
+        {"Bearer abc def", "abc def", true},
+        {"Bearer ", "", false},
+        {"Bearer", "", false},
+        {"Basic abc", "", false},
+        {"", "", false},
+    }
+    for _, tt := range tests {
+        token, ok := BearerToken(tt.header)
+        if token != tt.token || ok != tt.ok {
+            t.Errorf("BearerToken(%q) = %q, %v, want %q, %v", tt.header, token, ok, tt.token, tt.ok)
+        }
+    }
+}
+
+func TestGinMiddleware(t *testing.T) {
+    gin.SetMode(gin.TestMode)
+    server := newAuthServer(t)
+    client := newTestClient(t, server, false)
+    userID := uuid.New()
+
+    router := gin.New()
+    router.GET("/me", GinMiddleware(client), func(c *gin.Context) {
+        principal, ok := GinPrincipal(c)
+        if !ok {
+            c.Status(http.StatusInternalServerError)
+            return
+        }
+        c.String(http.StatusOK, principal.UserID.String())
+    })
+    router.GET("/admin", GinMiddleware(client, RequireRoles("admin")), func(c *gin.Context) {
+        c.Status(http.StatusOK)
+    })
+
+    tests := []struct {
+        name   string
+        path   string
+        token  string
+        status int
+    }{
+        {name: "missing token", path: "/me", status: http.StatusUnauthorized},
+        {name: "invalid token", path: "/me", token: "bogus", status: http.StatusUnauthorized},
+        {name: "valid token", path: "/me", token: server.sign(t, userID, "profile"), status: http.StatusOK},
+        {name: "missing role", path: "/admin", token: server.sign(t, userID, "profile", "user"), status: http.StatusForbidden},
+        {name: "has role", path: "/admin", token: server.sign(t, userID, "profile", "admin"), status: http.StatusOK},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+            if tt.token != "" {
+                req.Header.Set("Authorization", "Bearer "+tt.token)
+            }
+            rec := httptest.NewRecorder()
+            router.ServeHTTP(rec, req)
+
+            if rec.Code != tt.status {
+                t.Fatalf("status = %d, want %d (%s)", rec.Code, tt.status, rec.Body.String())
+            }
+            if tt.status == http.StatusUnauthorized && rec.Header().Get("WWW-Authenticate") == "" {
+                t.Error("missing WWW-Authenticate header")
+            }
+            if tt.path == "/me" && tt.status == http.StatusOK && rec.Body.String() != userID.String() {
+                t.Errorf("body = %q, want user id", rec.Body.String())
+            }
+        })
+    }
+}
+
+func TestHTTPMiddleware(t *testing.T) {
+    server := newAuthServer(t)
+    client := newTestClient(t, server, false)
+    userID := uuid.New()
+
+    handler := HTTPMiddleware(client, RequireScopes("chat"))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        principal, ok := PrincipalFromContext(r.Context())
+        if !ok || principal.UserID != userID {
+            w.WriteHeader(http.StatusInternalServerError)
+            return
+        }
+        w.WriteHeader(http.StatusNoContent)
+    }))
+
+    tests := []struct {
+        name   string
+        token  string
+        status int
+    }{
+        {name: "missing token", status: http.StatusUnauthorized},
+        {name: "missing scope", token: server.sign(t, userID, "profile"), status: http.StatusForbidden},
+        {name: "has scope", token: server.sign(t, userID, "profile chat"), status: http.StatusNoContent},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            req := httptest.NewRequest(http.MethodGet, "/", nil)
+            if tt.token != "" {
+                req.Header.Set("Authorization", "Bearer "+tt.token)
+            }
+            rec := httptest.NewRecorder()
+            handler.ServeHTTP(rec, req)
+
+            if rec.Code != tt.status {
+                t.Errorf("status = %d, want %d (%s)", rec.Code, tt.status, rec.Body.String())
+            }
+        })
+    }
+}
\ No newline at end of file
@@ -0,0 +1,207 @@
+package authclient
+
+import (
+    "context"
+    "crypto/rsa"
+    "crypto/sha256"
+    "encoding/base64"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "math/big"
+    "net/http"
+    "sync"
+    "time"
+)
+
+var errUnknownKeyID = errors.New("unknown key id")
+
+// JWK is a JSON Web Key as served by the auth service (RFC 7517)
+type JWK struct {
+    KeyType   string `json:"kty"`
+    KeyID     string `json:"kid"`
+    Use       string `json:"use,omitempty"`
+    Algorithm string `json:"alg,omitempty"`
+    N         string `json:"n"`
+    E         string `json:"e"`
+}
+
+// JWKSet is the document served at the JWKS endpoint
+type JWKSet struct {
+    Keys []JWK `json:"keys"`
+}
+
+// NewRSAJWK describes an RSA public key used to verify RS256 signatures
+func NewRSAJWK(keyID string, key *rsa.PublicKey) JWK {
+    return JWK{
+        KeyType:   "RSA",
+        KeyID:     keyID,
+        Use:       "sig",
+        Algorithm: "RS256",
+        N:         base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+        E:         base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+    }
+}
+
+// RSAThumbprint returns the RFC 7638 thumbprint of an RSA public key, used as its key ID
+func RSAThumbprint(key *rsa.PublicKey) string {
+    jwk := NewRSAJWK("", key)
+    // Members in lexicographic order, no whitespace
+    canonical := fmt.Sprintf(`{"e":"%s","kty":"RSA","n":"%s"}`, jwk.E, jwk.N)
+    sum := sha256.Sum256([]byte(canonical))
+    return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// PublicKey decodes an RSA JWK
+func (k JWK) PublicKey() (*rsa.PublicKey, error) {
+    if k.KeyType != "RSA" {
+        return nil, fmt.Errorf("unsupported key type %q", k.KeyType)
+    }
+
+    n, err := base64.RawURLEncoding.DecodeString(k.N)
+    if err != nil {
+        return nil, fmt.Errorf("invalid modulus: %w", err)
+    }
+    e, err := base64.RawURLEncoding.DecodeString(k.E)
+    if err != nil {
+        return nil, fmt.Errorf("invalid exponent: %w", err)
+    }
+
+    exponent := new(big.Int).SetBytes(e)
+    if !exponent.IsInt64() || exponent.Int64() < 3 || exponent.Int64() > 1<<31-1 {
+        return nil, fmt.Errorf("invalid exponent")
+    }
+    return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exponent.Int64())}, nil
+}
+
+// JWKSCache fetches verification keys and keeps them fresh. Keys are
+// refetched after the TTL and when a token names a key ID that is not cached,
+// so rotated keys are picked up without a restart.
+type JWKSCache struct {
+    url             string
+    client          *http.Client
+    ttl             time.Duration
+    minRefreshDelay time.Duration
+
+    mu          sync.RWMutex
+    keys        map[string]*rsa.PublicKey
+    fetchedAt   time.Time
+    lastAttempt time.Time
+    fetching    *jwksFetch
+}
+
+func NewJWKSCache(url string, client *http.Client, ttl, minRefreshDelay time.Duration) *JWKSCache {
+    return &JWKSCache{
+        url:             url,
+        client:          client,
+        ttl:             ttl,
+        minRefreshDelay: minRefreshDelay,
+        keys:            make(map[string]*rsa.PublicKey),
+    }
+}
+
+// Key returns the verification key for a key ID
+func (c *JWKSCache) Key(ctx context.Context, keyID string) (*rsa.PublicKey, error) {
+    c.mu.RLock()
+    key, ok := c.keys[keyID]
+    fresh := time.Since(c.fetchedAt) < c.ttl
+    c.mu.RUnlock()
+
+    if ok && fresh {
+        return key, nil
+    }
+
+    if err := c.refresh(ctx); err != nil {
+        // Keep serving known keys while the auth service is unreachable
+        if ok {
+            return key, nil
+        }
+        return nil, err
+    }
+
+    c.mu.RLock()
+    defer c.mu.RUnlock()
+    if key, ok := c.keys[keyID]; ok {
+        return key, nil
+    }
+    return nil, fmt.Errorf("%w %q", errUnknownKeyID, keyID)
+}
+
+// jwksFetch is a key set fetch in flight, err is set before done is closed
+type jwksFetch struct {
+    done chan struct{}
+    err  error
+}
+
+// refresh refetches the key set, at most once per minRefreshDelay. The fetch
+// runs without the lock so cached keys keep being served, and callers that
+// arrive meanwhile wait for it instead of fetching again.
+func (c *JWKSCache) refresh(ctx context.Context) error {
+    c.mu.Lock()
+    if fetch := c.fetching; fetch != nil {
+        c.mu.Unlock()
+        select {
+        case <-fetch.done:
+            return fetch.err
+        case <-ctx.Done():
+            return ctx.Err()
+        }
+    }
+    if time.Since(c.lastAttempt) < c.minRefreshDelay {
+        c.mu.Unlock()
+        return nil
+    }
+    c.lastAttempt = time.Now()
+    fetch := &jwksFetch{done: make(chan struct{})}
+    c.fetching = fetch
+    c.mu.Unlock()
+
+    keys, err := c.fetch(ctx)
+
+    c.mu.Lock()
+    if err == nil {
+        c.keys = keys
+        c.fetchedAt = time.Now()
+    }
+    c.fetching = nil
+    c.mu.Unlock()
+
+    fetch.err = err
+    close(fetch.done)
+    return err
+}
+
+// fetch downloads and decodes the key set
+func (c *JWKSCache) fetch(ctx context.Context) (map[string]*rsa.PublicKey, error) {
+    req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url, nil)
+    if err != nil {
+        return nil, fmt.Errorf("failed to build jwks request: %w", err)
+    }
+    resp, err := c.client.Do(req)
+    if err != nil {
+        return nil, fmt.Errorf("failed to fetch jwks: %w", err)
+    }
+    defer resp.Body.Close()
+
+    if resp.StatusCode != http.StatusOK {
+        return nil, fmt.Errorf("failed to fetch jwks: status %d", resp.StatusCode)
+    }
+
+    var set JWKSet
+    if err := json.NewDecoder(resp.Body).Decode(&set); err != nil {
+        return nil, fmt.Errorf("failed to decode jwks: %w", err)
+    }
+
+    keys := make(map[string]*rsa.PublicKey, len(set.Keys))
+    for _, jwk := range set.Keys {
+        if jwk.Use != "" && jwk.Use != "sig" {
+            continue
+        }
+        key, err := jwk.PublicKey()
+        if err != nil {
+            continue
+        }
+        keys[jwk.KeyID] = key
+    }
+    return keys, nil
+}
\ No newline at end of file
@@ -0,0 +1,158 @@
+package authclient
+
+import (
+    "encoding/json"
+    "errors"
+    "fmt"
+    "net/http"
+    "strings"
+
+    "github.com/gin-gonic/gin"
+)
+
+// Option adds authorization requirements to the middleware
+type Option func(*requirements)
+
+type requirements struct {
+    scopes []string
+    roles  []string
+}
+
+// RequireScopes rejects principals missing any of the scopes
+func RequireScopes(scopes ...string) Option {
+    return func(r *requirements) { r.scopes = append(r.scopes, scopes...) }
+}
+
+// RequireRoles rejects principals missing any of the roles
+func RequireRoles(roles ...string) Option {
+    return func(r *requirements) { r.roles = append(r.roles, roles...) }
+}
+
+type errorResponse struct {
+    Error   string `json:"error"`
+    Message string `json:"message,omitempty"`
+}
+
+// GinMiddleware authenticates requests and stores the principal in the gin
+// context under "principal", alongside the same user_id, email and device_id
+// keys the auth service's own middleware sets
+func GinMiddleware(client *Client, opts ...Option) gin.HandlerFunc {
+    reqs := buildRequirements(opts)
+
+    return func(c *gin.Context) {
+        principal, status, body := authenticate(client, c.Request, reqs, c.Writer.Header())
+        if principal == nil {
+            c.AbortWithStatusJSON(status, body)
+            return
+        }
+
+        c.Set("principal", principal)
+        c.Set("user_id", principal.UserID.String())
+        c.Set("email", principal.Email)
+        c.Set("device_id", principal.DeviceID)
+        c.Request = c.Request.WithContext(WithPrincipal(c.Request.Context(), principal))
+        c.Next()
+    }
+}
+
+// HTTPMiddleware authenticates requests for net/http handlers; the principal
+// is available through PrincipalFromContext
+func HTTPMiddleware(client *Client, opts ...Option) func(http.Handler) http.Handler {
+    reqs := buildRequirements(opts)
+
+    return func(next http.Handler) http.Handler {
+        return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+            principal, status, body := authenticate(client, r, reqs, w.Header())
+            if principal == nil {
+                w.Header().Set("Content-Type", "application/json")
+                w.WriteHeader(status)
+                json.NewEncoder(w).Encode(body)
+                return
+            }
+            next.ServeHTTP(w, r.WithContext(WithPrincipal(r.Context(), principal)))
+        })
+    }
+}
+
+// GinPrincipal returns the principal set by GinMiddleware
+func GinPrincipal(c *gin.Context) (*Principal, bool) {
+    value, ok := c.Get("principal")
+    if !ok {
+        return nil, false
+    }
+    principal, ok := value.(*Principal)
+    return principal, ok
+}
+
+// BearerToken extracts the token of an Authorization header. The scheme is
+// case-insensitive (RFC 9110) and the token must not be empty.
+func BearerToken(authHeader string) (string, bool) {
+    scheme, token, ok := strings.Cut(authHeader, " ")
+    if !ok || !strings.EqualFold(scheme, "Bearer") || token == "" {
+        return "", false
+    }
+    return token, true
+}
+
+func buildRequirements(opts []Option) *requirements {
+    reqs := &requirements{}
+    for _, opt := range opts {
+        opt(reqs)
+    }
+    return reqs
+}
+
+func authenticate(client *Client, r *http.Request, reqs *requirements, header http.Header) (*Principal, int, errorResponse) {
+    authHeader := r.Header.Get("Authorization")
+    if authHeader == "" {
+        header.Set("WWW-Authenticate", `Bearer realm="chat-platform"`)
+        return nil, http.StatusUnauthorized, errorResponse{
+            Error:   "missing_authorization",
+            Message: "Authorization header is required",
+        }
+    }
+
+    token, ok := BearerToken(authHeader)
+    if !ok {
+        header.Set("WWW-Authenticate", `Bearer realm="chat-platform", error="invalid_request"`)
+        return nil, http.StatusUnauthorized, errorResponse{
+            Error:   "invalid_authorization",
+            Message: "Authorization header must be in format: Bearer <token>",
+        }
+    }
+
+    principal, err := client.Verify(r.Context(), token)
+    if err != nil {
+        header.Set("WWW-Authenticate", `Bearer realm="chat-platform", error="invalid_token"`)
+        if errors.Is(err, ErrInvalidToken) || errors.Is(err, ErrInactiveToken) {
+            return nil, http.StatusUnauthorized, errorResponse{
+                Error:   "invalid_token",
+                Message: "Invalid or expired token",
+            }
+        }
+        return nil, http.StatusServiceUnavailable, errorResponse{
+            Error:   "auth_unavailable",
+            Message: "Unable to verify token",
+        }
+    }
+
+    for _, scope := range reqs.scopes {
+        if !principal.HasScope(scope) {
+            header.Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="chat-platform", error="insufficient_scope", scope="%s"`, strings.Join(reqs.scopes, " ")))
+            return nil, http.StatusForbidden, errorResponse{
+                Error:   "insufficient_scope",
+                Message: fmt.Sprintf("Token is missing the %q scope", scope),
+            }
+        }
+    }
+    for _, role := range reqs.roles {
+        if !principal.HasRole(role) {
+            return nil, http.StatusForbidden, errorResponse{
+                Error:   "forbidden",
+                Message: fmt.Sprintf("Requires the %q role", role),
+            }
+        }
+    }
+
+    return principal, 0, errorResponse{}
+}
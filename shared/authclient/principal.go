@@ -0,0 +1,48 @@
+package authclient
+
+import (
+    "context"
+    "time"
+
+    "github.com/google/uuid"
+)
+
+// Principal is the authenticated caller behind a verified access token
+type Principal struct {
+    UserID    uuid.UUID
+    Email     string
+    DeviceID  string
+    Scopes    []string
+    Roles     []string
+    ExpiresAt time.Time
+}
+
+func (p *Principal) HasScope(scope string) bool {
+    return contains(p.Scopes, scope)
+}
+
+func (p *Principal) HasRole(role string) bool {
+    return contains(p.Roles, role)
+}
+
+type principalKey struct{}
+
+// WithPrincipal returns a context carrying the principal
+func WithPrincipal(ctx context.Context, principal *Principal) context.Context {
+    return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// PrincipalFromContext returns the principal stored by the middleware
+func PrincipalFromContext(ctx context.Context) (*Principal, bool) {
+    principal, ok := ctx.Value(principalKey{}).(*Principal)
+    return principal, ok
+}
+
+func contains(values []string, value string) bool {
+    for _, v := range values {
+        if v == value {
+            return true
+        }
+    }
+    return false
+}
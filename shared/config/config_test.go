@@ -0,0 +1,276 @@
+package config
+
+import (
+    "os"
+    "path/filepath"
+    "reflect"
+    "strings"
+    "testing"
+    "time"
+)
+
+// isolate runs the test away from any .env file
+func isolate(t *testing.T) string {
+    t.Helper()
+    dir := t.TempDir()
+    t.Chdir(dir)
+    return dir
+}
+
+func writeFile(t *testing.T, dir, content string) string {
+    t.Helper()
+    path := filepath.Join(dir, "config.yaml")
+    if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+        t.Fatalf("failed to write config file: %v", err)
+    }
+    return path
+}
+
+func TestDefaults(t *testing.T) {
+    isolate(t)
+    cfg, err := NewLoader(nil).Load()
+    if err != nil {
+        t.Fatalf("defaults should be valid outside production: %v", err)
+    }
+    if cfg.Port != "8080" || cfg.RateLimitRPM != 60 || cfg.JWTExpiration != 15*time.Minute {
+        t.Errorf("unexpected defaults: port=%s rpm=%d jwt=%s", cfg.Port, cfg.RateLimitRPM, cfg.JWTExpiration)
+    }
+}
+
+func TestLayerPrecedence(t *testing.T) {
+    dir := isolate(t)
+    path := writeFile(t, dir, `
+port: 7000
+rate_limit_rpm: 100
+log_level: debug
+kafka_brokers: [kafka-1:9092, kafka-2:9092]
+route_timeouts:
+  POST /api/v1/auth/login: 5s
+`)
+    t.Setenv("RATE_LIMIT_RPM", "200")
+    t.Setenv("LOG_LEVEL", "warn")
+
+    loader := NewLoader([]string{"--config", path, "--log-level", "error", "migrate", "up"})
+    cfg, err := loader.Load()
+    if err != nil {
+        t.Fatalf("Load failed: %v", err)
+    }
+
+    if cfg.Port != "7000" {
+        t.Errorf("port = %s, want the file value", cfg.Port)
+    }
+    if cfg.RateLimitRPM != 200 {
+        t.Errorf("rpm = %d, want the environment to override the file", cfg.RateLimitRPM)
+    }
+    if cfg.LogLevel != "error" {
+        t.Errorf("log level = %s, want the flag to override the environment", cfg.LogLevel)
+    }
+    if want := []string{"kafka-1:9092", "kafka-2:9092"}; !reflect.DeepEqual(cfg.KafkaBrokers, want) {
+        t.Errorf("brokers = %v, want %v", cfg.KafkaBrokers, want)
+    }
+    if cfg.RouteTimeouts["POST /api/v1/auth/login"] != 5*time.Second {
+        t.Errorf("route timeouts = %v", cfg.RouteTimeouts)
+    }
+    if want := []string{"migrate", "up"}; !reflect.DeepEqual(loader.Args(), want) {
+        t.Errorf("args = %v, want %v", loader.Args(), want)
+    }
+}
+
+func TestCommaSeparatedKafkaBrokers(t *testing.T) {
+    isolate(t)
+    t.Setenv("KAFKA_BROKERS", "kafka-1:9092, kafka-2:9092,,kafka-3:9092")
+    cfg, err := NewLoader(nil).Load()
+    if err != nil {
+        t.Fatalf("Load failed: %v", err)
+    }
+    if len(cfg.KafkaBrokers) != 3 || cfg.KafkaBrokers[1] != "kafka-2:9092" {
+        t.Errorf("brokers = %q", cfg.KafkaBrokers)
+    }
+}
+
+func TestMalformedValuesAreReportedTogether(t *testing.T) {
+    isolate(t)
+    t.Setenv("RATE_LIMIT_RPM", "60RATE_LIMIT_RPM=6000")
+    t.Setenv("JWT_EXPIRATION", "15 minutes")
+    t.Setenv("ROUTE_TIMEOUTS", "/health")
+    t.Setenv("TRACING_SAMPLE_RATIO", "2")
+
+    _, err := NewLoader(nil).Load()
+    if err == nil {
+        t.Fatal("expected malformed values to fail")
+    }
+    for _, key := range []string{"RATE_LIMIT_RPM", "JWT_EXPIRATION", "ROUTE_TIMEOUTS"} {
+        if !strings.Contains(err.Error(), key) {
+            t.Errorf("error does not mention %s: %v", key, err)
+        }
+    }
+}
+
+func TestUnknownFileKeyIsRejected(t *testing.T) {
+    dir := isolate(t)
+    path := writeFile(t, dir, "rate_limt_rpm: 100\n")
+    if _, err := NewLoader([]string{"--config", path}).Load(); err == nil || !strings.Contains(err.Error(), "rate_limt_rpm") {
+        t.Errorf("expected an unknown setting error, got %v", err)
+    }
+}
+
+func TestSecretsHaveNoFlags(t *testing.T) {
+    isolate(t)
+    if _, err := NewLoader([]string{"--jwt-secret", "x"}).Load(); err == nil {
+        t.Error("expected secrets to be rejected as flags")
+    }
+}
+
+func TestProductionRejectsInsecureDefaults(t *testing.T) {
+    isolate(t)
+    t.Setenv("ENVIRONMENT", "production")
+
+    _, err := NewLoader(nil).Load()
+    if err == nil {
+        t.Fatal("expected production with development defaults to fail")
+    }
+    for _, key := range []string{"JWT_SECRET", "JWT_SIGNING_KEY_PATH", "DATABASE_URL", "PUBLIC_URL"} {
+        if !strings.Contains(err.Error(), key) {
+            t.Errorf("error does not mention %s: %v", key, err)
+        }
+    }
+
+    t.Setenv("JWT_SECRET", strings.Repeat("s", 48))
+    t.Setenv("JWT_SIGNING_KEY_PATH", "/etc/auth/signing.pem")
+    t.Setenv("DATABASE_URL", "postgres://auth@db.internal:5432/auth")
+    t.Setenv("PUBLIC_URL", "https://auth.example.com")
+    if _, err := NewLoader(nil).Load(); err != nil {
+        t.Errorf("hardened production config rejected: %v", err)
+    }
+}
+
+func TestReloadOnlyAcceptsReloadableSettings(t *testing.T) {
+    dir := isolate(t)
+    path := writeFile(t, dir, "rate_limit_rpm: 60\nport: 8080\n")
+    loader := NewLoader([]string{"--config", path})
+    current, err := loader.Load()
+    if err != nil {
+        t.Fatalf("Load failed: %v", err)
+    }
+
+    writeFile(t, dir, "rate_limit_rpm: 120\nport: 8080\nlog_level: debug\n")
+    next, changed, err := loader.Reload(current)
+    if err != nil {
+        t.Fatalf("Reload failed: %v", err)
+    }
+    if next.RateLimitRPM != 120 || !reflect.DeepEqual(changed, []string{"LOG_LEVEL", "RATE_LIMIT_RPM"}) {
+        t.Errorf("rpm = %d, changed = %v", next.RateLimitRPM, changed)
+    }
+
+    writeFile(t, dir, "rate_limit_rpm: 120\nport: 9000\n")
+    if _, _, err := loader.Reload(next); err == nil || !strings.Contains(err.Error(), "PORT") {
+        t.Errorf("expected a port change to require a restart, got %v", err)
+    }
+
+    writeFile(t, dir, "rate_limit_rpm: 0\n")
+    if _, _, err := loader.Reload(next); err == nil {
+        t.Error("expected an invalid reload to be rejected")
+    }
+}
+func TestSigningKeyRequiredOutsideDevelopment(t *testing.T) {
+    isolate(t)
+    if _, err := NewLoader(nil).Load(); err != nil {
+        t.Fatalf("development config without a signing key rejected: %v", err)
+    }
+
+    for _, environment := range []string{"test", "staging"} {
+        t.Setenv("ENVIRONMENT", environment)
+        if _, err := NewLoader(nil).Load(); err == nil || !strings.Contains(err.Error(), "JWT_SIGNING_KEY_PATH") {
+            t.Errorf("%s without a signing key: expected JWT_SIGNING_KEY_PATH to be required, got %v", environment, err)
+        }
+    }
+}
+
+func TestSecretsProviderSettings(t *testing.T) {
+    isolate(t)
+    t.Setenv("SECRETS_PROVIDER", "keyvault")
+    if _, err := NewLoader(nil).Load(); err == nil || !strings.Contains(err.Error(), "AZURE_KEY_VAULT_URL") {
+        t.Errorf("expected the keyvault provider to require a vault URL, got %v", err)
+    }
+
+    // Production credentials come from the vault rather than the environment
+    t.Setenv("AZURE_KEY_VAULT_URL", "https://auth.vault.azure.net")
+    t.Setenv("ENVIRONMENT", "production")
+    t.Setenv("JWT_SIGNING_KEY_PATH", "/etc/auth/signing.pem")
+    t.Setenv("PUBLIC_URL", "https://auth.example.com")
+    cfg, err := NewLoader(nil).Load()
+    if err != nil {
+        t.Fatalf("vault-backed production config rejected: %v", err)
+    }
+    if cfg.SecretsRefreshInterval != 5*time.Minute || cfg.SecretsDir != "/run/secrets" {
+        t.Errorf("interval = %s, dir = %s", cfg.SecretsRefreshInterval, cfg.SecretsDir)
+    }
+
+    // Until the vault has filled them in, the built-in credentials remain
+    err = cfg.ValidateSecrets()
+    if err == nil || !strings.Contains(err.Error(), "JWT_SECRET") || !strings.Contains(err.Error(), "DATABASE_URL") {
+        t.Errorf("expected the development secrets to be rejected, got %v", err)
+    }
+    cfg.JWTSecret = strings.Repeat("s", 48)
+    cfg.DatabaseURL = "postgres://auth@db.internal:5432/auth"
+    if err := cfg.ValidateSecrets(); err != nil {
+        t.Errorf("resolved secrets rejected: %v", err)
+    }
+}
+
+func TestHealthDependencies(t *testing.T) {
+    isolate(t)
+    t.Setenv("HEALTH_DEPENDENCIES", "user-service=http://user-service:8080/health?deep=1, workspace-service=http://workspace-service:8080/health")
+    cfg, err := NewLoader(nil).Load()
+    if err != nil {
+        t.Fatalf("Load failed: %v", err)
+    }
+    if got := cfg.HealthDependencies["user-service"]; got != "http://user-service:8080/health?deep=1" {
+        t.Errorf("user-service endpoint = %q", got)
+    }
+
+    t.Setenv("HEALTH_DEPENDENCIES", "user-service=not-a-url")
+    if _, err := NewLoader(nil).Load(); err == nil || !strings.Contains(err.Error(), "HEALTH_DEPENDENCIES") {
+        t.Errorf("expected an invalid endpoint to be rejected, got %v", err)
+    }
+}
+
+func TestRateLimitFailureMode(t *testing.T) {
+    isolate(t)
+    cfg, err := NewLoader(nil).Load()
+    if err != nil {
+        t.Fatalf("Load failed: %v", err)
+    }
+    if cfg.RateLimitFailureMode != "local" || cfg.RateLimitReplicas != 1 || cfg.RateLimitBreakerCooldown != 30*time.Second {
+        t.Errorf("unexpected defaults: mode=%s replicas=%d cooldown=%s", cfg.RateLimitFailureMode, cfg.RateLimitReplicas, cfg.RateLimitBreakerCooldown)
+    }
+
+    t.Setenv("RATE_LIMIT_FAILURE_MODE", "fallback")
+    t.Setenv("RATE_LIMIT_REPLICAS", "0")
+    _, err = NewLoader(nil).Load()
+    if err == nil || !strings.Contains(err.Error(), "RATE_LIMIT_FAILURE_MODE") || !strings.Contains(err.Error(), "RATE_LIMIT_REPLICAS") {
+        t.Errorf("expected the mode and replica count to be rejected, got %v", err)
+    }
+}
+
+func TestTrustedProxies(t *testing.T) {
+    isolate(t)
+    t.Setenv("TRUSTED_PROXIES", "10.0.0.0/8, 192.168.1.7,fd00::/8")
+    cfg, err := NewLoader(nil).Load()
+    if err != nil {
+        t.Fatalf("Load failed: %v", err)
+    }
+    if !reflect.DeepEqual(cfg.TrustedProxies, []string{"10.0.0.0/8", "192.168.1.7", "fd00::/8"}) || cfg.ProxyProtocol {
+        t.Errorf("unexpected proxies %v proxy protocol %v", cfg.TrustedProxies, cfg.ProxyProtocol)
+    }
+
+    t.Setenv("TRUSTED_PROXIES", "10.0.0.0/33")
+    t.Setenv("PROXY_PROTOCOL", "true")
+    if _, err := NewLoader(nil).Load(); err == nil || !strings.Contains(err.Error(), "TRUSTED_PROXIES") {
+        t.Errorf("expected the malformed CIDR to be rejected, got %v", err)
+    }
+    t.Setenv("TRUSTED_PROXIES", "")
+    if _, err := NewLoader(nil).Load(); err == nil || !strings.Contains(err.Error(), "PROXY_PROTOCOL") {
+        t.Errorf("expected PROXY_PROTOCOL without trusted proxies to be rejected, got %v", err)
+    }
+}
\ No newline at end of file
@@ -0,0 +1,69 @@
+package config
+
+import (
+    "context"
+    "fmt"
+    "log/slog"
+    "os"
+    "os/signal"
+    "sort"
+    "strings"
+    "syscall"
+)
+
+// Reload resolves the configuration again and returns it with the keys that
+// changed. Changes to settings that are not reloadable are rejected. Flags and
+// the environment are fixed for the life of the process, so in practice a
+// reload picks up edits to the YAML file.
+func (l *Loader) Reload(current *Config) (*Config, []string, error) {
+    next, err := l.Load()
+    if err != nil {
+        return nil, nil, err
+    }
+
+    var changed, restart []string
+    for _, s := range settings {
+        if current.settings[s.key] == next.settings[s.key] {
+            continue
+        }
+        if s.reloadable {
+            changed = append(changed, s.key)
+        } else {
+            restart = append(restart, s.key)
+        }
+    }
+    if len(restart) > 0 {
+        sort.Strings(restart)
+        return nil, nil, fmt.Errorf("changing %s requires a restart", strings.Join(restart, ", "))
+    }
+    return next, changed, nil
+}
+
+// WatchSIGHUP reloads the configuration on every SIGHUP until ctx is done and
+// passes accepted configurations with their changed keys to apply. A rejected
+// reload is logged and the running configuration stays in effect.
+func (l *Loader) WatchSIGHUP(ctx context.Context, current *Config, apply func(*Config, []string)) {
+    hup := make(chan os.Signal, 1)
+    signal.Notify(hup, syscall.SIGHUP)
+    defer signal.Stop(hup)
+
+    for {
+        select {
+        case <-ctx.Done():
+            return
+        case <-hup:
+            next, changed, err := l.Reload(current)
+            if err != nil {
+                slog.Error("Configuration reload rejected", "error", err)
+                continue
+            }
+            if len(changed) == 0 {
+                slog.Info("Configuration reloaded, nothing changed")
+                continue
+            }
+            apply(next, changed)
+            current = next
+            slog.Info("Configuration reloaded", "changed", changed)
+        }
+    }
+}
\ No newline at end of file
@@ -0,0 +1,261 @@
+package config
+
+import (
+    "fmt"
+    "sort"
+    "strconv"
+    "strings"
+    "time"
+)
+
+// setting describes one configuration key. The key is the environment
+// variable name; YAML files use it in lower case and flags in kebab case.
+type setting struct {
+    key        string
+    def        string
+    usage      string
+    // secret settings have no flag so they never show up in process listings
+    secret     bool
+    // reloadable settings may change on SIGHUP without a restart
+    reloadable bool
+}
+
+var settings = []setting{
+    {key: "PORT", def: "8080", usage: "HTTP listen port"},
+    {key: "ENVIRONMENT", def: "development", usage: "development, test, staging or production"},
+    {key: "PUBLIC_URL", def: "http://localhost:8080", usage: "externally visible base URL"},
+    {key: "GRPC_PORT", def: "9090", usage: "internal gRPC listen port"},
+    {key: "TRUSTED_PROXIES", usage: "comma-separated CIDRs or addresses of proxies whose Forwarded and X-Forwarded-For headers are believed"},
+    {key: "PROXY_PROTOCOL", def: "false", usage: "require a PROXY protocol v1 or v2 header on HTTP connections from TRUSTED_PROXIES"},
+
+    {key: "LOG_LEVEL", def: "info", usage: "debug, info, warn or error", reloadable: true},
+    {key: "LOG_FORMAT", def: "json", usage: "json or text"},
+
+    {key: "REQUEST_TIMEOUT", def: "10s", usage: "default request deadline"},
+    {key: "ROUTE_TIMEOUTS", usage: "per-route deadlines as route=duration pairs"},
+
+    {key: "DATABASE_URL", def: defaultDatabaseURL, usage: "Postgres connection URL", secret: true},
+    {key: "REDIS_URL", def: "redis://localhost:6379", usage: "Redis connection URL", secret: true},
+
+    {key: "KAFKA_BROKERS", def: "localhost:9092", usage: "comma-separated Kafka brokers"},
+
+    {key: "JWT_SECRET", def: defaultJWTSecret, usage: "HMAC secret for refresh tokens", secret: true},
+    {key: "JWT_EXPIRATION", def: "15m", usage: "access token lifetime"},
+    {key: "REFRESH_EXPIRATION", def: "168h", usage: "refresh token lifetime"},
+    {key: "JWT_SIGNING_KEY_PATH", usage: "PEM file with the active RSA signing key, required outside development"},
+    {key: "JWT_RETIRED_KEY_PATHS", usage: "comma-separated PEM files still accepted for verification"},
+
+    {key: "INTROSPECTION_TOKEN", usage: "service token for token introspection", secret: true},
+    {key: "GRPC_SERVICE_TOKEN", usage: "service token for the gRPC API", secret: true},
+
+    {key: "AZURE_KEY_VAULT_URL", usage: "Azure Key Vault URL"},
+    {key: "AZURE_KEY_VAULT_TOKEN", usage: "static vault bearer token, managed identity is used when empty", secret: true},
+
+    {key: "SECRETS_PROVIDER", def: "env", usage: "env, file or keyvault"},
+    {key: "SECRETS_DIR", def: "/run/secrets", usage: "directory of mounted secret files for the file provider"},
+    {key: "SECRETS_REFRESH_INTERVAL", def: "5m", usage: "how long secrets are cached before they are re-read"},
+
+    {key: "REGION", def: "us-east-1", usage: "deployment region"},
+    {key: "GDPR_REGION", def: "us", usage: "data residency region"},
+
+    {key: "RATE_LIMIT_ENABLED", def: "true", usage: "enable the global rate limiter"},
+    {key: "RATE_LIMIT_RPM", def: "60", usage: "requests per minute per client", reloadable: true},
+    {key: "RATE_LIMIT_POLICIES_FILE", usage: "YAML file of per-route rate limit policies, built-in policies when empty"},
+    {key: "RATE_LIMIT_FAILURE_MODE", def: "local", usage: "what Redis-backed limiters do while Redis is unavailable: open, closed or local"},
+    {key: "RATE_LIMIT_REPLICAS", def: "1", usage: "replicas sharing the Redis limits, local fallback limits are divided by it"},
+    {key: "RATE_LIMIT_BREAKER_THRESHOLD", def: "5", usage: "consecutive Redis errors that open the rate limiter circuit breaker"},
+    {key: "RATE_LIMIT_BREAKER_COOLDOWN", def: "30s", usage: "how long the open breaker skips Redis before probing it again"},
+    {key: "RATE_LIMIT_BATCH_SIZE", def: "0", usage: "tokens a replica reserves from Redis at once for hot keys, 0 disables batching"},
+    {key: "RATE_LIMIT_HOT_KEY_RPS", def: "10", usage: "requests per second from one client on one replica that make it a hot key"},
+    {key: "RATE_LIMIT_LEGACY_HEADERS", def: "true", usage: "send X-RateLimit-* headers next to the IETF RateLimit headers", reloadable: true},
+    {key: "RATE_LIMIT_ADMIN_TOKEN", usage: "service token for the rate limit admin API, disabled when empty", secret: true},
+    {key: "RATE_LIMIT_CONTROLS_REFRESH", def: "5s", usage: "how often replicas reload rate limit allowlists, denylists and overrides from Redis"},
+
+    {key: "RISK_ENABLED", def: "true", usage: "score logins and notify users of risky sign-ins, high-risk logins need step-up only with NOTIFY_WEBHOOK_URL"},
+    {key: "GEOIP_DATABASE_PATH", usage: "MaxMind GeoIP database"},
+    {key: "RISK_MEDIUM_THRESHOLD", def: "30", usage: "risk score that triggers a notification"},
+    {key: "RISK_HIGH_THRESHOLD", def: "60", usage: "risk score that requires step-up"},
+    {key: "RISK_MAX_TRAVEL_SPEED_KMH", def: "900", usage: "fastest plausible travel between logins"},
+    {key: "NOTIFY_WEBHOOK_URL", usage: "endpoint receiving security notifications and step-up codes as JSON, notifications are only logged when empty"},
+    {key: "NOTIFY_WEBHOOK_TOKEN", usage: "bearer token sent to NOTIFY_WEBHOOK_URL", secret: true},
+
+    {key: "SIGNUP_REQUIRE_VERIFIED_DOMAIN", def: "false", usage: "only allow signups from verified domains"},
+    {key: "DOMAIN_VERIFICATION_RESOLVER", def: "dns", usage: "dns or static"},
+
+    {key: "CHALLENGE_ENABLED", def: "true", usage: "enable abuse challenges"},
+    {key: "CHALLENGE_WINDOW", def: "10m", usage: "abuse signal window"},
+    {key: "CHALLENGE_IP_THRESHOLD", def: "10", usage: "attempts per IP before challenging"},
+    {key: "CHALLENGE_SUBNET_THRESHOLD", def: "30", usage: "attempts per subnet before challenging"},
+    {key: "CHALLENGE_GLOBAL_THRESHOLD", def: "300", usage: "attempts per route before challenging"},
+    {key: "CHALLENGE_FAILURE_THRESHOLD", def: "5", usage: "failed logins before challenging"},
+    {key: "CHALLENGE_BASE_DIFFICULTY", def: "16", usage: "proof-of-work leading zero bits"},
+    {key: "CHALLENGE_MAX_DIFFICULTY", def: "24", usage: "proof-of-work difficulty cap"},
+    {key: "CAPTCHA_VERIFY_URL", usage: "CAPTCHA siteverify endpoint"},
+    {key: "CAPTCHA_SECRET", usage: "CAPTCHA secret key", secret: true},
+
+    {key: "HEALTH_CHECK_INTERVAL", def: "10s", usage: "how often dependency health checks run"},
+    {key: "HEALTH_CHECK_TIMEOUT", def: "5s", usage: "deadline for one dependency health check"},
+    {key: "HEALTH_DEPENDENCIES", usage: "downstream health endpoints as name=url pairs"},
+
+    {key: "TRACING_EXPORTER", def: "none", usage: "none, stdout, file or otlp"},
+    {key: "TRACING_FILE_PATH", def: "traces.jsonl", usage: "trace file for the file exporter"},
+    {key: "TRACING_SAMPLE_RATIO", def: "1.0", usage: "fraction of new traces sampled"},
+}
+
+var settingsByKey = func() map[string]setting {
+    byKey := make(map[string]setting, len(settings))
+    for _, s := range settings {
+        byKey[s.key] = s
+    }
+    return byKey
+}()
+
+func flagName(key string) string {
+    return strings.ToLower(strings.ReplaceAll(key, "_", "-"))
+}
+
+// resolver looks keys up through the layers, highest precedence first, and
+// collects every parse error instead of falling back to defaults
+type resolver struct {
+    layers   []map[string]string
+    resolved map[string]string
+    errs     []error
+}
+
+func newResolver(layers ...map[string]string) *resolver {
+    return &resolver{layers: layers, resolved: make(map[string]string)}
+}
+
+func (r *resolver) raw(key string) string {
+    s, ok := settingsByKey[key]
+    if !ok {
+        panic("config: unknown setting " + key)
+    }
+    value := s.def
+    for _, layer := range r.layers {
+        if v, ok := layer[key]; ok && v != "" {
+            value = v
+            break
+        }
+    }
+    r.resolved[key] = value
+    return value
+}
+
+func (r *resolver) fail(key, raw, kind string) {
+    r.errs = append(r.errs, fmt.Errorf("%s: invalid %s %q", key, kind, raw))
+}
+
+func (r *resolver) str(key string) string {
+    return strings.TrimSpace(r.raw(key))
+}
+
+func (r *resolver) integer(key string) int {
+    raw := r.str(key)
+    value, err := strconv.Atoi(raw)
+    if err != nil {
+        r.fail(key, raw, "integer")
+    }
+    return value
+}
+
+func (r *resolver) float(key string) float64 {
+    raw := r.str(key)
+    value, err := strconv.ParseFloat(raw, 64)
+    if err != nil {
+        r.fail(key, raw, "number")
+    }
+    return value
+}
+
+func (r *resolver) boolean(key string) bool {
+    raw := r.str(key)
+    value, err := strconv.ParseBool(raw)
+    if err != nil {
+        r.fail(key, raw, "boolean")
+    }
+    return value
+}
+
+func (r *resolver) duration(key string) time.Duration {
+    raw := r.str(key)
+    value, err := time.ParseDuration(raw)
+    if err != nil {
+        r.fail(key, raw, "duration")
+    }
+    return value
+}
+
+// list splits a comma-separated value, dropping empty entries
+func (r *resolver) list(key string) []string {
+    var values []string
+    for _, value := range strings.Split(r.raw(key), ",") {
+        if value = strings.TrimSpace(value); value != "" {
+            values = append(values, value)
+        }
+    }
+    return values
+}
+
+// durationMap parses comma-separated key=duration pairs such as
+// "POST /api/v1/auth/login=5s,/scim/v2/Users=30s"
+func (r *resolver) durationMap(key string) map[string]time.Duration {
+    values := make(map[string]time.Duration)
+    for _, entry := range r.list(key) {
+        separator := strings.LastIndex(entry, "=")
+        if separator <= 0 {
+            r.fail(key, entry, "route=duration entry")
+            continue
+        }
+        duration, err := time.ParseDuration(strings.TrimSpace(entry[separator+1:]))
+        if err != nil {
+            r.fail(key, entry, "route=duration entry")
+            continue
+        }
+        values[strings.TrimSpace(entry[:separator])] = duration
+    }
+    return values
+}
+
+// stringMap parses comma-separated key=value pairs such as
+// "user-service=http://user-service:8080/health"
+func (r *resolver) stringMap(key string) map[string]string {
+    values := make(map[string]string)
+    for _, entry := range r.list(key) {
+        name, value, ok := strings.Cut(entry, "=")
+        if !ok || strings.TrimSpace(name) == "" {
+            r.fail(key, entry, "name=value entry")
+            continue
+        }
+        values[strings.TrimSpace(name)] = strings.TrimSpace(value)
+    }
+    return values
+}
+
+// flattenFileValue turns a YAML value into the string form the environment
+// uses: lists become comma-separated and maps become key=value pairs
+func flattenFileValue(value any) string {
+    switch v := value.(type) {
+    case nil:
+        return ""
+    case []any:
+        parts := make([]string, 0, len(v))
+        for _, item := range v {
+            parts = append(parts, flattenFileValue(item))
+        }
+        return strings.Join(parts, ",")
+    case map[string]any:
+        keys := make([]string, 0, len(v))
+        for key := range v {
+            keys = append(keys, key)
+        }
+        sort.Strings(keys)
+        parts := make([]string, 0, len(v))
+        for _, key := range keys {
+            parts = append(parts, key+"="+flattenFileValue(v[key]))
+        }
+        return strings.Join(parts, ",")
+    default:
+        return fmt.Sprint(v)
+    }
+}
\ No newline at end of file
@@ -0,0 +1,140 @@
+package config
+
+import (
+    "errors"
+    "fmt"
+    "net"
+    "net/netip"
+    "net/url"
+    "strconv"
+    "strings"
+)
+
+// Validate checks ranges and enumerations, and in production rejects the
+// development defaults that would leave the service insecure
+func (c *Config) Validate() error {
+    var errs []error
+    check := func(ok bool, format string, args ...any) {
+        if !ok {
+            errs = append(errs, fmt.Errorf(format, args...))
+        }
+    }
+    oneOf := func(key, value string, allowed ...string) {
+        for _, a := range allowed {
+            if value == a {
+                return
+            }
+        }
+        errs = append(errs, fmt.Errorf("%s: %q is not one of %s", key, value, strings.Join(allowed, ", ")))
+    }
+
+    oneOf("ENVIRONMENT", c.Environment, "development", "test", "staging", "production")
+    check(validPort(c.Port), "PORT: invalid port %q", c.Port)
+    check(validPort(c.GRPCPort), "GRPC_PORT: invalid port %q", c.GRPCPort)
+    check(validURL(c.PublicURL), "PUBLIC_URL: invalid URL %q", c.PublicURL)
+    for _, proxy := range c.TrustedProxies {
+        check(validCIDR(proxy), "TRUSTED_PROXIES: %q is not a CIDR or IP address", proxy)
+    }
+    check(!c.ProxyProtocol || len(c.TrustedProxies) > 0, "PROXY_PROTOCOL: requires TRUSTED_PROXIES, headers are only read from trusted proxies")
+    oneOf("LOG_LEVEL", strings.ToLower(c.LogLevel), "debug", "info", "warn", "warning", "error")
+    oneOf("LOG_FORMAT", c.LogFormat, "json", "text")
+
+    check(c.RequestTimeout >= 0, "REQUEST_TIMEOUT: must not be negative")
+    for route, timeout := range c.RouteTimeouts {
+        check(timeout >= 0, "ROUTE_TIMEOUTS: %s must not be negative", route)
+    }
+    check(c.JWTExpiration > 0, "JWT_EXPIRATION: must be positive")
+    check(c.RefreshExpiration > c.JWTExpiration, "REFRESH_EXPIRATION: must be longer than JWT_EXPIRATION")
+
+    check(len(c.KafkaBrokers) > 0, "KAFKA_BROKERS: at least one broker is required")
+    for _, broker := range c.KafkaBrokers {
+        _, port, err := net.SplitHostPort(broker)
+        check(err == nil && validPort(port), "KAFKA_BROKERS: %q is not host:port", broker)
+    }
+
+    check(c.RateLimitRPM > 0, "RATE_LIMIT_RPM: must be positive")
+    oneOf("RATE_LIMIT_FAILURE_MODE", c.RateLimitFailureMode, "open", "closed", "local")
+    check(c.RateLimitReplicas > 0, "RATE_LIMIT_REPLICAS: must be positive")
+    check(c.RateLimitBreakerThreshold > 0, "RATE_LIMIT_BREAKER_THRESHOLD: must be positive")
+    check(c.RateLimitBreakerCooldown > 0, "RATE_LIMIT_BREAKER_COOLDOWN: must be positive")
+    check(c.RateLimitBatchSize >= 0, "RATE_LIMIT_BATCH_SIZE: must not be negative")
+    check(c.RateLimitHotKeyRPS > 0, "RATE_LIMIT_HOT_KEY_RPS: must be positive")
+    check(c.RateLimitControlsRefresh > 0, "RATE_LIMIT_CONTROLS_REFRESH: must be positive")
+    check(c.RiskMediumThreshold >= 0 && c.RiskMediumThreshold <= 100, "RISK_MEDIUM_THRESHOLD: must be between 0 and 100")
+    check(c.RiskHighThreshold >= 0 && c.RiskHighThreshold <= 100, "RISK_HIGH_THRESHOLD: must be between 0 and 100")
+    check(c.RiskMediumThreshold < c.RiskHighThreshold, "RISK_MEDIUM_THRESHOLD: must be below RISK_HIGH_THRESHOLD")
+    check(c.RiskMaxTravelSpeedKMH > 0, "RISK_MAX_TRAVEL_SPEED_KMH: must be positive")
+    check(c.NotifyWebhookURL == "" || validURL(c.NotifyWebhookURL), "NOTIFY_WEBHOOK_URL: invalid URL %q", c.NotifyWebhookURL)
+    oneOf("DOMAIN_VERIFICATION_RESOLVER", c.DomainVerificationResolver, "dns", "static")
+
+    check(c.ChallengeWindow > 0, "CHALLENGE_WINDOW: must be positive")
+    check(c.ChallengeIPThreshold > 0 && c.ChallengeSubnetThreshold > 0 && c.ChallengeGlobalThreshold > 0 && c.ChallengeFailureThreshold > 0,
+        "CHALLENGE_*_THRESHOLD: thresholds must be positive")
+    check(c.ChallengeBaseDifficulty > 0 && c.ChallengeBaseDifficulty <= c.ChallengeMaxDifficulty && c.ChallengeMaxDifficulty <= 32,
+        "CHALLENGE_BASE_DIFFICULTY: must be positive and at most CHALLENGE_MAX_DIFFICULTY, which is capped at 32")
+    check(c.CaptchaVerifyURL == "" || validURL(c.CaptchaVerifyURL), "CAPTCHA_VERIFY_URL: invalid URL %q", c.CaptchaVerifyURL)
+    check(c.CaptchaVerifyURL == "" || c.CaptchaSecret != "", "CAPTCHA_SECRET: required when CAPTCHA_VERIFY_URL is set")
+
+    oneOf("SECRETS_PROVIDER", c.SecretsProvider, "env", "file", "keyvault")
+    check(c.SecretsRefreshInterval > 0, "SECRETS_REFRESH_INTERVAL: must be positive")
+    check(c.SecretsProvider != "keyvault" || validURL(c.AzureKeyVaultURL), "AZURE_KEY_VAULT_URL: a valid URL is required by the keyvault secrets provider")
+
+    check(c.HealthCheckInterval > 0, "HEALTH_CHECK_INTERVAL: must be positive")
+    check(c.HealthCheckTimeout > 0 && c.HealthCheckTimeout <= c.HealthCheckInterval, "HEALTH_CHECK_TIMEOUT: must be positive and at most HEALTH_CHECK_INTERVAL")
+    for name, endpoint := range c.HealthDependencies {
+        check(validURL(endpoint), "HEALTH_DEPENDENCIES: %s has invalid URL %q", name, endpoint)
+    }
+
+    oneOf("TRACING_EXPORTER", c.TracingExporter, "none", "stdout", "file", "otlp")
+    check(c.TracingSampleRatio >= 0 && c.TracingSampleRatio <= 1, "TRACING_SAMPLE_RATIO: must be between 0 and 1")
+
+    // Replicas would each sign with their own ephemeral key, so tokens
+    // only verify on the replica that issued them
+    check(c.Environment == "development" || c.JWTSigningKeyPath != "",
+        "JWT_SIGNING_KEY_PATH: required outside development, ephemeral signing keys are per process and do not survive restarts")
+    if c.Environment == "production" {
+        check(strings.HasPrefix(c.PublicURL, "https://"), "PUBLIC_URL: must use https in production")
+    }
+    // Other providers supply the secrets after loading, see ValidateSecrets
+    if c.SecretsProvider == "env" {
+        if err := c.ValidateSecrets(); err != nil {
+            errs = append(errs, err)
+        }
+    }
+
+    return errors.Join(errs...)
+}
+
+// ValidateSecrets rejects the development credentials in production. Run it
+// again once the secrets provider has resolved JWT_SECRET and DATABASE_URL.
+func (c *Config) ValidateSecrets() error {
+    if c.Environment != "production" {
+        return nil
+    }
+    var errs []error
+    if c.JWTSecret == defaultJWTSecret || len(c.JWTSecret) < 32 {
+        errs = append(errs, errors.New("JWT_SECRET: must be set to at least 32 random characters in production"))
+    }
+    if c.DatabaseURL == defaultDatabaseURL {
+        errs = append(errs, errors.New("DATABASE_URL: must be set in production"))
+    }
+    return errors.Join(errs...)
+}
+
+func validPort(port string) bool {
+    n, err := strconv.Atoi(port)
+    return err == nil && n > 0 && n <= 65535
+}
+
+func validCIDR(raw string) bool {
+    if _, err := netip.ParsePrefix(raw); err == nil {
+        return true
+    }
+    _, err := netip.ParseAddr(raw)
+    return err == nil
+}
+
+func validURL(raw string) bool {
+    u, err := url.Parse(raw)
+    return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
\ No newline at end of file
@@ -0,0 +1,316 @@
+package database
+
+import (
+    "context"
+    "crypto/sha256"
+    "encoding/hex"
+    "errors"
+    "fmt"
+    "io/fs"
+    "log/slog"
+    "path"
+    "regexp"
+    "sort"
+    "strconv"
+    "time"
+
+    "github.com/jmoiron/sqlx"
+)
+
+const (
+    migrationsTable = "schema_migrations"
+    // migrationLockID is the pg_advisory_lock key serialising replicas that migrate at startup
+    migrationLockID int64 = 7245150211
+)
+
+var (
+    ErrChecksumMismatch = errors.New("applied migration has been edited")
+    ErrUnknownMigration = errors.New("database has a migration this build does not know")
+    ErrNoDownMigration  = errors.New("migration has no down script")
+)
+
+var migrationFileName = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+// Migration is one versioned schema change loaded from NNN_name.up.sql and NNN_name.down.sql
+type Migration struct {
+    Version  int64
+    Name     string
+    Up       string
+    Down     string
+    Checksum string
+}
+
+// MigrationStatus pairs a known migration with when it was applied, if ever
+type MigrationStatus struct {
+    Migration
+    AppliedAt *time.Time
+}
+
+type appliedMigration struct {
+    Version   int64     `db:"version"`
+    Name      string    `db:"name"`
+    Checksum  string    `db:"checksum"`
+    AppliedAt time.Time `db:"applied_at"`
+}
+
+// Migrator applies embedded SQL migrations and records them in schema_migrations
+type Migrator struct {
+    db         *sqlx.DB
+    migrations []Migration
+}
+
+func NewMigrator(db *sqlx.DB, fsys fs.FS) (*Migrator, error) {
+    migrations, err := LoadMigrations(fsys)
+    if err != nil {
+        return nil, err
+    }
+    return &Migrator{db: db, migrations: migrations}, nil
+}
+
+// LoadMigrations reads the migrations in fsys sorted by version. Every
+// version needs an up script; the checksum covers the up script only, since
+// that is what has been applied.
+func LoadMigrations(fsys fs.FS) ([]Migration, error) {
+    entries, err := fs.ReadDir(fsys, ".")
+    if err != nil {
+        return nil, fmt.Errorf("failed to read migrations: %w", err)
+    }
+
+    byVersion := make(map[int64]*Migration)
+    for _, entry := range entries {
+        if entry.IsDir() || path.Ext(entry.Name()) != ".sql" {
+            continue
+        }
+        match := migrationFileName.FindStringSubmatch(entry.Name())
+        if match == nil {
+            return nil, fmt.Errorf("invalid migration file name %q, want NNN_name.up.sql or NNN_name.down.sql", entry.Name())
+        }
+        version, err := strconv.ParseInt(match[1], 10, 64)
+        if err != nil {
+            return nil, fmt.Errorf("invalid migration version in %q: %w", entry.Name(), err)
+        }
+        contents, err := fs.ReadFile(fsys, entry.Name())
+        if err != nil {
+            return nil, fmt.Errorf("failed to read migration %s: %w", entry.Name(), err)
+        }
+
+        migration, ok := byVersion[version]
+        if !ok {
+            migration = &Migration{Version: version, Name: match[2]}
+            byVersion[version] = migration
+        } else if migration.Name != match[2] {
+            return nil, fmt.Errorf("migration %d has conflicting names %q and %q", version, migration.Name, match[2])
+        }
+
+        if match[3] == "up" {
+            migration.Up = string(contents)
+            sum := sha256.Sum256(contents)
+            migration.Checksum = hex.EncodeToString(sum[:])
+        } else {
+            migration.Down = string(contents)
+        }
+    }
+
+    migrations := make([]Migration, 0, len(byVersion))
+    for _, migration := range byVersion {
+        if migration.Up == "" {
+            return nil, fmt.Errorf("migration %d_%s has no up script", migration.Version, migration.Name)
+        }
+        migrations = append(migrations, *migration)
+    }
+    sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+    return migrations, nil
+}
+
+// Up applies every pending migration in order, each in its own transaction
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+    var applied []Migration
+    err := m.withLock(ctx, func(conn *sqlx.Conn) error {
+        history, err := m.history(ctx, conn)
+        if err != nil {
+            return err
+        }
+        if err := verifyHistory(m.migrations, history); err != nil {
+            return err
+        }
+
+        for _, migration := range pendingMigrations(m.migrations, history) {
+            if err := m.apply(ctx, conn, migration); err != nil {
+                return err
+            }
+            slog.Info("Applied migration", "version", migration.Version, "name", migration.Name)
+            applied = append(applied, migration)
+        }
+        return nil
+    })
+    return applied, err
+}
+
+// Down reverts the most recently applied migrations, newest first
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+    var reverted []Migration
+    err := m.withLock(ctx, func(conn *sqlx.Conn) error {
+        history, err := m.history(ctx, conn)
+        if err != nil {
+            return err
+        }
+        if err := verifyHistory(m.migrations, history); err != nil {
+            return err
+        }
+
+        known := make(map[int64]Migration, len(m.migrations))
+        for _, migration := range m.migrations {
+            known[migration.Version] = migration
+        }
+        for i := len(history) - 1; i >= 0 && len(reverted) < steps; i-- {
+            migration := known[history[i].Version]
+            if migration.Down == "" {
+                return fmt.Errorf("%w: %d_%s", ErrNoDownMigration, migration.Version, migration.Name)
+            }
+            if err := m.revert(ctx, conn, migration); err != nil {
+                return err
+            }
+            slog.Info("Reverted migration", "version", migration.Version, "name", migration.Name)
+            reverted = append(reverted, migration)
+        }
+        return nil
+    })
+    return reverted, err
+}
+
+// Status lists every known migration and whether it has been applied
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+    var statuses []MigrationStatus
+    err := m.withLock(ctx, func(conn *sqlx.Conn) error {
+        history, err := m.history(ctx, conn)
+        if err != nil {
+            return err
+        }
+        if err := verifyHistory(m.migrations, history); err != nil {
+            return err
+        }
+
+        appliedAt := make(map[int64]time.Time, len(history))
+        for _, record := range history {
+            appliedAt[record.Version] = record.AppliedAt
+        }
+        for _, migration := range m.migrations {
+            status := MigrationStatus{Migration: migration}
+            if at, ok := appliedAt[migration.Version]; ok {
+                status.AppliedAt = &at
+            }
+            statuses = append(statuses, status)
+        }
+        return nil
+    })
+    return statuses, err
+}
+
+// withLock holds a session-level advisory lock on a dedicated connection so
+// replicas starting together apply each migration exactly once
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *sqlx.Conn) error) error {
+    conn, err := m.db.Connx(ctx)
+    if err != nil {
+        return fmt.Errorf("failed to get migration connection: %w", err)
+    }
+    defer conn.Close()
+
+    if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, migrationLockID); err != nil {
+        return fmt.Errorf("failed to acquire migration lock: %w", err)
+    }
+    defer func() {
+        // Unlock with a fresh context so a canceled run still releases the lock
+        if _, err := conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1)`, migrationLockID); err != nil {
+            slog.Error("Failed to release migration lock", "error", err)
+        }
+    }()
+
+    _, err = conn.ExecContext(ctx, `
+        CREATE TABLE IF NOT EXISTS `+migrationsTable+` (
+            version BIGINT PRIMARY KEY,
+            name VARCHAR(255) NOT NULL,
+            checksum VARCHAR(64) NOT NULL,
+            applied_at TIMESTAMP NOT NULL DEFAULT NOW()
+        )`)
+    if err != nil {
+        return fmt.Errorf("failed to create %s table: %w", migrationsTable, err)
+    }
+
+    return fn(conn)
+}
+
+func (m *Migrator) history(ctx context.Context, conn *sqlx.Conn) ([]appliedMigration, error) {
+    var history []appliedMigration
+    query := `SELECT version, name, checksum, applied_at FROM ` + migrationsTable + ` ORDER BY version`
+    if err := conn.SelectContext(ctx, &history, query); err != nil {
+        return nil, fmt.Errorf("failed to read applied migrations: %w", err)
+    }
+    return history, nil
+}
+
+func (m *Migrator) apply(ctx context.Context, conn *sqlx.Conn, migration Migration) error {
+    tx, err := conn.BeginTxx(ctx, nil)
+    if err != nil {
+        return fmt.Errorf("failed to begin migration %d: %w", migration.Version, err)
+    }
+    defer tx.Rollback()
+
+    if _, err := tx.ExecContext(ctx, migration.Up); err != nil {
+        return fmt.Errorf("failed to apply migration %d_%s: %w", migration.Version, migration.Name, err)
+    }
+    _, err = tx.ExecContext(ctx, `INSERT INTO `+migrationsTable+` (version, name, checksum) VALUES ($1, $2, $3)`,
+        migration.Version, migration.Name, migration.Checksum)
+    if err != nil {
+        return fmt.Errorf("failed to record migration %d: %w", migration.Version, err)
+    }
+    return tx.Commit()
+}
+
+func (m *Migrator) revert(ctx context.Context, conn *sqlx.Conn, migration Migration) error {
+    tx, err := conn.BeginTxx(ctx, nil)
+    if err != nil {
+        return fmt.Errorf("failed to begin migration %d: %w", migration.Version, err)
+    }
+    defer tx.Rollback()
+
+    if _, err := tx.ExecContext(ctx, migration.Down); err != nil {
+        return fmt.Errorf("failed to revert migration %d_%s: %w", migration.Version, migration.Name, err)
+    }
+    if _, err := tx.ExecContext(ctx, `DELETE FROM `+migrationsTable+` WHERE version = $1`, migration.Version); err != nil {
+        return fmt.Errorf("failed to record revert of migration %d: %w", migration.Version, err)
+    }
+    return tx.Commit()
+}
+
+// verifyHistory refuses to continue when an applied migration was edited or
+// is missing from this build, since the schema no longer matches the files
+func verifyHistory(migrations []Migration, history []appliedMigration) error {
+    known := make(map[int64]Migration, len(migrations))
+    for _, migration := range migrations {
+        known[migration.Version] = migration
+    }
+    for _, record := range history {
+        migration, ok := known[record.Version]
+        if !ok {
+            return fmt.Errorf("%w: %d_%s", ErrUnknownMigration, record.Version, record.Name)
+        }
+        if migration.Checksum != record.Checksum {
+            return fmt.Errorf("%w: %d_%s", ErrChecksumMismatch, record.Version, record.Name)
+        }
+    }
+    return nil
+}
+
+func pendingMigrations(migrations []Migration, history []appliedMigration) []Migration {
+    applied := make(map[int64]bool, len(history))
+    for _, record := range history {
+        applied[record.Version] = true
+    }
+    var pending []Migration
+    for _, migration := range migrations {
+        if !applied[migration.Version] {
+            pending = append(pending, migration)
+        }
+    }
+    return pending
+}
\ No newline at end of file
@@ -0,0 +1,81 @@
+package database
+
+import (
+    "errors"
+    "testing"
+    "testing/fstest"
+)
+
+func TestLoadMigrations(t *testing.T) {
+    fsys := fstest.MapFS{
+        "002_add_sessions.up.sql":   {Data: []byte("CREATE TABLE sessions (id INT);")},
+        "002_add_sessions.down.sql": {Data: []byte("DROP TABLE sessions;")},
+        "001_add_users.up.sql":      {Data: []byte("CREATE TABLE users (id INT);")},
+        "migrations.go":             {Data: []byte("package migrations")},
+    }
+
+    migrations, err := LoadMigrations(fsys)
+    if err != nil {
+        t.Fatalf("LoadMigrations: %v", err)
+    }
+    if len(migrations) != 2 || migrations[0].Version != 1 || migrations[1].Version != 2 {
+        t.Fatalf("got %+v, want versions 1 and 2", migrations)
+    }
+    if migrations[1].Name != "add_sessions" || migrations[1].Down != "DROP TABLE sessions;" {
+        t.Errorf("got %+v", migrations[1])
+    }
+    if migrations[0].Down != "" || len(migrations[0].Checksum) != 64 {
+        t.Errorf("got %+v", migrations[0])
+    }
+}
+
+func TestLoadMigrationsRejectsBadFiles(t *testing.T) {
+    tests := []struct {
+        name string
+        fsys fstest.MapFS
+    }{
+        {name: "unversioned name", fsys: fstest.MapFS{"add_users.sql": {Data: []byte("SELECT 1")}}},
+        {name: "down without up", fsys: fstest.MapFS{"001_add_users.down.sql": {Data: []byte("SELECT 1")}}},
+        {name: "conflicting names", fsys: fstest.MapFS{
+            "001_add_users.up.sql":  {Data: []byte("SELECT 1")},
+            "001_add_people.up.sql": {Data: []byte("SELECT 1")},
+        }},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            if _, err := LoadMigrations(tt.fsys); err == nil {
+                t.Error("expected an error")
+            }
+        })
+    }
+}
+
+func TestVerifyHistory(t *testing.T) {
+    migrations := []Migration{
+        {Version: 1, Name: "add_users", Checksum: "aaa"},
+        {Version: 2, Name: "add_sessions", Checksum: "bbb"},
+    }
+
+    if err := verifyHistory(migrations, []appliedMigration{{Version: 1, Checksum: "aaa"}}); err != nil {
+        t.Errorf("unexpected error: %v", err)
+    }
+
+    err := verifyHistory(migrations, []appliedMigration{{Version: 1, Name: "add_users", Checksum: "edited"}})
+    if !errors.Is(err, ErrChecksumMismatch) {
+        t.Errorf("got %v, want ErrChecksumMismatch", err)
+    }
+
+    err = verifyHistory(migrations, []appliedMigration{{Version: 3, Name: "from_newer_build", Checksum: "ccc"}})
+    if !errors.Is(err, ErrUnknownMigration) {
+        t.Errorf("got %v, want ErrUnknownMigration", err)
+    }
+}
+
+func TestPendingMigrations(t *testing.T) {
+    migrations := []Migration{{Version: 1}, {Version: 2}, {Version: 3}}
+    pending := pendingMigrations(migrations, []appliedMigration{{Version: 1}, {Version: 3}})
+    if len(pending) != 1 || pending[0].Version != 2 {
+        t.Errorf("got %+v, want only version 2", pending)
+    }
+}
\ No newline at end of file
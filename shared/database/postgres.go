@@ -1,23 +1,44 @@
 package database
 
 import (
-   
+    "context"
+    "database/sql"
+    "database/sql/driver"
     "fmt"
+    "sync/atomic"
     "time"
 
-    _ "github.com/lib/pq"
+    "github.com/lib/pq"
     "github.com/jmoiron/sqlx"
 )
 
 type PostgresDB struct {
-    DB *sqlx.DB
+    DB        *sqlx.DB
+    connector *rotatingConnector
+}
+
+// rotatingConnector opens new connections with the current credentials, so
+// a rotated DATABASE_URL applies as pooled connections reach their lifetime
+type rotatingConnector struct {
+    current atomic.Pointer[pq.Connector]
+}
+
+func (r *rotatingConnector) Connect(ctx context.Context) (driver.Conn, error) {
+    return r.current.Load().Connect(ctx)
+}
+
+func (r *rotatingConnector) Driver() driver.Driver {
+    return r.current.Load().Driver()
 }
 
 func NewPostgresConnection(databaseURL string) (*PostgresDB, error) {
-    db, err := sqlx.Connect("postgres", databaseURL)
+    connector, err := pq.NewConnector(databaseURL)
     if err != nil {
-        return nil, fmt.Errorf("failed to connect to postgres: %w", err)
+        return nil, fmt.Errorf("invalid postgres URL: %w", err)
     }
+    rotating := &rotatingConnector{}
+    rotating.current.Store(connector)
+    db := sqlx.NewDb(sql.OpenDB(rotating), "postgres")
 
     // Configure connection pool
     db.SetMaxOpenConns(25)
@@ -26,10 +47,22 @@ func NewPostgresConnection(databaseURL string) (*PostgresDB, error) {
 
     // Test connection
     if err := db.Ping(); err != nil {
-        return nil, fmt.Errorf("failed to ping postgres: %w", err)
+        db.Close()
+        return nil, fmt.Errorf("failed to connect to postgres: %w", err)
     }
 
-    return &PostgresDB{DB: db}, nil
+    return &PostgresDB{DB: db, connector: rotating}, nil
+}
+
+// SetDatabaseURL switches new connections to rotated credentials. Open
+// connections keep the old ones until the pool retires them.
+func (p *PostgresDB) SetDatabaseURL(databaseURL string) error {
+    connector, err := pq.NewConnector(databaseURL)
+    if err != nil {
+        return fmt.Errorf("invalid postgres URL: %w", err)
+    }
+    p.connector.current.Store(connector)
+    return nil
 }
 
 func (p *PostgresDB) Close() error {
@@ -0,0 +1,79 @@
+package health
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "net"
+    "net/http"
+
+    "github.com/Shridhar2104/chat-platform/shared/database"
+)
+
+// Postgres pings the pool and runs a trivial query
+func Postgres(db *database.PostgresDB) func(ctx context.Context) error {
+    return func(ctx context.Context) error {
+        if err := db.DB.PingContext(ctx); err != nil {
+            return fmt.Errorf("database connection failed: %w", err)
+        }
+        var result int
+        if err := db.DB.GetContext(ctx, &result, "SELECT 1"); err != nil {
+            return fmt.Errorf("database query failed: %w", err)
+        }
+        return nil
+    }
+}
+
+// Redis pings the Redis server
+func Redis(redis *database.RedisClient) func(ctx context.Context) error {
+    return func(ctx context.Context) error {
+        if err := redis.Client.Ping(ctx).Err(); err != nil {
+            return fmt.Errorf("redis connection failed: %w", err)
+        }
+        return nil
+    }
+}
+
+// TCP passes when any of the addresses accepts a connection, which suits
+// clustered dependencies such as Kafka brokers or Cassandra nodes
+func TCP(addrs ...string) func(ctx context.Context) error {
+    return func(ctx context.Context) error {
+        if len(addrs) == 0 {
+            return errors.New("no addresses configured")
+        }
+        var dialer net.Dialer
+        var errs []error
+        for _, addr := range addrs {
+            conn, err := dialer.DialContext(ctx, "tcp", addr)
+            if err == nil {
+                conn.Close()
+                return nil
+            }
+            errs = append(errs, err)
+        }
+        return fmt.Errorf("no address reachable: %w", errors.Join(errs...))
+    }
+}
+
+// HTTP passes when a GET of url answers with a 2xx status, for downstream
+// services' own health endpoints. client may be nil.
+func HTTP(client *http.Client, url string) func(ctx context.Context) error {
+    if client == nil {
+        client = http.DefaultClient
+    }
+    return func(ctx context.Context) error {
+        req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+        if err != nil {
+            return err
+        }
+        resp, err := client.Do(req)
+        if err != nil {
+            return err
+        }
+        defer resp.Body.Close()
+        if resp.StatusCode < 200 || resp.StatusCode > 299 {
+            return fmt.Errorf("%s returned status %d", url, resp.StatusCode)
+        }
+        return nil
+    }
+}
\ No newline at end of file
@@ -0,0 +1,282 @@
+// Package health keeps a registry of dependency checks that run in the
+// background, so health endpoints serve cached results instead of probing
+// every dependency on each request.
+package health
+
+import (
+    "context"
+    "sort"
+    "sync"
+    "time"
+)
+
+const (
+    StatusHealthy   = "healthy"
+    StatusDegraded  = "degraded"
+    StatusUnhealthy = "unhealthy"
+    // StatusUnknown is reported until a check has run once
+    StatusUnknown = "unknown"
+)
+
+// Criticality decides how a failing check affects the service
+type Criticality int
+
+const (
+    // Critical checks make the service unhealthy and not ready when they fail
+    Critical Criticality = iota
+    // NonCritical failures only degrade the service
+    NonCritical
+)
+
+const (
+    defaultInterval    = 10 * time.Second
+    defaultTimeout     = 5 * time.Second
+    defaultHistorySize = 10
+)
+
+// Check describes one dependency. Run returns nil when the dependency is
+// usable; a passing run slower than SlowThreshold counts as degraded.
+type Check struct {
+    Name          string
+    Criticality   Criticality
+    Run           func(ctx context.Context) error
+    Interval      time.Duration
+    Timeout       time.Duration
+    SlowThreshold time.Duration
+}
+
+// Sample is one past run of a check
+type Sample struct {
+    Status       string    `json:"status"`
+    Message      string    `json:"message,omitempty"`
+    ResponseTime string    `json:"response_time"`
+    CheckedAt    time.Time `json:"checked_at"`
+}
+
+// Result is the latest state of a check with its recent history, newest first
+type Result struct {
+    Status              string    `json:"status"`
+    Message             string    `json:"message,omitempty"`
+    Critical            bool      `json:"critical"`
+    ResponseTime        string    `json:"response_time,omitempty"`
+    CheckedAt           time.Time `json:"checked_at,omitempty"`
+    ConsecutiveFailures int       `json:"consecutive_failures"`
+    History             []Sample  `json:"history,omitempty"`
+}
+
+type entry struct {
+    check  Check
+    result Result
+}
+
+// Registry runs registered checks and caches their results
+type Registry struct {
+    interval    time.Duration
+    timeout     time.Duration
+    historySize int
+
+    mu      sync.RWMutex
+    entries map[string]*entry
+    started bool
+}
+
+// NewRegistry creates a registry whose checks default to the given interval
+// and timeout; zero values fall back to 10s and 5s
+func NewRegistry(interval, timeout time.Duration) *Registry {
+    if interval <= 0 {
+        interval = defaultInterval
+    }
+    if timeout <= 0 {
+        timeout = defaultTimeout
+    }
+    return &Registry{
+        interval:    interval,
+        timeout:     timeout,
+        historySize: defaultHistorySize,
+        entries:     make(map[string]*entry),
+    }
+}
+
+// Register adds a check, replacing any check with the same name. Checks
+// must be registered before Start.
+func (r *Registry) Register(check Check) {
+    if check.Interval <= 0 {
+        check.Interval = r.interval
+    }
+    if check.Timeout <= 0 {
+        check.Timeout = r.timeout
+    }
+
+    r.mu.Lock()
+    defer r.mu.Unlock()
+    r.entries[check.Name] = &entry{
+        check: check,
+        result: Result{
+            Status:   StatusUnknown,
+            Message:  "Not checked yet",
+            Critical: check.Criticality == Critical,
+        },
+    }
+}
+
+// Start runs every check immediately and then on its interval until ctx is done
+func (r *Registry) Start(ctx context.Context) {
+    r.mu.RLock()
+    checks := make([]Check, 0, len(r.entries))
+    for _, e := range r.entries {
+        checks = append(checks, e.check)
+    }
+    r.mu.RUnlock()
+
+    for _, check := range checks {
+        go func(check Check) {
+            ticker := time.NewTicker(check.Interval)
+            defer ticker.Stop()
+            for {
+                r.run(ctx, check)
+                select {
+                case <-ctx.Done():
+                    return
+                case <-ticker.C:
+                }
+            }
+        }(check)
+    }
+}
+
+// RunOnce runs every check concurrently and waits for them to finish
+func (r *Registry) RunOnce(ctx context.Context) {
+    r.mu.RLock()
+    checks := make([]Check, 0, len(r.entries))
+    for _, e := range r.entries {
+        checks = append(checks, e.check)
+    }
+    r.mu.RUnlock()
+
+    var wg sync.WaitGroup
+    for _, check := range checks {
+        wg.Add(1)
+        go func(check Check) {
+            defer wg.Done()
+            r.run(ctx, check)
+        }(check)
+    }
+    wg.Wait()
+}
+
+func (r *Registry) run(parent context.Context, check Check) {
+    ctx, cancel := context.WithTimeout(parent, check.Timeout)
+    defer cancel()
+
+    start := time.Now()
+    err := check.Run(ctx)
+    elapsed := time.Since(start)
+
+    sample := Sample{Status: StatusHealthy, ResponseTime: elapsed.String(), CheckedAt: start.UTC()}
+    switch {
+    case err != nil:
+        sample.Status = StatusUnhealthy
+        sample.Message = err.Error()
+    case check.SlowThreshold > 0 && elapsed > check.SlowThreshold:
+        sample.Status = StatusDegraded
+        sample.Message = "Responding slowly"
+    }
+
+    r.mu.Lock()
+    defer r.mu.Unlock()
+    e, ok := r.entries[check.Name]
+    if !ok {
+        return
+    }
+    result := &e.result
+    result.Status = sample.Status
+    result.Message = sample.Message
+    result.ResponseTime = sample.ResponseTime
+    result.CheckedAt = sample.CheckedAt
+    if err != nil {
+        result.ConsecutiveFailures++
+    } else {
+        result.ConsecutiveFailures = 0
+    }
+    result.History = append([]Sample{sample}, result.History...)
+    if len(result.History) > r.historySize {
+        result.History = result.History[:r.historySize]
+    }
+
+    if !r.started && r.criticalPassingLocked() {
+        r.started = true
+    }
+}
+
+// Results returns a copy of every check's latest result
+func (r *Registry) Results() map[string]Result {
+    r.mu.RLock()
+    defer r.mu.RUnlock()
+    results := make(map[string]Result, len(r.entries))
+    for name, e := range r.entries {
+        result := e.result
+        result.History = append([]Sample(nil), e.result.History...)
+        results[name] = result
+    }
+    return results
+}
+
+// Status is unhealthy when a critical check is failing, degraded when any
+// other check is failing or slow, and healthy otherwise
+func (r *Registry) Status() string {
+    r.mu.RLock()
+    defer r.mu.RUnlock()
+    status := StatusHealthy
+    for _, e := range r.entries {
+        switch {
+        case e.result.Status == StatusHealthy:
+        case e.check.Criticality == Critical && e.result.Status != StatusDegraded:
+            return StatusUnhealthy
+        default:
+            status = StatusDegraded
+        }
+    }
+    return status
+}
+
+// Ready reports whether every critical check currently passes
+func (r *Registry) Ready() bool {
+    r.mu.RLock()
+    defer r.mu.RUnlock()
+    return r.criticalPassingLocked()
+}
+
+// Failing returns the sorted names of critical checks that are not passing
+func (r *Registry) Failing() []string {
+    r.mu.RLock()
+    defer r.mu.RUnlock()
+    var names []string
+    for name, e := range r.entries {
+        if e.check.Criticality == Critical && !passing(e.result.Status) {
+            names = append(names, name)
+        }
+    }
+    sort.Strings(names)
+    return names
+}
+
+// Started reports whether every critical check has passed at least once.
+// It stays true afterwards, later failures only affect readiness.
+func (r *Registry) Started() bool {
+    r.mu.RLock()
+    defer r.mu.RUnlock()
+    return r.started
+}
+
+func (r *Registry) criticalPassingLocked() bool {
+    for _, e := range r.entries {
+        if e.check.Criticality == Critical && !passing(e.result.Status) {
+            return false
+        }
+    }
+    return true
+}
+
+func passing(status string) bool {
+    return status == StatusHealthy || status == StatusDegraded
+}
\ No newline at end of file
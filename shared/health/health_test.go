@@ -0,0 +1,168 @@
+package health
+
+import (
+    "context"
+    "errors"
+    "net"
+    "net/http"
+    "net/http/httptest"
+    "sync/atomic"
+    "testing"
+    "time"
+)
+
+type toggle struct {
+    failing atomic.Bool
+}
+
+func (t *toggle) run(ctx context.Context) error {
+    if t.failing.Load() {
+        return errors.New("connection refused")
+    }
+    return nil
+}
+
+func TestCriticalityDecidesStatus(t *testing.T) {
+    database, kafka := &toggle{}, &toggle{}
+    registry := NewRegistry(time.Minute, time.Second)
+    registry.Register(Check{Name: "database", Criticality: Critical, Run: database.run})
+    registry.Register(Check{Name: "kafka", Criticality: NonCritical, Run: kafka.run})
+    ctx := context.Background()
+
+    if registry.Status() != StatusUnhealthy || registry.Started() || registry.Ready() {
+        t.Errorf("before the first run: status=%s started=%v ready=%v", registry.Status(), registry.Started(), registry.Ready())
+    }
+
+    registry.RunOnce(ctx)
+    if registry.Status() != StatusHealthy || !registry.Started() || !registry.Ready() {
+        t.Errorf("all passing: status=%s started=%v ready=%v", registry.Status(), registry.Started(), registry.Ready())
+    }
+
+    kafka.failing.Store(true)
+    registry.RunOnce(ctx)
+    if registry.Status() != StatusDegraded || !registry.Ready() {
+        t.Errorf("non-critical failing: status=%s ready=%v", registry.Status(), registry.Ready())
+    }
+
+    database.failing.Store(true)
+    registry.RunOnce(ctx)
+    if registry.Status() != StatusUnhealthy || registry.Ready() {
+        t.Errorf("critical failing: status=%s ready=%v", registry.Status(), registry.Ready())
+    }
+    if !registry.Started() {
+        t.Error("startup should stay complete after a later failure")
+    }
+    if failing := registry.Failing(); len(failing) != 1 || failing[0] != "database" {
+        t.Errorf("failing = %v", failing)
+    }
+}
+
+func TestResultsKeepHistory(t *testing.T) {
+    dependency := &toggle{}
+    registry := NewRegistry(time.Minute, time.Second)
+    registry.historySize = 3
+    registry.Register(Check{Name: "redis", Criticality: Critical, Run: dependency.run})
+    ctx := context.Background()
+
+    for i := 0; i < 4; i++ {
+        dependency.failing.Store(i%2 == 1)
+        registry.RunOnce(ctx)
+    }
+    dependency.failing.Store(true)
+    registry.RunOnce(ctx)
+
+    result := registry.Results()["redis"]
+    if len(result.History) != 3 {
+        t.Fatalf("history = %d samples, want 3", len(result.History))
+    }
+    if result.History[0].Status != StatusUnhealthy || result.History[1].Status != StatusUnhealthy || result.History[2].Status != StatusHealthy {
+        t.Errorf("history should be newest first: %+v", result.History)
+    }
+    if result.ConsecutiveFailures != 2 || !result.Critical {
+        t.Errorf("result = %+v", result)
+    }
+}
+
+func TestSlowCheckIsDegraded(t *testing.T) {
+    registry := NewRegistry(time.Minute, time.Second)
+    registry.Register(Check{
+        Name:          "database",
+        Criticality:   Critical,
+        SlowThreshold: time.Millisecond,
+        Run: func(ctx context.Context) error {
+            time.Sleep(5 * time.Millisecond)
+            return nil
+        },
+    })
+    registry.RunOnce(context.Background())
+    if registry.Status() != StatusDegraded || !registry.Ready() {
+        t.Errorf("slow critical check: status=%s ready=%v", registry.Status(), registry.Ready())
+    }
+}
+
+func TestTimeoutFailsCheck(t *testing.T) {
+    registry := NewRegistry(time.Minute, 10*time.Millisecond)
+    registry.Register(Check{Name: "downstream", Criticality: Critical, Run: func(ctx context.Context) error {
+        <-ctx.Done()
+        return ctx.Err()
+    }})
+    registry.RunOnce(context.Background())
+    if result := registry.Results()["downstream"]; result.Status != StatusUnhealthy {
+        t.Errorf("timed out check = %+v", result)
+    }
+}
+
+func TestStartRunsInBackground(t *testing.T) {
+    var runs atomic.Int32
+    registry := NewRegistry(5*time.Millisecond, time.Second)
+    registry.Register(Check{Name: "cassandra", Run: func(ctx context.Context) error {
+        runs.Add(1)
+        return nil
+    }})
+
+    ctx, cancel := context.WithCancel(context.Background())
+    defer cancel()
+    registry.Start(ctx)
+
+    deadline := time.Now().Add(time.Second)
+    for runs.Load() < 3 && time.Now().Before(deadline) {
+        time.Sleep(time.Millisecond)
+    }
+    if runs.Load() < 3 {
+        t.Errorf("check ran %d times, want it repeated on the interval", runs.Load())
+    }
+    if !registry.Started() {
+        t.Error("expected startup to complete")
+    }
+}
+
+func TestTCPAndHTTPChecks(t *testing.T) {
+    ctx := context.Background()
+    server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        if r.URL.Path != "/health" {
+            w.WriteHeader(http.StatusServiceUnavailable)
+        }
+    }))
+    defer server.Close()
+
+    if err := HTTP(nil, server.URL+"/health")(ctx); err != nil {
+        t.Errorf("healthy downstream: %v", err)
+    }
+    if err := HTTP(nil, server.URL+"/down")(ctx); err == nil {
+        t.Error("expected a 503 to fail the check")
+    }
+
+    closed, err := net.Listen("tcp", "127.0.0.1:0")
+    if err != nil {
+        t.Fatal(err)
+    }
+    closedAddr := closed.Addr().String()
+    closed.Close()
+
+    if err := TCP(closedAddr, server.Listener.Addr().String())(ctx); err != nil {
+        t.Errorf("one reachable broker should pass: %v", err)
+    }
+    if err := TCP(closedAddr)(ctx); err == nil {
+        t.Error("expected an unreachable address to fail")
+    }
+}
\ No newline at end of file
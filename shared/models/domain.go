@@ -0,0 +1,21 @@
+package models
+
+import (
+    "time"
+    "github.com/google/uuid"
+)
+
+// DomainPolicy holds the rules a workspace applies to users of an email domain
+type DomainPolicy struct {
+    ID                uuid.UUID  `json:"id" db:"id"`
+    WorkspaceID       uuid.UUID  `json:"workspace_id" db:"workspace_id"`
+    Domain            string     `json:"domain" db:"domain"`
+    VerificationToken string     `json:"verification_token" db:"verification_token"`
+    Verified          bool       `json:"verified" db:"verified"`
+    VerifiedAt        *time.Time `json:"verified_at,omitempty" db:"verified_at"`
+    AllowSignup       bool       `json:"allow_signup" db:"allow_signup"`
+    EnforceSSO        bool       `json:"enforce_sso" db:"enforce_sso"`
+    AutoJoin          bool       `json:"auto_join" db:"auto_join"`
+    CreatedAt         time.Time  `json:"created_at" db:"created_at"`
+    UpdatedAt         time.Time  `json:"updated_at" db:"updated_at"`
+}
@@ -0,0 +1,26 @@
+package models
+
+import (
+    "time"
+    "github.com/google/uuid"
+)
+
+type SCIMToken struct {
+    ID          uuid.UUID  `json:"id" db:"id"`
+    WorkspaceID uuid.UUID  `json:"workspace_id" db:"workspace_id"`
+    TokenHash   string     `json:"-" db:"token_hash"`
+    Description string     `json:"description" db:"description"`
+    Revoked     bool       `json:"revoked" db:"revoked"`
+    CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+    LastUsedAt  *time.Time `json:"last_used_at" db:"last_used_at"`
+}
+
+type Group struct {
+    ID          uuid.UUID   `json:"id" db:"id"`
+    WorkspaceID uuid.UUID   `json:"workspace_id" db:"workspace_id"`
+    DisplayName string      `json:"display_name" db:"display_name"`
+    ExternalID  *string     `json:"external_id,omitempty" db:"external_id"`
+    MemberIDs   []uuid.UUID `json:"member_ids" db:"-"`
+    CreatedAt   time.Time   `json:"created_at" db:"created_at"`
+    UpdatedAt   time.Time   `json:"updated_at" db:"updated_at"`
+}
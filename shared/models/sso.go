@@ -0,0 +1,19 @@
+package models
+
+import (
+    "time"
+    "github.com/google/uuid"
+)
+
+type SAMLIdentityProvider struct {
+    ID                   uuid.UUID `json:"id" db:"id"`
+    WorkspaceID          uuid.UUID `json:"workspace_id" db:"workspace_id"`
+    EntityID             string    `json:"entity_id" db:"entity_id"`
+    SSOURL               string    `json:"sso_url" db:"sso_url"`
+    Certificate          string    `json:"certificate" db:"certificate"`
+    EmailAttribute       string    `json:"email_attribute" db:"email_attribute"`
+    DisplayNameAttribute string    `json:"display_name_attribute" db:"display_name_attribute"`
+    Enabled              bool      `json:"enabled" db:"enabled"`
+    CreatedAt            time.Time `json:"created_at" db:"created_at"`
+    UpdatedAt            time.Time `json:"updated_at" db:"updated_at"`
+}
@@ -12,6 +12,8 @@ type User struct {
     DisplayName   string     `json:"display_name" db:"display_name"`
     AvatarURL     *string    `json:"avatar_url" db:"avatar_url"`
     EmailVerified bool       `json:"email_verified" db:"email_verified"`
+    Active        bool       `json:"active" db:"active"`
+    ExternalID    *string    `json:"external_id,omitempty" db:"external_id"`
     CreatedAt     time.Time  `json:"created_at" db:"created_at"`
     UpdatedAt     time.Time  `json:"updated_at" db:"updated_at"`
 }
@@ -39,4 +41,19 @@ type UserPresence struct {
     DeviceID      string    `json:"device_id" db:"device_id"`
     Status        string    `json:"status" db:"status"`
     LastHeartbeat time.Time `json:"last_heartbeat" db:"last_heartbeat"`
-}
\ No newline at end of file
+}
+
+type LoginEvent struct {
+    ID          uuid.UUID `json:"id" db:"id"`
+    UserID      uuid.UUID `json:"user_id" db:"user_id"`
+    DeviceID    string    `json:"device_id" db:"device_id"`
+    IPAddress   string    `json:"ip_address" db:"ip_address"`
+    IPPrefix    string    `json:"ip_prefix" db:"ip_prefix"`
+    CountryCode *string   `json:"country_code" db:"country_code"`
+    City        *string   `json:"city" db:"city"`
+    Latitude    *float64  `json:"latitude" db:"latitude"`
+    Longitude   *float64  `json:"longitude" db:"longitude"`
+    RiskScore   int       `json:"risk_score" db:"risk_score"`
+    RiskLevel   string    `json:"risk_level" db:"risk_level"`
+    CreatedAt   time.Time `json:"created_at" db:"created_at"`
+}
@@ -0,0 +1,147 @@
+package secrets
+
+import (
+    "context"
+    "encoding/json"
+    "fmt"
+    "net/http"
+    "net/url"
+    "strconv"
+    "strings"
+    "sync"
+    "time"
+)
+
+const (
+    keyVaultAPIVersion = "7.4"
+    keyVaultResource   = "https://vault.azure.net"
+    // DefaultIdentityEndpoint is the Azure instance metadata token endpoint
+    DefaultIdentityEndpoint = "http://169.254.169.254/metadata/identity/oauth2/token"
+)
+
+// TokenSource supplies bearer tokens for the vault
+type TokenSource interface {
+    Token(ctx context.Context) (string, error)
+}
+
+// StaticToken is a fixed bearer token, for local vault stand-ins and tests
+type StaticToken string
+
+func (t StaticToken) Token(ctx context.Context) (string, error) {
+    return string(t), nil
+}
+
+// ManagedIdentityToken fetches vault tokens from the managed identity
+// endpoint and reuses them until shortly before they expire
+type ManagedIdentityToken struct {
+    endpoint string
+    client   *http.Client
+
+    mu        sync.Mutex
+    token     string
+    expiresAt time.Time
+}
+
+func NewManagedIdentityToken(endpoint string, client *http.Client) *ManagedIdentityToken {
+    if endpoint == "" {
+        endpoint = DefaultIdentityEndpoint
+    }
+    if client == nil {
+        client = &http.Client{Timeout: 10 * time.Second}
+    }
+    return &ManagedIdentityToken{endpoint: endpoint, client: client}
+}
+
+func (m *ManagedIdentityToken) Token(ctx context.Context) (string, error) {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    if m.token != "" && time.Until(m.expiresAt) > time.Minute {
+        return m.token, nil
+    }
+
+    query := url.Values{"api-version": {"2018-02-01"}, "resource": {keyVaultResource}}
+    req, err := http.NewRequestWithContext(ctx, http.MethodGet, m.endpoint+"?"+query.Encode(), nil)
+    if err != nil {
+        return "", fmt.Errorf("failed to build identity request: %w", err)
+    }
+    req.Header.Set("Metadata", "true")
+
+    resp, err := m.client.Do(req)
+    if err != nil {
+        return "", fmt.Errorf("identity token request failed: %w", err)
+    }
+    defer resp.Body.Close()
+    if resp.StatusCode != http.StatusOK {
+        return "", fmt.Errorf("identity token request failed with status %d", resp.StatusCode)
+    }
+
+    var body struct {
+        AccessToken string `json:"access_token"`
+        ExpiresOn   string `json:"expires_on"`
+    }
+    if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+        return "", fmt.Errorf("failed to decode identity token: %w", err)
+    }
+    m.token = body.AccessToken
+    m.expiresAt = time.Now().Add(5 * time.Minute)
+    if seconds, err := strconv.ParseInt(body.ExpiresOn, 10, 64); err == nil {
+        m.expiresAt = time.Unix(seconds, 0)
+    }
+    return m.token, nil
+}
+
+// KeyVaultProvider reads secrets from the Azure Key Vault REST API, or any
+// service answering GET /secrets/{name} with {"value": ...}
+type KeyVaultProvider struct {
+    vaultURL string
+    tokens   TokenSource
+    client   *http.Client
+}
+
+// NewKeyVaultProvider creates a vault provider, client may be nil
+func NewKeyVaultProvider(vaultURL string, tokens TokenSource, client *http.Client) *KeyVaultProvider {
+    if client == nil {
+        client = &http.Client{Timeout: 10 * time.Second}
+    }
+    return &KeyVaultProvider{
+        vaultURL: strings.TrimRight(vaultURL, "/"),
+        tokens:   tokens,
+        client:   client,
+    }
+}
+
+func (p *KeyVaultProvider) GetSecret(ctx context.Context, name string) (string, error) {
+    token, err := p.tokens.Token(ctx)
+    if err != nil {
+        return "", err
+    }
+
+    endpoint := fmt.Sprintf("%s/secrets/%s?api-version=%s", p.vaultURL, url.PathEscape(name), keyVaultAPIVersion)
+    req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+    if err != nil {
+        return "", fmt.Errorf("failed to build vault request: %w", err)
+    }
+    req.Header.Set("Authorization", "Bearer "+token)
+
+    resp, err := p.client.Do(req)
+    if err != nil {
+        return "", fmt.Errorf("vault request for %s failed: %w", name, err)
+    }
+    defer resp.Body.Close()
+
+    switch resp.StatusCode {
+    case http.StatusOK:
+    case http.StatusNotFound:
+        return "", fmt.Errorf("%w: %s", ErrNotFound, name)
+    default:
+        return "", fmt.Errorf("vault request for %s failed with status %d", name, resp.StatusCode)
+    }
+
+    var body struct {
+        Value string `json:"value"`
+    }
+    if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+        return "", fmt.Errorf("failed to decode secret %s: %w", name, err)
+    }
+    return body.Value, nil
+}
\ No newline at end of file
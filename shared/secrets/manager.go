@@ -0,0 +1,125 @@
+package secrets
+
+import (
+    "context"
+    "errors"
+    "log/slog"
+    "sync"
+    "time"
+)
+
+type entry struct {
+    value     string
+    fetchedAt time.Time
+}
+
+// Manager caches secrets for a TTL. Refresh re-reads every secret that was
+// requested or subscribed to, and subscribers are called when a value changes.
+type Manager struct {
+    provider Provider
+    ttl      time.Duration
+    now      func() time.Time
+
+    mu          sync.Mutex
+    entries     map[string]entry
+    subscribers map[string][]func(string)
+}
+
+func NewManager(provider Provider, ttl time.Duration) *Manager {
+    return &Manager{
+        provider:    provider,
+        ttl:         ttl,
+        now:         time.Now,
+        entries:     make(map[string]entry),
+        subscribers: make(map[string][]func(string)),
+    }
+}
+
+// Get returns the cached value while it is fresh and fetches it otherwise.
+// When the provider fails a stale value is served rather than nothing.
+func (m *Manager) Get(ctx context.Context, name string) (string, error) {
+    m.mu.Lock()
+    cached, ok := m.entries[name]
+    m.mu.Unlock()
+    if ok && m.now().Sub(cached.fetchedAt) < m.ttl {
+        return cached.value, nil
+    }
+
+    value, err := m.fetch(ctx, name)
+    if err != nil {
+        if ok && !errors.Is(err, ErrNotFound) {
+            slog.Warn("Serving stale secret after refresh failed", "secret", name, "error", err)
+            return cached.value, nil
+        }
+        return "", err
+    }
+    return value, nil
+}
+
+// Subscribe calls fn with the new value whenever the secret rotates
+func (m *Manager) Subscribe(name string, fn func(value string)) {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    m.subscribers[name] = append(m.subscribers[name], fn)
+}
+
+// Refresh re-reads every known secret and notifies subscribers of changes
+func (m *Manager) Refresh(ctx context.Context) error {
+    m.mu.Lock()
+    names := make(map[string]bool, len(m.entries)+len(m.subscribers))
+    for name := range m.entries {
+        names[name] = true
+    }
+    for name := range m.subscribers {
+        names[name] = true
+    }
+    m.mu.Unlock()
+
+    var errs []error
+    for name := range names {
+        if _, err := m.fetch(ctx, name); err != nil {
+            errs = append(errs, err)
+        }
+    }
+    return errors.Join(errs...)
+}
+
+// Run refreshes every TTL until ctx is done
+func (m *Manager) Run(ctx context.Context) {
+    ticker := time.NewTicker(m.ttl)
+    defer ticker.Stop()
+
+    for {
+        select {
+        case <-ctx.Done():
+            return
+        case <-ticker.C:
+            if err := m.Refresh(ctx); err != nil {
+                slog.Error("Failed to refresh secrets", "error", err)
+            }
+        }
+    }
+}
+
+// fetch reads the secret from the provider, caches it and notifies
+// subscribers outside the lock when it replaced a different value
+func (m *Manager) fetch(ctx context.Context, name string) (string, error) {
+    value, err := m.provider.GetSecret(ctx, name)
+    if err != nil {
+        return "", err
+    }
+
+    m.mu.Lock()
+    previous, known := m.entries[name]
+    m.entries[name] = entry{value: value, fetchedAt: m.now()}
+    subscribers := append([]func(string){}, m.subscribers[name]...)
+    m.mu.Unlock()
+
+    if known && previous.value != value {
+        slog.Info("Secret rotated", "secret", name)
+        for _, fn := range subscribers {
+            fn(value)
+        }
+    }
+    return value, nil
+}
\ No newline at end of file
@@ -0,0 +1,67 @@
+// Package secrets resolves named secrets from the environment, mounted files
+// or an Azure Key Vault compatible API, and caches them in a Manager that
+// refreshes values and notifies subscribers when a secret rotates.
+package secrets
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "os"
+    "path/filepath"
+    "strings"
+)
+
+// ErrNotFound is returned when the provider has no value for a secret
+var ErrNotFound = errors.New("secret not found")
+
+// Provider looks up the current value of a secret. Names use the Key Vault
+// form, lower-case words separated by dashes such as jwt-secret.
+type Provider interface {
+    GetSecret(ctx context.Context, name string) (string, error)
+}
+
+// EnvName maps a secret name to its environment variable, jwt-secret to JWT_SECRET
+func EnvName(name string) string {
+    return strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+}
+
+// EnvProvider reads secrets from environment variables
+type EnvProvider struct{}
+
+func NewEnvProvider() *EnvProvider {
+    return &EnvProvider{}
+}
+
+func (p *EnvProvider) GetSecret(ctx context.Context, name string) (string, error) {
+    value, ok := os.LookupEnv(EnvName(name))
+    if !ok || value == "" {
+        return "", fmt.Errorf("%w: %s", ErrNotFound, name)
+    }
+    return value, nil
+}
+
+// FileProvider reads secrets mounted as one file per secret, as Kubernetes
+// and Docker do, so rotated files are picked up on the next read
+type FileProvider struct {
+    dir string
+}
+
+func NewFileProvider(dir string) *FileProvider {
+    return &FileProvider{dir: dir}
+}
+
+func (p *FileProvider) GetSecret(ctx context.Context, name string) (string, error) {
+    if name == "" || name != filepath.Base(name) || strings.HasPrefix(name, ".") {
+        return "", fmt.Errorf("invalid secret name %q", name)
+    }
+
+    data, err := os.ReadFile(filepath.Join(p.dir, name))
+    if err != nil {
+        if errors.Is(err, os.ErrNotExist) {
+            return "", fmt.Errorf("%w: %s", ErrNotFound, name)
+        }
+        return "", fmt.Errorf("failed to read secret %s: %w", name, err)
+    }
+    return strings.TrimRight(string(data), "\r\n"), nil
+}
\ No newline at end of file
@@ -0,0 +1,158 @@
+package secrets
+
+import (
+    "context"
+    "encoding/json"
+    "errors"
+    "net/http"
+    "net/http/httptest"
+    "os"
+    "path/filepath"
+    "strings"
+    "sync"
+    "testing"
+    "time"
+)
+
+// fakeVault is a local stand-in for the Key Vault secrets API
+type fakeVault struct {
+    mu      sync.Mutex
+    secrets map[string]string
+    calls   int
+}
+
+func (v *fakeVault) set(name, value string) {
+    v.mu.Lock()
+    defer v.mu.Unlock()
+    v.secrets[name] = value
+}
+
+func (v *fakeVault) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+    v.mu.Lock()
+    defer v.mu.Unlock()
+    v.calls++
+
+    if r.Header.Get("Authorization") != "Bearer test-token" {
+        w.WriteHeader(http.StatusUnauthorized)
+        return
+    }
+    if r.URL.Query().Get("api-version") == "" {
+        w.WriteHeader(http.StatusBadRequest)
+        return
+    }
+    value, ok := v.secrets[strings.TrimPrefix(r.URL.Path, "/secrets/")]
+    if !ok {
+        w.WriteHeader(http.StatusNotFound)
+        return
+    }
+    json.NewEncoder(w).Encode(map[string]string{"value": value})
+}
+
+func newVault(t *testing.T) (*fakeVault, *KeyVaultProvider) {
+    t.Helper()
+    vault := &fakeVault{secrets: map[string]string{"jwt-secret": "first"}}
+    server := httptest.NewServer(vault)
+    t.Cleanup(server.Close)
+    return vault, NewKeyVaultProvider(server.URL+"/", StaticToken("test-token"), server.Client())
+}
+
+func TestKeyVaultProvider(t *testing.T) {
+    _, provider := newVault(t)
+    ctx := context.Background()
+
+    value, err := provider.GetSecret(ctx, "jwt-secret")
+    if err != nil || value != "first" {
+        t.Fatalf("GetSecret = %q, %v", value, err)
+    }
+    if _, err := provider.GetSecret(ctx, "missing"); !errors.Is(err, ErrNotFound) {
+        t.Errorf("missing secret error = %v, want ErrNotFound", err)
+    }
+
+    denied := NewKeyVaultProvider(provider.vaultURL, StaticToken("wrong"), nil)
+    if _, err := denied.GetSecret(ctx, "jwt-secret"); err == nil || errors.Is(err, ErrNotFound) {
+        t.Errorf("unauthorized request error = %v", err)
+    }
+}
+
+func TestFileProvider(t *testing.T) {
+    dir := t.TempDir()
+    if err := os.WriteFile(filepath.Join(dir, "database-url"), []byte("postgres://db\n"), 0o600); err != nil {
+        t.Fatal(err)
+    }
+    provider := NewFileProvider(dir)
+    ctx := context.Background()
+
+    if value, err := provider.GetSecret(ctx, "database-url"); err != nil || value != "postgres://db" {
+        t.Errorf("GetSecret = %q, %v", value, err)
+    }
+    if _, err := provider.GetSecret(ctx, "jwt-secret"); !errors.Is(err, ErrNotFound) {
+        t.Errorf("missing file error = %v, want ErrNotFound", err)
+    }
+    if _, err := provider.GetSecret(ctx, "../database-url"); err == nil {
+        t.Error("expected names escaping the directory to be rejected")
+    }
+}
+
+func TestEnvProvider(t *testing.T) {
+    t.Setenv("JWT_SECRET", "from-env")
+    if value, err := NewEnvProvider().GetSecret(context.Background(), "jwt-secret"); err != nil || value != "from-env" {
+        t.Errorf("GetSecret = %q, %v", value, err)
+    }
+}
+
+func TestManagerCachesAndNotifiesOnRotation(t *testing.T) {
+    vault, provider := newVault(t)
+    manager := NewManager(provider, time.Minute)
+    now := time.Now()
+    manager.now = func() time.Time { return now }
+    ctx := context.Background()
+
+    var rotated []string
+    manager.Subscribe("jwt-secret", func(value string) { rotated = append(rotated, value) })
+
+    for i := 0; i < 3; i++ {
+        if value, err := manager.Get(ctx, "jwt-secret"); err != nil || value != "first" {
+            t.Fatalf("Get = %q, %v", value, err)
+        }
+    }
+    if vault.calls != 1 {
+        t.Errorf("vault calls = %d, want cached reads", vault.calls)
+    }
+
+    vault.set("jwt-secret", "second")
+    if err := manager.Refresh(ctx); err != nil {
+        t.Fatalf("Refresh failed: %v", err)
+    }
+    if len(rotated) != 1 || rotated[0] != "second" {
+        t.Errorf("subscribers saw %v, want one rotation", rotated)
+    }
+
+    // An unchanged value does not notify again
+    if err := manager.Refresh(ctx); err != nil {
+        t.Fatalf("Refresh failed: %v", err)
+    }
+    if len(rotated) != 1 {
+        t.Errorf("subscribers saw %v after an unchanged refresh", rotated)
+    }
+}
+
+func TestManagerServesStaleValueWhenProviderFails(t *testing.T) {
+    vault, provider := newVault(t)
+    manager := NewManager(provider, time.Minute)
+    now := time.Now()
+    manager.now = func() time.Time { return now }
+    ctx := context.Background()
+
+    if _, err := manager.Get(ctx, "jwt-secret"); err != nil {
+        t.Fatal(err)
+    }
+
+    provider.tokens = StaticToken("expired")
+    now = now.Add(2 * time.Minute)
+    if value, err := manager.Get(ctx, "jwt-secret"); err != nil || value != "first" {
+        t.Errorf("Get = %q, %v, want the stale value", value, err)
+    }
+    if vault.calls != 2 {
+        t.Errorf("vault calls = %d, want a refetch after the TTL", vault.calls)
+    }
+}
\ No newline at end of file